@@ -1,11 +1,27 @@
 package main
 
 import (
+	"context"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/alecthomas/kingpin"
 	"github.com/arminc/k8s-platform-lcm/internal"
 	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/internal/logging"
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/tracing"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -15,49 +31,325 @@ var Version = "dev"
 func initLogging(config config.Config) {
 	log.SetOutput(os.Stdout)     // Default to out instead of err
 	log.SetLevel(log.ErrorLevel) // Default only Errors
+	if config.SbomToStdout() {
+		log.SetOutput(os.Stderr) // The SBOM is the only output on stdout
+	}
 	if config.IsVerboseLoggingEnabled() {
 		log.SetLevel(log.InfoLevel)
 	} else if config.IsDebugLoggingEnabled() {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	var formatter log.Formatter = &log.TextFormatter{}
 	if config.IsJsonLoggingEnabled() {
-		log.SetFormatter(&log.JSONFormatter{})
+		formatter = &log.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	}
+	if config.ClusterName != "" {
+		formatter = logging.FieldsFormatter{Fields: log.Fields{"cluster": config.ClusterName}, Formatter: formatter}
 	}
+	log.SetFormatter(formatter)
 	enabled, logFile := config.LogToFilePath()
 	if enabled {
-		file, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE, 0755)
-		if err != nil {
+		file := &logging.RotatingFile{
+			Path:       logFile,
+			MaxSize:    config.AppConfig.LogRotation.GetMaxSize(),
+			MaxAge:     config.AppConfig.LogRotation.GetMaxAge(),
+			MaxBackups: config.AppConfig.LogRotation.MaxBackups,
+		}
+		if err := file.Open(); err != nil {
 			log.WithError(err).Fatal("Could not log to file")
 		}
-		log.SetOutput(file)
+		if config.LogToStdout() {
+			log.SetOutput(io.MultiWriter(os.Stdout, file))
+		} else {
+			log.SetOutput(file)
+		}
+	}
+}
+
+func initTimeouts(config config.Config) {
+	kubernetes.SetTimeout(config.Timeouts.GetKubernetesTimeout())
+	kubernetes.SetPageSize(int64(config.KubernetesPageSize))
+	kubernetes.SetWorkloadsEnabled(config.KubernetesWorkloads)
+	kubernetes.SetOpenShiftEnabled(config.KubernetesOpenShift)
+	kubernetes.SetCustomResources(config.GetCustomResources())
+	kubernetes.SetLabelSelector(config.GetPodLabelSelector())
+	kubernetes.SetExcludedNamespaces(config.ExcludeNamespaces)
+	registries.SetTimeouts(config.Timeouts.GetRegistryTimeout(), config.Timeouts.GetToolTimeout())
+	scanning.SetTimeout(config.Timeouts.GetScannerTimeout())
+}
+
+func initHTTP(config config.Config) {
+	if err := configureHTTP(config); err != nil {
+		log.WithError(err).Fatal("Http settings not valid")
+	}
+}
+
+func configureHTTP(config config.Config) error {
+	if config.HTTP.UserAgent == "" {
+		config.HTTP.UserAgent = httpclient.DefaultUserAgent(Version, config.ClusterName)
+	}
+	return httpclient.Configure(config.HTTP)
+}
+
+func initTracing(config config.Config) {
+	if err := tracing.Configure(config.Tracing, Version); err != nil {
+		log.WithError(err).Fatal("Tracing settings not valid")
+	}
+}
+
+func initAudit(config config.Config) {
+	if err := audit.Configure(config.Audit); err != nil {
+		log.WithError(err).Fatal("Audit settings not valid")
 	}
 }
 
+func initCache(config config.Config) {
+	if err := cache.Configure(config.Cache); err != nil {
+		log.WithError(err).Fatal("Cache settings not valid")
+	}
+}
+
+var (
+	podCacheLock sync.Mutex
+	stopPodCache chan struct{}
+)
+
+// startPodCache starts the pod cache with the settings of the config when it is enabled, the pod cache of the previous config is stopped
+func startPodCache(config config.Config) {
+	podCacheLock.Lock()
+	defer podCacheLock.Unlock()
+	if stopPodCache != nil {
+		close(stopPodCache)
+		stopPodCache = nil
+	}
+	if !config.IsPodCacheEnabled() {
+		return
+	}
+	stop := make(chan struct{})
+	if err := kubernetes.StartPodCache(config.Namespaces, config.RunningLocally(), config.GetKubernetesOptions(), stop); err != nil {
+		log.WithError(err).Warn("Could not start the pod cache, the pods are listed for every scan")
+		return
+	}
+	stopPodCache = stop
+}
+
 func initFlags() config.AppConfig {
 	app := kingpin.New("lcm", "Kubernetes platform lifecycle management")
 	app.Version(Version)
 	cliFlags := new(config.AppConfig)
 	app.Flag("config", "Provide the path to the config file. Default is config.yaml which is in the same folder as lcm").Default("config.yaml").StringVar(&cliFlags.ConfigFile)
+	app.Flag("configMap", "Load the config from the config.yaml key of a ConfigMap instead of a file, in the form of namespace/name").StringVar(&cliFlags.ConfigMap)
+	app.Flag("configResource", "Load the config from the spec of a LifecycleScan resource instead of a file, in the form of namespace/name").StringVar(&cliFlags.ConfigResource)
+	app.Flag("profile", "Use the named profile from the config, the profile overrides the settings in the config").StringVar(&cliFlags.Profile)
+	app.Flag("podLabelSelector", "Only scan the pods and workloads that match the label selector, for example app.kubernetes.io/part-of=platform. This overrides the config setting").StringVar(&cliFlags.PodLabelSelector)
+	app.Flag("scanner", "Only run the vulnerability scanners of the kind like trivy or grype, or with the id like external/corp-scanner. Can be repeated. This overrides the config setting").StringsVar(&cliFlags.Scanners)
 	app.Flag("local", "Run locally, default expected behavior is to run in the Kubernetes cluster").BoolVar(&cliFlags.Locally)
 	app.Flag("verbose", "Show more information. This overrides the config setting").BoolVar(&cliFlags.Verbose)
 	app.Flag("debug", "Show debug information, debug includes verbose. This overrides the config setting").BoolVar(&cliFlags.Debug)
-	app.Flag("jsonLogging", "Log in json format").BoolVar(&cliFlags.JsonLoggingEnabled)
+	app.Flag("jsonLogging", "Log in json format, same as --logFormat=json").BoolVar(&cliFlags.JsonLoggingEnabled)
+	app.Flag("logFormat", "Log format, text or json. Json logs contain the fields component, namespace, image and duration where applicable").EnumVar(&cliFlags.LogFormat, config.LogFormatText, config.LogFormatJSON)
 	app.Flag("logFile", "Log file path").StringVar(&cliFlags.LogFile)
+	app.Flag("logToStdout", "Log to stdout as well when logging to a file").BoolVar(&cliFlags.LogToStdout)
+	app.Flag("watch", "Check new images as soon as they appear in the cluster while running the server").BoolVar(&cliFlags.WatchWorkloads)
+	app.Flag("failOn", "Exit with a failing exit code on scan-errors (2), vulnerable (3), outdated, outdated-minor or outdated-major (4), policy-violations (5), drift (6), exploited (8) or epss (9). Can be repeated, default is scan-errors").EnumsVar(&cliFlags.FailOn, internal.FailOnScanErrors, internal.FailOnVulnerable, internal.FailOnOutdated, internal.FailOnOutdatedMinor, internal.FailOnOutdatedMajor, internal.FailOnPolicyViolations, internal.FailOnDrift, internal.FailOnExploited, internal.FailOnEpss)
+	app.Flag("sbom", "Write the SBOM of the running images in the format after the scan, cyclonedx or spdx").EnumVar(&cliFlags.Sbom, config.SbomCycloneDX, config.SbomSPDX)
+	app.Flag("sbomFile", "Write the SBOM to the file instead of stdout").StringVar(&cliFlags.SbomFile)
+	app.Flag("sbomPackages", "Add the packages of the images to the SBOM from the scanners that list them like trivy").BoolVar(&cliFlags.SbomPackages)
 	app.Flag("server", "Start the server").BoolVar(&cliFlags.StartServer)
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	app.Flag("leaderElection", "Only scan on the replica that is elected as leader with a Kubernetes lease while running the server").BoolVar(&cliFlags.LeaderElection.Enabled)
+	app.Flag("debugEndpoints", "Serve /debug/pprof and the runtime stats on /debug/runtime while running the server").BoolVar(&cliFlags.DebugEndpoints)
+	app.Flag("grpcAddress", "Serve the gRPC API on the address while running the server, for example :7322").StringVar(&cliFlags.GrpcAddress)
+	app.Command(config.CommandScan, "Run the scan and show the results").Default()
+	app.Command(config.CommandTUI, "Run the scan in an interactive terminal UI to filter, sort and drill down into the results")
+	cliFlags.Command = kingpin.MustParse(app.Parse(os.Args[1:]))
 
 	return *cliFlags
 }
 
+// scanContext returns the context for the scan, it is cancelled at the deadline or when lcm is asked to stop
+func scanContext(deadline time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			log.WithField("signal", sig).Warn("Stopping the scan")
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
+
+// runSubcommand runs lcm-<name> from the PATH when lcm is invoked as lcm <name>, like kubectl plugins
+func runSubcommand() {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		return
+	}
+	switch os.Args[1] {
+	case config.CommandScan, config.CommandTUI, "help":
+		return
+	}
+	if path, found := plugins.FindSubcommand(os.Args[1]); found {
+		os.Exit(plugins.RunSubcommand(path, os.Args[2:]))
+	}
+}
+
+// writeSbom writes the SBOM of the scan result to stdout or the file of --sbomFile
+func writeSbom(config config.Config, result internal.ScanResult) {
+	var out io.Writer = os.Stdout
+	if config.CliFlags.SbomFile != "" {
+		file, err := os.Create(config.CliFlags.SbomFile)
+		if err != nil {
+			log.WithError(err).Fatal("Could not create the SBOM file")
+		}
+		defer file.Close()
+		out = file
+	}
+	ctx, cancel := scanContext(config.Timeouts.GetScanTimeout())
+	defer cancel()
+	if err := internal.WriteSbom(ctx, out, config, result, Version); err != nil {
+		log.WithError(err).WithField("format", config.CliFlags.Sbom).Fatal("Could not write the SBOM")
+	}
+}
+
+var (
+	backgroundLock   sync.Mutex
+	backgroundCtx    context.Context
+	cancelBackground context.CancelFunc = func() {}
+)
+
+// backgroundContext returns the context of the scans and watches that run while the server runs, it is done once the config changes
+func backgroundContext() context.Context {
+	backgroundLock.Lock()
+	defer backgroundLock.Unlock()
+	if backgroundCtx == nil {
+		backgroundCtx, cancelBackground = context.WithCancel(context.Background())
+	}
+	return backgroundCtx
+}
+
+// restartBackground stops the scans and watches of the previous config and returns the context for the ones of the new config
+func restartBackground() context.Context {
+	backgroundLock.Lock()
+	cancelBackground()
+	backgroundCtx = nil
+	backgroundLock.Unlock()
+	return backgroundContext()
+}
+
+// runInBackground scans with the config and watches for new images, with leader election only while this replica is the leader
+func runInBackground(ctx context.Context, config config.Config, scan bool) {
+	if config.IsLeaderElectionEnabled() {
+		internal.KeepRunningAsLeader(ctx, config)
+		return
+	}
+	if scan {
+		scanCtx, cancel := context.WithTimeout(ctx, config.Timeouts.GetScanTimeout())
+		internal.Execute(scanCtx, config)
+		cancel()
+	}
+	if config.IsWatchWorkloadsEnabled() && ctx.Err() == nil {
+		internal.WatchForNewImages(ctx, config)
+	}
+}
+
+// watchConfig scans again with the config of the ConfigMap or LifecycleScan resource every time it changes, so changing it doesn't require
+// restarting lcm
+func watchConfig(current config.Config) {
+	config.WatchConfigFromCluster(context.Background(), current.CliFlags, current.GetConfigReload(), func(changed config.Config) {
+		if !reloadConfig(current, changed) {
+			return
+		}
+		current = changed
+		go runInBackground(restartBackground(), changed, true)
+	})
+}
+
+// reloadConfig applies the changed config to the defaults of the packages, the HTTP client, the cache, the pod cache, the health checks
+// and the gRPC service. Settings that are only applied when lcm starts, like logging and the server, are logged as needing a restart
+// It returns false when the changed config can't be used, the current config is kept then
+func reloadConfig(current, changed config.Config) bool {
+	if err := changed.GetImageScanners().Validate(); err != nil {
+		log.WithError(err).Error("Could not select the scanners of the --scanner flag, the current config is kept")
+		return false
+	}
+	if err := configureHTTP(changed); err != nil {
+		log.WithError(err).Error("Http settings of the changed config not valid, the current config is kept")
+		return false
+	}
+	if err := cache.Configure(changed.Cache); err != nil {
+		log.WithError(err).Error("Cache settings of the changed config not valid, the current cache is kept")
+	}
+	if settings := current.RestartRequired(changed); len(settings) > 0 {
+		log.WithField("settings", settings).Warn("Changed settings are only applied when lcm starts, restart lcm to apply them")
+	}
+	initTimeouts(changed)
+	startPodCache(changed)
+	internal.UseConfig(changed)
+	return true
+}
+
 func main() {
+	runSubcommand()
 	cliFlags := initFlags()
-	config := config.LoadConfiguration(cliFlags.ConfigFile)
+	config, err := config.LoadConfigurationFromCluster(cliFlags)
+	if err != nil {
+		log.WithError(err).Fatal("Could not load the config")
+	}
 	config.CliFlags = cliFlags // Add cli flags to config object
+	if err := config.GetImageScanners().Validate(); err != nil {
+		log.WithError(err).Fatal("Could not select the scanners of the --scanner flag")
+	}
 	initLogging(config)
+	initTimeouts(config)
+	initHTTP(config)
+	initTracing(config)
+	initAudit(config)
+	initCache(config)
 	log.WithField("version", Version).Info("Running version")
-	internal.Execute(config)
+	startPodCache(config)
+
+	var result internal.ScanResult
+	if config.IsLeaderElectionEnabled() {
+		// The leader scans in the background while every replica serves the latest result it has
+		go runInBackground(backgroundContext(), config, false)
+	} else if config.IsTUIEnabled() {
+		// The user decides how long the TUI runs
+		result = internal.StartTUI(context.Background(), config, os.Stdin, os.Stdout)
+	} else {
+		deadline := config.Timeouts.GetScanTimeout()
+		ctx, cancel := scanContext(deadline)
+		result = internal.Execute(ctx, config)
+		if ctx.Err() == context.DeadlineExceeded {
+			log.WithField("deadline", deadline).Fatal("Scan did not finish within the deadline")
+		} else if ctx.Err() != nil {
+			log.Fatal("Scan was stopped before it finished")
+		}
+		cancel()
+	}
+	if config.CliFlags.Sbom != "" && !config.IsLeaderElectionEnabled() {
+		writeSbom(config, result)
+	}
 	if config.CliFlags.StartServer {
-		internal.StartServer()
+		if config.IsWatchWorkloadsEnabled() && !config.IsLeaderElectionEnabled() {
+			internal.WatchForNewImages(backgroundContext(), config)
+		}
+		if config.CliFlags.IsClusterConfig() && config.GetConfigReload() > 0 {
+			go watchConfig(config)
+		}
+		if addr := config.GetGrpcAddress(); addr != "" {
+			if err := internal.StartGRPCServer(config, addr); err != nil {
+				log.WithError(err).WithField("addr", addr).Fatal("Could not start the gRPC server")
+			}
+		}
+		internal.StartServer(config)
+	}
+	if exitCode := result.ExitCode(config.GetFailOn()); exitCode != 0 {
+		log.WithField("problems", len(result.Problems)).WithField("exitCode", exitCode).Error("Scan failed on the fail on conditions")
+		os.Exit(exitCode)
 	}
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal"
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`
+
+func TestReloadedExcludeNamespacesAreUsedByTheNextScan(t *testing.T) {
+	var requestedLock sync.Mutex
+	requested := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestedLock.Lock()
+		requested = append(requested, req.URL.Path)
+		requestedLock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL.Path == "/api/v1/namespaces" {
+			fmt.Fprint(w, `{"kind":"NamespaceList","apiVersion":"v1","items":[{"metadata":{"name":"default"}},{"metadata":{"name":"pr-1"}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"kind":"List","apiVersion":"v1","items":[]}`)
+	}))
+	defer server.Close()
+
+	home, err := ioutil.TempDir("", "lcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	if err := os.MkdirAll(filepath.Join(home, ".kube"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, ".kube", "config"), []byte(fmt.Sprintf(kubeconfig, server.URL)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	load := func(yaml string) config.Config {
+		file := filepath.Join(home, "config.yaml")
+		if err := ioutil.WriteFile(file, []byte(yaml), 0600); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := config.LoadConfiguration(file, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded.CliFlags.Locally = true
+		return loaded
+	}
+	scanned := func(namespace string) bool {
+		requestedLock.Lock()
+		defer requestedLock.Unlock()
+		for _, path := range requested {
+			if strings.Contains(path, "/namespaces/"+namespace+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	current := load("namespaces: []\n")
+	defer internal.UseConfig(current)
+	internal.Execute(context.Background(), current)
+	if !scanned("pr-1") {
+		t.Fatalf("Expected the namespace pr-1 to be scanned before the reload but got %v", requested)
+	}
+
+	changed := load("namespaces: []\nexcludeNamespaces: [\"pr-.*\"]\n")
+	if !reloadConfig(current, changed) {
+		t.Fatal("Expected the changed config to be applied")
+	}
+	requested = []string{}
+	runInBackground(context.Background(), changed, true)
+	if scanned("pr-1") {
+		t.Errorf("Expected the namespace pr-1 to be excluded after the reload but got %v", requested)
+	}
+	if !scanned("default") {
+		t.Errorf("Expected the namespace default to be scanned after the reload but got %v", requested)
+	}
+}
@@ -3,8 +3,11 @@ module github.com/arminc/k8s-platform-lcm
 go 1.13
 
 require (
+	github.com/Masterminds/semver/v3 v3.0.1
 	github.com/alecthomas/kingpin v2.2.6+incompatible
 	github.com/docker/distribution v2.7.1+incompatible
+	github.com/go-redis/redis/v8 v8.11.5
+	github.com/golang/protobuf v1.5.2
 	github.com/google/go-github/v28 v28.1.1
 	github.com/gorilla/mux v1.7.3
 	github.com/heptiolabs/healthcheck v0.0.0-20180807145615-6ff867650f40
@@ -13,14 +16,19 @@ require (
 	github.com/knadh/koanf v0.6.0
 	github.com/mcuadros/go-version v0.0.0-20190830083331-035f6764e8d2
 	github.com/olekukonko/tablewriter v0.0.4
+	github.com/prometheus/client_golang v1.2.1
 	github.com/prometheus/common v0.7.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/target/go-arty v0.0.0-20191122155631-9967a6326524
 	github.com/urfave/negroni v1.0.0
+	golang.org/x/net v0.0.0-20210428140749-89ef3d95e781
 	golang.org/x/oauth2 v0.0.0-20191202225959-858c2ad4c8b6
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 	google.golang.org/appengine v1.6.5
-	gopkg.in/yaml.v2 v2.2.4
+	google.golang.org/grpc v1.24.0
+	gopkg.in/yaml.v2 v2.4.0
 	helm.sh/helm/v3 v3.0.1
+	k8s.io/api v0.0.0-20191016110408-35e52d86657a
 	k8s.io/apimachinery v0.0.0-20191004115801-a2eda9f80ab8
 	k8s.io/client-go v0.0.0-20191016111102-bec269661e48
 )
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	"github.com/olekukonko/tablewriter"
+)
+
+// AddonInfo contains the compatibility of an installed addon with the Kubernetes version of the cluster
+type AddonInfo struct {
+	Addon         string
+	Image         string
+	Version       string
+	LatestVersion string
+	Kubernetes    string
+	// Supported are the Kubernetes versions that the installed version of the addon supports
+	Supported  string
+	Compatible bool
+	// LatestCompatible is true when the latest version of the addon supports the Kubernetes version as well
+	LatestCompatible bool
+}
+
+// getKubernetesVersion returns the version of the API server for the addon checks, the version of the control plane check when it ran
+func getKubernetesVersion(ctx context.Context, config config.Config, controlPlane ControlPlaneInfo, problems *scanProblems) string {
+	if controlPlane.Version != "" {
+		return controlPlane.Version
+	}
+	serverVersion, err := kubernetes.GetServerVersion(ctx, config.RunningLocally())
+	problems.add(SectionKubernetes, "addons", err)
+	return serverVersion
+}
+
+// checkAddons returns the compatibility of the images of known addons with the Kubernetes version,
+// the versions that are not in the compatibility table of the addon are left out
+func checkAddons(addons []versioning.Addon, kubernetesVersion string, info []ContainerInfo) []AddonInfo {
+	checked := []AddonInfo{}
+	if kubernetesVersion == "" {
+		return checked
+	}
+	for _, container := range info {
+		for _, addon := range addons {
+			if !addon.Matches(container.Container.Name) {
+				continue
+			}
+			supported, compatible, known := addon.Compatible(container.Container.Version, kubernetesVersion)
+			if !known {
+				break
+			}
+			_, latestCompatible, _ := addon.Compatible(container.LatestVersion, kubernetesVersion)
+			checked = append(checked, AddonInfo{
+				Addon:            addon.Name,
+				Image:            container.Container.FullPath,
+				Version:          container.Container.Version,
+				LatestVersion:    container.LatestVersion,
+				Kubernetes:       kubernetesVersion,
+				Supported:        supported,
+				Compatible:       compatible,
+				LatestCompatible: latestCompatible,
+			})
+			break
+		}
+	}
+	return checked
+}
+
+// GetStatus returns a failure when the installed version of the addon doesn't support the Kubernetes version
+func (a AddonInfo) GetStatus() string {
+	if !a.Compatible {
+		return versioning.Failure
+	}
+	return versioning.DetermineLifeCycleStatus(a.LatestVersion, a.Version)
+}
+
+func countIncompatibleAddons(addons []AddonInfo) int {
+	incompatible := 0
+	for _, addon := range addons {
+		if !addon.Compatible {
+			incompatible++
+		}
+	}
+	return incompatible
+}
+
+func prettyPrintAddonInfo(addons []AddonInfo) {
+	if len(addons) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Addon", "Version", "Kubernetes", "Supported Kubernetes", "Compatible", "Latest", "Latest compatible"})
+	table.SetColumnAlignment([]int{3, 1, 1, 3, 1, 1, 1})
+	table.SetAutoWrapText(false)
+
+	for _, addon := range addons {
+		table.Append([]string{
+			addon.Addon,
+			addon.Version,
+			addon.Kubernetes,
+			addon.Supported,
+			strconv.FormatBool(addon.Compatible),
+			addon.LatestVersion,
+			strconv.FormatBool(addon.LatestCompatible),
+		})
+	}
+	table.Render()
+}
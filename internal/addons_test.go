@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestCheckAddons(t *testing.T) {
+	info := []ContainerInfo{
+		{Container: kubernetes.Container{FullPath: "registry.k8s.io/ingress-nginx/controller:v1.9.6", Name: "ingress-nginx/controller", Version: "v1.9.6"}, LatestVersion: "v1.12.1"},
+		{Container: kubernetes.Container{FullPath: "nginx:1.0", Name: "library/nginx", Version: "1.0"}, LatestVersion: "1.1"},
+	}
+	addons := checkAddons(versioning.DefaultAddons(), "v1.30.2", info)
+	if len(addons) != 1 {
+		t.Fatalf("Expected only ingress-nginx to be checked but got %v", addons)
+	}
+	if addons[0].Compatible || !addons[0].LatestCompatible || addons[0].GetStatus() != versioning.Failure {
+		t.Errorf("Expected v1.9.6 to not support 1.30 but v1.12.1 to support it, got %v", addons[0])
+	}
+	if countIncompatibleAddons(addons) != 1 {
+		t.Errorf("Expected one incompatible addon")
+	}
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ClusterResult contains the images and charts of one of the clusters when multiple clusters are scanned
+type ClusterResult struct {
+	Cluster       Cluster
+	ContainerInfo []ContainerInfo
+	ChartInfo     []ChartInfo
+	NodeInfo      []NodeInfo
+	ControlPlane  ControlPlaneInfo
+	AddonInfo     []AddonInfo
+	Problems      []ScanProblem
+	Skipped       []string
+}
+
+// clusterScan contains what is found in a single cluster, every cluster has its own problems so a failing cluster doesn't affect the others
+type clusterScan struct {
+	cluster      config.Cluster
+	containers   []kubernetes.Container
+	pullSecrets  map[string]map[string]registries.Credential
+	charts       []ChartInfo
+	nodes        []NodeInfo
+	controlPlane ControlPlaneInfo
+	// kubernetesVersion is the version of the API server for the addon checks
+	kubernetesVersion string
+	problems          *scanProblems
+}
+
+// context returns the context that makes the Kubernetes calls go to the cluster
+func (c clusterScan) context(ctx context.Context) context.Context {
+	return kubernetes.WithTarget(ctx, kubernetes.Target{Kubeconfig: c.cluster.Kubeconfig, Context: c.cluster.Context})
+}
+
+func (c clusterScan) namespaces(config config.Config) []string {
+	if len(c.cluster.Namespaces) > 0 {
+		return c.cluster.Namespaces
+	}
+	return config.Namespaces
+}
+
+// getContainersFromClusters fetches the containers of all clusters at the same time
+func getContainersFromClusters(ctx context.Context, config config.Config, progress ProgressFunc) []clusterScan {
+	clusters := make([]clusterScan, len(config.Clusters))
+	runParallel(SectionKubernetes, len(clusters), config.Workers.Clusters, progress, func(index int) {
+		scan := clusterScan{cluster: config.Clusters[index], problems: &scanProblems{}}
+		containers, err := kubernetes.GetContainersFromNamespaces(scan.context(ctx), scan.namespaces(config), config.RunningLocally(), config.GetKubernetesOptions())
+		scan.problems.add(SectionKubernetes, "containers", err)
+		scan.pullSecrets = getPullSecretCredentials(scan.context(ctx), containers, config, scan.problems)
+		scan.containers = uniqueContainers(containers)
+		if config.IsNodeScanEnabled() {
+			scan.nodes = getNodeInfo(scan.context(ctx), config.RunningLocally(), scan.problems)
+		}
+		if config.IsControlPlaneCheckEnabled() {
+			scan.controlPlane = getControlPlaneInfo(scan.context(ctx), config.KubernetesReleases, config.RunningLocally(), scan.problems)
+		}
+		if config.IsAddonCheckEnabled() {
+			scan.kubernetesVersion = getKubernetesVersion(scan.context(ctx), config, scan.controlPlane, scan.problems)
+		}
+		clusters[index] = scan
+	})
+	return clusters
+}
+
+// getChartsFromClusters fetches the charts of all clusters at the same time and looks up their latest versions
+func getChartsFromClusters(ctx context.Context, config config.Config, clusters []clusterScan, progress ProgressFunc) {
+	runParallel(SectionCharts, len(clusters), config.Workers.Clusters, progress, func(index int) {
+		scan := &clusters[index]
+		scan.charts = getLatestVersionsForHelmCharts(scan.context(ctx), config.HelmRegistries, scan.namespaces(config), config.RunningLocally(), config.Workers.Charts, scan.problems, func(string, int, int) {})
+	})
+}
+
+// clusterResults splits the checked images over the clusters they run in and sets the clusters of the checked images,
+// the images that don't run in any of the clusters like the images from the config and the collectors are returned separately
+func clusterResults(clusters []clusterScan, info []ContainerInfo) ([]ClusterResult, []ContainerInfo) {
+	checked := map[string]ContainerInfo{}
+	for _, ci := range info {
+		checked[imageKey(ci.Container)] = ci
+	}
+
+	inCluster := map[string][]string{}
+	results := make([]ClusterResult, len(clusters))
+	for index, scan := range clusters {
+		result := ClusterResult{
+			Cluster:      Cluster{Name: scan.cluster.Name, Labels: scan.cluster.Labels},
+			ChartInfo:    scan.charts,
+			NodeInfo:     scan.nodes,
+			ControlPlane: scan.controlPlane,
+			Problems:     scan.problems.sorted(),
+			Skipped:      scan.problems.sortedSkipped(),
+		}
+		for _, container := range scan.containers {
+			ci := checked[imageKey(container)]
+			// the namespaces of the checked image are those of all clusters, only keep the namespaces of this cluster
+			ci.Container = container
+			result.ContainerInfo = append(result.ContainerInfo, ci)
+			inCluster[imageKey(container)] = append(inCluster[imageKey(container)], scan.cluster.Name)
+		}
+		sortContainerInfo(result.ContainerInfo)
+		results[index] = result
+	}
+
+	var other []ContainerInfo
+	for index, ci := range info {
+		info[index].Clusters = inCluster[imageKey(ci.Container)]
+		if len(info[index].Clusters) == 0 {
+			other = append(other, ci)
+		}
+	}
+	return results, other
+}
+
+// addClusterProblems adds the problems and the skipped resources of the clusters to the scan with the name of the cluster in front
+func addClusterProblems(problems *scanProblems, clusters []ClusterResult) {
+	for _, cluster := range clusters {
+		for _, skipped := range cluster.Skipped {
+			problems.skip(cluster.Cluster.Name + "/" + skipped)
+		}
+	}
+	problems.lock.Lock()
+	defer problems.lock.Unlock()
+	for _, cluster := range clusters {
+		for _, problem := range cluster.Problems {
+			problem.Item = cluster.Cluster.Name + "/" + problem.Item
+			problems.problems = append(problems.problems, problem)
+		}
+	}
+}
+
+func prettyPrintClusterResults(clusters []ClusterResult, other []ContainerInfo) {
+	for _, cluster := range clusters {
+		prettyPrintCluster(cluster.Cluster)
+		prettyPrintContainerInfo(cluster.ContainerInfo)
+		prettyPrintChartInfo(cluster.ChartInfo)
+		prettyPrintControlPlaneInfo(cluster.ControlPlane)
+		prettyPrintAddonInfo(cluster.AddonInfo)
+		prettyPrintNodeInfo(cluster.NodeInfo)
+	}
+	if len(other) > 0 {
+		fmt.Fprintln(os.Stdout, "Images not running in any of the clusters")
+		prettyPrintContainerInfo(other)
+	}
+}
+
+// prettyPrintCombinedClusterResults prints the images of all clusters in one table with a row per cluster an image runs in,
+// so the versions of the same image in the different clusters are next to each other
+func prettyPrintCombinedClusterResults(clusters []ClusterResult, other []ContainerInfo) {
+	type clusterRow struct {
+		cluster string
+		info    ContainerInfo
+	}
+	var rows []clusterRow
+	for _, cluster := range clusters {
+		for _, ci := range cluster.ContainerInfo {
+			rows = append(rows, clusterRow{cluster: cluster.Cluster.Name, info: ci})
+		}
+	}
+	for _, ci := range other {
+		rows = append(rows, clusterRow{info: ci})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].info.Container.Name != rows[j].info.Container.Name {
+			return rows[i].info.Container.Name < rows[j].info.Container.Name
+		}
+		return rows[i].cluster < rows[j].cluster
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Cluster", "Version", "Latest", "Upgrade", "Behind", "Cves"})
+	table.SetColumnAlignment([]int{3, 3, 1, 1, 1, 1, 3})
+	for _, row := range rows {
+		table.Append([]string{
+			row.info.Container.Name,
+			row.cluster,
+			row.info.Container.Version,
+			row.info.LatestVersion,
+			row.info.Upgrade,
+			strconv.Itoa(row.info.Behind),
+			row.info.GetCveStatus(),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestClusterResults(t *testing.T) {
+	nginx := kubernetes.Container{URL: "docker.io", Name: "library/nginx", Version: "1.0"}
+	redis := kubernetes.Container{URL: "docker.io", Name: "library/redis", Version: "5", Namespaces: []string{"frontend"}}
+	extra := kubernetes.Container{URL: "quay.io", Name: "a/b", Version: "1.0"}
+	inOne, inTwo := nginx, nginx
+	inOne.Namespaces = []string{"web"}
+	inTwo.Namespaces = []string{"frontend"}
+
+	clusters := []clusterScan{
+		{cluster: config.Cluster{Name: "one"}, containers: []kubernetes.Container{inOne}, problems: &scanProblems{}},
+		{cluster: config.Cluster{Name: "two"}, containers: []kubernetes.Container{redis, inTwo}, problems: &scanProblems{}},
+	}
+	clusters[1].problems.add(SectionKubernetes, "charts", errors.New("unreachable"))
+	clusters[0].problems.add(SectionKubernetes, "containers", &kubernetes.SkippedError{Namespace: "web", Resource: "pods", Err: errors.New("forbidden")})
+	info := []ContainerInfo{
+		{Container: nginx, LatestVersion: "1.1"},
+		{Container: redis, LatestVersion: "6"},
+		{Container: extra, LatestVersion: "2.0"},
+	}
+
+	results, other := clusterResults(clusters, info)
+	if len(results) != 2 || len(other) != 1 || other[0].Container.Name != "a/b" {
+		t.Fatalf("Unexpected results %v and other %v", results, other)
+	}
+	if len(results[0].ContainerInfo) != 1 || results[0].ContainerInfo[0].LatestVersion != "1.1" || results[0].ContainerInfo[0].Container.Namespaces[0] != "web" {
+		t.Errorf("Unexpected cluster one %v", results[0].ContainerInfo)
+	}
+	if len(info[0].Clusters) != 2 || info[0].Clusters[1] != "two" || len(info[2].Clusters) != 0 {
+		t.Errorf("Expected nginx in both clusters and the extra image in none but got %v and %v", info[0].Clusters, info[2].Clusters)
+	}
+	if len(results[1].ContainerInfo) != 2 || results[1].ContainerInfo[0].Container.Name != "library/nginx" || results[1].ContainerInfo[0].Container.Namespaces[0] != "frontend" {
+		t.Errorf("Unexpected cluster two %v", results[1].ContainerInfo)
+	}
+
+	problems := &scanProblems{}
+	addClusterProblems(problems, results)
+	if len(problems.problems) != 1 || problems.problems[0].Item != "two/charts" {
+		t.Errorf("Unexpected problems %v", problems.problems)
+	}
+	if skipped := problems.sortedSkipped(); len(skipped) != 1 || skipped[0] != "one/pods in namespace [web]" {
+		t.Errorf("Expected the forbidden pods as skipped and not as problem but got %v", skipped)
+	}
+}
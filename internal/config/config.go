@@ -1,66 +1,612 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
 	log "github.com/sirupsen/logrus"
 
-	"github.com/arminc/k8s-platform-lcm/internal/registries"
-	"github.com/arminc/k8s-platform-lcm/internal/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/tracing"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var logger = log.WithField("component", "config")
+
+const (
+	// CommandScan runs the scan and prints the results, it is the default command
+	CommandScan = "scan"
+	// CommandTUI runs the scan in the interactive terminal UI
+	CommandTUI = "tui"
+
+	profilesKey = "profiles"
+	// LogFormatText is the default human readable log format
+	LogFormatText = "text"
+	// LogFormatJSON is the structured json log format
+	LogFormatJSON = "json"
+	// ClusterReportSections prints a section per cluster when multiple clusters are scanned, it is the default
+	ClusterReportSections = "sections"
+	// ClusterReportCombined prints the images of all clusters in one table with a cluster column
+	ClusterReportCombined = "combined"
+	// SbomCycloneDX writes the SBOM of the images as CycloneDX json
+	SbomCycloneDX = "cyclonedx"
+	// SbomSPDX writes the SBOM of the images as SPDX json
+	SbomSPDX = "spdx"
+	// ProjectPerNamespace exports the images of every namespace as a project, it is the default
+	ProjectPerNamespace = "namespace"
+	// ProjectPerWorkload exports the images of every workload as a project
+	ProjectPerWorkload = "workload"
 )
 
 // Config of the lcm application, normally loaded from the config file
 type Config struct {
 	CliFlags               AppConfig
-	AppConfig              AppConfig                  `koanf:"app"`
-	KubernetesFetchEnabled bool                       `koanf:"kubernetesFetchEnabled"`
-	Namespaces             []string                   `koanf:"namespaces"`
-	ImageRegistries        registries.ImageRegistries `koanf:"imageRegistries"`
-	ImageScanners          scanning.ImageScanners     `koanf:"imageScanners"`
-	ToolRegistries         registries.ToolRegistries  `koanf:"toolRegistries"`
-	Tools                  []registries.Tool          `koanf:"tools"`
-	Images                 []string                   `koanf:"images"`
-	HelmRegistries         registries.HelmRegistries  `koanf:"helmRegistries"`
+	AppConfig              AppConfig                     `koanf:"app"`
+	ClusterName            string                        `koanf:"clusterName"`
+	ClusterLabels          map[string]string             `koanf:"clusterLabels"`
+	Clusters               []Cluster                     `koanf:"clusters"`
+	ClusterReport          string                        `koanf:"clusterReport"`
+	ClusterDrift           ClusterDrift                  `koanf:"clusterDrift"`
+	Sharding               Sharding                      `koanf:"sharding"`
+	KubernetesFetchEnabled bool                          `koanf:"kubernetesFetchEnabled"`
+	Namespaces             []string                      `koanf:"namespaces"`
+	ExcludeNamespaces      []string                      `koanf:"excludeNamespaces"`
+	PodLabelSelector       string                        `koanf:"podLabelSelector"`
+	KubernetesPageSize     int                           `koanf:"kubernetesPageSize"`
+	KubernetesWorkloads    bool                          `koanf:"kubernetesWorkloads"`
+	KubernetesOpenShift    bool                          `koanf:"kubernetesOpenShift"`
+	KubernetesPodCache     bool                          `koanf:"kubernetesPodCache"`
+	KubernetesNodes        bool                          `koanf:"kubernetesNodes"`
+	KubernetesReleases     registries.KubernetesReleases `koanf:"kubernetesReleases"`
+	AddonChecks            AddonChecks                   `koanf:"addonChecks"`
+	CustomResources        CustomResources               `koanf:"customResources"`
+	ImageRegistries        registries.ImageRegistries    `koanf:"imageRegistries"`
+	RegistryPolicy         registries.RegistryPolicy     `koanf:"registryPolicy"`
+	ImageScanners          scanning.ImageScanners        `koanf:"imageScanners"`
+	ToolRegistries         registries.ToolRegistries     `koanf:"toolRegistries"`
+	Tools                  []registries.Tool             `koanf:"tools"`
+	Images                 []string                      `koanf:"images"`
+	HelmRegistries         registries.HelmRegistries     `koanf:"helmRegistries"`
+	Timeouts               Timeouts                      `koanf:"timeouts"`
+	Workers                Workers                       `koanf:"workers"`
+	Plugins                plugins.Plugins               `koanf:"plugins"`
+	HTTP                   httpclient.Config             `koanf:"http"`
+	Cache                  cache.Config                  `koanf:"cache"`
+	Tracing                tracing.Config                `koanf:"tracing"`
+	Audit                  audit.Config                  `koanf:"audit"`
+	DependencyTrack        DependencyTrack               `koanf:"dependencyTrack"`
+	DefectDojo             DefectDojo                    `koanf:"defectDojo"`
+}
+
+// Cluster is one of the clusters that are scanned at the same time, the namespaces default to the namespaces of the config
+type Cluster struct {
+	Name       string            `koanf:"name"`
+	Labels     map[string]string `koanf:"labels"`
+	Kubeconfig string            `koanf:"kubeconfig"`
+	Context    string            `koanf:"context"`
+	Namespaces []string          `koanf:"namespaces"`
+}
+
+// ClusterDrift compares the versions of the images that the workloads run in the clusters, like staging and production,
+// all the clusters are compared when there are no clusters
+type ClusterDrift struct {
+	Enabled  bool     `koanf:"enabled"`
+	Clusters []string `koanf:"clusters"`
+}
+
+// AddonChecks checks if the installed versions of the core addons support the Kubernetes version of the cluster,
+// the addons replace the defaults with the same name and add new ones
+type AddonChecks struct {
+	Enabled bool               `koanf:"enabled"`
+	Addons  []versioning.Addon `koanf:"addons"`
+}
+
+// CustomResources finds the images in custom resources that only show up in pods at runtime, like the templates of Argo Workflows,
+// the resources replace the defaults with the same name and add new ones
+type CustomResources struct {
+	Enabled   bool                        `koanf:"enabled"`
+	Resources []kubernetes.CustomResource `koanf:"resources"`
+}
+
+// DependencyTrack uploads a CycloneDX SBOM per namespace or per workload to Dependency-Track after every scan,
+// the projects are created when they don't exist yet and the cluster is the version of the projects
+type DependencyTrack struct {
+	Enabled       bool   `koanf:"enabled"`
+	URL           string `koanf:"url"`
+	APIKey        string `koanf:"apiKey"`
+	ProjectPer    string `koanf:"projectPer"`    // namespace or workload, default is namespace
+	ProjectPrefix string `koanf:"projectPrefix"` // Added in front of the project names like k8s/
+	// Packages adds the packages of the images from the scanners that list them, so Dependency-Track can analyze them
+	Packages bool `koanf:"packages"`
+}
+
+// DefectDojo imports the vulnerabilities of every image into DefectDojo after every scan, the image is the test of the engagement of the cluster
+// in the product of the image or in the product when it is set. The products and engagements are created when they don't exist yet
+type DefectDojo struct {
+	Enabled     bool   `koanf:"enabled"`
+	URL         string `koanf:"url"`
+	APIKey      string `koanf:"apiKey"`
+	Product     string `koanf:"product"`     // The product of all images, by default every image is a product
+	ProductType string `koanf:"productType"` // The product type of the products that are created, default is Kubernetes
+}
+
+// Sharding splits the namespaces over multiple replicas that each scan their own shard,
+// without an index the index is the ordinal at the end of the hostname like lcm-2 of a StatefulSet
+type Sharding struct {
+	Shards int `koanf:"shards"`
+	Index  int `koanf:"index"`
+}
+
+// LogRotation contains the settings for rotating the log file
+type LogRotation struct {
+	MaxSize    int    `koanf:"maxSize"`    // In megabytes
+	MaxAge     string `koanf:"maxAge"`     // In time.Duration format like 24h
+	MaxBackups int    `koanf:"maxBackups"` // Number of rotated files to keep
+}
+
+// LeaderElection contains the settings for electing the replica that scans when running the server with multiple replicas,
+// the durations are in time.Duration format like 15s
+type LeaderElection struct {
+	Enabled       bool   `koanf:"enabled"`
+	Namespace     string `koanf:"namespace"`
+	Name          string `koanf:"name"`
+	LeaseDuration string `koanf:"leaseDuration"`
+	RenewDeadline string `koanf:"renewDeadline"`
+	RetryPeriod   string `koanf:"retryPeriod"`
+}
+
+// Timeouts contains the timeouts for the calls to external systems and the deadline for the whole scan, in time.Duration format like 30s or 5m
+type Timeouts struct {
+	Kubernetes string        `koanf:"kubernetes"`
+	Registry   string        `koanf:"registry"`
+	Scanner    string        `koanf:"scanner"`
+	Tool       string        `koanf:"tool"`
+	Scan       string        `koanf:"scan"`
+	Phases     PhaseTimeouts `koanf:"phases"`
+}
+
+// PhaseTimeouts contains the deadlines per phase of the scan, a phase without a deadline can use what is left of the scan deadline
+type PhaseTimeouts struct {
+	Kubernetes      string `koanf:"kubernetes"`
+	Images          string `koanf:"images"`
+	Vulnerabilities string `koanf:"vulnerabilities"`
+	Charts          string `koanf:"charts"`
+	Tools           string `koanf:"tools"`
+	Reporters       string `koanf:"reporters"`
+}
+
+// Workers contains the number of parallel workers per phase of the scan, clusters is the number of clusters fetched at the same time
+type Workers struct {
+	Images          int `koanf:"images"`
+	Vulnerabilities int `koanf:"vulnerabilities"`
+	Charts          int `koanf:"charts"`
+	Tools           int `koanf:"tools"`
+	Clusters        int `koanf:"clusters"`
 }
 
 // AppConfig is the config for the app which can be set trough cli and config
 type AppConfig struct {
+	Command            string
 	Locally            bool
 	ConfigFile         string
-	StartServer        bool   `koanf:"startServer"`
-	JsonLoggingEnabled bool   `koanf:"jsonLoggingEnabled"`
-	LogFile            string `koanf:"logFile"`
-	Verbose            bool   `koanf:"verbose"`
-	Debug              bool   `koanf:"debug"`
+	ConfigMap          string
+	ConfigResource     string
+	Profile            string
+	PodLabelSelector   string
+	Scanners           []string
+	Sbom               string
+	SbomFile           string
+	SbomPackages       bool
+	StartServer        bool           `koanf:"startServer"`
+	GrpcAddress        string         `koanf:"grpcAddress"`
+	DebugEndpoints     bool           `koanf:"debugEndpoints"`
+	LeaderElection     LeaderElection `koanf:"leaderElection"`
+	WatchWorkloads     bool           `koanf:"watchWorkloads"`
+	RescanDebounce     string         `koanf:"rescanDebounce"`
+	RescanMaxWait      string         `koanf:"rescanMaxWait"`
+	ReadyStaleness     string         `koanf:"readyStaleness"`
+	ConfigReload       string         `koanf:"configReload"`
+	JsonLoggingEnabled bool           `koanf:"jsonLoggingEnabled"`
+	LogFormat          string         `koanf:"logFormat"`
+	LogFile            string         `koanf:"logFile"`
+	LogToStdout        bool           `koanf:"logToStdout"`
+	LogRotation        LogRotation    `koanf:"logRotation"`
+	FailOn             []string       `koanf:"failOn"`
+	Verbose            bool           `koanf:"verbose"`
+	Debug              bool           `koanf:"debug"`
+}
+
+// LoadConfiguration loads the configuration from file, when a profile name is provided the profile overrides the config
+func LoadConfiguration(configFile, profile string) (Config, error) {
+	logger.WithField("configFile", configFile).Debug("Loading config file")
+	return loadConfiguration(file.Provider(configFile), profile)
+}
+
+// LoadConfigurationFromCluster loads the configuration from a ConfigMap or a LifecycleScan resource when one is provided, otherwise from file
+func LoadConfigurationFromCluster(cliFlags AppConfig) (Config, error) {
+	if !cliFlags.IsClusterConfig() {
+		return LoadConfiguration(cliFlags.ConfigFile, cliFlags.Profile)
+	}
+	data, err := fetchClusterConfig(cliFlags)
+	if err != nil {
+		return Config{}, err
+	}
+	return loadConfiguration(rawbytes.Provider(data), cliFlags.Profile)
+}
+
+// IsClusterConfig returns true when the config is loaded from a ConfigMap or a LifecycleScan resource
+func (a AppConfig) IsClusterConfig() bool {
+	return a.ConfigMap != "" || a.ConfigResource != ""
+}
+
+// RestartRequired returns the settings that are different in the changed config and are only applied when lcm starts,
+// like logging, tracing, the audit log and the server. All other settings are applied when the config is reloaded
+func (c Config) RestartRequired(changed Config) []string {
+	settings := []struct {
+		name             string
+		current, changed interface{}
+	}{
+		{"app.startServer", c.AppConfig.StartServer, changed.AppConfig.StartServer},
+		{"app.grpcAddress", c.AppConfig.GrpcAddress, changed.AppConfig.GrpcAddress},
+		{"app.debugEndpoints", c.AppConfig.DebugEndpoints, changed.AppConfig.DebugEndpoints},
+		{"app.leaderElection.enabled", c.AppConfig.LeaderElection.Enabled, changed.AppConfig.LeaderElection.Enabled},
+		{"app.configReload", c.AppConfig.ConfigReload, changed.AppConfig.ConfigReload},
+		{"app.jsonLoggingEnabled", c.AppConfig.JsonLoggingEnabled, changed.AppConfig.JsonLoggingEnabled},
+		{"app.logFormat", c.AppConfig.LogFormat, changed.AppConfig.LogFormat},
+		{"app.logFile", c.AppConfig.LogFile, changed.AppConfig.LogFile},
+		{"app.logToStdout", c.AppConfig.LogToStdout, changed.AppConfig.LogToStdout},
+		{"app.logRotation", c.AppConfig.LogRotation, changed.AppConfig.LogRotation},
+		{"app.verbose", c.AppConfig.Verbose, changed.AppConfig.Verbose},
+		{"app.debug", c.AppConfig.Debug, changed.AppConfig.Debug},
+		{"tracing", c.Tracing, changed.Tracing},
+		{"audit", c.Audit, changed.Audit},
+	}
+	var names []string
+	for _, setting := range settings {
+		if !reflect.DeepEqual(setting.current, setting.changed) {
+			names = append(names, setting.name)
+		}
+	}
+	return names
+}
+
+func fetchClusterConfig(cliFlags AppConfig) ([]byte, error) {
+	var data []byte
+	var err error
+	if cliFlags.ConfigMap != "" {
+		data, err = kubernetes.GetConfigFromConfigMap(cliFlags.ConfigMap, cliFlags.Locally)
+	} else {
+		data, err = kubernetes.GetConfigFromLifecycleScan(cliFlags.ConfigResource, cliFlags.Locally)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching config from the cluster: %w", err)
+	}
+	return data, nil
+}
+
+// WatchConfigFromCluster fetches the ConfigMap or LifecycleScan resource of the config every interval until the context is done,
+// and passes the config to onChange when the resource changed. A config that can't be fetched or loaded is logged and the current
+// config is kept
+func WatchConfigFromCluster(ctx context.Context, cliFlags AppConfig, interval time.Duration, onChange func(Config)) {
+	current, err := fetchClusterConfig(cliFlags)
+	if err != nil {
+		logger.WithError(err).Warn("Could not fetch the config, changes are picked up once it can be fetched")
+	}
+	watchConfig(ctx, interval, current, func() ([]byte, error) {
+		return fetchClusterConfig(cliFlags)
+	}, func(data []byte) {
+		config, err := loadConfiguration(rawbytes.Provider(data), cliFlags.Profile)
+		if err != nil {
+			logger.WithError(err).Error("Could not load the changed config, the current config is kept")
+			return
+		}
+		config.CliFlags = cliFlags
+		logger.Info("Config changed, applying it")
+		onChange(config)
+	})
 }
 
-// LoadConfiguration loads the configuration from file
-func LoadConfiguration(configFile string) Config {
-	log.WithField("configFile", configFile).Debug("Loading config file")
+func watchConfig(ctx context.Context, interval time.Duration, current []byte, fetch func() ([]byte, error), onChange func([]byte)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		data, err := fetch()
+		if err != nil {
+			logger.WithError(err).Warn("Could not fetch the config, the current config is kept")
+			continue
+		}
+		if bytes.Equal(data, current) {
+			continue
+		}
+		current = data
+		onChange(data)
+	}
+}
 
+func loadConfiguration(provider koanf.Provider, profile string) (Config, error) {
 	var lcmConfig Config
 	k := koanf.New(".")
 
 	// load defaults
 	if err := k.Load(confmap.Provider(map[string]interface{}{
-		"kubernetesFetchEnabled": "true",
-		"jsonLoggingEnabled":     "false",
+		"kubernetesFetchEnabled":               "true",
+		"kubernetesPageSize":                   500,
+		"kubernetesWorkloads":                  "true",
+		"kubernetesOpenShift":                  "false",
+		"clusterReport":                        ClusterReportSections,
+		"kubernetesReleases.url":               registries.DefaultKubernetesReleasesURL,
+		"kubernetesReleases.supportedMinors":   3,
+		"jsonLoggingEnabled":                   "false",
+		"timeouts.kubernetes":                  "30s",
+		"timeouts.registry":                    "30s",
+		"timeouts.scanner":                     "60s",
+		"timeouts.tool":                        "30s",
+		"timeouts.scan":                        "15m",
+		"app.rescanDebounce":                   "30s",
+		"app.rescanMaxWait":                    "5m",
+		"app.configReload":                     "1m",
+		"app.leaderElection.name":              "lcm",
+		"app.leaderElection.leaseDuration":     "15s",
+		"app.leaderElection.renewDeadline":     "10s",
+		"app.leaderElection.retryPeriod":       "2s",
+		"defectDojo.productType":               "Kubernetes",
+		"http.retry.attempts":                  3,
+		"http.circuitBreaker.failureThreshold": 5,
+		"http.conditionalRequests.enabled":     true,
+		"http.adaptiveRateLimits.enabled":      true,
+		"workers.images":                       10,
+		"workers.vulnerabilities":              5,
+		"workers.charts":                       5,
+		"workers.tools":                        5,
+		"workers.clusters":                     5,
+		"sharding.index":                       -1,
+		"imageRegistries.pullSecrets":          false,
+		"imageRegistries.mutableTags":          []string{"latest", "stable"},
 	}, "."), nil); err != nil {
-		log.WithError(err).Fatal("Error loading config")
+		return lcmConfig, fmt.Errorf("Error loading config: %w", err)
 	}
 
-	if err := k.Load(file.Provider(configFile), yaml.Parser()); err != nil {
-		log.WithError(err).Fatal("Error loading config")
+	if err := k.Load(provider, yaml.Parser()); err != nil {
+		return lcmConfig, fmt.Errorf("Error loading config: %w", err)
+	}
+
+	if profile != "" {
+		if err := applyProfile(k, profile); err != nil {
+			return lcmConfig, err
+		}
 	}
 
 	if err := k.Unmarshal("", &lcmConfig); err != nil {
-		log.WithError(err).Fatal("Error unmarshaling config")
+		return lcmConfig, fmt.Errorf("Error unmarshaling config: %w", err)
+	}
+
+	if err := lcmConfig.validate(); err != nil {
+		return lcmConfig, err
 	}
 
 	lcmConfig.ImageRegistries.DefaultRegistries()
-	return lcmConfig
+	return lcmConfig, nil
+}
+
+// applyProfile merges the settings of the named profile on top of the config, every top level setting can be overridden
+func applyProfile(k *koanf.Koanf, profile string) error {
+	path := profilesKey + "." + profile
+	if !k.Exists(path) {
+		return fmt.Errorf("Profile [%s] not found in config", profile)
+	}
+	logger.WithField("profile", profile).Debug("Applying profile")
+	k.Merge(k.Cut(path))
+	return nil
+}
+
+// validate checks the settings that can't be checked while unmarshaling
+func (c Config) validate() error {
+	durations := map[string]string{
+		"timeouts.kubernetes":              c.Timeouts.Kubernetes,
+		"timeouts.registry":                c.Timeouts.Registry,
+		"timeouts.scanner":                 c.Timeouts.Scanner,
+		"timeouts.tool":                    c.Timeouts.Tool,
+		"timeouts.scan":                    c.Timeouts.Scan,
+		"timeouts.phases.kubernetes":       c.Timeouts.Phases.Kubernetes,
+		"timeouts.phases.images":           c.Timeouts.Phases.Images,
+		"timeouts.phases.vulnerabilities":  c.Timeouts.Phases.Vulnerabilities,
+		"timeouts.phases.charts":           c.Timeouts.Phases.Charts,
+		"timeouts.phases.tools":            c.Timeouts.Phases.Tools,
+		"timeouts.phases.reporters":        c.Timeouts.Phases.Reporters,
+		"app.logRotation.maxAge":           c.AppConfig.LogRotation.MaxAge,
+		"app.rescanDebounce":               c.AppConfig.RescanDebounce,
+		"app.rescanMaxWait":                c.AppConfig.RescanMaxWait,
+		"app.readyStaleness":               c.AppConfig.ReadyStaleness,
+		"app.configReload":                 c.AppConfig.ConfigReload,
+		"app.leaderElection.leaseDuration": c.AppConfig.LeaderElection.LeaseDuration,
+		"app.leaderElection.renewDeadline": c.AppConfig.LeaderElection.RenewDeadline,
+		"app.leaderElection.retryPeriod":   c.AppConfig.LeaderElection.RetryPeriod,
+	}
+	for name, value := range durations {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("Setting [%s] is not a valid duration: %w", name, err)
+		}
+	}
+
+	workers := map[string]int{
+		"workers.images":          c.Workers.Images,
+		"workers.vulnerabilities": c.Workers.Vulnerabilities,
+		"workers.charts":          c.Workers.Charts,
+		"workers.tools":           c.Workers.Tools,
+		"workers.clusters":        c.Workers.Clusters,
+	}
+	for name, value := range workers {
+		if value < 1 {
+			return fmt.Errorf("Setting [%s] must be at least 1 but is [%d]", name, value)
+		}
+	}
+	if c.KubernetesPageSize < 1 {
+		return fmt.Errorf("Setting [kubernetesPageSize] must be at least 1 but is [%d]", c.KubernetesPageSize)
+	}
+
+	names := map[string]bool{}
+	for _, cluster := range c.Clusters {
+		if cluster.Name == "" {
+			return fmt.Errorf("Every cluster in [clusters] needs a name")
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("Cluster [%s] is configured more than once", cluster.Name)
+		}
+		names[cluster.Name] = true
+	}
+	if c.ClusterDrift.Enabled && len(c.GetDriftClusters()) < 2 {
+		return fmt.Errorf("Setting [clusterDrift] needs at least 2 clusters to compare")
+	}
+	for _, cluster := range c.ClusterDrift.Clusters {
+		if !names[cluster] {
+			return fmt.Errorf("Cluster [%s] of [clusterDrift] is not one of the clusters", cluster)
+		}
+	}
+	for _, namespace := range append(append([]string{}, c.Namespaces...), c.ExcludeNamespaces...) {
+		if _, err := regexp.Compile(namespace); err != nil {
+			return fmt.Errorf("Namespace [%s] not valid: %w", namespace, err)
+		}
+	}
+	if _, err := labels.Parse(c.PodLabelSelector); err != nil {
+		return fmt.Errorf("Setting [podLabelSelector] not valid: %w", err)
+	}
+	for _, addon := range c.AddonChecks.Addons {
+		if err := addon.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, resource := range c.CustomResources.Resources {
+		if err := resource.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.KubernetesReleases.SupportedMinors < 1 {
+		return fmt.Errorf("Setting [kubernetesReleases.supportedMinors] must be at least 1 but is [%d]", c.KubernetesReleases.SupportedMinors)
+	}
+	if c.DependencyTrack.Enabled && (c.DependencyTrack.URL == "" || c.DependencyTrack.APIKey == "") {
+		return fmt.Errorf("Settings [dependencyTrack.url] and [dependencyTrack.apiKey] are needed for Dependency-Track")
+	}
+	if c.DefectDojo.Enabled && (c.DefectDojo.URL == "" || c.DefectDojo.APIKey == "") {
+		return fmt.Errorf("Settings [defectDojo.url] and [defectDojo.apiKey] are needed for DefectDojo")
+	}
+	if per := c.DependencyTrack.ProjectPer; per != "" && per != ProjectPerNamespace && per != ProjectPerWorkload {
+		return fmt.Errorf("Setting [dependencyTrack.projectPer] must be %s or %s but is [%s]", ProjectPerNamespace, ProjectPerWorkload, per)
+	}
+	if c.ClusterReport != ClusterReportSections && c.ClusterReport != ClusterReportCombined {
+		return fmt.Errorf("Setting [clusterReport] must be %s or %s but is [%s]", ClusterReportSections, ClusterReportCombined, c.ClusterReport)
+	}
+
+	if err := c.RegistryPolicy.Validate(); err != nil {
+		return err
+	}
+	if err := c.ImageScanners.Validate(); err != nil {
+		return fmt.Errorf("Setting [imageScanners.only] not valid: %w", err)
+	}
+	if err := registries.ValidPrereleases(c.ImageRegistries.Prereleases); err != nil {
+		return fmt.Errorf("Setting [imageRegistries.prereleases] not valid: %w", err)
+	}
+	for _, rule := range c.ImageRegistries.VersionRules {
+		if err := registries.ValidPrereleases(rule.Prereleases); err != nil {
+			return fmt.Errorf("Version rule of %v not valid: %w", rule.Images, err)
+		}
+		if err := registries.ValidScheme(rule.Scheme); err != nil {
+			return fmt.Errorf("Version rule of %v not valid: %w", rule.Images, err)
+		}
+		if rule.VersionRegex != "" {
+			if versionRegex, err := regexp.Compile(rule.VersionRegex); err != nil || versionRegex.NumSubexp() < 1 {
+				return fmt.Errorf("Version rule of %v not valid: version regexp [%s] needs to be a regular expression with a capture group", rule.Images, rule.VersionRegex)
+			}
+		}
+	}
+	for _, tag := range c.ImageRegistries.MutableTags {
+		if _, err := regexp.Compile(tag); err != nil {
+			return fmt.Errorf("Mutable tag [%s] not valid: %w", tag, err)
+		}
+	}
+
+	if c.Sharding.Shards > 1 {
+		if len(c.Clusters) > 0 {
+			return fmt.Errorf("Sharding can't be combined with multiple clusters")
+		}
+		if c.Cache.Type != cache.TypeFile && c.Cache.Type != cache.TypeRedis {
+			return fmt.Errorf("Sharding needs a cache of type file or redis to share the results between the shards")
+		}
+		if c.Sharding.Index >= c.Sharding.Shards {
+			return fmt.Errorf("Setting [sharding.index] must be lower than the number of shards [%d] but is [%d]", c.Sharding.Shards, c.Sharding.Index)
+		}
+	}
+	return nil
+}
+
+// GetKubernetesTimeout returns the timeout for calls to the Kubernetes API
+func (t Timeouts) GetKubernetesTimeout() time.Duration {
+	return parseDuration(t.Kubernetes)
+}
+
+// GetRegistryTimeout returns the timeout for calls to image and chart registries
+func (t Timeouts) GetRegistryTimeout() time.Duration {
+	return parseDuration(t.Registry)
+}
+
+// GetScannerTimeout returns the timeout for calls to vulnerability scanners
+func (t Timeouts) GetScannerTimeout() time.Duration {
+	return parseDuration(t.Scanner)
+}
+
+// GetToolTimeout returns the timeout for calls to tool registries like GitHub
+func (t Timeouts) GetToolTimeout() time.Duration {
+	return parseDuration(t.Tool)
+}
+
+// GetScanTimeout returns the deadline for the whole scan
+func (t Timeouts) GetScanTimeout() time.Duration {
+	return parseDuration(t.Scan)
+}
+
+// Get returns the deadline of the phase by its name like images or tools, zero when the phase has no deadline
+func (p PhaseTimeouts) Get(phase string) time.Duration {
+	deadlines := map[string]string{
+		"kubernetes":      p.Kubernetes,
+		"images":          p.Images,
+		"vulnerabilities": p.Vulnerabilities,
+		"charts":          p.Charts,
+		"tools":           p.Tools,
+		"reporters":       p.Reporters,
+	}
+	return parseDuration(deadlines[strings.ToLower(phase)])
+}
+
+// parseDuration parses durations that are already validated while loading the config
+func parseDuration(value string) time.Duration {
+	duration, _ := time.ParseDuration(value)
+	return duration
 }
 
 // IsVerboseLoggingEnabled returns true when verbose logging is enabled
@@ -73,6 +619,117 @@ func (c Config) IsDebugLoggingEnabled() bool {
 	return c.AppConfig.Debug || c.CliFlags.Debug
 }
 
+// IsMultiClusterEnabled returns true when multiple clusters are configured to be scanned at the same time
+func (c Config) IsMultiClusterEnabled() bool {
+	return len(c.Clusters) > 0 && c.IsKubernetesFetchEnabled()
+}
+
+// GetPodLabelSelector returns the label selector the scanned pods and workloads must match, the cli flag overrides the config
+func (c Config) GetPodLabelSelector() string {
+	if c.CliFlags.PodLabelSelector != "" {
+		return c.CliFlags.PodLabelSelector
+	}
+	return c.PodLabelSelector
+}
+
+// GetImageScanners returns the vulnerability scanners, the scanners of the cli flag override the ones of the config for the run
+func (c Config) GetImageScanners() scanning.ImageScanners {
+	scanners := c.ImageScanners
+	if len(c.CliFlags.Scanners) > 0 {
+		scanners.Only = c.CliFlags.Scanners
+	}
+	return scanners
+}
+
+// IsCombinedClusterReport returns true when the images of all clusters are printed in one table with a cluster column
+func (c Config) IsCombinedClusterReport() bool {
+	return c.ClusterReport == ClusterReportCombined
+}
+
+// IsClusterDriftEnabled returns true when the versions that the workloads run in the clusters are compared
+func (c Config) IsClusterDriftEnabled() bool {
+	return c.ClusterDrift.Enabled && c.IsMultiClusterEnabled()
+}
+
+// GetDriftClusters returns the names of the clusters that are compared, all the clusters when none are configured
+func (c Config) GetDriftClusters() []string {
+	if len(c.ClusterDrift.Clusters) > 0 {
+		return c.ClusterDrift.Clusters
+	}
+	var names []string
+	for _, cluster := range c.Clusters {
+		names = append(names, cluster.Name)
+	}
+	return names
+}
+
+// IsNodeScanEnabled returns true when the versions of the components of the nodes are reported
+func (c Config) IsNodeScanEnabled() bool {
+	return c.KubernetesNodes && c.IsKubernetesFetchEnabled()
+}
+
+// IsControlPlaneCheckEnabled returns true when the version of the API server is compared with the upstream releases of Kubernetes
+func (c Config) IsControlPlaneCheckEnabled() bool {
+	return c.KubernetesReleases.Enabled && c.IsKubernetesFetchEnabled()
+}
+
+// IsAddonCheckEnabled returns true when the compatibility of the addons with the Kubernetes version is checked
+func (c Config) IsAddonCheckEnabled() bool {
+	return c.AddonChecks.Enabled && c.IsKubernetesFetchEnabled()
+}
+
+// GetAddons returns the default addons merged with the addons of the config
+func (c Config) GetAddons() []versioning.Addon {
+	return versioning.MergeAddons(versioning.DefaultAddons(), c.AddonChecks.Addons)
+}
+
+// GetCustomResources returns the default custom resources merged with the custom resources of the config, none when the scan is disabled
+func (c Config) GetCustomResources() []kubernetes.CustomResource {
+	if !c.CustomResources.Enabled {
+		return nil
+	}
+	return kubernetes.MergeCustomResources(kubernetes.DefaultCustomResources(), c.CustomResources.Resources)
+}
+
+// GetKubernetesOptions returns the settings of the calls to the Kubernetes API and of the images that are collected
+func (c Config) GetKubernetesOptions() kubernetes.Options {
+	return kubernetes.Options{
+		Timeout:            c.Timeouts.GetKubernetesTimeout(),
+		PageSize:           int64(c.KubernetesPageSize),
+		ExcludedNamespaces: c.ExcludeNamespaces,
+		LabelSelector:      c.GetPodLabelSelector(),
+		Workloads:          c.KubernetesWorkloads,
+		OpenShift:          c.KubernetesOpenShift,
+		CustomResources:    c.GetCustomResources(),
+	}
+}
+
+// IsPodCacheEnabled returns true when the server keeps the pods in an informer cache instead of listing them for every scan
+func (c Config) IsPodCacheEnabled() bool {
+	return c.KubernetesPodCache && c.CliFlags.StartServer && c.IsKubernetesFetchEnabled() && !c.IsMultiClusterEnabled()
+}
+
+// IsShardingEnabled returns true when the namespaces are split over multiple replicas
+func (c Config) IsShardingEnabled() bool {
+	return c.Sharding.Shards > 1 && c.IsKubernetesFetchEnabled()
+}
+
+// GetShardIndex returns the index of the shard of this replica, from the config or from the ordinal at the end of the hostname
+func (c Config) GetShardIndex() (int, error) {
+	if c.Sharding.Index >= 0 {
+		return c.Sharding.Index, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+	index, err := strconv.Atoi(hostname[strings.LastIndex(hostname, "-")+1:])
+	if err != nil || index >= c.Sharding.Shards {
+		return 0, fmt.Errorf("Could not find the shard index in hostname [%s], set sharding.index instead", hostname)
+	}
+	return index, nil
+}
+
 // IsKubernetesFetchEnabled returns true when Kubernetes fetch is enabled
 func (c Config) IsKubernetesFetchEnabled() bool {
 	return c.KubernetesFetchEnabled
@@ -83,9 +740,16 @@ func (c Config) RunningLocally() bool {
 	return c.CliFlags.Locally
 }
 
-// IsJsonLoggingEnabled returns true when json logging is enabled
+// IsJsonLoggingEnabled returns true when json logging is enabled, either trough the json logging setting or the json log format
 func (c Config) IsJsonLoggingEnabled() bool {
-	return c.AppConfig.JsonLoggingEnabled || c.CliFlags.JsonLoggingEnabled
+	return c.AppConfig.JsonLoggingEnabled || c.CliFlags.JsonLoggingEnabled || c.getLogFormat() == LogFormatJSON
+}
+
+func (c Config) getLogFormat() string {
+	if c.CliFlags.LogFormat != "" {
+		return c.CliFlags.LogFormat
+	}
+	return c.AppConfig.LogFormat
 }
 
 // LogToFilePath returns true and the log file path when log file is provided
@@ -98,8 +762,99 @@ func (c Config) LogToFilePath() (bool, string) {
 	return false, ""
 }
 
+// GetMaxSize returns the max size of the log file in bytes, zero means no size based rotation
+func (l LogRotation) GetMaxSize() int64 {
+	return int64(l.MaxSize) * 1024 * 1024
+}
+
+// GetMaxAge returns the max age of the log file, zero means no age based rotation
+func (l LogRotation) GetMaxAge() time.Duration {
+	return parseDuration(l.MaxAge)
+}
+
+// LogToStdout returns true when logs should go to stdout as well as to the log file
+func (c Config) LogToStdout() bool {
+	return c.AppConfig.LogToStdout || c.CliFlags.LogToStdout
+}
+
+// GetFailOn returns the conditions on which lcm exits with a failing exit code, default is failing on scan errors
+func (c Config) GetFailOn() []string {
+	if len(c.CliFlags.FailOn) > 0 {
+		return c.CliFlags.FailOn
+	} else if len(c.AppConfig.FailOn) > 0 {
+		return c.AppConfig.FailOn
+	}
+	return []string{"scan-errors"}
+}
+
 // PrettyPrintAllowed returns true when pretty print is allowed
 func (c Config) PrettyPrintAllowed() bool {
 	logFileEnabled := c.CliFlags.LogFile != "" || c.AppConfig.LogFile != ""
-	return !logFileEnabled && !c.IsJsonLoggingEnabled() && !c.CliFlags.StartServer
+	return !logFileEnabled && !c.IsJsonLoggingEnabled() && !c.CliFlags.StartServer && !c.IsTUIEnabled() && !c.SbomToStdout()
+}
+
+// SbomToStdout returns true when the SBOM is written to stdout instead of a file, nothing else may be printed to stdout then
+func (c Config) SbomToStdout() bool {
+	return c.CliFlags.Sbom != "" && c.CliFlags.SbomFile == ""
+}
+
+// IsWatchWorkloadsEnabled returns true when new images in the cluster should be checked right away while running the server
+func (c Config) IsWatchWorkloadsEnabled() bool {
+	return (c.AppConfig.WatchWorkloads || c.CliFlags.WatchWorkloads) && c.CliFlags.StartServer && c.IsKubernetesFetchEnabled()
+}
+
+// IsDebugEndpointsEnabled returns true when the pprof and runtime stats endpoints should be served
+func (c Config) IsDebugEndpointsEnabled() bool {
+	return c.AppConfig.DebugEndpoints || c.CliFlags.DebugEndpoints
+}
+
+// GetGrpcAddress returns the address of the gRPC service, the service is only started with the server when the address is set
+func (c Config) GetGrpcAddress() string {
+	if c.CliFlags.GrpcAddress != "" {
+		return c.CliFlags.GrpcAddress
+	}
+	return c.AppConfig.GrpcAddress
+}
+
+// IsLeaderElectionEnabled returns true when only the elected replica should scan while running the server
+func (c Config) IsLeaderElectionEnabled() bool {
+	return (c.AppConfig.LeaderElection.Enabled || c.CliFlags.LeaderElection.Enabled) && c.CliFlags.StartServer
+}
+
+// GetLeaderElection returns the settings for the leader election
+func (c Config) GetLeaderElection() kubernetes.LeaderElection {
+	election := c.AppConfig.LeaderElection
+	return kubernetes.LeaderElection{
+		Namespace:     election.Namespace,
+		Name:          election.Name,
+		LeaseDuration: parseDuration(election.LeaseDuration),
+		RenewDeadline: parseDuration(election.RenewDeadline),
+		RetryPeriod:   parseDuration(election.RetryPeriod),
+	}
+}
+
+// GetRescanDebounce returns how long to wait for more new images before checking them
+func (c Config) GetRescanDebounce() time.Duration {
+	return parseDuration(c.AppConfig.RescanDebounce)
+}
+
+// GetRescanMaxWait returns how long new images wait at most before they are checked, even when more new images keep appearing
+func (c Config) GetRescanMaxWait() time.Duration {
+	return parseDuration(c.AppConfig.RescanMaxWait)
+}
+
+// GetConfigReload returns how often the config of a ConfigMap or LifecycleScan resource is checked for changes while running the server,
+// zero when it is only loaded at startup
+func (c Config) GetConfigReload() time.Duration {
+	return parseDuration(c.AppConfig.ConfigReload)
+}
+
+// GetReadyStaleness returns how old the last successful scan may be before lcm is no longer ready, zero when it never gets stale
+func (c Config) GetReadyStaleness() time.Duration {
+	return parseDuration(c.AppConfig.ReadyStaleness)
+}
+
+// IsTUIEnabled returns true when running the interactive terminal UI
+func (c Config) IsTUIEnabled() bool {
+	return c.CliFlags.Command == CommandTUI
 }
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/providers/rawbytes"
+)
+
+const profilesConfig = `
+namespaces:
+  - default
+excludeNamespaces:
+  - kube-system
+  - monitoring
+profiles:
+  prod:
+    namespaces:
+      - production
+      - payments
+    excludeNamespaces:
+      - pr-.*
+  audit:
+    kubernetesFetchEnabled: false
+`
+
+func TestLoadConfiguration(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		profile    string
+		err        string
+		namespaces []string
+		excluded   []string
+		fetch      bool
+	}{
+		{name: "without profile", config: profilesConfig, namespaces: []string{"default"}, excluded: []string{"kube-system", "monitoring"}, fetch: true},
+		{name: "profile replaces the lists", config: profilesConfig, profile: "prod", namespaces: []string{"production", "payments"}, excluded: []string{"pr-.*"}, fetch: true},
+		{name: "profile keeps the settings it doesn't override", config: profilesConfig, profile: "audit", namespaces: []string{"default"}, excluded: []string{"kube-system", "monitoring"}, fetch: false},
+		{name: "unknown profile", config: profilesConfig, profile: "staging", err: "Profile [staging] not found in config"},
+		{name: "invalid duration", config: "timeouts:\n  scan: 5 minutes\n", err: "Setting [timeouts.scan] is not a valid duration"},
+		{name: "invalid duration of a profile", config: profilesConfig + "  slow:\n    app:\n      configReload: often\n", profile: "slow", err: "Setting [app.configReload] is not a valid duration"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := loadConfiguration(rawbytes.Provider([]byte(test.config)), test.profile)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Expected error [%s] but got [%v]", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got [%v]", err)
+			}
+			if !reflect.DeepEqual(config.Namespaces, test.namespaces) {
+				t.Errorf("Expected namespaces %v but got %v", test.namespaces, config.Namespaces)
+			}
+			if !reflect.DeepEqual(config.ExcludeNamespaces, test.excluded) {
+				t.Errorf("Expected excluded namespaces %v but got %v", test.excluded, config.ExcludeNamespaces)
+			}
+			if config.IsKubernetesFetchEnabled() != test.fetch {
+				t.Errorf("Expected kubernetes fetch %v but got %v", test.fetch, config.IsKubernetesFetchEnabled())
+			}
+		})
+	}
+}
+
+func TestWatchConfigOnlyReloadsChangedConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		fetched  []string
+		expected []string
+	}{
+		{name: "unchanged", current: "namespaces: [a]", fetched: []string{"namespaces: [a]", "namespaces: [a]"}, expected: []string{}},
+		{name: "changed once", current: "namespaces: [a]", fetched: []string{"namespaces: [a]", "namespaces: [b]", "namespaces: [b]"}, expected: []string{"namespaces: [b]"}},
+		{name: "changed back", current: "namespaces: [a]", fetched: []string{"namespaces: [b]", "namespaces: [a]"}, expected: []string{"namespaces: [b]", "namespaces: [a]"}},
+		{name: "not fetched at startup", fetched: []string{"namespaces: [a]", "namespaces: [a]"}, expected: []string{"namespaces: [a]"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var mu sync.Mutex
+			fetches := 0
+			fetch := func() ([]byte, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if fetches == len(test.fetched) {
+					cancel()
+					return []byte(test.fetched[len(test.fetched)-1]), nil
+				}
+				fetches++
+				return []byte(test.fetched[fetches-1]), nil
+			}
+			reloaded := []string{}
+			var current []byte
+			if test.current != "" {
+				current = []byte(test.current)
+			}
+			watchConfig(ctx, time.Millisecond, current, fetch, func(data []byte) {
+				reloaded = append(reloaded, string(data))
+			})
+			if !reflect.DeepEqual(reloaded, test.expected) {
+				t.Errorf("Expected reloads %v but got %v", test.expected, reloaded)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ControlPlaneInfo contains the version of the API server compared to the upstream releases of Kubernetes,
+// the version is empty when the control plane is not checked
+type ControlPlaneInfo struct {
+	Version string
+	// LatestPatch is the latest upstream patch release of the minor version that runs
+	LatestPatch string
+	// LatestVersion is the latest upstream release
+	LatestVersion string
+	// Supported is false when the minor version that runs doesn't get patches upstream anymore
+	Supported bool
+}
+
+// getControlPlaneInfo compares the version of the API server with the latest upstream patch of its minor and the latest release
+func getControlPlaneInfo(ctx context.Context, releases registries.KubernetesReleases, useLocally bool, problems *scanProblems) ControlPlaneInfo {
+	serverVersion, err := kubernetes.GetServerVersion(ctx, useLocally)
+	if err != nil {
+		problems.add(SectionKubernetes, "control plane", err)
+		return ControlPlaneInfo{}
+	}
+	info := ControlPlaneInfo{Version: serverVersion, LatestPatch: versioning.CheckFailed, LatestVersion: versioning.CheckFailed, Supported: true}
+	latest, err := releases.GetLatestRelease(ctx)
+	problems.add(SectionKubernetes, "control plane", err)
+	if err == nil {
+		info.LatestVersion = latest
+		latestMajor, latestMinor, latestOk := majorMinor(latest)
+		major, minor, ok := majorMinor(serverVersion)
+		info.Supported = !latestOk || !ok || (major == latestMajor && latestMinor-minor < releases.SupportedMinors)
+	}
+	if major, minor, ok := majorMinor(serverVersion); ok {
+		patch, err := releases.GetLatestPatch(ctx, fmt.Sprintf("%d.%d", major, minor))
+		problems.add(SectionKubernetes, "control plane", err)
+		if err == nil {
+			info.LatestPatch = patch
+		}
+	}
+	return info
+}
+
+// Unpatched returns true when a newer upstream patch release of the minor version that runs is available
+func (c ControlPlaneInfo) Unpatched() bool {
+	if c.LatestPatch == versioning.CheckFailed || c.LatestPatch == "" {
+		return false
+	}
+	return versioning.CompareVersions(versioning.KubernetesCore(c.LatestPatch), versioning.KubernetesCore(c.Version)) > 0
+}
+
+// GetStatus returns a failure when the minor version is not supported anymore, otherwise how far the control plane is behind
+func (c ControlPlaneInfo) GetStatus() string {
+	if !c.Supported {
+		return versioning.Failure
+	}
+	if c.Unpatched() {
+		return versioning.Patch
+	}
+	if c.LatestVersion == versioning.CheckFailed {
+		return versioning.CheckFailed
+	}
+	return versioning.DetermineLifeCycleStatus(versioning.KubernetesCore(c.LatestVersion), versioning.KubernetesCore(c.Version))
+}
+
+func prettyPrintControlPlaneInfo(info ControlPlaneInfo) {
+	if info.Version == "" {
+		return
+	}
+	supported := "yes"
+	if !info.Supported {
+		supported = "no"
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Control plane", "Latest patch", "Latest", "Supported", "Status"})
+	table.SetColumnAlignment([]int{1, 1, 1, 1, 3})
+	table.Append([]string{info.Version, info.LatestPatch, info.LatestVersion, supported, info.GetStatus()})
+	table.Render()
+}
@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestControlPlaneStatus(t *testing.T) {
+	for _, test := range []struct {
+		info      ControlPlaneInfo
+		unpatched bool
+		status    string
+	}{
+		{ControlPlaneInfo{Version: "v1.31.2", LatestPatch: "v1.31.2", LatestVersion: "v1.31.2", Supported: true}, false, versioning.Same},
+		{ControlPlaneInfo{Version: "v1.29.3-eks-5e0fdde", LatestPatch: "v1.29.10", LatestVersion: "v1.31.2", Supported: true}, true, versioning.Patch},
+		{ControlPlaneInfo{Version: "v1.29.10+k3s1", LatestPatch: "v1.29.10", LatestVersion: "v1.31.2", Supported: true}, false, versioning.Minor},
+		{ControlPlaneInfo{Version: "v1.27.16", LatestPatch: "v1.27.16", LatestVersion: "v1.31.2", Supported: false}, false, versioning.Failure},
+	} {
+		if test.info.Unpatched() != test.unpatched || test.info.GetStatus() != test.status {
+			t.Errorf("Expected unpatched %t and %s for %s but got %t and %s", test.unpatched, test.status, test.info.Version, test.info.Unpatched(), test.info.GetStatus())
+		}
+	}
+}
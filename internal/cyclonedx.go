@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// cycloneDX is a CycloneDX 1.5 BOM with the clusters as platform components, the images as container components
+// and the packages of the images as their subcomponents
+type cycloneDX struct {
+	BomFormat       string                `json:"bomFormat"`
+	SpecVersion     string                `json:"specVersion"`
+	Version         int                   `json:"version"`
+	Metadata        cycloneDXMetadata     `json:"metadata"`
+	Components      []cycloneDXComponent  `json:"components"`
+	Dependencies    []cycloneDXDependency `json:"dependencies,omitempty"`
+	Vulnerabilities []cycloneDXVuln       `json:"vulnerabilities,omitempty"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cycloneDXComponent `json:"components"`
+	} `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXComponent struct {
+	Type       string               `json:"type"`
+	BomRef     string               `json:"bom-ref,omitempty"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	Purl       string               `json:"purl,omitempty"`
+	Hashes     []cycloneDXHash      `json:"hashes,omitempty"`
+	Properties []cycloneDXProperty  `json:"properties,omitempty"`
+	Components []cycloneDXComponent `json:"components,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+type cycloneDXVuln struct {
+	ID      string            `json:"id"`
+	Affects []cycloneDXAffect `json:"affects"`
+}
+
+type cycloneDXAffect struct {
+	Ref string `json:"ref"`
+}
+
+// newCycloneDX returns the BOM of the images of the scan result with the packages per reference, every image is in the BOM once
+// and the clusters depend on the images they run
+func newCycloneDX(result ScanResult, packages map[string][]scanning.Package, version string, now time.Time) cycloneDX {
+	bom := cycloneDX{BomFormat: "CycloneDX", SpecVersion: "1.5", Version: 1, Components: []cycloneDXComponent{}}
+	bom.Metadata.Timestamp = now.UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []cycloneDXComponent{{Type: "application", Name: "lcm", Version: version}}
+	bom.Metadata.Component = clusterComponent(result.Cluster)
+
+	vulnerabilities := map[string][]string{}
+	images := map[string]bool{}
+	var references []string
+	for _, ci := range result.ContainerInfo {
+		reference := ci.Container.Reference()
+		if images[reference] {
+			continue
+		}
+		images[reference] = true
+		references = append(references, reference)
+		image := imageComponent(ci)
+		var dependsOn []string
+		for _, pkg := range packages[reference] {
+			component := cycloneDXComponent{Type: "library", BomRef: reference + "#" + packageID(pkg), Name: pkg.Name, Version: pkg.Version, Purl: pkg.Purl}
+			image.Components = append(image.Components, component)
+			dependsOn = append(dependsOn, component.BomRef)
+		}
+		bom.Components = append(bom.Components, image)
+		if len(dependsOn) > 0 {
+			bom.Dependencies = append(bom.Dependencies, cycloneDXDependency{Ref: reference, DependsOn: dependsOn})
+		}
+		for _, cve := range ci.Cves {
+			if cve != versioning.Nodata && cve != versioning.CheckFailed {
+				vulnerabilities[cve] = append(vulnerabilities[cve], reference)
+			}
+		}
+	}
+
+	if len(result.Clusters) == 0 {
+		bom.Dependencies = append(bom.Dependencies, cycloneDXDependency{Ref: bom.Metadata.Component.BomRef, DependsOn: references})
+	} else {
+		var clusters []string
+		for _, cluster := range result.Clusters {
+			component := clusterComponent(cluster.Cluster)
+			var dependsOn []string
+			for _, ci := range cluster.ContainerInfo {
+				dependsOn = append(dependsOn, ci.Container.Reference())
+			}
+			bom.Components = append(bom.Components, component)
+			bom.Dependencies = append(bom.Dependencies, cycloneDXDependency{Ref: component.BomRef, DependsOn: dependsOn})
+			clusters = append(clusters, component.BomRef)
+		}
+		bom.Dependencies = append(bom.Dependencies, cycloneDXDependency{Ref: bom.Metadata.Component.BomRef, DependsOn: clusters})
+	}
+
+	for cve, affected := range vulnerabilities {
+		vulnerability := cycloneDXVuln{ID: cve}
+		for _, reference := range affected {
+			vulnerability.Affects = append(vulnerability.Affects, cycloneDXAffect{Ref: reference})
+		}
+		bom.Vulnerabilities = append(bom.Vulnerabilities, vulnerability)
+	}
+	sort.Slice(bom.Vulnerabilities, func(a, b int) bool {
+		return bom.Vulnerabilities[a].ID < bom.Vulnerabilities[b].ID
+	})
+	return bom
+}
+
+// clusterComponent returns the platform component of the cluster, the cluster without a name is the cluster lcm runs in
+func clusterComponent(cluster Cluster) cycloneDXComponent {
+	name := cluster.Name
+	if name == "" {
+		name = "kubernetes"
+	}
+	component := cycloneDXComponent{Type: "platform", BomRef: "cluster/" + name, Name: name}
+	var labels []string
+	for label := range cluster.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "lcm:label:" + label, Value: cluster.Labels[label]})
+	}
+	return component
+}
+
+// imageComponent returns the container component of the image with the namespaces, workloads and clusters it runs in as properties
+func imageComponent(ci ContainerInfo) cycloneDXComponent {
+	container := ci.Container
+	digest := imageDigest(container)
+	component := cycloneDXComponent{
+		Type:    "container",
+		BomRef:  container.Reference(),
+		Name:    container.URL + "/" + container.Name,
+		Version: container.Version,
+		Purl:    ociPurl(container, digest),
+	}
+	if strings.HasPrefix(digest, "sha256:") {
+		component.Hashes = []cycloneDXHash{{Alg: "SHA-256", Content: strings.TrimPrefix(digest, "sha256:")}}
+	}
+	for _, cluster := range ci.Clusters {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "lcm:cluster", Value: cluster})
+	}
+	for _, namespace := range container.Namespaces {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "lcm:namespace", Value: namespace})
+	}
+	for _, workload := range container.Workloads {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "lcm:workload", Value: workload})
+	}
+	if ci.Upgrade != "" {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "lcm:latestVersion", Value: ci.LatestVersion})
+	}
+	return component
+}
+
+// imageDigest returns the digest the image is pinned to or the digest that all pods run, it is empty when the pods run different digests
+func imageDigest(container kubernetes.Container) string {
+	if container.Digest != "" {
+		return container.Digest
+	}
+	if digests := imageDigests(container); len(digests) == 1 {
+		return digests[0]
+	}
+	return ""
+}
+
+// ociPurl returns the package url of the image like pkg:oci/nginx@sha256%3Aabc?repository_url=docker.io/library/nginx&tag=1.25
+func ociPurl(container kubernetes.Container, digest string) string {
+	name := container.Name[strings.LastIndex(container.Name, "/")+1:]
+	purl := "pkg:oci/" + url.PathEscape(name)
+	if digest != "" {
+		purl += "@" + strings.Replace(digest, ":", "%3A", 1)
+	}
+	purl += "?repository_url=" + container.URL + "/" + container.Name
+	if container.Tag != "" {
+		purl += "&tag=" + url.QueryEscape(container.Tag)
+	}
+	return purl
+}
+
+// packageID identifies the package within the image, by the package url when the scanner knows it
+func packageID(pkg scanning.Package) string {
+	if pkg.Purl != "" {
+		return pkg.Purl
+	}
+	return pkg.Name + "@" + pkg.Version
+}
+
+func writeCycloneDX(w io.Writer, bom cycloneDX) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bom)
+}
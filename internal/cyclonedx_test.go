@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestCycloneDXHasTheImagesPerCluster(t *testing.T) {
+	nginx := ContainerInfo{
+		Container: kubernetes.Container{URL: "docker.io", Name: "library/nginx", Version: "1.25", Tag: "1.25", Digest: "sha256:abc", Namespaces: []string{"web"}},
+		Clusters:  []string{"prod", "staging"},
+		Cves:      []string{"CVE-2023-4911"},
+	}
+	app := ContainerInfo{
+		Container: kubernetes.Container{URL: "registry.io", Name: "team/app", Version: "1.0", Tag: "1.0", RunningDigests: map[string][]string{"sha256:one": {"app/a"}, "sha256:two": {"app/b"}}},
+		Clusters:  []string{"prod"},
+		Cves:      []string{versioning.CheckFailed},
+	}
+	result := ScanResult{
+		ContainerInfo: []ContainerInfo{nginx, app},
+		Clusters: []ClusterResult{
+			{Cluster: Cluster{Name: "prod"}, ContainerInfo: []ContainerInfo{nginx, app}},
+			{Cluster: Cluster{Name: "staging"}, ContainerInfo: []ContainerInfo{nginx}},
+		},
+	}
+	packages := map[string][]scanning.Package{"docker.io/library/nginx:1.25@sha256:abc": {{Name: "openssl", Version: "3.0.11", Purl: "pkg:deb/debian/openssl@3.0.11"}}}
+
+	var out bytes.Buffer
+	if err := writeCycloneDX(&out, newCycloneDX(result, packages, "1.2.3", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))); err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	var bom cycloneDX
+	if err := json.Unmarshal(out.Bytes(), &bom); err != nil {
+		t.Fatalf("Expected valid json but got [%v]", err)
+	}
+
+	image := bom.Components[0]
+	if image.Purl != "pkg:oci/nginx@sha256%3Aabc?repository_url=docker.io/library/nginx&tag=1.25" || !reflect.DeepEqual(image.Hashes, []cycloneDXHash{{Alg: "SHA-256", Content: "abc"}}) {
+		t.Errorf("Expected the purl and the hash of the digest but got [%s] and %v", image.Purl, image.Hashes)
+	}
+	if len(image.Components) != 1 || image.Components[0].BomRef != "docker.io/library/nginx:1.25@sha256:abc#pkg:deb/debian/openssl@3.0.11" {
+		t.Errorf("Expected the package as subcomponent but got %v", image.Components)
+	}
+	if purl := bom.Components[1].Purl; purl != "pkg:oci/app?repository_url=registry.io/team/app&tag=1.0" {
+		t.Errorf("Expected no digest in the purl of an image that runs multiple digests but got [%s]", purl)
+	}
+	if len(bom.Components) != 4 || bom.Components[2].BomRef != "cluster/prod" || bom.Components[3].BomRef != "cluster/staging" {
+		t.Errorf("Expected the images once and a component per cluster but got %v", bom.Components)
+	}
+
+	expectedDependencies := []cycloneDXDependency{
+		{Ref: "docker.io/library/nginx:1.25@sha256:abc", DependsOn: []string{"docker.io/library/nginx:1.25@sha256:abc#pkg:deb/debian/openssl@3.0.11"}},
+		{Ref: "cluster/prod", DependsOn: []string{"docker.io/library/nginx:1.25@sha256:abc", "registry.io/team/app:1.0"}},
+		{Ref: "cluster/staging", DependsOn: []string{"docker.io/library/nginx:1.25@sha256:abc"}},
+		{Ref: "cluster/kubernetes", DependsOn: []string{"cluster/prod", "cluster/staging"}},
+	}
+	if !reflect.DeepEqual(bom.Dependencies, expectedDependencies) {
+		t.Errorf("Expected %v but got %v", expectedDependencies, bom.Dependencies)
+	}
+	expectedVulnerabilities := []cycloneDXVuln{{ID: "CVE-2023-4911", Affects: []cycloneDXAffect{{Ref: "docker.io/library/nginx:1.25@sha256:abc"}}}}
+	if !reflect.DeepEqual(bom.Vulnerabilities, expectedVulnerabilities) {
+		t.Errorf("Expected only the vulnerability and not the failed check but got %v", bom.Vulnerabilities)
+	}
+	if bom.Metadata.Timestamp != "2024-01-02T03:04:05Z" || bom.Metadata.Tools.Components[0].Version != "1.2.3" {
+		t.Errorf("Expected the timestamp and the version of lcm but got %v", bom.Metadata)
+	}
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// started is used to report the uptime in the runtime stats
+var started = time.Now()
+
+// RuntimeStats contains the runtime information served on /debug/runtime
+type RuntimeStats struct {
+	Uptime      string
+	Goroutines  int
+	HeapAlloc   uint64
+	HeapInuse   uint64
+	HeapObjects uint64
+	Sys         uint64
+	NumGC       uint32
+	LastGCPause string
+	GoVersion   string
+	NumCPU      int
+	GOMAXPROCS  int
+}
+
+// addDebugEndpoints adds the pprof endpoints on /debug/pprof and the runtime stats on /debug/runtime
+func addDebugEndpoints(r *mux.Router) {
+	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// Index also serves the named profiles like heap and goroutine
+	r.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	r.HandleFunc("/debug/runtime", runtimeStats)
+}
+
+func runtimeStats(w http.ResponseWriter, req *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats := RuntimeStats{
+		Uptime:      time.Since(started).Round(time.Second).String(),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapInuse:   mem.HeapInuse,
+		HeapObjects: mem.HeapObjects,
+		Sys:         mem.Sys,
+		NumGC:       mem.NumGC,
+		LastGCPause: time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).String(),
+		GoVersion:   runtime.Version(),
+		NumCPU:      runtime.NumCPU(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		logger.WithError(err).Error("Could not serve the runtime stats")
+	}
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"sort"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+// imageKey identifies the image independent of how it is written, nginx:1.0 and docker.io/library/nginx:1.0 are the same image
+func imageKey(container kubernetes.Container) string {
+	return container.URL + "/" + container.Name + ":" + container.Version
+}
+
+// uniqueContainers merges the containers that reference the same image, the namespaces of all occurrences are kept
+func uniqueContainers(containers []kubernetes.Container) []kubernetes.Container {
+	var unique []kubernetes.Container
+	indexes := map[string]int{}
+	for _, container := range containers {
+		index, exists := indexes[imageKey(container)]
+		if !exists {
+			indexes[imageKey(container)] = len(unique)
+			unique = append(unique, container)
+			continue
+		}
+		unique[index].Namespaces = mergeSorted(unique[index].Namespaces, container.Namespaces)
+		unique[index].PullSecrets = mergeSorted(unique[index].PullSecrets, container.PullSecrets)
+		unique[index].Workloads = mergeSorted(unique[index].Workloads, container.Workloads)
+		unique[index].Replicas = addReplicas(unique[index].Replicas, container.Replicas)
+		for digest, pods := range container.RunningDigests {
+			if unique[index].RunningDigests == nil {
+				unique[index].RunningDigests = map[string][]string{}
+			}
+			unique[index].RunningDigests[digest] = mergeSorted(unique[index].RunningDigests[digest], pods)
+		}
+	}
+	return unique
+}
+
+// addReplicas returns the running pods per workload of both, the maps are not changed because they can be shared with cached results
+func addReplicas(replicas, other map[string]int) map[string]int {
+	if len(replicas) == 0 && len(other) == 0 {
+		return nil
+	}
+	added := map[string]int{}
+	for workload, pods := range replicas {
+		added[workload] = pods
+	}
+	for workload, pods := range other {
+		added[workload] += pods
+	}
+	return added
+}
+
+func mergeSorted(values, other []string) []string {
+	for _, value := range other {
+		if !contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// groupBy groups the indexes from 0 to total by their key in the order the keys are first seen,
+// so work that only depends on the key is done once per group and fanned back out to every index
+func groupBy(total int, key func(index int) string) [][]int {
+	var groups [][]int
+	positions := map[string]int{}
+	for index := 0; index < total; index++ {
+		k := key(index)
+		position, exists := positions[k]
+		if !exists {
+			positions[k] = len(groups)
+			groups = append(groups, []int{index})
+			continue
+		}
+		groups[position] = append(groups[position], index)
+	}
+	return groups
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestUniqueContainersMergesTheSameImage(t *testing.T) {
+	var containers []kubernetes.Container
+	for _, image := range []string{"nginx:1.0", "docker.io/library/nginx:1.0", "nginx:1.1"} {
+		container, _ := kubernetes.ImageStringToContainerStruct(image)
+		container.Namespaces = []string{image}
+		container.Replicas = map[string]int{"web/Deployment/web": 2}
+		containers = append(containers, container)
+	}
+
+	unique := uniqueContainers(containers)
+	if len(unique) != 2 {
+		t.Fatalf("Expected 2 unique images but got %v", unique)
+	}
+	if expected := []string{"docker.io/library/nginx:1.0", "nginx:1.0"}; !reflect.DeepEqual(unique[0].Namespaces, expected) {
+		t.Errorf("Expected namespaces %v but got %v", expected, unique[0].Namespaces)
+	}
+	if pods := unique[0].Replicas["web/Deployment/web"]; pods != 4 || containers[0].Replicas["web/Deployment/web"] != 2 {
+		t.Errorf("Expected the pods of both images to be added but got %d", pods)
+	}
+}
@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// defectDojoTest is the import of the findings of an image into the engagement of a cluster
+type defectDojoTest struct {
+	Product    string
+	Engagement string
+	Title      string
+	Findings   []defectDojoFinding
+}
+
+// defectDojoFinding is a finding of the Generic Findings Import of DefectDojo
+type defectDojoFinding struct {
+	Title            string                      `json:"title"`
+	Description      string                      `json:"description"`
+	Severity         string                      `json:"severity"`
+	VulnerabilityIDs []defectDojoVulnerabilityID `json:"vulnerability_ids"`
+	ComponentName    string                      `json:"component_name"`
+	ComponentVersion string                      `json:"component_version"`
+	UniqueID         string                      `json:"unique_id_from_tool"`
+}
+
+type defectDojoVulnerabilityID struct {
+	VulnerabilityID string `json:"vulnerability_id"`
+}
+
+// exportToDefectDojo imports the findings of every image into DefectDojo, when images fail the other images are still imported
+// and an aggregated error of the failed images is returned
+func exportToDefectDojo(ctx context.Context, defectDojo config.DefectDojo, result ScanResult) error {
+	var errs []error
+	for _, test := range defectDojoTests(defectDojo, result) {
+		purpose := "findings of image " + test.Title + " in engagement " + test.Engagement
+		if err := importFindings(audit.WithPurpose(ctx, purpose), defectDojo, test); err != nil {
+			errs = append(errs, fmt.Errorf("Could not import the findings of [%s] into engagement [%s] of product [%s]: %w", test.Title, test.Engagement, test.Product, err))
+			continue
+		}
+		logger.WithField("image", test.Title).WithField("engagement", test.Engagement).Debug("Imported the findings into DefectDojo")
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// defectDojoTests returns a test per repository of the images and cluster it runs in. The test is the repository of the image so the findings
+// of a new version replace the ones of the old version, all the versions of a repository that run in a cluster are imported in the same test
+// and every finding is the vulnerability in a version, so importing one version doesn't close the findings of another version.
+// The repositories of which the vulnerabilities of a version couldn't be checked are left out so their findings are not closed
+func defectDojoTests(defectDojo config.DefectDojo, result ScanResult) []defectDojoTest {
+	var tests []defectDojoTest
+	indexes := map[[3]string]int{}
+	failed := map[string]bool{}
+	for _, ci := range result.ContainerInfo {
+		repository := ci.Container.URL + "/" + ci.Container.Name
+		if ci.ScanFailed || len(ci.Cves) > 0 && (ci.Cves[0] == versioning.Nodata || ci.Cves[0] == versioning.CheckFailed) {
+			failed[repository] = true
+			continue
+		}
+		reference := ci.Container.Reference()
+		var findings []defectDojoFinding
+		for _, cve := range ci.Cves {
+			findings = append(findings, defectDojoFinding{
+				Title:            cve + " in " + reference,
+				Description:      defectDojoDescription(ci, cve),
+				Severity:         defectDojoSeverity(ci.Severities[cve]),
+				VulnerabilityIDs: []defectDojoVulnerabilityID{{VulnerabilityID: cve}},
+				ComponentName:    repository,
+				ComponentVersion: ci.Container.Version,
+				UniqueID:         cve + " " + reference,
+			})
+		}
+		product := defectDojo.Product
+		if product == "" {
+			product = repository
+		}
+		clusters := ci.Clusters
+		if len(clusters) == 0 {
+			clusters = []string{result.Cluster.Name}
+		}
+		for _, cluster := range clusters {
+			if cluster == "" {
+				cluster = "kubernetes"
+			}
+			key := [3]string{product, cluster, repository}
+			if index, exists := indexes[key]; exists {
+				tests[index].Findings = append(tests[index].Findings, findings...)
+				continue
+			}
+			indexes[key] = len(tests)
+			tests = append(tests, defectDojoTest{Product: product, Engagement: cluster, Title: repository, Findings: findings})
+		}
+	}
+	selected := []defectDojoTest{}
+	for _, test := range tests {
+		if !failed[test.Title] {
+			selected = append(selected, test)
+		}
+	}
+	return selected
+}
+
+// defectDojoDescription describes where the image with the vulnerability runs and how likely it is exploited when that is known
+func defectDojoDescription(ci ContainerInfo, cve string) string {
+	lines := []string{fmt.Sprintf("%s in image %s", cve, ci.Container.Reference())}
+	if len(ci.Container.Namespaces) > 0 {
+		lines = append(lines, "Namespaces: "+strings.Join(ci.Container.Namespaces, ", "))
+	}
+	if len(ci.Container.Workloads) > 0 {
+		lines = append(lines, "Workloads: "+strings.Join(ci.Container.Workloads, ", "))
+	}
+	if contains(ci.Exploited, cve) {
+		lines = append(lines, "Actively exploited according to the KEV catalog")
+	}
+	if score, exists := ci.Epss[cve]; exists {
+		lines = append(lines, fmt.Sprintf("EPSS score: %.3f", score))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// defectDojoSeverity returns the severity like DefectDojo names them, the severities below Low are Info
+func defectDojoSeverity(severity string) string {
+	switch severity {
+	case "Critical", "High", "Medium", "Low":
+		return severity
+	default:
+		return "Info"
+	}
+}
+
+// importFindings imports the findings of the test with the reimport API of DefectDojo, it creates the product, the engagement and the test
+// when they don't exist yet and closes the findings of the test that are no longer found
+func importFindings(ctx context.Context, defectDojo config.DefectDojo, test defectDojoTest) error {
+	report, err := json.Marshal(map[string]interface{}{"findings": append([]defectDojoFinding{}, test.Findings...)})
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := [][2]string{
+		{"scan_type", "Generic Findings Import"},
+		{"product_type_name", defectDojo.ProductType},
+		{"product_name", test.Product},
+		{"engagement_name", test.Engagement},
+		{"test_title", test.Title},
+		{"auto_create_context", "true"},
+		{"close_old_findings", "true"},
+		{"active", "true"},
+		{"minimum_severity", "Info"},
+	}
+	for _, field := range fields {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	file, err := form.CreateFormFile("file", "lcm.json")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(report); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(defectDojo.URL, "/")+"/api/v2/reimport-scan/", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Token "+defectDojo.APIKey)
+	resp, err := exportClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 201 but [%v]", resp.StatusCode))
+	}
+	return nil
+}
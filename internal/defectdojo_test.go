@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestDefectDojoImportsTheFindingsPerImageAndCluster(t *testing.T) {
+	var imported []string
+	var findings []defectDojoFinding
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v2/reimport-scan/" || r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("scan_type") != "Generic Findings Import" || r.FormValue("auto_create_context") != "true" || r.FormValue("product_type_name") != "Kubernetes" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var report struct {
+			Findings []defectDojoFinding `json:"findings"`
+		}
+		if err := json.NewDecoder(file).Decode(&report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		imported = append(imported, r.FormValue("product_name")+"/"+r.FormValue("engagement_name")+"/"+r.FormValue("test_title"))
+		findings = append(findings, report.Findings...)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	result := ScanResult{
+		ContainerInfo: []ContainerInfo{
+			{
+				Container:  kubernetes.Container{URL: "docker.io", Name: "library/nginx", Version: "1.25", Tag: "1.25", Namespaces: []string{"web"}},
+				Clusters:   []string{"prod", "staging"},
+				Cves:       []string{"CVE-2023-4911"},
+				Severities: map[string]string{"CVE-2023-4911": "High"},
+				Exploited:  []string{"CVE-2023-4911"},
+			},
+			{Container: kubernetes.Container{URL: "registry.io", Name: "team/app", Version: "1.0"}, Clusters: []string{"prod"}, Cves: []string{versioning.CheckFailed}},
+			{Container: kubernetes.Container{URL: "registry.io", Name: "team/api", Version: "2.0"}, Clusters: []string{"prod"}, Cves: []string{}},
+		},
+	}
+	defectDojo := config.DefectDojo{Enabled: true, URL: server.URL, APIKey: "secret", ProductType: "Kubernetes"}
+	if err := exportToDefectDojo(context.Background(), defectDojo, result); err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+
+	expected := []string{"docker.io/library/nginx/prod/docker.io/library/nginx", "docker.io/library/nginx/staging/docker.io/library/nginx", "registry.io/team/api/prod/registry.io/team/api"}
+	if !reflect.DeepEqual(imported, expected) {
+		t.Errorf("Expected the imports %v without the failed check but got %v", expected, imported)
+	}
+	finding := findings[0]
+	if len(findings) != 2 || finding.Severity != "High" || finding.UniqueID != "CVE-2023-4911 docker.io/library/nginx:1.25" || finding.Description != "CVE-2023-4911 in image docker.io/library/nginx:1.25\nNamespaces: web\nActively exploited according to the KEV catalog" {
+		t.Errorf("Expected the finding in both clusters but got %v", findings)
+	}
+
+	defectDojo.APIKey = "wrong"
+	if err := exportToDefectDojo(context.Background(), defectDojo, result); err == nil {
+		t.Errorf("Expected an error without a valid API key")
+	}
+}
+
+func TestDefectDojoImportsTheVersionsOfARepositoryInOneTest(t *testing.T) {
+	result := ScanResult{
+		Cluster: Cluster{Name: "prod"},
+		ContainerInfo: []ContainerInfo{
+			{Container: kubernetes.Container{URL: "docker.io", Name: "library/nginx", Version: "1.24", Tag: "1.24"}, Cves: []string{"CVE-2023-4911", "CVE-2023-1234"}},
+			{Container: kubernetes.Container{URL: "docker.io", Name: "library/nginx", Version: "1.25", Tag: "1.25"}, Cves: []string{"CVE-2023-4911"}},
+			{Container: kubernetes.Container{URL: "docker.io", Name: "library/redis", Version: "6.0", Tag: "6.0"}, Cves: []string{"CVE-2023-5678"}},
+			{Container: kubernetes.Container{URL: "docker.io", Name: "library/redis", Version: "7.0", Tag: "7.0"}, Cves: []string{versioning.CheckFailed}},
+		},
+	}
+	tests := defectDojoTests(config.DefectDojo{Product: "platform"}, result)
+	if len(tests) != 1 || tests[0].Title != "docker.io/library/nginx" || tests[0].Engagement != "prod" {
+		t.Fatalf("Expected one test of nginx without the failed redis but got %v", tests)
+	}
+	var ids []string
+	for _, finding := range tests[0].Findings {
+		ids = append(ids, finding.UniqueID)
+	}
+	expected := []string{"CVE-2023-4911 docker.io/library/nginx:1.24", "CVE-2023-1234 docker.io/library/nginx:1.24", "CVE-2023-4911 docker.io/library/nginx:1.25"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected the findings of both versions %v but got %v", expected, ids)
+	}
+}
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// dependencyTrackProject is the CycloneDX SBOM of the images of a project in the version of the cluster
+type dependencyTrackProject struct {
+	Name    string
+	Version string
+	Bom     cycloneDX
+}
+
+// exportToDependencyTrack uploads the SBOM of every project to Dependency-Track, when projects fail the other projects are still uploaded
+// and an aggregated error of the failed projects is returned
+func exportToDependencyTrack(ctx context.Context, lcmConfig config.Config, result ScanResult) error {
+	packages := map[string][]scanning.Package{}
+	if lcmConfig.DependencyTrack.Packages {
+		packages = getPackages(ctx, lcmConfig, result.ContainerInfo)
+	}
+	var errs []error
+	for _, project := range dependencyTrackProjects(lcmConfig.DependencyTrack, result, packages, time.Now()) {
+		purpose := "SBOM of project " + project.Name + " " + project.Version
+		if err := uploadBom(audit.WithPurpose(ctx, purpose), lcmConfig.DependencyTrack, project); err != nil {
+			errs = append(errs, fmt.Errorf("Could not upload the SBOM of project [%s] version [%s]: %w", project.Name, project.Version, err))
+			continue
+		}
+		logger.WithField("project", project.Name).WithField("version", project.Version).Debug("Uploaded the SBOM to Dependency-Track")
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// dependencyTrackProjects returns the projects of the namespaces or the workloads of every cluster sorted by name,
+// the images without a workload are not in a project per workload
+func dependencyTrackProjects(dependencyTrack config.DependencyTrack, result ScanResult, packages map[string][]scanning.Package, now time.Time) []dependencyTrackProject {
+	clusters := result.Clusters
+	if len(clusters) == 0 {
+		clusters = []ClusterResult{{Cluster: result.Cluster, ContainerInfo: result.ContainerInfo}}
+	}
+	var projects []dependencyTrackProject
+	for _, cluster := range clusters {
+		version := cluster.Cluster.Name
+		if version == "" {
+			version = "latest"
+		}
+		images := map[string][]ContainerInfo{}
+		for _, ci := range cluster.ContainerInfo {
+			names := ci.Container.Namespaces
+			if dependencyTrack.ProjectPer == config.ProjectPerWorkload {
+				names = ci.Container.Workloads
+			}
+			for _, name := range names {
+				images[dependencyTrack.ProjectPrefix+name] = append(images[dependencyTrack.ProjectPrefix+name], ci)
+			}
+		}
+		var names []string
+		for name := range images {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			bom := newCycloneDX(ScanResult{Cluster: cluster.Cluster, ContainerInfo: images[name]}, packages, "", now)
+			bom.Metadata.Component.Name = name
+			bom.Metadata.Component.Version = version
+			projects = append(projects, dependencyTrackProject{Name: name, Version: version, Bom: bom})
+		}
+	}
+	return projects
+}
+
+// uploadBom uploads the SBOM of the project with the BOM API of Dependency-Track, it creates the project when it doesn't exist yet
+// Dependency-Track analyzes the SBOM in the background after the upload
+func uploadBom(ctx context.Context, dependencyTrack config.DependencyTrack, project dependencyTrackProject) error {
+	var bom bytes.Buffer
+	if err := writeCycloneDX(&bom, project.Bom); err != nil {
+		return err
+	}
+	request, err := json.Marshal(map[string]interface{}{
+		"projectName":    project.Name,
+		"projectVersion": project.Version,
+		"autoCreate":     true,
+		"bom":            base64.StdEncoding.EncodeToString(bom.Bytes()),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", strings.TrimSuffix(dependencyTrack.URL, "/")+"/api/v1/bom", bytes.NewReader(request))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Key", dependencyTrack.APIKey)
+	resp, err := exportClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	return nil
+}
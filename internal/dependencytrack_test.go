@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestDependencyTrackProjectPerWorkload(t *testing.T) {
+	var lock sync.Mutex
+	uploaded := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ProjectName    string `json:"projectName"`
+			ProjectVersion string `json:"projectVersion"`
+			AutoCreate     bool   `json:"autoCreate"`
+			Bom            string `json:"bom"`
+		}
+		if r.Method != "PUT" || r.URL.Path != "/api/v1/bom" || r.Header.Get("X-Api-Key") != "secret" || json.NewDecoder(r.Body).Decode(&request) != nil || !request.AutoCreate {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if request.ProjectName == "k8s/jobs/CronJob/backup" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, _ := base64.StdEncoding.DecodeString(request.Bom)
+		var bom cycloneDX
+		if err := json.Unmarshal(data, &bom); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		for _, component := range bom.Components {
+			key := request.ProjectName + "@" + request.ProjectVersion
+			uploaded[key] = append(uploaded[key], component.BomRef)
+		}
+		w.Write([]byte(`{"token": "abc"}`))
+	}))
+	defer server.Close()
+
+	result := ScanResult{
+		Cluster: Cluster{Name: "prod"},
+		ContainerInfo: []ContainerInfo{
+			{Container: kubernetes.Container{URL: "docker.io", Name: "library/nginx", Tag: "1.25", Namespaces: []string{"web"}, Workloads: []string{"web/Deployment/nginx", "web/Deployment/proxy"}}},
+			{Container: kubernetes.Container{URL: "registry.io", Name: "team/app", Tag: "1.0", Namespaces: []string{"web"}, Workloads: []string{"web/Deployment/nginx"}}},
+			{Container: kubernetes.Container{URL: "registry.io", Name: "team/backup", Tag: "2.0", Namespaces: []string{"jobs"}, Workloads: []string{"jobs/CronJob/backup"}}},
+		},
+	}
+	lcmConfig := config.Config{DependencyTrack: config.DependencyTrack{Enabled: true, URL: server.URL + "/", APIKey: "secret", ProjectPer: config.ProjectPerWorkload, ProjectPrefix: "k8s/"}}
+
+	err := exportToDependencyTrack(context.Background(), lcmConfig, result)
+	if err == nil {
+		t.Errorf("Expected an error for the project that may not be uploaded")
+	}
+	expected := map[string][]string{
+		"k8s/web/Deployment/nginx@prod": {"docker.io/library/nginx:1.25", "registry.io/team/app:1.0"},
+		"k8s/web/Deployment/proxy@prod": {"docker.io/library/nginx:1.25"},
+	}
+	for _, refs := range uploaded {
+		sort.Strings(refs)
+	}
+	if !reflect.DeepEqual(uploaded, expected) {
+		t.Errorf("Expected %v but got %v", expected, uploaded)
+	}
+}
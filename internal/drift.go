@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// DriftInfo is an image of a workload that runs a different version in the compared clusters or only runs in some of them
+type DriftInfo struct {
+	Workload string
+	Image    string
+	// Versions are the versions of the image per cluster, a cluster where the workload doesn't run the image has no version
+	Versions map[string]string
+}
+
+// compareClusters returns the images of the workloads that differ between the clusters with the names, the workloads are matched
+// by namespace, kind and name and the images by registry and repository, so staging/Deployment/web in both clusters is the same workload
+// The clusters of which the images couldn't be collected are left out of the comparison and returned, so they don't show every workload as drift
+func compareClusters(names []string, clusters []ClusterResult) ([]DriftInfo, []string) {
+	type workloadImage struct {
+		workload, image string
+	}
+	compared := map[string]bool{}
+	for _, name := range names {
+		compared[name] = true
+	}
+	var failed []string
+	for _, cluster := range clusters {
+		if compared[cluster.Cluster.Name] && cluster.collectionFailed() {
+			compared[cluster.Cluster.Name] = false
+			failed = append(failed, cluster.Cluster.Name)
+		}
+	}
+	versions := map[workloadImage]map[string][]string{}
+	for _, cluster := range clusters {
+		if !compared[cluster.Cluster.Name] {
+			continue
+		}
+		for _, ci := range cluster.ContainerInfo {
+			for _, workload := range ci.Container.Workloads {
+				key := workloadImage{workload: workload, image: ci.Container.URL + "/" + ci.Container.Name}
+				if versions[key] == nil {
+					versions[key] = map[string][]string{}
+				}
+				versions[key][cluster.Cluster.Name] = mergeSorted(versions[key][cluster.Cluster.Name], []string{ci.Container.Version})
+			}
+		}
+	}
+
+	drift := []DriftInfo{}
+	for key, perCluster := range versions {
+		info := DriftInfo{Workload: key.workload, Image: key.image, Versions: map[string]string{}}
+		distinct := map[string]bool{}
+		for cluster, clusterVersions := range perCluster {
+			info.Versions[cluster] = strings.Join(clusterVersions, " ")
+			distinct[info.Versions[cluster]] = true
+		}
+		if len(perCluster) < len(names)-len(failed) || len(distinct) > 1 {
+			drift = append(drift, info)
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Workload != drift[j].Workload {
+			return drift[i].Workload < drift[j].Workload
+		}
+		return drift[i].Image < drift[j].Image
+	})
+	return drift, failed
+}
+
+// collectionFailed returns true when the images of the cluster couldn't be collected, the cluster has problems with Kubernetes and no images
+func (c ClusterResult) collectionFailed() bool {
+	if len(c.ContainerInfo) > 0 {
+		return false
+	}
+	for _, problem := range c.Problems {
+		if problem.Section == SectionKubernetes {
+			return true
+		}
+	}
+	return false
+}
+
+// prettyPrintDrift prints the images of the workloads that differ between the clusters with a column per cluster,
+// a cluster where the workload doesn't run the image shows a dash
+func prettyPrintDrift(names []string, drift []DriftInfo) {
+	if len(drift) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(append([]string{"Workload", "Image"}, names...))
+	alignment := []int{3, 3}
+	for range names {
+		alignment = append(alignment, 1)
+	}
+	table.SetColumnAlignment(alignment)
+	table.SetAutoWrapText(false)
+	for _, info := range drift {
+		row := []string{info.Workload, info.Image}
+		for _, name := range names {
+			version, exists := info.Versions[name]
+			if !exists {
+				version = "-"
+			}
+			row = append(row, version)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
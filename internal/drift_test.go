@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestCompareClusters(t *testing.T) {
+	image := func(name, version string, workloads ...string) ContainerInfo {
+		return ContainerInfo{Container: kubernetes.Container{URL: "docker.io", Name: name, Version: version, Workloads: workloads}}
+	}
+	clusters := []ClusterResult{
+		{Cluster: Cluster{Name: "staging"}, ContainerInfo: []ContainerInfo{
+			image("library/nginx", "1.25", "web/Deployment/web"),
+			image("library/redis", "7", "web/StatefulSet/cache"),
+			image("library/busybox", "1.36", "web/Job/migrate"),
+		}},
+		{Cluster: Cluster{Name: "production"}, ContainerInfo: []ContainerInfo{
+			image("library/nginx", "1.24", "web/Deployment/web"),
+			image("library/redis", "7", "web/StatefulSet/cache"),
+		}},
+		{Cluster: Cluster{Name: "dev"}, ContainerInfo: []ContainerInfo{image("library/nginx", "1.26", "web/Deployment/web")}},
+	}
+
+	drift, failed := compareClusters([]string{"staging", "production"}, clusters)
+	expected := []DriftInfo{
+		{Workload: "web/Deployment/web", Image: "docker.io/library/nginx", Versions: map[string]string{"staging": "1.25", "production": "1.24"}},
+		{Workload: "web/Job/migrate", Image: "docker.io/library/busybox", Versions: map[string]string{"staging": "1.36"}},
+	}
+	if !reflect.DeepEqual(drift, expected) || len(failed) != 0 {
+		t.Errorf("Expected %v but got %v and the failed clusters %v", expected, drift, failed)
+	}
+	if code := (ScanResult{Drift: drift}).ExitCode([]string{FailOnDrift}); code != ExitCodeDrift {
+		t.Errorf("Expected exit code %d but got %d", ExitCodeDrift, code)
+	}
+}
+
+func TestCompareClustersWithoutTheFailedClusters(t *testing.T) {
+	image := func(name, version string, workloads ...string) ContainerInfo {
+		return ContainerInfo{Container: kubernetes.Container{URL: "docker.io", Name: name, Version: version, Workloads: workloads}}
+	}
+	clusters := []ClusterResult{
+		{Cluster: Cluster{Name: "staging"}, ContainerInfo: []ContainerInfo{image("library/nginx", "1.25", "web/Deployment/web")}},
+		{Cluster: Cluster{Name: "production"}, ContainerInfo: []ContainerInfo{image("library/nginx", "1.25", "web/Deployment/web")}},
+		{Cluster: Cluster{Name: "dr"}, Problems: []ScanProblem{{Section: SectionKubernetes, Item: "containers", Error: "connection refused"}}},
+	}
+
+	drift, failed := compareClusters([]string{"staging", "production", "dr"}, clusters)
+	if len(drift) != 0 || !reflect.DeepEqual(failed, []string{"dr"}) {
+		t.Errorf("Expected no drift without the unreachable cluster dr but got %v and the failed clusters %v", drift, failed)
+	}
+	if code := (ScanResult{Drift: drift}).ExitCode([]string{FailOnDrift}); code != 0 {
+		t.Errorf("Expected exit code 0 but got %d", code)
+	}
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"context"
+	"sort"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+)
+
+// addEpssScores adds the EPSS scores to the CVEs of the images and sorts the CVEs from the highest score,
+// the scores of all images are looked up together so every CVE is only looked up once
+func addEpssScores(ctx context.Context, epss scanning.EpssConfig, info []ContainerInfo, problems *scanProblems) {
+	if !epss.Enabled {
+		return
+	}
+	seen := map[string]bool{}
+	var cves []string
+	for _, ci := range info {
+		for _, cve := range ci.Cves {
+			if !seen[cve] {
+				seen[cve] = true
+				cves = append(cves, cve)
+			}
+		}
+	}
+	scores, err := epss.GetScores(audit.WithPurpose(ctx, "epss scores"), cves)
+	problems.add(SectionVulnerabilities, "epss", err)
+	for index := range info {
+		ci := &info[index]
+		ci.Epss = map[string]float64{}
+		ci.AboveEpss = nil
+		for _, cve := range ci.Cves {
+			if score, exists := scores[cve]; exists {
+				ci.Epss[cve] = score
+			}
+		}
+		// the Cves are shared by the images of the same reference, so they are copied before sorting
+		ci.Cves = append([]string{}, ci.Cves...)
+		sort.SliceStable(ci.Cves, func(a, b int) bool {
+			return ci.Epss[ci.Cves[a]] > ci.Epss[ci.Cves[b]]
+		})
+		for _, cve := range ci.Cves {
+			if epss.Threshold > 0 && ci.Epss[cve] >= epss.Threshold {
+				ci.AboveEpss = append(ci.AboveEpss, cve)
+			}
+		}
+	}
+}
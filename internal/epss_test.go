@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+)
+
+func TestEpssScoresSortTheCves(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cve") != "CVE-2023-1234,CVE-2023-4911,CVE-2023-5678" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"status": "OK", "data": [
+			{"cve": "CVE-2023-4911", "epss": "0.87", "percentile": "0.99"},
+			{"cve": "CVE-2023-5678", "epss": "0.002", "percentile": "0.56"}
+		]}`))
+	}))
+	defer server.Close()
+
+	info := []ContainerInfo{
+		{Container: kubernetes.Container{Name: "library/nginx"}, Cves: []string{"CVE-2023-1234", "CVE-2023-4911", "GHSA-abcd", "CVE-2023-5678"}},
+		{Container: kubernetes.Container{Name: "library/redis"}, Cves: []string{"CVE-2023-5678"}},
+	}
+	problems := &scanProblems{}
+	addEpssScores(context.Background(), scanning.EpssConfig{Enabled: true, URL: server.URL, Threshold: 0.1}, info, problems)
+	if len(problems.problems) != 0 {
+		t.Fatalf("Expected no problems but got %v", problems.problems)
+	}
+	if expected := []string{"CVE-2023-4911", "CVE-2023-5678", "CVE-2023-1234", "GHSA-abcd"}; !reflect.DeepEqual(info[0].Cves, expected) {
+		t.Errorf("Expected the cves from the highest score %v but got %v", expected, info[0].Cves)
+	}
+	if !reflect.DeepEqual(info[0].AboveEpss, []string{"CVE-2023-4911"}) || len(info[1].AboveEpss) != 0 {
+		t.Errorf("Expected only CVE-2023-4911 above the threshold but got %v and %v", info[0].AboveEpss, info[1].AboveEpss)
+	}
+	if scores := info[0].EpssScores(info[0].Cves[:3]); !reflect.DeepEqual(scores, []string{"CVE-2023-4911 (0.870)", "CVE-2023-5678 (0.002)", "CVE-2023-1234"}) {
+		t.Errorf("Expected the cves with their scores but got %v", scores)
+	}
+	if code := (ScanResult{ContainerInfo: info}).ExitCode([]string{FailOnEpss}); code != ExitCodeEpss {
+		t.Errorf("Expected exit code %d but got %d", ExitCodeEpss, code)
+	}
+}
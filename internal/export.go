@@ -0,0 +1,11 @@
+package internal
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+)
+
+// exportClient is used for all the calls to the systems the results are exported to, the reporters phase deadline also applies to them
+var exportClient = &http.Client{Timeout: 60 * time.Second, Transport: httpclient.Transport(httpclient.Exporter)}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+const (
+	// FailOnScanErrors fails when some parts of the scan failed
+	FailOnScanErrors = "scan-errors"
+	// FailOnVulnerable fails when an image has vulnerabilities or doesn't pass the policy of a scanner
+	FailOnVulnerable = "vulnerable"
+	// FailOnOutdated fails when an image, chart or tool has a newer version
+	FailOnOutdated = "outdated"
+	// FailOnOutdatedMinor fails when an image, chart or tool is a minor or major version behind
+	FailOnOutdatedMinor = "outdated-minor"
+	// FailOnOutdatedMajor fails when an image, chart or tool is a major version behind
+	FailOnOutdatedMajor = "outdated-major"
+	// FailOnPolicyViolations fails when an image is of a registry that is not allowed by the registry policy
+	FailOnPolicyViolations = "policy-violations"
+	// FailOnDrift fails when the workloads run different images in the compared clusters
+	FailOnDrift = "drift"
+	// FailOnExploited fails when an image has a vulnerability of the KEV catalog
+	FailOnExploited = "exploited"
+	// FailOnEpss fails when an image has a CVE with at least the EPSS threshold score
+	FailOnEpss = "epss"
+
+	// ExitCodeScanErrors is the exit code when failing on scan errors
+	ExitCodeScanErrors = 2
+	// ExitCodeVulnerable is the exit code when failing on vulnerabilities
+	ExitCodeVulnerable = 3
+	// ExitCodeOutdated is the exit code when failing on outdated versions, also for the minor and major upgrades
+	ExitCodeOutdated = 4
+	// ExitCodePolicyViolations is the exit code when failing on policy violations
+	ExitCodePolicyViolations = 5
+	// ExitCodeDrift is the exit code when failing on drift between the clusters
+	ExitCodeDrift = 6
+	// ExitCodeSeverityThreshold is the exit code when an image has a vulnerability of at least the fail threshold, it doesn't need a fail on condition
+	ExitCodeSeverityThreshold = 7
+	// ExitCodeExploited is the exit code when failing on actively exploited vulnerabilities
+	ExitCodeExploited = 8
+	// ExitCodeEpss is the exit code when failing on CVEs above the EPSS threshold
+	ExitCodeEpss = 9
+)
+
+// ExitCode returns the exit code for the fail on conditions and the fail threshold of the severity, when multiple conditions match the lowest exit code is returned
+func (r ScanResult) ExitCode(failOn []string) int {
+	if contains(failOn, FailOnScanErrors) && len(r.Problems) > 0 {
+		return ExitCodeScanErrors
+	}
+	if contains(failOn, FailOnVulnerable) && r.HasVulnerabilities() {
+		return ExitCodeVulnerable
+	}
+	if contains(failOn, FailOnOutdated) && r.HasOutdated() {
+		return ExitCodeOutdated
+	}
+	if contains(failOn, FailOnOutdatedMinor) && r.hasUpgrade(versioning.Major, versioning.Minor) {
+		return ExitCodeOutdated
+	}
+	if contains(failOn, FailOnOutdatedMajor) && r.hasUpgrade(versioning.Major) {
+		return ExitCodeOutdated
+	}
+	if contains(failOn, FailOnPolicyViolations) && len(r.PolicyViolations) > 0 {
+		return ExitCodePolicyViolations
+	}
+	if contains(failOn, FailOnDrift) && len(r.Drift) > 0 {
+		return ExitCodeDrift
+	}
+	if r.countThresholds(r.Thresholds.Fails) > 0 {
+		return ExitCodeSeverityThreshold
+	}
+	if contains(failOn, FailOnExploited) && countExploited(r.ContainerInfo) > 0 {
+		return ExitCodeExploited
+	}
+	if contains(failOn, FailOnEpss) && countAboveEpss(r.ContainerInfo) > 0 {
+		return ExitCodeEpss
+	}
+	return 0
+}
+
+// countThresholds returns the number of images with a highest severity that breaches the threshold
+func (r ScanResult) countThresholds(breaches func(severity string) bool) int {
+	breached := 0
+	for _, container := range r.ContainerInfo {
+		if breaches(container.Severity) {
+			breached++
+		}
+	}
+	return breached
+}
+
+// HasVulnerabilities returns true when at least one image has vulnerabilities or doesn't pass the policy of a scanner
+func (r ScanResult) HasVulnerabilities() bool {
+	for _, container := range r.ContainerInfo {
+		if container.PolicyFailed() {
+			return true
+		}
+		status := container.GetCveStatus()
+		if len(container.Cves) > 0 && status != versioning.Failure && status != versioning.Nodata && status != versioning.CheckFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOutdated returns true when at least one image, chart or tool has a newer version
+func (r ScanResult) HasOutdated() bool {
+	return r.hasUpgrade(versioning.Major, versioning.Minor, versioning.Patch)
+}
+
+// hasUpgrade returns true when at least one image, chart or tool has an upgrade of one of the types, the upgrades of the images
+// are compared with the versions that their version regex extracts from the tags
+func (r ScanResult) hasUpgrade(types ...string) bool {
+	for _, container := range r.ContainerInfo {
+		if contains(types, container.Upgrade) {
+			return true
+		}
+	}
+	for _, chart := range r.ChartInfo {
+		if contains(types, versioning.UpgradeType(chart.LatestVersion, chart.Chart.Version)) {
+			return true
+		}
+	}
+	for _, tool := range r.ToolInfo {
+		if contains(types, versioning.UpgradeType(tool.LatestVersion, tool.Tool.Version)) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestFailOnTheUpgradeType(t *testing.T) {
+	result := ScanResult{ContainerInfo: []ContainerInfo{
+		{Container: kubernetes.Container{Name: "library/nginx", Version: "1.19.2"}, LatestVersion: "1.19.4"},
+		{Container: kubernetes.Container{Name: "library/redis", Version: "6.0.1"}, LatestVersion: "6.2.0"},
+	}}
+	for index, container := range result.ContainerInfo {
+		result.ContainerInfo[index].Upgrade = versioning.UpgradeType(container.LatestVersion, container.Container.Version)
+	}
+	tests := map[string]int{
+		FailOnOutdated:      ExitCodeOutdated,
+		FailOnOutdatedMinor: ExitCodeOutdated,
+		FailOnOutdatedMajor: 0,
+	}
+	for failOn, expected := range tests {
+		if code := result.ExitCode([]string{failOn}); code != expected {
+			t.Errorf("Expected exit code %d for %s but got %d", expected, failOn, code)
+		}
+	}
+
+	if upgrades := countUpgrades(result.ContainerInfo); upgrades[versioning.Patch] != 1 || upgrades[versioning.Minor] != 1 || upgrades[versioning.Major] != 0 {
+		t.Errorf("Expected a patch and a minor upgrade but got %v", upgrades)
+	}
+}
+
+func TestFailOnTheUpgradeOfTheVersionRegex(t *testing.T) {
+	imageRegistries := registries.ImageRegistries{
+		VersionRules: []registries.VersionRule{{Images: []string{"vendor/appliance"}, VersionRegex: `^release-v(\d+\.\d+\.\d+)`}},
+	}
+	container := kubernetes.Container{Name: "vendor/appliance", URL: "registry.example.com", Version: "release-v1.4.2-hotfix"}
+	info := ContainerInfo{
+		Container:     container,
+		LatestVersion: "release-v1.10.0",
+		Upgrade:       upgradeType(imageRegistries, container, "release-v1.10.0"),
+		Lifecycle:     lifecycleStatus(imageRegistries, container, "release-v1.10.0"),
+	}
+	result := ScanResult{ContainerInfo: []ContainerInfo{info}}
+	tests := map[string]int{
+		FailOnOutdated:      ExitCodeOutdated,
+		FailOnOutdatedMinor: ExitCodeOutdated,
+		FailOnOutdatedMajor: 0,
+	}
+	for failOn, expected := range tests {
+		if code := result.ExitCode([]string{failOn}); code != expected {
+			t.Errorf("Expected exit code %d for %s but got %d", expected, failOn, code)
+		}
+	}
+	if status := info.GetStatus(); status != versioning.Minor {
+		t.Errorf("Expected the status %s of 1.4.2 to 1.10.0 but got %s", versioning.Minor, status)
+	}
+}
+
+func TestFailOnTheSeverityThreshold(t *testing.T) {
+	result := ScanResult{ContainerInfo: []ContainerInfo{
+		{Container: kubernetes.Container{Name: "library/nginx"}, Cves: []string{"CVE-2023-4911"}, Severity: "High"},
+		{Container: kubernetes.Container{Name: "library/redis"}, Cves: []string{"CVE-2023-1234"}, Severity: "Medium"},
+	}}
+	tests := []struct {
+		thresholds scanning.SeverityThresholds
+		expected   int
+		warnings   int
+	}{
+		{scanning.SeverityThresholds{}, 0, 0},
+		{scanning.SeverityThresholds{Fail: "Critical", Warn: "High"}, 0, 1},
+		{scanning.SeverityThresholds{Fail: "HIGH", Warn: "Medium"}, ExitCodeSeverityThreshold, 1},
+	}
+	for _, test := range tests {
+		result.Thresholds = test.thresholds
+		if code := result.ExitCode(nil); code != test.expected {
+			t.Errorf("Expected exit code %d for %v but got %d", test.expected, test.thresholds, code)
+		}
+		if warnings := result.countThresholds(result.Thresholds.Warns); warnings != test.warnings {
+			t.Errorf("Expected %d images above the warn threshold for %v but got %d", test.warnings, test.thresholds, warnings)
+		}
+	}
+}
+
+func TestFailOnExploitedVulnerabilities(t *testing.T) {
+	result := ScanResult{ContainerInfo: []ContainerInfo{
+		{Container: kubernetes.Container{Name: "library/nginx"}, Cves: []string{"CVE-2023-1234", "CVE-2023-4911"}, Exploited: []string{"CVE-2023-4911"}},
+	}}
+	if status := result.ContainerInfo[0].GetCveStatus(); status != "2 (1 actively exploited)" {
+		t.Errorf("Expected the exploited marker in the cve status but got [%s]", status)
+	}
+	if code := result.ExitCode([]string{FailOnExploited}); code != ExitCodeExploited {
+		t.Errorf("Expected exit code %d but got %d", ExitCodeExploited, code)
+	}
+	result.ContainerInfo[0].Exploited = nil
+	if code := result.ExitCode([]string{FailOnExploited}); code != 0 {
+		t.Errorf("Expected no failing exit code without exploited vulnerabilities but got %d", code)
+	}
+}
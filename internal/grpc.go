@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/internal/grpcapi"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// lifecycleServer implements the gRPC service on top of the latest scan result
+type lifecycleServer struct {
+	config config.Config
+	// running is 1 while a scan started through the service is running
+	running int32
+}
+
+// StartGRPCServer starts the gRPC service on the address in the background
+func StartGRPCServer(config config.Config, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	grpcapi.RegisterLifecycleServiceServer(srv, &lifecycleServer{config: config})
+	logger.WithFields(log.Fields{"addr": addr}).Info("Started gRPC server")
+
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			logger.WithError(err).Error("Could not start gRPC server")
+		}
+	}()
+	return nil
+}
+
+// GetScanResult returns the latest scan result
+func (s *lifecycleServer) GetScanResult(ctx context.Context, req *grpcapi.GetScanResultRequest) (*grpcapi.ScanResult, error) {
+	webDataLock.RLock()
+	defer webDataLock.RUnlock()
+	return toGRPCScanResult(WebDataVar), nil
+}
+
+// TriggerScan starts a new scan in the background unless a scan started through the service is still running,
+// with leader election only the leader scans
+func (s *lifecycleServer) TriggerScan(ctx context.Context, req *grpcapi.TriggerScanRequest) (*grpcapi.TriggerScanResponse, error) {
+	if !IsLeader() {
+		return &grpcapi.TriggerScanResponse{Started: false, Status: "Not the leader"}, nil
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return &grpcapi.TriggerScanResponse{Started: false, Status: "Running"}, nil
+	}
+
+	config := currentConfig(s.config)
+	go func() {
+		defer atomic.StoreInt32(&s.running, 0)
+		// The scan outlives the call so it doesn't use the context of the call
+		scanCtx, cancel := context.WithTimeout(context.Background(), config.Timeouts.GetScanTimeout())
+		defer cancel()
+		logger.Info("Scan triggered through gRPC")
+		Execute(scanCtx, config)
+	}()
+	return &grpcapi.TriggerScanResponse{Started: true, Status: "Running"}, nil
+}
+
+func toGRPCScanResult(data WebData) *grpcapi.ScanResult {
+	result := &grpcapi.ScanResult{
+		Status:          data.Status,
+		LastTimeFetched: data.LastTimeFetched,
+		Cluster: &grpcapi.Cluster{
+			Name:   data.Cluster.Name,
+			Labels: data.Cluster.Labels,
+		},
+	}
+	for _, info := range data.ContainerInfo {
+		result.Images = append(result.Images, &grpcapi.Image{
+			FullPath:      info.Container.FullPath,
+			Registry:      info.Container.URL,
+			Name:          info.Container.Name,
+			Version:       info.Container.Version,
+			LatestVersion: info.LatestVersion,
+			Cves:          info.Cves,
+			Namespaces:    info.Container.Namespaces,
+		})
+	}
+	for _, info := range data.ChartInfo {
+		result.Charts = append(result.Charts, &grpcapi.Chart{
+			Name:          info.Chart.Name,
+			Version:       info.Chart.Version,
+			LatestVersion: info.LatestVersion,
+		})
+	}
+	for _, info := range data.ToolInfo {
+		result.Tools = append(result.Tools, &grpcapi.Tool{
+			Repo:          info.Tool.Repo,
+			Version:       info.Tool.Version,
+			LatestVersion: info.LatestVersion,
+		})
+	}
+	for _, problem := range data.Problems {
+		result.Problems = append(result.Problems, &grpcapi.Problem{
+			Section: problem.Section,
+			Item:    problem.Item,
+			Error:   problem.Error,
+			Code:    string(problem.Code),
+		})
+	}
+	return result
+}
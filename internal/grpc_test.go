@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal/grpcapi"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGetScanResultOverGRPC(t *testing.T) {
+	webDataLock.Lock()
+	WebDataVar = WebData{Status: "Done", ScanResult: ScanResult{
+		Cluster:       Cluster{Name: "prod"},
+		ContainerInfo: []ContainerInfo{{Container: kubernetes.Container{Name: "nginx", Version: "1.0", Namespaces: []string{"web"}}, LatestVersion: "1.1"}},
+		Problems:      []ScanProblem{{Section: SectionImages, Item: "nginx", Error: "timeout"}},
+	}}
+	webDataLock.Unlock()
+	defer func() { WebDataVar = WebData{} }()
+
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	grpcapi.RegisterLifecycleServiceServer(srv, &lifecycleServer{})
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	result, err := grpcapi.NewLifecycleServiceClient(conn).GetScanResult(context.Background(), &grpcapi.GetScanResultRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "Done" || result.Cluster.Name != "prod" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	if len(result.Images) != 1 || result.Images[0].LatestVersion != "1.1" || result.Images[0].Namespaces[0] != "web" {
+		t.Errorf("Unexpected images %v", result.Images)
+	}
+	if len(result.Problems) != 1 || result.Problems[0].Error != "timeout" {
+		t.Errorf("Unexpected problems %v", result.Problems)
+	}
+}
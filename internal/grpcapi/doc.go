@@ -0,0 +1,4 @@
+// Package grpcapi contains the gRPC service generated from lcm.proto to query the scan results and trigger scans
+package grpcapi
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. lcm.proto
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/heptiolabs/healthcheck"
+)
+
+// lastSuccessfulScan is the time in unix nanoseconds the last scan finished before its deadline, zero before the first scan
+var lastSuccessfulScan int64
+
+// scanSucceeded records that a scan finished before its deadline
+func scanSucceeded(at time.Time) {
+	atomic.StoreInt64(&lastSuccessfulScan, at.UnixNano())
+}
+
+// newHealthHandler returns the liveness and readiness checks, lcm is alive while it serves requests
+// and ready once a scan succeeded and the last successful scan isn't older than the staleness threshold of the current config
+func newHealthHandler(config config.Config) healthcheck.Handler {
+	health := healthcheck.NewHandler()
+	health.AddReadinessCheck("scan", func() error {
+		return scanCheck(currentConfig(config).GetReadyStaleness(), time.Now)()
+	})
+	return health
+}
+
+// scanCheck fails until the first successful scan and when the last successful scan is older than the staleness, zero staleness never gets stale
+// A replica that waits for the leadership never scans, it is ready so rolling updates with multiple replicas don't stall on it
+func scanCheck(staleness time.Duration, now func() time.Time) healthcheck.Check {
+	return func() error {
+		if !IsLeader() {
+			return nil
+		}
+		last := atomic.LoadInt64(&lastSuccessfulScan)
+		if last == 0 {
+			return errors.New("No successful scan yet")
+		}
+		age := now().Sub(time.Unix(0, last))
+		if staleness > 0 && age > staleness {
+			return fmt.Errorf("Last successful scan is older than [%s]: [%s]", staleness, age.Round(time.Second))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScanCheck(t *testing.T) {
+	lastSuccessfulScan = 0
+	defer func() { lastSuccessfulScan = 0 }()
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	check := scanCheck(time.Hour, func() time.Time { return now })
+
+	if check() == nil {
+		t.Error("Expected not ready before the first successful scan")
+	}
+
+	scanSucceeded(start)
+	now = start.Add(30 * time.Minute)
+	if err := check(); err != nil {
+		t.Errorf("Expected ready after a recent scan but got [%v]", err)
+	}
+
+	now = start.Add(2 * time.Hour)
+	if check() == nil {
+		t.Error("Expected not ready when the last scan is stale")
+	}
+
+	if err := scanCheck(0, func() time.Time { return now })(); err != nil {
+		t.Errorf("Expected ready without a staleness threshold but got [%v]", err)
+	}
+}
+
+func TestScanCheckOfAFollower(t *testing.T) {
+	lastSuccessfulScan = 0
+	atomic.StoreInt32(&leading, 0)
+	defer atomic.StoreInt32(&leading, 1)
+	check := scanCheck(time.Hour, time.Now)
+
+	if err := check(); err != nil {
+		t.Errorf("Expected a replica waiting for the leadership to be ready but got [%v]", err)
+	}
+
+	atomic.StoreInt32(&leading, 1)
+	if check() == nil {
+		t.Error("Expected the leader not to be ready before its first successful scan")
+	}
+}
@@ -1,14 +1,26 @@
 package internal
 
 import (
+	"context"
+	"fmt"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/arminc/k8s-platform-lcm/internal/config"
-	"github.com/arminc/k8s-platform-lcm/internal/kubernetes"
-	"github.com/arminc/k8s-platform-lcm/internal/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/metrics"
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/tracing"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	log "github.com/sirupsen/logrus"
 )
 
+var logger = log.WithField("component", "lcm")
+
 // ToolInfo contains tool information with the latest version
 type ToolInfo struct {
 	Tool          registries.Tool
@@ -25,114 +37,680 @@ type ChartInfo struct {
 type ContainerInfo struct {
 	Container     kubernetes.Container
 	LatestVersion string
-	Fetched       bool
-	Cves          []string
+	// Upgrade is MAJOR, MINOR or PATCH when there is a newer version of the image, otherwise it is empty
+	Upgrade string
+	// Lifecycle is the status of the running version compared to the latest version like SAME or MAJOR, it is compared
+	// with the versions that the version regex of the image extracts from the tags
+	Lifecycle string
+	// Behind is the number of newer releases of the image than the version that is running, including the latest version
+	Behind int
+	// Created and LatestCreated are when the running and the latest version were built, they are zero when the tag age is not fetched
+	Created       time.Time
+	LatestCreated time.Time
+	// OutdatedBuilds are the pods as namespace/name that run an older build of a mutable tag like latest than the tag points to
+	OutdatedBuilds []string
+	// Clusters are the names of the clusters the image runs in when multiple clusters are scanned
+	Clusters []string
+	Fetched  bool
+	Cves     []string
+	// ScanFailed is true when a scanner failed, the Cves are then the findings of the scanners that succeeded
+	ScanFailed bool
+	// Severity is the highest severity of the vulnerabilities of the image and Severities the severity per vulnerability
+	Severity   string
+	Severities map[string]string
+	// Exploited are the vulnerabilities of the image that are actively exploited according to the KEV catalog
+	Exploited []string
+	// Epss are the EPSS scores of the CVEs of the image when EPSS is enabled, the Cves are then sorted from the highest score
+	// and AboveEpss are the CVEs with at least the threshold score
+	Epss      map[string]float64
+	AboveEpss []string
+	// Policies are the evaluations of the image by the scanners with a policy like Anchore
+	Policies []scanning.PolicyEvaluation
+	// BaseImages are the recommended upgrades of the base image of the image by the scanners that have them like Snyk
+	BaseImages []scanning.BaseImageAdvice
+}
+
+// Cluster identifies the cluster the results belong to
+type Cluster struct {
+	Name   string
+	Labels map[string]string
+}
+
+// ScanResult contains all the information found during a single scan
+type ScanResult struct {
+	Cluster       Cluster
+	ContainerInfo []ContainerInfo
+	ChartInfo     []ChartInfo
+	ToolInfo      []ToolInfo
+	// NodeInfo contains the versions of the components of the nodes when the nodes are scanned
+	NodeInfo []NodeInfo
+	// ControlPlane contains the version of the API server compared to the upstream releases when the control plane is checked
+	ControlPlane ControlPlaneInfo
+	// AddonInfo contains the compatibility of the installed addons with the Kubernetes version when the addons are checked
+	AddonInfo []AddonInfo
+	Problems  []ScanProblem
+	// Skipped are the namespaces and resources that the service account may not read, the results are partial when there are any
+	Skipped []string
+	// PolicyViolations contains the images of registries that are not allowed by the registry policy
+	PolicyViolations []PolicyViolation
+	Summary          Summary
+	// Clusters contains a section per cluster when multiple clusters are scanned, the other fields contain the results of all clusters
+	Clusters []ClusterResult
+	// DriftClusters are the clusters that are compared when the cluster drift is enabled and Drift the images of the workloads that differ
+	DriftClusters []string
+	Drift         []DriftInfo
+	// Thresholds are the severities on which the images with vulnerabilities fail or warn
+	Thresholds scanning.SeverityThresholds
+	// registries are the image registries with the credentials of the scan, to look up the images of the result again
+	registries registries.ImageRegistries
+}
+
+// ProgressFunc is called during the scan with the phase and how many of the total items are done
+type ProgressFunc func(phase string, done, total int)
+
+// phaseReporters is the phase in which the reporter plugins run
+const phaseReporters = "Reporters"
+
+// Execute runs all the checks for LCM, the scan stops when the context is done
+func Execute(ctx context.Context, config config.Config) ScanResult {
+	return ExecuteWithProgress(ctx, config, func(string, int, int) {})
 }
 
-// Execute runs all the checks for LCM
-func Execute(config config.Config) {
+// ExecuteWithProgress runs all the checks for LCM and reports the progress, the scan stops when the context is done
+func ExecuteWithProgress(ctx context.Context, config config.Config, progress ProgressFunc) ScanResult {
 
+	webDataLock.Lock()
 	WebDataVar.Status = "Running"
+	webDataLock.Unlock()
+	start := time.Now()
+	audit.StartRun(start)
+	ctx, span := tracing.StartTrace(ctx, "scan")
+	span.SetAttribute("lcm.cluster", config.ClusterName)
+	defer span.End()
+	// all the Kubernetes calls of the scan use the settings of this config, also when it was reloaded
+	ctx = kubernetes.WithOptions(ctx, config.GetKubernetesOptions())
+	summary := newSummary()
+	problems := &scanProblems{}
+	result := ScanResult{
+		Cluster: Cluster{
+			Name:   config.ClusterName,
+			Labels: config.ClusterLabels,
+		},
+	}
+	if config.PrettyPrintAllowed() {
+		prettyPrintCluster(result.Cluster)
+	}
 
 	var containers = []kubernetes.Container{}
-	if config.IsKubernetesFetchEnabled() {
-		containers = kubernetes.GetContainersFromNamespaces(config.Namespaces, config.RunningLocally())
+	var clusters []clusterScan
+	pullSecrets := map[string]map[string]registries.Credential{}
+	current := shard{namespaces: config.Namespaces}
+	phaseCtx, endPhase := startPhase(ctx, config, summary, SectionKubernetes)
+	if config.IsMultiClusterEnabled() {
+		clusters = getContainersFromClusters(phaseCtx, config, progress)
+		for _, cluster := range clusters {
+			containers = append(containers, cluster.containers...)
+			addCredentials(pullSecrets, cluster.pullSecrets)
+		}
+	} else if config.IsKubernetesFetchEnabled() {
+		var err error
+		current, err = getShard(phaseCtx, config)
+		problems.add(SectionKubernetes, "shard", err)
+		if err == nil && current.fetchesKubernetes() {
+			containers, err = kubernetes.GetContainersFromNamespaces(phaseCtx, current.namespaces, config.RunningLocally(), config.GetKubernetesOptions())
+			problems.add(SectionKubernetes, "containers", err)
+			pullSecrets = getPullSecretCredentials(phaseCtx, containers, config, problems)
+		}
+		if config.IsNodeScanEnabled() && current.ownsShared() {
+			result.NodeInfo = getNodeInfo(phaseCtx, config.RunningLocally(), problems)
+		}
+		if config.IsControlPlaneCheckEnabled() && current.ownsShared() {
+			result.ControlPlane = getControlPlaneInfo(phaseCtx, config.KubernetesReleases, config.RunningLocally(), problems)
+		}
 	}
 
-	containers = getExtraImages(config.Images, containers)
-	info := getLatestVersionsForContainers(containers, config.ImageRegistries)
-	info = getVulnerabilities(info, config)
-	if config.PrettyPrintAllowed() {
+	if current.ownsShared() {
+		containers = getExtraImages(config.Images, containers, problems)
+		containers = getImagesFromCollectors(phaseCtx, config.Plugins.Collectors, containers, problems)
+	}
+	containers = uniqueContainers(containers)
+	endPhase()
+	phaseCtx, endPhase = startPhase(ctx, config, summary, SectionImages)
+	imageRegistries := scanRegistries(config, pullSecrets, problems)
+	result.registries = imageRegistries
+	info := getLatestVersionsForContainers(phaseCtx, containers, imageRegistries, config.Workers.Images, problems, progress)
+	endPhase()
+	phaseCtx, endPhase = startPhase(ctx, config, summary, SectionVulnerabilities)
+	info = getVulnerabilities(phaseCtx, info, config, imageRegistries, problems, progress)
+	endPhase()
+	if config.PrettyPrintAllowed() && !config.IsMultiClusterEnabled() {
 		prettyPrintContainerInfo(info)
 	}
-	WebDataVar.ContainerInfo = info
+	result.ContainerInfo = info
+	if config.IsAddonCheckEnabled() && !config.IsMultiClusterEnabled() && current.fetchesKubernetes() {
+		result.AddonInfo = checkAddons(config.GetAddons(), getKubernetesVersion(ctx, config, result.ControlPlane, problems), info)
+		if config.PrettyPrintAllowed() {
+			prettyPrintAddonInfo(result.AddonInfo)
+		}
+	}
+	result.PolicyViolations = checkRegistryPolicy(config.RegistryPolicy, info)
+	for _, violation := range result.PolicyViolations {
+		logger.WithField("image", violation.Image).WithField("namespaces", violation.Namespaces).Warn(violation.Reason)
+	}
+	result.Thresholds = config.GetImageScanners().Thresholds
+	for _, ci := range info {
+		if result.Thresholds.Fails(ci.Severity) {
+			logger.WithField("image", ci.Container.FullPath).WithField("severity", ci.Severity).Error("Image has vulnerabilities above the fail threshold")
+		} else if result.Thresholds.Warns(ci.Severity) {
+			logger.WithField("image", ci.Container.FullPath).WithField("severity", ci.Severity).Warn("Image has vulnerabilities above the warn threshold")
+		}
+	}
 
-	if config.IsKubernetesFetchEnabled() {
-		charts := getLatestVersionsForHelmCharts(config.HelmRegistries, config.Namespaces, config.RunningLocally())
+	if config.IsMultiClusterEnabled() {
+		phaseCtx, endPhase = startPhase(ctx, config, summary, SectionCharts)
+		getChartsFromClusters(phaseCtx, config, clusters, progress)
+		endPhase()
+		var other []ContainerInfo
+		result.Clusters, other = clusterResults(clusters, info)
+		for _, cluster := range result.Clusters {
+			result.ChartInfo = append(result.ChartInfo, cluster.ChartInfo...)
+			result.NodeInfo = append(result.NodeInfo, cluster.NodeInfo...)
+		}
+		if config.IsAddonCheckEnabled() {
+			for index := range result.Clusters {
+				result.Clusters[index].AddonInfo = checkAddons(config.GetAddons(), clusters[index].kubernetesVersion, result.Clusters[index].ContainerInfo)
+				result.AddonInfo = append(result.AddonInfo, result.Clusters[index].AddonInfo...)
+			}
+		}
+		if config.IsClusterDriftEnabled() {
+			result.DriftClusters = config.GetDriftClusters()
+			var failed []string
+			result.Drift, failed = compareClusters(result.DriftClusters, result.Clusters)
+			for _, name := range failed {
+				problems.add(SectionKubernetes, name+"/drift", fmt.Errorf("Cluster [%s] is left out of the drift comparison because its images could not be collected", name))
+			}
+		}
+		sortChartInfo(result.ChartInfo)
+		addClusterProblems(problems, result.Clusters)
+		if config.PrettyPrintAllowed() && config.IsCombinedClusterReport() {
+			prettyPrintCombinedClusterResults(result.Clusters, other)
+			prettyPrintChartInfo(result.ChartInfo)
+			prettyPrintAddonInfo(result.AddonInfo)
+			prettyPrintNodeInfo(result.NodeInfo)
+		} else if config.PrettyPrintAllowed() {
+			prettyPrintClusterResults(result.Clusters, other)
+		}
+		if config.PrettyPrintAllowed() {
+			prettyPrintDrift(result.DriftClusters, result.Drift)
+		}
+	} else if config.IsKubernetesFetchEnabled() && current.fetchesKubernetes() {
+		phaseCtx, endPhase = startPhase(ctx, config, summary, SectionCharts)
+		charts := getLatestVersionsForHelmCharts(phaseCtx, config.HelmRegistries, current.namespaces, config.RunningLocally(), config.Workers.Charts, problems, progress)
 		if config.PrettyPrintAllowed() {
 			prettyPrintChartInfo(charts)
+			prettyPrintControlPlaneInfo(result.ControlPlane)
+			prettyPrintNodeInfo(result.NodeInfo)
 		}
-		WebDataVar.ChartInfo = charts
+		result.ChartInfo = charts
+		endPhase()
 	}
 
-	tools := getLatestVersionsForTools(config.Tools, config.ToolRegistries)
+	var tools []ToolInfo
+	if current.ownsShared() {
+		phaseCtx, endPhase = startPhase(ctx, config, summary, SectionTools)
+		tools = getLatestVersionsForTools(phaseCtx, config.Tools, config.ToolRegistries, config.Workers.Tools, problems, progress)
+		endPhase()
+	}
 	if config.PrettyPrintAllowed() {
 		prettyPrintToolInfo(tools)
+		prettyPrintPolicyViolations(result.PolicyViolations)
+		prettyPrintImageUsage(result.ContainerInfo)
+		prettyPrintStaticPods(result.ContainerInfo)
+		prettyPrintOutdatedWorkloads(result.ContainerInfo)
+		prettyPrintOutdatedBuilds(result.ContainerInfo)
+		prettyPrintScannerPolicies(result.ContainerInfo)
+		prettyPrintBaseImageUpgrades(result.ContainerInfo)
+		prettyPrintExploited(result.ContainerInfo)
+		prettyPrintAboveEpss(result.ContainerInfo)
+		prettyPrintScanProblems(problems.sorted())
+		prettyPrintSkipped(problems.sortedSkipped())
 	}
-	WebDataVar.ToolInfo = tools
+	result.ToolInfo = tools
+	result.Problems = problems.sorted()
+	result.Skipped = problems.sortedSkipped()
+	summary.finish(result, start)
+	result.Summary = *summary
+	setOutdatedImages(config, result.ContainerInfo)
+	setVersionsBehind(config, result.ContainerInfo)
+	setImagesCreated(config, result.ContainerInfo)
+	if config.PrettyPrintAllowed() {
+		prettyPrintSummary(result.Summary)
+	}
+
+	phaseCtx, endPhase = startPhase(ctx, config, summary, phaseReporters)
+	for _, reporter := range config.Plugins.Reporters {
+		problems.add(SectionPlugins, reporter.Name, reporter.Report(phaseCtx, result))
+	}
+	if config.DependencyTrack.Enabled {
+		problems.add(SectionExports, "dependency-track", exportToDependencyTrack(phaseCtx, config, result))
+	}
+	if config.DefectDojo.Enabled {
+		problems.add(SectionExports, "defectdojo", exportToDefectDojo(phaseCtx, config.DefectDojo, result))
+	}
+	endPhase()
+	result.Problems = problems.sorted()
+	result.Summary.Problems = len(result.Problems)
+	span.SetAttribute("lcm.problems", strconv.Itoa(result.Summary.Problems))
+	span.SetError(ctx.Err())
+
+	webResult := result
+	if current.enabled && current.index >= 0 {
+		// every shard shows the results of all shards, the result of the scan is only the shard itself
+		webResult = mergeShards(ctx, config, current, result)
+	}
+	if ctx.Err() == nil {
+		scanSucceeded(time.Now())
+	}
+	webDataLock.Lock()
+	WebDataVar.ScanResult = webResult
 	WebDataVar.Status = "Done"
 	WebDataVar.LastTimeFetched = time.Now().Format("15:04:05 02-01-2006")
+	webDataLock.Unlock()
+	return result
 }
 
-func getExtraImages(images []string, containers []kubernetes.Container) []kubernetes.Container {
+// startPhase starts timing the phase and returns the context for the phase with the deadline of the phase when it is configured,
+// the returned func ends the phase
+func startPhase(ctx context.Context, config config.Config, summary *Summary, phase string) (context.Context, func()) {
+	summary.startPhase(phase)
+	ctx, span := tracing.Start(ctx, phase)
+	span.SetAttribute("lcm.phase", phase)
+	var cancel context.CancelFunc
+	if deadline := config.Timeouts.Phases.Get(phase); deadline > 0 {
+		ctx, cancel = context.WithTimeout(ctx, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	return ctx, func() {
+		span.SetError(ctx.Err())
+		cancel()
+		summary.endPhase(phase)
+		span.End()
+	}
+}
+
+func getExtraImages(images []string, containers []kubernetes.Container, problems *scanProblems) []kubernetes.Container {
 	for _, image := range images {
 		container, err := kubernetes.ImageStringToContainerStruct(image)
-		if err == nil {
-			containers = append(containers, container)
+		if err != nil {
+			problems.add(SectionImages, image, err)
+			continue
 		}
+		containers = append(containers, container)
 	}
 	return containers
 }
 
-func getLatestVersionsForContainers(containers []kubernetes.Container, registries registries.ImageRegistries) []ContainerInfo {
-	containerInfo := []ContainerInfo{}
-	for _, container := range containers {
-		version := registries.GetLatestVersionForImage(container.Name, container.URL)
-		containerInfo = append(containerInfo, ContainerInfo{
-			Container:     container,
-			LatestVersion: version,
-		})
+func getImagesFromCollectors(ctx context.Context, collectors []plugins.Plugin, containers []kubernetes.Container, problems *scanProblems) []kubernetes.Container {
+	for _, collector := range collectors {
+		images, err := collector.Collect(ctx)
+		if err != nil {
+			problems.add(SectionPlugins, collector.Name, err)
+			continue
+		}
+		containers = getExtraImages(images, containers, problems)
 	}
+	return containers
+}
+
+// getPullSecretCredentials reads the imagePullSecrets of the containers from the cluster, so the tags of private registries
+// can be listed without adding their credentials to the config. The credentials are keyed by the repository as url/name,
+// every image only uses the imagePullSecrets of its own pods and namespaces
+func getPullSecretCredentials(ctx context.Context, containers []kubernetes.Container, config config.Config, problems *scanProblems) map[string]map[string]registries.Credential {
+	credentials := map[string]map[string]registries.Credential{}
+	if !config.ImageRegistries.PullSecrets {
+		return credentials
+	}
+	configs, err := kubernetes.GetPullSecrets(ctx, containers, config.RunningLocally())
+	problems.add(SectionKubernetes, "pull secrets", err)
+	for repository, dockerConfigs := range configs {
+		for _, dockerConfig := range dockerConfigs {
+			parsed, err := registries.DockerConfigCredentials(dockerConfig)
+			problems.add(SectionKubernetes, "pull secrets", err)
+			addCredentials(credentials, map[string]map[string]registries.Credential{repository: parsed})
+		}
+	}
+	return credentials
+}
 
-	sort.Slice(containerInfo, func(i, j int) bool {
-		return containerInfo[i].Container.Name < containerInfo[j].Container.Name
+// scanRegistries returns the image registries of the config with the credentials of the imagePullSecrets and,
+// when running locally, the credentials of the docker config
+func scanRegistries(config config.Config, pullSecrets map[string]map[string]registries.Credential, problems *scanProblems) registries.ImageRegistries {
+	imageRegistries := config.ImageRegistries.WithRepositoryCredentials(pullSecrets)
+	if config.RunningLocally() {
+		// the docker config of the developer is only used for the repositories without imagePullSecrets, which are what the cluster itself uses
+		var err error
+		imageRegistries, err = imageRegistries.WithDockerConfig(registries.DockerConfigPath())
+		problems.add(SectionImages, "docker config", err)
+	}
+	return imageRegistries
+}
+
+// addCredentials adds the credentials of the hosts of the repositories that don't have credentials yet
+func addCredentials(credentials, other map[string]map[string]registries.Credential) {
+	for repository, hosts := range other {
+		if credentials[repository] == nil {
+			credentials[repository] = map[string]registries.Credential{}
+		}
+		for host, credential := range hosts {
+			if _, exists := credentials[repository][host]; !exists {
+				credentials[repository][host] = credential
+			}
+		}
+	}
+}
+
+func getLatestVersionsForContainers(ctx context.Context, containers []kubernetes.Container, registries registries.ImageRegistries, workers int, problems *scanProblems, progress ProgressFunc) []ContainerInfo {
+	// the latest version only depends on the flavor of the version that is running, like alpine, so every image is only looked up once per flavor
+	groups := groupBy(len(containers), func(index int) string {
+		_, variant := versioning.SplitVariant(containers[index].Version)
+		return containers[index].URL + "/" + containers[index].Name + ":" + variant
 	})
+	containerInfo := make([]ContainerInfo, len(containers))
+	runParallel(SectionImages, len(groups), workers, progress, func(group int) {
+		container := containers[groups[group][0]]
+		resolved := map[string]string{}
+		running := make([]kubernetes.Container, len(groups[group]))
+		tags := make([]string, len(groups[group]))
+		for i, index := range groups[group] {
+			c := containers[index]
+			if c.Digest != "" && c.Version == "0" {
+				if _, exists := resolved[c.Digest]; !exists {
+					resolved[c.Digest] = resolveDigest(ctx, registries, c, problems)
+				}
+				c.Version = resolved[c.Digest]
+			}
+			running[i] = c
+			tags[i] = c.Version
+		}
+		start := time.Now()
+		version, behind := "", make([]int, len(tags))
+		err := safely(func() (err error) {
+			version, behind, err = registries.GetLatestVersionForTags(audit.WithPurpose(ctx, "latest version of image "+container.Name), container.Name, container.URL, tags)
+			return err
+		})
+		problems.add(SectionImages, container.Name, err)
+		if err != nil {
+			version = versioning.CheckFailed
+		}
+		logger.WithField("image", container.Name).WithField("duration", time.Since(start)).Debug("Fetched latest version for image")
+		created := map[string]time.Time{}
+		if registries.TagAge {
+			created = getTagsCreated(ctx, registries, container, append(tags, version), problems)
+		}
+		digests := map[string]string{}
+		for i, index := range groups[group] {
+			containerInfo[index] = ContainerInfo{
+				Container:      running[i],
+				LatestVersion:  version,
+				Upgrade:        upgradeType(registries, running[i], version),
+				Lifecycle:      lifecycleStatus(registries, running[i], version),
+				Behind:         behind[i],
+				Created:        created[running[i].Version],
+				LatestCreated:  created[version],
+				OutdatedBuilds: getOutdatedBuilds(ctx, registries, running[i], digests, problems),
+			}
+		}
+	})
+
+	sortContainerInfo(containerInfo)
 	return containerInfo
 }
 
-func getVulnerabilities(containerInfo []ContainerInfo, config config.Config) []ContainerInfo {
-	containerInfoWithVul := []ContainerInfo{}
-	for _, ci := range containerInfo {
-		vulnerabilities := config.ImageScanners.GetVulnerabilities(ci.Container.Name, ci.Container.Version)
-		ci.Cves = vulnerabilities
-		containerInfoWithVul = append(containerInfoWithVul, ci)
+// getTagsCreated returns when the images of the tags were built, every tag is only fetched once and the tags without a version are skipped
+func getTagsCreated(ctx context.Context, registries registries.ImageRegistries, container kubernetes.Container, tags []string, problems *scanProblems) map[string]time.Time {
+	created := map[string]time.Time{}
+	for _, tag := range tags {
+		if _, exists := created[tag]; exists || tag == "0" || tag == versioning.CheckFailed || tag == versioning.Notfound || tag == versioning.Failure {
+			continue
+		}
+		purpose := "creation time of tag " + tag + " of image " + container.Name
+		var err error
+		created[tag], err = registries.GetTagCreated(audit.WithPurpose(ctx, purpose), container.Name, container.URL, tag)
+		problems.add(SectionImages, container.Name, err)
+	}
+	return created
+}
+
+// upgradeType returns the upgrade type from the running to the latest version, compared with the versions
+// that the version regex of the image extracts from the tags
+func upgradeType(registries registries.ImageRegistries, container kubernetes.Container, latestVersion string) string {
+	latest := registries.ComparableVersion(container.Name, container.URL, latestVersion)
+	current := registries.ComparableVersion(container.Name, container.URL, container.Version)
+	if latest == "" || current == "" {
+		return ""
+	}
+	return versioning.UpgradeType(latest, current)
+}
+
+// lifecycleStatus returns the status of the running compared to the latest version like the upgradeType, it is unknown
+// when the version regex of the image doesn't extract a version from one of the tags
+func lifecycleStatus(registries registries.ImageRegistries, container kubernetes.Container, latestVersion string) string {
+	latest := registries.ComparableVersion(container.Name, container.URL, latestVersion)
+	current := registries.ComparableVersion(container.Name, container.URL, container.Version)
+	if latest == "" || current == "" {
+		return versioning.Unknown
+	}
+	return versioning.DetermineLifeCycleStatus(latest, current)
+}
+
+// getOutdatedBuilds returns the pods that run an older build of the mutable tag of the image than the tag points to in the registry,
+// the digests of the tags are kept in digests so every tag is only looked up once
+func getOutdatedBuilds(ctx context.Context, registries registries.ImageRegistries, container kubernetes.Container, digests map[string]string, problems *scanProblems) []string {
+	if len(container.RunningDigests) == 0 || !registries.IsMutableTag(container.Tag) {
+		return nil
+	}
+	if _, exists := digests[container.Tag]; !exists {
+		purpose := "digest of tag " + container.Tag + " of image " + container.Name
+		digest, err := registries.GetTagDigest(audit.WithPurpose(ctx, purpose), container.Name, container.URL, container.Tag)
+		problems.add(SectionImages, container.Name, err)
+		digests[container.Tag] = digest
+	}
+	if digests[container.Tag] == "" {
+		return nil
+	}
+	var pods []string
+	for digest, running := range container.RunningDigests {
+		if digest != digests[container.Tag] {
+			pods = append(pods, running...)
+		}
+	}
+	sort.Strings(pods)
+	if len(pods) > 0 {
+		logger.WithField("image", container.FullPath).WithField("pods", pods).Warn("Pods run an older build of the tag")
+	}
+	return pods
+}
+
+// metricClusters returns the clusters the image runs in for the cluster label of the metrics, the clusters of a multi cluster scan
+// or else the clusterName of the config
+func metricClusters(config config.Config, container ContainerInfo) []string {
+	if len(container.Clusters) > 0 {
+		return container.Clusters
+	}
+	return []string{config.ClusterName}
+}
+
+// setOutdatedImages sets the metric of the outdated images per cluster and upgrade type
+func setOutdatedImages(config config.Config, info []ContainerInfo) {
+	upgrades := map[string]map[string]int{config.ClusterName: {}}
+	for _, cluster := range config.Clusters {
+		upgrades[cluster.Name] = map[string]int{}
+	}
+	for _, container := range info {
+		for _, cluster := range metricClusters(config, container) {
+			if upgrades[cluster] == nil {
+				upgrades[cluster] = map[string]int{}
+			}
+			if container.Upgrade != "" {
+				upgrades[cluster][container.Upgrade]++
+			}
+		}
+	}
+	metrics.SetOutdatedImages(upgrades)
+}
+
+// setVersionsBehind sets the metric of the versions behind of every image that is outdated
+func setVersionsBehind(config config.Config, info []ContainerInfo) {
+	behind := map[[3]string]int{}
+	for _, container := range info {
+		if container.Behind == 0 {
+			continue
+		}
+		for _, cluster := range metricClusters(config, container) {
+			behind[[3]string{cluster, container.Container.Name, container.Container.Version}] = container.Behind
+		}
+	}
+	metrics.SetVersionsBehind(behind)
+}
+
+// setImagesCreated sets the metrics of when the images and their latest versions were built
+func setImagesCreated(config config.Config, info []ContainerInfo) {
+	images := []metrics.ImageCreated{}
+	for _, container := range info {
+		for _, cluster := range metricClusters(config, container) {
+			images = append(images, metrics.ImageCreated{
+				Cluster:       cluster,
+				Image:         container.Container.Name,
+				Version:       container.Container.Version,
+				LatestVersion: container.LatestVersion,
+				Created:       container.Created,
+				LatestCreated: container.LatestCreated,
+			})
+		}
+	}
+	metrics.SetImagesCreated(images)
+}
+
+// resolveDigest returns the highest version of the tags that point to the digest of the image, or 0 when the digest can't be resolved
+func resolveDigest(ctx context.Context, registries registries.ImageRegistries, container kubernetes.Container, problems *scanProblems) string {
+	purpose := "tags of digest " + container.Digest + " of image " + container.Name
+	tags, err := registries.GetTagsForDigest(audit.WithPurpose(ctx, purpose), container.Name, container.URL, container.Digest)
+	problems.add(SectionImages, container.Name, err)
+	if len(tags) == 0 {
+		return container.Version
 	}
+	logger.WithField("image", container.Name).WithField("digest", container.Digest).WithField("tags", tags).Debug("Resolved digest of image")
+	if version := versioning.FindHighestVersionInList(tags, true); version != versioning.Notfound {
+		return version
+	}
+	return tags[0]
+}
 
-	sort.Slice(containerInfoWithVul, func(i, j int) bool {
-		return containerInfoWithVul[i].Container.Name < containerInfoWithVul[j].Container.Name
+func getVulnerabilities(ctx context.Context, containerInfo []ContainerInfo, config config.Config, registries registries.ImageRegistries, problems *scanProblems, progress ProgressFunc) []ContainerInfo {
+	documents, err := scanning.LoadVexDocuments(config.GetImageScanners().Vex.Documents)
+	problems.add(SectionVulnerabilities, "vex", err)
+	exploited, err := config.GetImageScanners().Kev.GetExploited(audit.WithPurpose(ctx, "known exploited vulnerabilities"))
+	problems.add(SectionVulnerabilities, "kev", err)
+	// every reference is only scanned once, the scanners that only get the name and version scan every combination once through the cache
+	groups := groupBy(len(containerInfo), func(index int) string {
+		return containerInfo[index].Container.Reference()
+	})
+	containerInfoWithVul := make([]ContainerInfo, len(containerInfo))
+	runParallel(SectionVulnerabilities, len(groups), config.Workers.Vulnerabilities, progress, func(group int) {
+		ci := containerInfo[groups[group][0]]
+		start := time.Now()
+		purpose := "vulnerabilities of image " + ci.Container.Name + ":" + ci.Container.Version
+		var findings []scanning.Finding
+		err := safely(func() (err error) {
+			findings, err = config.GetImageScanners().GetImageFindings(audit.WithPurpose(ctx, purpose), ci.Container.Reference(), ci.Container.Name, ci.Container.Version)
+			return err
+		})
+		problems.add(SectionVulnerabilities, ci.Container.Name, err)
+		statements := append(append([]scanning.VexStatement{}, documents...), getVexAttestations(ctx, registries, ci.Container, config.GetImageScanners().Vex, problems)...)
+		findings = scanning.ApplyVex(findings, statements, ci.Container.Reference(), imageDigests(ci.Container))
+		vulnerabilities := []string{}
+		severities := map[string]string{}
+		var exploitedVulnerabilities []string
+		for _, finding := range findings {
+			vulnerabilities = append(vulnerabilities, finding.ID)
+			severities[finding.ID] = finding.Severity
+			if exploited[finding.ID] {
+				exploitedVulnerabilities = append(exploitedVulnerabilities, finding.ID)
+			}
+		}
+		scanFailed := err != nil
+		policies, err := config.GetImageScanners().GetPolicyEvaluations(audit.WithPurpose(ctx, "policy evaluation of image "+ci.Container.Name+":"+ci.Container.Version), ci.Container.Reference())
+		problems.add(SectionVulnerabilities, ci.Container.Name, err)
+		baseImages, err := config.GetImageScanners().GetBaseImageAdvice(audit.WithPurpose(ctx, "base image of image "+ci.Container.Name+":"+ci.Container.Version), ci.Container.Reference())
+		problems.add(SectionVulnerabilities, ci.Container.Name, err)
+		logger.WithField("image", ci.Container.Name).WithField("duration", time.Since(start)).Debug("Fetched vulnerabilities for image")
+		for _, index := range groups[group] {
+			ci := containerInfo[index]
+			ci.Cves = vulnerabilities
+			ci.ScanFailed = scanFailed
+			ci.Severity = scanning.HighestSeverity(findings)
+			ci.Severities = severities
+			ci.Exploited = exploitedVulnerabilities
+			ci.Policies = policies
+			ci.BaseImages = baseImages
+			containerInfoWithVul[index] = ci
+		}
 	})
+
+	addEpssScores(ctx, config.GetImageScanners().Epss, containerInfoWithVul, problems)
+	sortContainerInfo(containerInfoWithVul)
 	return containerInfoWithVul
 }
 
-func getLatestVersionsForHelmCharts(helmRegistries registries.HelmRegistries, namespaces []string, local bool) []ChartInfo {
-	var chartInfo []ChartInfo
-	charts := kubernetes.GetHelmChartsFromNamespaces(namespaces, local)
-	for _, chart := range charts {
-		version := helmRegistries.GetLatestVersionFromHelm(chart.Name)
-		chartInfo = append(chartInfo, ChartInfo{
+func getLatestVersionsForHelmCharts(ctx context.Context, helmRegistries registries.HelmRegistries, namespaces []string, local bool, workers int, problems *scanProblems, progress ProgressFunc) []ChartInfo {
+	charts, err := kubernetes.GetHelmChartsFromNamespaces(ctx, namespaces, local)
+	problems.add(SectionKubernetes, "charts", err)
+	chartInfo := make([]ChartInfo, len(charts))
+	runParallel(SectionCharts, len(charts), workers, progress, func(index int) {
+		chart := charts[index]
+		start := time.Now()
+		var version string
+		err := safely(func() (err error) {
+			version, err = helmRegistries.GetLatestVersionFromHelm(audit.WithPurpose(ctx, "latest version of chart "+chart.Name), chart.Name)
+			return err
+		})
+		problems.add(SectionCharts, chart.Name, err)
+		if err != nil {
+			version = versioning.CheckFailed
+		}
+		logger.WithField("chart", chart.Name).WithField("duration", time.Since(start)).Debug("Fetched latest version for chart")
+		chartInfo[index] = ChartInfo{
 			Chart:         chart,
 			LatestVersion: version,
-		})
-	}
-
-	sort.Slice(chartInfo, func(i, j int) bool {
-		return chartInfo[i].Chart.Name < chartInfo[j].Chart.Name
+		}
 	})
+
+	sortChartInfo(chartInfo)
 	return chartInfo
 }
 
-func getLatestVersionsForTools(tools []registries.Tool, registries registries.ToolRegistries) []ToolInfo {
-	var toolInfo []ToolInfo
-	for _, tool := range tools {
-		version := registries.GetLatestVersionForTool(tool)
-		toolInfo = append(toolInfo, ToolInfo{
+func getLatestVersionsForTools(ctx context.Context, tools []registries.Tool, registries registries.ToolRegistries, workers int, problems *scanProblems, progress ProgressFunc) []ToolInfo {
+	toolInfo := make([]ToolInfo, len(tools))
+	runParallel(SectionTools, len(tools), workers, progress, func(index int) {
+		tool := tools[index]
+		start := time.Now()
+		var version string
+		err := safely(func() (err error) {
+			version, err = registries.GetLatestVersionForTool(audit.WithPurpose(ctx, "latest version of tool "+tool.Repo), tool)
+			return err
+		})
+		problems.add(SectionTools, tool.Repo, err)
+		if err != nil {
+			version = versioning.CheckFailed
+		}
+		logger.WithField("tool", tool.Repo).WithField("duration", time.Since(start)).Debug("Fetched latest version for tool")
+		toolInfo[index] = ToolInfo{
 			Tool:          tool,
 			LatestVersion: version,
-		})
-	}
-
-	sort.Slice(toolInfo, func(i, j int) bool {
-		return toolInfo[i].Tool.Repo < toolInfo[j].Tool.Repo
+		}
 	})
+
+	sortToolInfo(toolInfo)
 	return toolInfo
 }
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+// leading is 1 while this replica may scan, without leader election every replica may scan
+var leading int32 = 1
+
+// runLeaderElection takes part in the leader election of the cluster, it is replaced in the tests
+var runLeaderElection = kubernetes.RunLeaderElection
+
+// IsLeader returns true when this replica may scan and send notifications
+func IsLeader() bool {
+	return atomic.LoadInt32(&leading) == 1
+}
+
+// KeepRunningAsLeader takes part in the leader election until the context is done, when the election fails or the leadership is lost
+// the error is shown as the status and logged, and this replica takes part again after the retry period instead of stopping lcm
+func KeepRunningAsLeader(ctx context.Context, config config.Config) {
+	keepRunning(ctx, config.GetLeaderElection().RetryPeriod, func(ctx context.Context) error {
+		return RunAsLeader(ctx, config)
+	})
+}
+
+func keepRunning(ctx context.Context, retry time.Duration, run func(context.Context) error) {
+	for {
+		err := run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("retry", retry).Error("Stopped taking part in the leader election, taking part again")
+			webDataLock.Lock()
+			WebDataVar.Status = "Leader election failed: " + err.Error()
+			webDataLock.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// RunAsLeader takes part in the leader election and only scans and watches for new images while this replica is the leader,
+// so replicas don't send duplicate notifications. When the leadership is lost an error is returned so the replica can take part
+// again from scratch
+func RunAsLeader(ctx context.Context, config config.Config) error {
+	atomic.StoreInt32(&leading, 0)
+	webDataLock.Lock()
+	WebDataVar.Status = "Waiting for leadership"
+	webDataLock.Unlock()
+
+	lost := false
+	err := runLeaderElection(ctx, config.GetLeaderElection(), config.RunningLocally(), func(ctx context.Context) {
+		atomic.StoreInt32(&leading, 1)
+		logger.Info("Became the leader, starting the scan")
+		scanCtx, cancel := context.WithTimeout(ctx, config.Timeouts.GetScanTimeout())
+		Execute(scanCtx, config)
+		cancel()
+		if config.IsWatchWorkloadsEnabled() {
+			WatchForNewImages(ctx, config)
+		}
+	}, func() {
+		atomic.StoreInt32(&leading, 0)
+		lost = ctx.Err() == nil
+	})
+	if err != nil {
+		return fmt.Errorf("Could not take part in the leader election: %w", err)
+	}
+	if lost {
+		return errors.New("Lost the leadership")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestKeepRunningTakesPartAgainAfterLosingTheLeadership(t *testing.T) {
+	defer func() { WebDataVar.Status = "" }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := 0
+	keepRunning(ctx, time.Millisecond, func(ctx context.Context) error {
+		runs++
+		if runs == 3 {
+			cancel()
+			return nil
+		}
+		return errors.New("Lost the leadership")
+	})
+
+	if runs != 3 {
+		t.Errorf("Expected to take part 3 times but took part %d times", runs)
+	}
+	if WebDataVar.Status != "Leader election failed: Lost the leadership" {
+		t.Errorf("Expected the lost leadership as status but got [%s]", WebDataVar.Status)
+	}
+}
+
+func TestRunAsLeaderReportsTheLostLeadership(t *testing.T) {
+	defer func() {
+		runLeaderElection = kubernetes.RunLeaderElection
+		atomic.StoreInt32(&leading, 1)
+		WebDataVar.Status = ""
+	}()
+	tests := map[string]struct {
+		election func(ctx context.Context, cancel context.CancelFunc, onStoppedLeading func()) error
+		err      string
+	}{
+		"lost": {election: func(ctx context.Context, cancel context.CancelFunc, onStoppedLeading func()) error {
+			onStoppedLeading()
+			return nil
+		}, err: "Lost the leadership"},
+		"failed": {election: func(ctx context.Context, cancel context.CancelFunc, onStoppedLeading func()) error {
+			return errors.New("forbidden")
+		}, err: "Could not take part in the leader election: forbidden"},
+		"stopped": {election: func(ctx context.Context, cancel context.CancelFunc, onStoppedLeading func()) error {
+			cancel()
+			onStoppedLeading()
+			return nil
+		}},
+	}
+	for name, test := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		runLeaderElection = func(ctx context.Context, election kubernetes.LeaderElection, useLocally bool, onStartedLeading func(context.Context), onStoppedLeading func()) error {
+			return test.election(ctx, cancel, onStoppedLeading)
+		}
+		err := RunAsLeader(ctx, config.Config{})
+		cancel()
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("Expected the error [%s] when the election %s but got [%v]", test.err, name, err)
+		}
+		if IsLeader() {
+			t.Errorf("Expected not to be the leader when the election %s", name)
+		}
+		if WebDataVar.Status != "Waiting for leadership" {
+			t.Errorf("Expected to wait for the leadership when the election %s but got [%s]", name, WebDataVar.Status)
+		}
+	}
+}
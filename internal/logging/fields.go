@@ -0,0 +1,26 @@
+package logging
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// FieldsFormatter adds the fields to every log entry before formatting it with the wrapped formatter
+// The entry is copied because the data of an entry is shared with the entry it was created from
+type FieldsFormatter struct {
+	Fields    log.Fields
+	Formatter log.Formatter
+}
+
+// Format formats the entry with the fields added, fields already on the entry take precedence
+func (f FieldsFormatter) Format(entry *log.Entry) ([]byte, error) {
+	data := make(log.Fields, len(entry.Data)+len(f.Fields))
+	for key, value := range f.Fields {
+		data[key] = value
+	}
+	for key, value := range entry.Data {
+		data[key] = value
+	}
+	withFields := *entry
+	withFields.Data = data
+	return f.Formatter.Format(&withFields)
+}
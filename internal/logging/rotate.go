@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000000000"
+
+// RotatingFile is a log file that is rotated when it grows bigger than MaxSize bytes or gets older than MaxAge
+type RotatingFile struct {
+	Path       string
+	MaxSize    int64         // Zero means no size based rotation
+	MaxAge     time.Duration // Zero means no age based rotation
+	MaxBackups int           // Zero means all rotated files are kept
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	created time.Time
+}
+
+// Open opens the log file so problems with the path show up before the first write
+func (r *RotatingFile) Open() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		return nil
+	}
+	return r.open()
+}
+
+// Write writes to the log file and rotates it first when needed
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	r.created, err = r.creationTime(info)
+	if err != nil {
+		file.Close()
+		r.file = nil
+		return err
+	}
+	return nil
+}
+
+// createdPath is the file that keeps when the log file was created, the modification time of the log file changes with every write
+// so runs that append to the log file would otherwise never reach MaxAge. It is hidden so it doesn't match the backups
+func (r *RotatingFile) createdPath() string {
+	return filepath.Join(filepath.Dir(r.Path), "."+filepath.Base(r.Path)+".created")
+}
+
+// creationTime returns when the log file was created, a new or empty log file is created now. Log files of older versions
+// without the created file use their modification time once
+func (r *RotatingFile) creationTime(info os.FileInfo) (time.Time, error) {
+	if info.Size() > 0 {
+		if content, err := ioutil.ReadFile(r.createdPath()); err == nil {
+			if created, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(content))); err == nil {
+				return created, nil
+			}
+		}
+	}
+	created := time.Now()
+	if info.Size() > 0 {
+		created = info.ModTime()
+	}
+	return created, ioutil.WriteFile(r.createdPath(), []byte(created.Format(time.RFC3339Nano)), 0644)
+}
+
+func (r *RotatingFile) shouldRotate(writeSize int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.MaxSize > 0 && r.size+writeSize > r.MaxSize {
+		return true
+	}
+	return r.MaxAge > 0 && time.Since(r.created) > r.MaxAge
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	backup := fmt.Sprintf("%s.%s", r.Path, time.Now().Format(backupTimeFormat))
+	if err := os.Rename(r.Path, backup); err != nil {
+		return err
+	}
+	if err := r.removeOldBackups(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *RotatingFile) removeOldBackups() error {
+	if r.MaxBackups <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(r.Path + ".*")
+	if err != nil {
+		return err
+	}
+	// Only the rotated files are removed, other files that start with the name of the log file are kept
+	backups := []string{}
+	for _, match := range matches {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(match, r.Path+".")); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	// The timestamp suffix sorts in chronological order
+	sort.Strings(backups)
+	for len(backups) > r.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileRotatesOnSize(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	file := &RotatingFile{Path: filepath.Join(dir, "lcm.log"), MaxSize: 10, MaxBackups: 2}
+	defer file.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := file.Write([]byte("12345678\n")); err != nil {
+			t.Fatalf("Write failed %v", err)
+		}
+	}
+
+	backups, _ := filepath.Glob(file.Path + ".*")
+	if len(backups) != 2 {
+		t.Errorf("Expected 2 backups but got %v", backups)
+	}
+	content, _ := ioutil.ReadFile(file.Path)
+	if string(content) != "12345678\n" {
+		t.Errorf("Expected only the last write in the log file but got %q", content)
+	}
+}
+
+func TestRotatingFileKeepsOtherFilesWithTheSameName(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	file := &RotatingFile{Path: filepath.Join(dir, "lcm.log"), MaxSize: 10, MaxBackups: 1}
+	defer file.Close()
+	keep := file.Path + ".keep"
+	if err := ioutil.WriteFile(keep, []byte("not a backup\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := file.Write([]byte("12345678\n")); err != nil {
+			t.Fatalf("Write failed %v", err)
+		}
+	}
+
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("Expected %s to survive the rotation but got %v", keep, err)
+	}
+	backups, _ := filepath.Glob(file.Path + ".2*")
+	if len(backups) != 1 {
+		t.Errorf("Expected 1 backup but got %v", backups)
+	}
+}
+
+func TestRotatingFileRotatesAnOldFileThatIsAppendedTo(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lcm.log")
+
+	first := &RotatingFile{Path: path, MaxAge: time.Hour}
+	if _, err := first.Write([]byte("first run\n")); err != nil {
+		t.Fatalf("Write failed %v", err)
+	}
+	first.Close()
+	// the file was created two hours ago but the last run appended to it just now
+	created := time.Now().Add(-2 * time.Hour)
+	if err := ioutil.WriteFile(first.createdPath(), []byte(created.Format(time.RFC3339Nano)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &RotatingFile{Path: path, MaxAge: time.Hour}
+	defer second.Close()
+	if _, err := second.Write([]byte("second run\n")); err != nil {
+		t.Fatalf("Write failed %v", err)
+	}
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 1 {
+		t.Errorf("Expected the old file to be rotated but got %v", backups)
+	}
+	content, _ := ioutil.ReadFile(path)
+	if string(content) != "second run\n" {
+		t.Errorf("Expected only the second run in the log file but got %q", content)
+	}
+}
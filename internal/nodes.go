@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	"github.com/olekukonko/tablewriter"
+)
+
+// maxKubeletSkew is the number of minor versions the kubelet may be older than the API server, since Kubernetes 1.28
+const maxKubeletSkew = 3
+
+// NodeInfo contains the versions of the components of a node and how far its kubelet is behind the API server
+type NodeInfo struct {
+	Node          kubernetes.Node
+	ServerVersion string
+	// MinorsBehind is the number of minor versions the kubelet is older than the API server, negative when it is newer
+	MinorsBehind int
+}
+
+// getNodeInfo fetches the nodes of the cluster and compares the versions of their kubelets with the version of the API server
+func getNodeInfo(ctx context.Context, useLocally bool, problems *scanProblems) []NodeInfo {
+	nodes, serverVersion, err := kubernetes.GetNodes(ctx, useLocally)
+	problems.add(SectionKubernetes, "nodes", err)
+	info := []NodeInfo{}
+	for _, node := range nodes {
+		info = append(info, NodeInfo{
+			Node:          node,
+			ServerVersion: serverVersion,
+			MinorsBehind:  minorsBehind(serverVersion, node.KubeletVersion),
+		})
+	}
+	return info
+}
+
+// minorsBehind returns how many minor versions current is older than latest, 0 when one of them can't be parsed
+func minorsBehind(latest, current string) int {
+	latestMajor, latestMinor, ok := majorMinor(latest)
+	currentMajor, currentMinor, currentOk := majorMinor(current)
+	if !ok || !currentOk || latestMajor != currentMajor {
+		return 0
+	}
+	return latestMinor - currentMinor
+}
+
+// majorMinor returns the major and minor version of a Kubernetes version like v1.29.3-eks-5e0fdde
+func majorMinor(v string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// UnsupportedSkew returns true when the kubelet is newer than the API server or older than the version skew policy allows
+func (n NodeInfo) UnsupportedSkew() bool {
+	return n.MinorsBehind < 0 || n.MinorsBehind > maxKubeletSkew
+}
+
+// Outdated returns true when the kubelet runs an older version than the API server
+func (n NodeInfo) Outdated() bool {
+	if n.MinorsBehind != 0 {
+		return n.MinorsBehind > 0
+	}
+	return versioning.UpgradeType(n.ServerVersion, n.Node.KubeletVersion) != ""
+}
+
+// GetSkew returns how far the kubelet is behind the API server
+func (n NodeInfo) GetSkew() string {
+	switch {
+	case n.MinorsBehind < 0:
+		return "newer than the API server"
+	case n.MinorsBehind > maxKubeletSkew:
+		return fmt.Sprintf("%d minor versions behind, unsupported", n.MinorsBehind)
+	case n.MinorsBehind > 0:
+		return fmt.Sprintf("%d minor versions behind", n.MinorsBehind)
+	case n.Outdated():
+		return "patch behind"
+	}
+	return ""
+}
+
+// GetStatus returns the status of the node, a failure when the version skew is not supported
+func (n NodeInfo) GetStatus() string {
+	if n.UnsupportedSkew() {
+		return versioning.Failure
+	}
+	return versioning.DetermineLifeCycleStatus(n.ServerVersion, n.Node.KubeletVersion)
+}
+
+// countNodes returns the number of nodes with an older kubelet than the API server and the number of nodes with an unsupported skew
+func countNodes(nodes []NodeInfo) (int, int) {
+	outdated, unsupported := 0, 0
+	for _, node := range nodes {
+		if node.Outdated() {
+			outdated++
+		}
+		if node.UnsupportedSkew() {
+			unsupported++
+		}
+	}
+	return outdated, unsupported
+}
+
+func prettyPrintNodeInfo(nodes []NodeInfo) {
+	if len(nodes) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Node", "Kubelet", "API server", "Skew", "Runtime", "Kernel", "OS image"})
+	table.SetColumnAlignment([]int{3, 1, 1, 3, 3, 3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, node := range nodes {
+		table.Append([]string{
+			node.Node.Name,
+			node.Node.KubeletVersion,
+			node.ServerVersion,
+			node.GetSkew(),
+			node.Node.ContainerRuntimeVersion,
+			node.Node.KernelVersion,
+			node.Node.OSImage,
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestNodeSkew(t *testing.T) {
+	for _, test := range []struct {
+		kubelet     string
+		outdated    bool
+		unsupported bool
+		status      string
+	}{
+		{"v1.29.3", false, false, versioning.Same},
+		{"v1.29.1-eks-5e0fdde", true, false, versioning.Patch},
+		{"v1.27.8", true, false, versioning.Minor},
+		{"v1.25.16", true, true, versioning.Failure},
+		{"v1.30.0", false, true, versioning.Failure},
+	} {
+		node := NodeInfo{Node: kubernetes.Node{KubeletVersion: test.kubelet}, ServerVersion: "v1.29.3"}
+		node.MinorsBehind = minorsBehind(node.ServerVersion, test.kubelet)
+		if node.Outdated() != test.outdated || node.UnsupportedSkew() != test.unsupported || node.GetStatus() != test.status {
+			t.Errorf("Expected outdated %t, unsupported %t and %s for %s but got %t, %t and %s (%s)", test.outdated, test.unsupported, test.status,
+				test.kubelet, node.Outdated(), node.UnsupportedSkew(), node.GetStatus(), node.GetSkew())
+		}
+	}
+}
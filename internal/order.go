@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+// The results are sorted on all their fields so every output format is the same for the same results,
+// diffs between archived reports then only show real changes
+
+// sortContainerInfo sorts by the namespaces the image runs in, then the image and its version
+func sortContainerInfo(info []ContainerInfo) {
+	sort.Slice(info, func(i, j int) bool {
+		a, b := info[i].Container, info[j].Container
+		if namespacesA, namespacesB := strings.Join(a.Namespaces, ","), strings.Join(b.Namespaces, ","); namespacesA != namespacesB {
+			return namespacesA < namespacesB
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.URL < b.URL
+	})
+}
+
+func sortChartInfo(info []ChartInfo) {
+	sort.Slice(info, func(i, j int) bool {
+		a, b := info[i].Chart, info[j].Chart
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+}
+
+func sortToolInfo(info []ToolInfo) {
+	sort.Slice(info, func(i, j int) bool {
+		a, b := info[i].Tool, info[j].Tool
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		return a.Version < b.Version
+	})
+}
+
+// sortProblems sorts by section, item and error, the problems are found in parallel so their order is random
+func sortProblems(problems []ScanProblem) {
+	sort.Slice(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.Section != b.Section {
+			return a.Section < b.Section
+		}
+		if a.Item != b.Item {
+			return a.Item < b.Item
+		}
+		return a.Error < b.Error
+	})
+}
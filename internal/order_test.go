@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestSortContainerInfoByNamespaceImageAndVersion(t *testing.T) {
+	info := []ContainerInfo{
+		{Container: kubernetes.Container{Name: "nginx", Version: "1.1", Namespaces: []string{"web"}}},
+		{Container: kubernetes.Container{Name: "redis", Version: "5", Namespaces: []string{"api"}}},
+		{Container: kubernetes.Container{Name: "nginx", Version: "1.0", Namespaces: []string{"web"}}},
+		{Container: kubernetes.Container{Name: "busybox", Version: "1", Namespaces: []string{"web"}}},
+	}
+	sortContainerInfo(info)
+	var order []string
+	for _, ci := range info {
+		order = append(order, ci.Container.Name+":"+ci.Container.Version)
+	}
+	if strings.Join(order, " ") != "redis:5 busybox:1 nginx:1.0 nginx:1.1" {
+		t.Errorf("Unexpected order %v", order)
+	}
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"os"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/olekukonko/tablewriter"
+)
+
+// PolicyViolation is an image of a registry that is not allowed by the registry policy
+type PolicyViolation struct {
+	Image      string
+	Registry   string
+	Namespaces []string
+	Reason     string
+}
+
+// checkRegistryPolicy returns the images that are not allowed by the policy, every image is only reported once
+func checkRegistryPolicy(policy registries.RegistryPolicy, info []ContainerInfo) []PolicyViolation {
+	violations := []PolicyViolation{}
+	if !policy.Enabled() {
+		return violations
+	}
+	seen := map[string]bool{}
+	for _, container := range info {
+		c := container.Container
+		reason := policy.Violation(c.Name, c.URL)
+		if reason == "" || seen[c.FullPath] {
+			continue
+		}
+		seen[c.FullPath] = true
+		violations = append(violations, PolicyViolation{
+			Image:      c.FullPath,
+			Registry:   c.URL,
+			Namespaces: c.Namespaces,
+			Reason:     reason,
+		})
+	}
+	return violations
+}
+
+func prettyPrintPolicyViolations(violations []PolicyViolation) {
+	if len(violations) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Namespaces", "Policy violation"})
+	table.SetColumnAlignment([]int{3, 3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, violation := range violations {
+		table.Append([]string{
+			violation.Image,
+			strings.Join(violation.Namespaces, " "),
+			violation.Reason,
+		})
+	}
+	table.Render()
+}
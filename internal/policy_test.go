@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+)
+
+func TestPolicyViolationsFailTheScan(t *testing.T) {
+	allowed := kubernetes.Container{FullPath: "registry.corp.local/app:1.0", URL: "registry.corp.local", Name: "app", Version: "1.0"}
+	other := kubernetes.Container{FullPath: "nginx:1.0", URL: "docker.io", Name: "library/nginx", Version: "1.0", Namespaces: []string{"web"}}
+	info := []ContainerInfo{
+		{Container: allowed, LatestVersion: "1.0"},
+		{Container: other, LatestVersion: "1.0"},
+	}
+
+	result := ScanResult{PolicyViolations: checkRegistryPolicy(registries.RegistryPolicy{Allowed: []string{"registry.corp.local"}}, info)}
+	if len(result.PolicyViolations) != 1 || result.PolicyViolations[0].Image != "nginx:1.0" || result.PolicyViolations[0].Namespaces[0] != "web" {
+		t.Fatalf("Expected nginx as the only violation but got %v", result.PolicyViolations)
+	}
+	if code := result.ExitCode([]string{FailOnPolicyViolations}); code != ExitCodePolicyViolations {
+		t.Errorf("Expected exit code %d but got %d", ExitCodePolicyViolations, code)
+	}
+	if code := result.ExitCode([]string{FailOnScanErrors}); code != 0 {
+		t.Errorf("Expected violations not to fail the scan without the fail on condition but got %d", code)
+	}
+}
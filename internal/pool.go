@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"sync"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/metrics"
+)
+
+// runParallel calls work for every index from 0 to total with at most the given number of workers at the same time,
+// the progress of the phase is reported after every finished item
+func runParallel(phase string, total, workers int, progress ProgressFunc, work func(index int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > total {
+		workers = total
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var progressLock sync.Mutex
+	done := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				workerDone := metrics.WorkerStarted(phase)
+				work(index)
+				workerDone()
+				progressLock.Lock()
+				done++
+				progress(phase, done, total)
+				progressLock.Unlock()
+			}
+		}()
+	}
+
+	for index := 0; index < total; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -1,26 +1,52 @@
 package internal
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/arminc/k8s-platform-lcm/internal/versioning"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
 	"github.com/olekukonko/tablewriter"
 )
 
+func prettyPrintCluster(cluster Cluster) {
+	if cluster.Name == "" {
+		return
+	}
+	fmt.Printf("Cluster: %s %s\n", cluster.Name, cluster.FormatLabels())
+}
+
 func prettyPrintContainerInfo(info []ContainerInfo) {
+	// the age is only shown when the tag age is fetched
+	withAge := false
+	for _, container := range info {
+		withAge = withAge || container.GetAge() != ""
+	}
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Image", "Version", "Latest", "Cves"})
-	table.SetColumnAlignment([]int{3, 1, 1, 3})
+	if withAge {
+		table.SetHeader([]string{"Image", "Version", "Latest", "Upgrade", "Behind", "Age", "Cves", "Pods"})
+		table.SetColumnAlignment([]int{3, 1, 1, 1, 1, 3, 3, 1})
+	} else {
+		table.SetHeader([]string{"Image", "Version", "Latest", "Upgrade", "Behind", "Cves", "Pods"})
+		table.SetColumnAlignment([]int{3, 1, 1, 1, 1, 3, 1})
+	}
 
 	for _, container := range info {
 		row := []string{
 			container.Container.Name,
 			container.Container.Version,
 			container.LatestVersion,
-			container.GetCveStatus(),
+			container.Upgrade,
+			strconv.Itoa(container.Behind),
 		}
-		table.Append(row)
+		if withAge {
+			row = append(row, container.GetAge())
+		}
+		table.Append(append(row, container.GetCveStatus(), strconv.Itoa(container.RunningPods())))
 	}
 	table.Render()
 }
@@ -57,8 +83,287 @@ func prettyPrintChartInfo(charts []ChartInfo) {
 	table.Render()
 }
 
+func prettyPrintOutdatedBuilds(info []ContainerInfo) {
+	if countOutdatedBuilds(info) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Pods with an older build"})
+	table.SetColumnAlignment([]int{3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, container := range info {
+		if len(container.OutdatedBuilds) > 0 {
+			table.Append([]string{container.Container.FullPath, strings.Join(container.OutdatedBuilds, " ")})
+		}
+	}
+	table.Render()
+}
+
+func prettyPrintScannerPolicies(info []ContainerInfo) {
+	if countPolicyFailures(info) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Scanner", "Status", "Reasons"})
+	table.SetColumnAlignment([]int{3, 3, 3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, container := range info {
+		for _, policy := range container.Policies {
+			if policy.Failed() {
+				table.Append([]string{container.Container.FullPath, policy.Scanner, policy.Status, strings.Join(policy.Reasons, "\n")})
+			}
+		}
+	}
+	table.Render()
+}
+
+func prettyPrintBaseImageUpgrades(info []ContainerInfo) {
+	if countBaseImageUpgrades(info) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Scanner", "Base image", "Recommended upgrades"})
+	table.SetColumnAlignment([]int{3, 3, 3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, container := range info {
+		for _, advice := range container.BaseImages {
+			table.Append([]string{container.Container.FullPath, advice.Scanner, advice.BaseImage, strings.Join(advice.Upgrades, " ")})
+		}
+	}
+	table.Render()
+}
+
+func prettyPrintExploited(info []ContainerInfo) {
+	if countExploited(info) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Actively exploited vulnerabilities"})
+	table.SetColumnAlignment([]int{3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, container := range info {
+		if len(container.Exploited) > 0 {
+			table.Append([]string{container.Container.FullPath, strings.Join(container.Exploited, " ")})
+		}
+	}
+	table.Render()
+}
+
+func prettyPrintAboveEpss(info []ContainerInfo) {
+	if countAboveEpss(info) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Likely exploited vulnerabilities (EPSS)"})
+	table.SetColumnAlignment([]int{3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, container := range info {
+		if len(container.AboveEpss) > 0 {
+			table.Append([]string{container.Container.FullPath, strings.Join(container.EpssScores(container.AboveEpss), " ")})
+		}
+	}
+	table.Render()
+}
+
+// EpssScores returns the CVEs with their EPSS score like CVE-2023-4911 (0.870), a CVE without a score is returned as is
+func (c ContainerInfo) EpssScores(cves []string) []string {
+	var scored []string
+	for _, cve := range cves {
+		if score, exists := c.Epss[cve]; exists {
+			scored = append(scored, fmt.Sprintf("%s (%.3f)", cve, score))
+		} else {
+			scored = append(scored, cve)
+		}
+	}
+	return scored
+}
+
+// PolicyFailed returns true when the image doesn't pass the policy of one of the scanners
+func (c ContainerInfo) PolicyFailed() bool {
+	for _, policy := range c.Policies {
+		if policy.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// RunningPods returns the number of running pods that use the image in all workloads
+func (c ContainerInfo) RunningPods() int {
+	pods := 0
+	for _, replicas := range c.Container.Replicas {
+		pods += replicas
+	}
+	return pods
+}
+
+// WorkloadUsage returns the workloads that use the image with their running pods like web/Deployment/api (3 pods),
+// a workload without running pods like a deployment that is scaled to zero has 0 pods
+func (c ContainerInfo) WorkloadUsage() []string {
+	var usage []string
+	for _, workload := range c.Container.Workloads {
+		usage = append(usage, fmt.Sprintf("%s (%d pods)", workload, c.Container.Replicas[workload]))
+	}
+	return usage
+}
+
+// prettyPrintImageUsage prints where every image runs, the namespaces and the workloads with their running pods,
+// so the impact of updating an outdated or vulnerable image is clear
+func prettyPrintImageUsage(info []ContainerInfo) {
+	if len(info) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Version", "Namespaces", "Workloads", "Pods"})
+	table.SetColumnAlignment([]int{3, 1, 3, 3, 1})
+	table.SetAutoWrapText(false)
+	for _, container := range info {
+		table.Append([]string{
+			container.Container.Name,
+			container.Container.Version,
+			strings.Join(container.Container.Namespaces, "\n"),
+			strings.Join(container.WorkloadUsage(), "\n"),
+			strconv.Itoa(container.RunningPods()),
+		})
+	}
+	table.Render()
+}
+
+// StaticPods returns the static pods that run the image, like the control plane components of kubeadm
+func (c ContainerInfo) StaticPods() []string {
+	var staticPods []string
+	for _, workload := range c.Container.Workloads {
+		if strings.Contains(workload, "/"+kubernetes.StaticPodKind+"/") {
+			staticPods = append(staticPods, workload)
+		}
+	}
+	return staticPods
+}
+
+// HasStaticPods returns true when images run in static pods
+func (r ScanResult) HasStaticPods() bool {
+	for _, container := range r.ContainerInfo {
+		if len(container.StaticPods()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// prettyPrintStaticPods prints the images of the static pods separately, they are the control plane components that have to be
+// upgraded together with the cluster
+func prettyPrintStaticPods(info []ContainerInfo) {
+	var rows [][]string
+	for _, container := range info {
+		for _, staticPod := range container.StaticPods() {
+			rows = append(rows, []string{staticPod, container.Container.Name, container.Container.Version, container.LatestVersion, container.Upgrade})
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][0] < rows[j][0]
+	})
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Static pod", "Image", "Version", "Latest", "Upgrade"})
+	table.SetColumnAlignment([]int{3, 3, 1, 1, 1})
+	table.SetAutoWrapText(false)
+	table.AppendBulk(rows)
+	table.Render()
+}
+
+// prettyPrintOutdatedWorkloads prints the workloads that run an image with a newer version, so it is clear which workloads to update
+func prettyPrintOutdatedWorkloads(info []ContainerInfo) {
+	var rows [][]string
+	for _, container := range info {
+		if container.Upgrade == "" {
+			continue
+		}
+		for _, workload := range container.Container.Workloads {
+			rows = append(rows, []string{
+				workload,
+				container.Container.Name,
+				container.Container.Version,
+				container.LatestVersion,
+				container.Upgrade,
+				strconv.Itoa(container.Container.Replicas[workload]),
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][0] < rows[j][0]
+	})
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Workload", "Image", "Version", "Latest", "Upgrade", "Pods"})
+	table.SetColumnAlignment([]int{3, 3, 1, 1, 1, 1})
+	table.SetAutoWrapText(false)
+	table.AppendBulk(rows)
+	table.Render()
+}
+
+func prettyPrintScanProblems(problems []ScanProblem) {
+	if len(problems) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Section", "Item", "Code", "Scan problem"})
+	table.SetColumnAlignment([]int{3, 3, 3, 3})
+	table.SetAutoWrapText(false)
+
+	for _, problem := range problems {
+		row := []string{
+			problem.Section,
+			problem.Item,
+			string(problem.Code),
+			problem.Error,
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
+// prettyPrintSkipped prints the namespaces and resources that were skipped because the service account may not read them
+func prettyPrintSkipped(skipped []string) {
+	if len(skipped) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Skipped, partial results"})
+	table.SetColumnAlignment([]int{3})
+	table.SetAutoWrapText(false)
+	for _, item := range skipped {
+		table.Append([]string{item})
+	}
+	table.Render()
+}
+
+// FormatLabels returns the labels sorted by key in the form of key=value
+func (c Cluster) FormatLabels() string {
+	var labels []string
+	for key, value := range c.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, ",")
+}
+
 func (c ContainerInfo) GetCveStatus() string {
+	if c.ScanFailed && len(c.Cves) == 0 {
+		return versioning.CheckFailed
+	}
 	cve := strconv.Itoa(len(c.Cves))
+	if c.ScanFailed {
+		cve += " (incomplete)"
+	}
 
 	if len(c.Cves) == 1 {
 		switch c.Cves[0] {
@@ -66,32 +371,72 @@ func (c ContainerInfo) GetCveStatus() string {
 			cve = versioning.Failure
 		case versioning.Nodata:
 			cve = versioning.Nodata
+		case versioning.CheckFailed:
+			cve = versioning.CheckFailed
 		}
 	}
+	if len(c.Exploited) > 0 {
+		cve += fmt.Sprintf(" (%d actively exploited)", len(c.Exploited))
+	}
 	return cve
 }
 
+// GetAge returns how long ago the running and the latest version were built, like built 412 days ago, newest built 6 days ago,
+// it is empty when the tag age is not fetched
+func (c ContainerInfo) GetAge() string {
+	if c.Created.IsZero() && c.LatestCreated.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("built %s, newest built %s", daysAgo(c.Created), daysAgo(c.LatestCreated))
+}
+
+func daysAgo(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d days ago", int(time.Since(t).Hours()/24))
+}
+
 func (c ContainerInfo) GetStatus() string {
-	if c.LatestVersion == versioning.Notfound {
+	if c.CheckFailed() {
+		return versioning.CheckFailed
+	} else if c.LatestVersion == versioning.Notfound {
 		return c.LatestVersion
 	} else if c.GetCveStatus() == versioning.Failure || c.GetCveStatus() == versioning.Nodata {
 		return c.GetCveStatus()
-	} else if len(c.Cves) >= 1 {
+	} else if len(c.Cves) >= 1 || c.PolicyFailed() {
 		return versioning.Failure
+	} else if c.Lifecycle != "" {
+		return c.Lifecycle
 	}
 	return versioning.DetermineLifeCycleStatus(c.LatestVersion, c.Container.Version)
 }
 
 func (c ChartInfo) GetStatus() string {
-	if c.LatestVersion == versioning.Failure {
+	if c.LatestVersion == versioning.Failure || c.CheckFailed() {
 		return c.LatestVersion
 	}
 	return versioning.DetermineLifeCycleStatus(c.LatestVersion, c.Chart.Version)
 }
 
 func (t ToolInfo) GetStatus() string {
-	if t.LatestVersion == versioning.Notfound || t.LatestVersion == versioning.Failure {
+	if t.LatestVersion == versioning.Notfound || t.LatestVersion == versioning.Failure || t.CheckFailed() {
 		return t.LatestVersion
 	}
 	return versioning.DetermineLifeCycleStatus(t.LatestVersion, t.Tool.Version)
 }
+
+// CheckFailed returns true when the latest version or the vulnerabilities of the image could not be checked
+func (c ContainerInfo) CheckFailed() bool {
+	return c.LatestVersion == versioning.CheckFailed || c.GetCveStatus() == versioning.CheckFailed || c.ScanFailed
+}
+
+// CheckFailed returns true when the latest version of the chart could not be checked
+func (c ChartInfo) CheckFailed() bool {
+	return c.LatestVersion == versioning.CheckFailed
+}
+
+// CheckFailed returns true when the latest version of the tool could not be checked
+func (t ToolInfo) CheckFailed() bool {
+	return t.LatestVersion == versioning.CheckFailed
+}
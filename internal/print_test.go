@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestFailedScannerMarksTheImageAsCheckFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var conf config.Config
+	conf.Workers.Vulnerabilities = 1
+	conf.ImageScanners = scanning.ImageScanners{
+		Severity: []string{"High"},
+		External: []scanning.ExternalScanner{{Name: "failing", URL: server.URL}},
+	}
+	info := []ContainerInfo{{Container: kubernetes.Container{Name: "nginx", Version: "1.0"}, LatestVersion: "1.0"}}
+	problems := &scanProblems{}
+
+	result := getVulnerabilities(context.Background(), info, conf, conf.ImageRegistries, problems, func(string, int, int) {})
+	if len(result) != 1 {
+		t.Fatalf("Expected the image to still be reported but got %v", result)
+	}
+	if status := result[0].GetStatus(); status != versioning.CheckFailed {
+		t.Errorf("Expected status [%s] but got [%s]", versioning.CheckFailed, status)
+	}
+	if len(problems.problems) != 1 {
+		t.Errorf("Expected the failure as scan problem but got %v", problems.problems)
+	}
+	if (ScanResult{ContainerInfo: result}).HasVulnerabilities() {
+		t.Errorf("Expected a failed check not to count as vulnerable but got %v", result[0])
+	}
+}
+
+func TestFailedScannerKeepsTheFindingsOfTheOtherScanners(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"findings": [{"id": "CVE-2023-4911", "severity": "High"}]}`))
+	}))
+	defer working.Close()
+
+	var conf config.Config
+	conf.Workers.Vulnerabilities = 1
+	conf.ImageScanners = scanning.ImageScanners{
+		Severity: []string{"High"},
+		External: []scanning.ExternalScanner{{Name: "failing", URL: failing.URL}, {Name: "working", URL: working.URL}},
+	}
+	info := []ContainerInfo{{Container: kubernetes.Container{Name: "nginx", Version: "1.0"}, LatestVersion: "1.0"}}
+
+	result := getVulnerabilities(context.Background(), info, conf, conf.ImageRegistries, &scanProblems{}, func(string, int, int) {})
+	if len(result[0].Cves) != 1 || result[0].Cves[0] != "CVE-2023-4911" || result[0].Severity != "High" || !result[0].ScanFailed {
+		t.Errorf("Expected the finding of the working scanner and the failed scan but got %v", result[0])
+	}
+	if status := result[0].GetCveStatus(); status != "1 (incomplete)" {
+		t.Errorf("Expected the incomplete findings but got [%s]", status)
+	}
+	if status := result[0].GetStatus(); status != versioning.CheckFailed {
+		t.Errorf("Expected status [%s] but got [%s]", versioning.CheckFailed, status)
+	}
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// SectionKubernetes is used for problems while fetching information from Kubernetes
+	SectionKubernetes = "Kubernetes"
+	// SectionImages is used for problems while fetching the latest image versions
+	SectionImages = "Images"
+	// SectionVulnerabilities is used for problems while fetching image vulnerabilities
+	SectionVulnerabilities = "Vulnerabilities"
+	// SectionCharts is used for problems while fetching the latest chart versions
+	SectionCharts = "Charts"
+	// SectionTools is used for problems while fetching the latest tool versions
+	SectionTools = "Tools"
+	// SectionPlugins is used for problems while running plugins
+	SectionPlugins = "Plugins"
+	// SectionExports is used for problems while exporting the results to other systems like Dependency-Track
+	SectionExports = "Exports"
+)
+
+// ScanProblem is an error that occurred during the scan, the scan continues with everything else
+type ScanProblem struct {
+	Section string
+	Item    string
+	Error   string
+	// Code is the machine readable code of the error, see lcmerrors for the codes
+	Code lcmerrors.Code
+}
+
+// scanProblems collects all the problems of a single scan and what the scan was not allowed to read
+type scanProblems struct {
+	lock     sync.Mutex
+	problems []ScanProblem
+	skipped  []string
+}
+
+// add records the error as a problem, aggregated errors are recorded as separate problems
+// The namespaces and resources that may not be read are recorded as skipped instead, the scan is partial but not failing
+func (s *scanProblems) add(section, item string, err error) {
+	if err == nil {
+		return
+	}
+	if aggregate, ok := err.(utilerrors.Aggregate); ok {
+		for _, e := range aggregate.Errors() {
+			s.add(section, item, e)
+		}
+		return
+	}
+	var skipped *kubernetes.SkippedError
+	if errors.As(err, &skipped) {
+		s.skip(skipped.Skipped())
+		return
+	}
+
+	logger.WithError(err).WithField("section", section).WithField("item", item).Error("Scan problem")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.problems = append(s.problems, ScanProblem{
+		Section: section,
+		Item:    item,
+		Error:   err.Error(),
+		Code:    lcmerrors.CodeOf(err),
+	})
+}
+
+func (s *scanProblems) skip(skipped string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !contains(s.skipped, skipped) {
+		s.skipped = append(s.skipped, skipped)
+	}
+}
+
+// sortedSkipped returns a sorted copy of what was skipped
+func (s *scanProblems) sortedSkipped() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sorted := append([]string{}, s.skipped...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// safely calls call and returns a panic of the call as error, so a bug in the client of a single registry or scanner
+// is reported as the problem of that item and the scan continues with the others
+func safely(call func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.WithField("stack", string(debug.Stack())).Debug("Recovered from a panic")
+			err = fmt.Errorf("Check failed unexpectedly: %v", r)
+		}
+	}()
+	return call()
+}
+
+// sorted returns a sorted copy of the problems
+func (s *scanProblems) sorted() []ScanProblem {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sorted := append([]ScanProblem{}, s.problems...)
+	sortProblems(sorted)
+	return sorted
+}
@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestSafelyReturnsAPanicAsError(t *testing.T) {
+	var tags map[string]string
+	err := safely(func() error {
+		tags["latest"] = "1.0"
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Expected the panic as error")
+	}
+	problems := &scanProblems{}
+	problems.add(SectionImages, "nginx", err)
+	problems.add(SectionKubernetes, "containers", &kubernetes.SkippedError{Resource: "namespaces", Err: errors.New("forbidden")})
+	if len(problems.problems) != 1 || problems.problems[0].Item != "nginx" {
+		t.Errorf("Expected the panic as scan problem but got %v", problems.problems)
+	}
+	if skipped := problems.sortedSkipped(); len(skipped) != 1 || skipped[0] != "namespaces" {
+		t.Errorf("Expected the namespaces to be skipped but got %v", skipped)
+	}
+}
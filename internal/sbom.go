@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+)
+
+// WriteSbom writes the SBOM of the running images of the scan result in the format of the --sbom flag,
+// with the packages of the images from the scanners that list them when --sbomPackages is set
+func WriteSbom(ctx context.Context, w io.Writer, lcmConfig config.Config, result ScanResult, version string) error {
+	packages := map[string][]scanning.Package{}
+	if lcmConfig.CliFlags.SbomPackages {
+		packages = getPackages(ctx, lcmConfig, result.ContainerInfo)
+	}
+	switch lcmConfig.CliFlags.Sbom {
+	case config.SbomCycloneDX:
+		return writeCycloneDX(w, newCycloneDX(result, packages, version, time.Now()))
+	case config.SbomSPDX:
+		return writeSPDX(w, newSPDX(result, packages, version, time.Now()))
+	default:
+		return fmt.Errorf("SBOM format [%s] is not supported", lcmConfig.CliFlags.Sbom)
+	}
+}
+
+// getPackages returns the packages per reference of the images, an image of which the packages can't be listed is left out with a warning
+// so the SBOM still has the image
+func getPackages(ctx context.Context, config config.Config, containerInfo []ContainerInfo) map[string][]scanning.Package {
+	groups := groupBy(len(containerInfo), func(index int) string {
+		return containerInfo[index].Container.Reference()
+	})
+	found := make([][]scanning.Package, len(groups))
+	runParallel(SectionVulnerabilities, len(groups), config.Workers.Vulnerabilities, func(string, int, int) {}, func(group int) {
+		container := containerInfo[groups[group][0]].Container
+		err := safely(func() (err error) {
+			found[group], err = config.GetImageScanners().GetPackages(audit.WithPurpose(ctx, "packages of image "+container.Name+":"+container.Version), container.Reference())
+			return err
+		})
+		if err != nil {
+			logger.WithError(err).WithField("image", container.Reference()).Warn("Could not list the packages of the image for the SBOM")
+		}
+	})
+	packages := map[string][]scanning.Package{}
+	for group, indexes := range groups {
+		packages[containerInfo[indexes[0]].Container.Reference()] = found[group]
+	}
+	return packages
+}
@@ -6,33 +6,69 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/metrics"
 	"github.com/gorilla/mux"
-	"github.com/heptiolabs/healthcheck"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/negroni"
 )
 
+// WebData contains the information shown by the web UI
 type WebData struct {
 	Status          string
 	LastTimeFetched string
-	ContainerInfo   []ContainerInfo
-	ChartInfo       []ChartInfo
-	ToolInfo        []ToolInfo
+	ScanResult
 }
 
 var (
-	WebDataVar = WebData{}
+	// WebDataVar contains the latest scan result shown by the web UI
+	WebDataVar  = WebData{}
+	webDataLock sync.RWMutex
 )
 
-func StartServer() {
+var (
+	// reloaded is the config of UseConfig, the server and the gRPC service use it instead of the config they were started with
+	reloaded     *config.Config
+	reloadedLock sync.RWMutex
+)
+
+// UseConfig makes the health checks and the gRPC service use the config from now on, for when the config was reloaded
+func UseConfig(c config.Config) {
+	reloadedLock.Lock()
+	defer reloadedLock.Unlock()
+	reloaded = &c
+}
+
+// currentConfig returns the config of UseConfig, the started config when the config wasn't reloaded
+func currentConfig(started config.Config) config.Config {
+	reloadedLock.RLock()
+	defer reloadedLock.RUnlock()
+	if reloaded == nil {
+		return started
+	}
+	return *reloaded
+}
+
+// StartServer serves the web UI, the health checks and the metrics until lcm is stopped,
+// the debug endpoints are only added when they are enabled because they expose internals of the process
+func StartServer(config config.Config) {
 	r := mux.NewRouter()
 
-	health := healthcheck.NewHandler()
+	health := newHealthHandler(config)
+	r.HandleFunc("/healthz", health.LiveEndpoint)
+	r.HandleFunc("/readyz", health.ReadyEndpoint)
+	// kept for deployments that still probe the old paths
 	r.Handle("/live", health)
 	r.Handle("/ready", health)
 
+	r.Handle("/metrics", metrics.Handler())
+	if config.IsDebugEndpointsEnabled() {
+		addDebugEndpoints(r)
+		logger.Info("Serving the debug endpoints on /debug/pprof and /debug/runtime")
+	}
 	r.HandleFunc("/", index)
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
@@ -40,7 +76,7 @@ func StartServer() {
 	n.UseHandler(r)
 
 	addr := ":7321"
-	log.WithFields(log.Fields{"addr": addr}).Info("Started server")
+	logger.WithFields(log.Fields{"addr": addr}).Info("Started server")
 
 	srv := &http.Server{
 		Addr:         addr,
@@ -52,7 +88,7 @@ func StartServer() {
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil {
-			log.WithError(err).Error("Could not start server")
+			logger.WithError(err).Error("Could not start server")
 		}
 	}()
 
@@ -67,16 +103,18 @@ func StartServer() {
 	defer cancel()
 	err := srv.Shutdown(ctx)
 	if err != nil {
-		log.WithError(err).Error("Failed to properly shutdown")
+		logger.WithError(err).Error("Failed to properly shutdown")
 	}
-	log.Info("Shutting down")
+	logger.Info("Shutting down")
 	os.Exit(0)
 }
 
 func index(w http.ResponseWriter, req *http.Request) {
 	templates := template.Must(template.ParseGlob("templates/*"))
+	webDataLock.RLock()
+	defer webDataLock.RUnlock()
 	err := templates.ExecuteTemplate(w, "index.gohtml", WebDataVar)
 	if err != nil {
-		log.WithError(err).Error("Could not server index template")
+		logger.WithError(err).Error("Could not server index template")
 	}
 }
@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// shard is the part of the cluster this replica scans, without sharding it is the whole cluster
+type shard struct {
+	enabled    bool
+	index      int
+	namespaces []string
+}
+
+// getShard returns the shard of this replica with the namespaces it owns
+func getShard(ctx context.Context, config config.Config) (shard, error) {
+	if !config.IsShardingEnabled() {
+		return shard{namespaces: config.Namespaces}, nil
+	}
+	index, err := config.GetShardIndex()
+	if err != nil {
+		// without an index the shard doesn't scan anything and doesn't store a result
+		return shard{enabled: true, index: -1}, err
+	}
+	namespaces, err := kubernetes.SelectNamespaces(ctx, config.Namespaces, config.RunningLocally())
+	if err != nil && !kubernetes.IsSkipped(err) {
+		return shard{enabled: true, index: index}, err
+	}
+
+	current := shard{enabled: true, index: index, namespaces: []string{}}
+	for _, namespace := range namespaces {
+		if shardOf(namespace, config.Sharding.Shards) == index {
+			current.namespaces = append(current.namespaces, namespace)
+		}
+	}
+	logger.WithField("shard", index).WithField("namespaces", current.namespaces).Info("Scanning the namespaces of the shard")
+	return current, err
+}
+
+// shardOf returns the shard of the namespace with rendezvous hashing,
+// so when the number of shards changes only the namespaces of the added or removed shards move
+func shardOf(namespace string, shards int) int {
+	best, bestScore := 0, uint64(0)
+	for index := 0; index < shards; index++ {
+		hash := fnv.New64a()
+		hash.Write([]byte(namespace + "/" + strconv.Itoa(index)))
+		if score := hash.Sum64(); index == 0 || score > bestScore {
+			best, bestScore = index, score
+		}
+	}
+	return best
+}
+
+// fetchesKubernetes returns false when the shard doesn't own any namespaces, an empty list would mean all namespaces
+func (s shard) fetchesKubernetes() bool {
+	return !s.enabled || len(s.namespaces) > 0
+}
+
+// ownsShared returns true when the shard checks what doesn't belong to a namespace like the extra images, collectors and tools
+func (s shard) ownsShared() bool {
+	return !s.enabled || s.index == 0
+}
+
+func shardKey(config config.Config, index int) string {
+	return fmt.Sprintf("shards/%s/%d/%d", config.ClusterName, config.Sharding.Shards, index)
+}
+
+// mergeShards stores the result of the shard in the cache and returns the result merged with the latest results of the other shards,
+// shards that have no result yet are added as scan problems
+func mergeShards(ctx context.Context, config config.Config, current shard, result ScanResult) ScanResult {
+	cache.SetJSON(ctx, shardKey(config, current.index), result)
+
+	merged := result
+	merged.ContainerInfo = append([]ContainerInfo{}, result.ContainerInfo...)
+	merged.ChartInfo = append([]ChartInfo{}, result.ChartInfo...)
+	merged.ToolInfo = append([]ToolInfo{}, result.ToolInfo...)
+	merged.NodeInfo = append([]NodeInfo{}, result.NodeInfo...)
+	merged.AddonInfo = append([]AddonInfo{}, result.AddonInfo...)
+	merged.Problems = append([]ScanProblem{}, result.Problems...)
+	merged.Skipped = append([]string{}, result.Skipped...)
+	for index := 0; index < config.Sharding.Shards; index++ {
+		if index == current.index {
+			continue
+		}
+		var other ScanResult
+		if !cache.GetJSON(ctx, "shards", shardKey(config, index), &other) {
+			merged.Problems = append(merged.Problems, ScanProblem{Section: SectionKubernetes, Item: "shard " + strconv.Itoa(index), Error: "No result from the shard yet", Code: lcmerrors.CodeUnavailable})
+			continue
+		}
+		merged.ContainerInfo = append(merged.ContainerInfo, other.ContainerInfo...)
+		merged.ChartInfo = append(merged.ChartInfo, other.ChartInfo...)
+		merged.ToolInfo = append(merged.ToolInfo, other.ToolInfo...)
+		merged.NodeInfo = append(merged.NodeInfo, other.NodeInfo...)
+		merged.AddonInfo = append(merged.AddonInfo, other.AddonInfo...)
+		if other.ControlPlane.Version != "" {
+			merged.ControlPlane = other.ControlPlane
+		}
+		merged.Problems = append(merged.Problems, other.Problems...)
+		for _, skipped := range other.Skipped {
+			if !contains(merged.Skipped, skipped) {
+				merged.Skipped = append(merged.Skipped, skipped)
+			}
+		}
+	}
+	merged.ContainerInfo = uniqueContainerInfo(merged.ContainerInfo)
+	merged.PolicyViolations = checkRegistryPolicy(config.RegistryPolicy, merged.ContainerInfo)
+
+	sortContainerInfo(merged.ContainerInfo)
+	sortChartInfo(merged.ChartInfo)
+	sortToolInfo(merged.ToolInfo)
+	sortProblems(merged.Problems)
+	sort.Strings(merged.Skipped)
+	merged.Summary.Partial = len(merged.Skipped) > 0
+	return merged
+}
+
+// uniqueContainerInfo merges the checked images that run in the namespaces of multiple shards
+func uniqueContainerInfo(info []ContainerInfo) []ContainerInfo {
+	var unique []ContainerInfo
+	indexes := map[string]int{}
+	for _, ci := range info {
+		index, exists := indexes[imageKey(ci.Container)]
+		if !exists {
+			indexes[imageKey(ci.Container)] = len(unique)
+			unique = append(unique, ci)
+			continue
+		}
+		unique[index].Container.Namespaces = mergeSorted(unique[index].Container.Namespaces, ci.Container.Namespaces)
+		unique[index].Container.Workloads = mergeSorted(unique[index].Container.Workloads, ci.Container.Workloads)
+		unique[index].Container.Replicas = addReplicas(unique[index].Container.Replicas, ci.Container.Replicas)
+	}
+	return unique
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+func TestShardOfOnlyMovesToAddedShard(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		namespace := fmt.Sprintf("namespace-%d", i)
+		before, after := shardOf(namespace, 3), shardOf(namespace, 4)
+		if before < 0 || before >= 3 {
+			t.Fatalf("Namespace [%s] got shard [%d] out of range", namespace, before)
+		}
+		if before != after && after != 3 {
+			t.Errorf("Namespace [%s] moved from shard [%d] to existing shard [%d]", namespace, before, after)
+		}
+	}
+}
+
+func TestMergeShards(t *testing.T) {
+	if err := cache.Configure(cache.Config{Type: cache.TypeMemory}); err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Configure(cache.Config{})
+
+	nginx := kubernetes.Container{URL: "docker.io", Name: "library/nginx", Version: "1.0"}
+	inZero, inOne := nginx, nginx
+	inZero.Namespaces = []string{"web"}
+	inOne.Namespaces = []string{"api"}
+	config := config.Config{ClusterName: "prod", Sharding: config.Sharding{Shards: 3}}
+
+	mergeShards(context.Background(), config, shard{enabled: true, index: 1}, ScanResult{ContainerInfo: []ContainerInfo{{Container: inOne}}})
+	merged := mergeShards(context.Background(), config, shard{enabled: true, index: 0}, ScanResult{ContainerInfo: []ContainerInfo{{Container: inZero}}})
+
+	if len(merged.ContainerInfo) != 1 || len(merged.ContainerInfo[0].Container.Namespaces) != 2 {
+		t.Errorf("Expected the image once with both namespaces but got %v", merged.ContainerInfo)
+	}
+	if len(merged.Problems) != 1 || merged.Problems[0].Item != "shard 2" {
+		t.Errorf("Expected a problem for the missing shard but got %v", merged.Problems)
+	}
+}
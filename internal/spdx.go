@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+)
+
+// spdxInvalid matches the characters that are not allowed in an SPDX identifier
+var spdxInvalid = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// spdx is an SPDX 2.3 document with the clusters and the images as packages, the clusters contain the images they run
+// and the images contain their packages
+type spdx struct {
+	SpdxVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string            `json:"name"`
+	SPDXID                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SpdxElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSpdxElement string `json:"relatedSpdxElement"`
+}
+
+// newSPDX returns the SPDX document of the images of the scan result with the packages per reference, every image is in the document once.
+// The document describes the cluster, with multiple clusters the cluster of the document contains the clusters that contain their images
+func newSPDX(result ScanResult, packages map[string][]scanning.Package, version string, now time.Time) spdx {
+	cluster := spdxCluster(result.Cluster)
+	document := spdx{
+		SpdxVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "lcm-" + cluster.Name,
+		DocumentNamespace: fmt.Sprintf("https://github.com/arminc/k8s-platform-lcm/spdx/%s-%d", spdxInvalid.ReplaceAllString(cluster.Name, "-"), now.UnixNano()),
+		CreationInfo:      spdxCreationInfo{Created: now.UTC().Format(time.RFC3339), Creators: []string{"Tool: lcm-" + version}},
+		Packages:          []spdxPackage{cluster},
+		Relationships:     []spdxRelationship{{SpdxElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSpdxElement: cluster.SPDXID}},
+	}
+
+	images := map[string]string{}
+	for _, ci := range result.ContainerInfo {
+		reference := ci.Container.Reference()
+		if _, exists := images[reference]; exists {
+			continue
+		}
+		image := spdxImage(ci, len(images)+1)
+		images[reference] = image.SPDXID
+		document.Packages = append(document.Packages, image)
+		for index, pkg := range packages[reference] {
+			contained := spdxPackage{
+				Name:                  pkg.Name,
+				SPDXID:                fmt.Sprintf("%s-Package-%d", image.SPDXID, index+1),
+				VersionInfo:           pkg.Version,
+				DownloadLocation:      "NOASSERTION",
+				PrimaryPackagePurpose: "LIBRARY",
+			}
+			if pkg.Purl != "" {
+				contained.ExternalRefs = []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: pkg.Purl}}
+			}
+			document.Packages = append(document.Packages, contained)
+			document.Relationships = append(document.Relationships, spdxRelationship{SpdxElementID: image.SPDXID, RelationshipType: "CONTAINS", RelatedSpdxElement: contained.SPDXID})
+		}
+	}
+
+	if len(result.Clusters) == 0 {
+		for _, ci := range result.ContainerInfo {
+			document.Relationships = appendContains(document.Relationships, cluster.SPDXID, images[ci.Container.Reference()])
+		}
+		return document
+	}
+	for _, clusterResult := range result.Clusters {
+		contained := spdxCluster(clusterResult.Cluster)
+		document.Packages = append(document.Packages, contained)
+		document.Relationships = append(document.Relationships, spdxRelationship{SpdxElementID: cluster.SPDXID, RelationshipType: "CONTAINS", RelatedSpdxElement: contained.SPDXID})
+		for _, ci := range clusterResult.ContainerInfo {
+			document.Relationships = appendContains(document.Relationships, contained.SPDXID, images[ci.Container.Reference()])
+		}
+	}
+	return document
+}
+
+// appendContains adds the relationship of the element containing the image once
+func appendContains(relationships []spdxRelationship, element, image string) []spdxRelationship {
+	relationship := spdxRelationship{SpdxElementID: element, RelationshipType: "CONTAINS", RelatedSpdxElement: image}
+	for _, existing := range relationships {
+		if existing == relationship {
+			return relationships
+		}
+	}
+	return append(relationships, relationship)
+}
+
+// spdxCluster returns the package of the cluster, the cluster without a name is the cluster lcm runs in
+func spdxCluster(cluster Cluster) spdxPackage {
+	name := cluster.Name
+	if name == "" {
+		name = "kubernetes"
+	}
+	return spdxPackage{
+		Name:                  name,
+		SPDXID:                "SPDXRef-Cluster-" + spdxInvalid.ReplaceAllString(name, "-"),
+		DownloadLocation:      "NOASSERTION",
+		PrimaryPackagePurpose: "OTHER",
+	}
+}
+
+// spdxImage returns the container package of the image with the namespaces, workloads and clusters it runs in as comment
+func spdxImage(ci ContainerInfo, number int) spdxPackage {
+	container := ci.Container
+	digest := imageDigest(container)
+	image := spdxPackage{
+		Name:                  container.URL + "/" + container.Name,
+		SPDXID:                fmt.Sprintf("SPDXRef-Image-%d", number),
+		VersionInfo:           container.Version,
+		DownloadLocation:      "NOASSERTION",
+		PrimaryPackagePurpose: "CONTAINER",
+		ExternalRefs:          []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: ociPurl(container, digest)}},
+	}
+	if strings.HasPrefix(digest, "sha256:") {
+		image.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: strings.TrimPrefix(digest, "sha256:")}}
+	}
+	var comment []string
+	if len(ci.Clusters) > 0 {
+		comment = append(comment, "clusters: "+strings.Join(ci.Clusters, ", "))
+	}
+	if len(container.Namespaces) > 0 {
+		comment = append(comment, "namespaces: "+strings.Join(container.Namespaces, ", "))
+	}
+	if len(container.Workloads) > 0 {
+		comment = append(comment, "workloads: "+strings.Join(container.Workloads, ", "))
+	}
+	image.Comment = strings.Join(comment, "; ")
+	return image
+}
+
+func writeSPDX(w io.Writer, document spdx) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
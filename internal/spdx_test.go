@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+)
+
+func TestSPDXDescribesTheClusterWithTheImages(t *testing.T) {
+	nginx := ContainerInfo{Container: kubernetes.Container{URL: "docker.io", Name: "library/nginx", Version: "1.25", Tag: "1.25", Digest: "sha256:abc", Namespaces: []string{"web"}, Workloads: []string{"web/Deployment/nginx"}}}
+	app := ContainerInfo{Container: kubernetes.Container{URL: "registry.io", Name: "team/app", Version: "1.0", Tag: "1.0"}}
+	result := ScanResult{Cluster: Cluster{Name: "prod eu"}, ContainerInfo: []ContainerInfo{nginx, app, nginx}}
+	packages := map[string][]scanning.Package{"docker.io/library/nginx:1.25@sha256:abc": {{Name: "openssl", Version: "3.0.11", Purl: "pkg:deb/debian/openssl@3.0.11"}}}
+
+	var out bytes.Buffer
+	if err := writeSPDX(&out, newSPDX(result, packages, "1.2.3", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))); err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	var document spdx
+	if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+		t.Fatalf("Expected valid json but got [%v]", err)
+	}
+
+	if len(document.Packages) != 4 {
+		t.Fatalf("Expected the cluster, the images once and the package but got %v", document.Packages)
+	}
+	image := document.Packages[1]
+	if image.ExternalRefs[0].ReferenceLocator != "pkg:oci/nginx@sha256%3Aabc?repository_url=docker.io/library/nginx&tag=1.25" || image.Checksums[0].ChecksumValue != "abc" {
+		t.Errorf("Expected the purl and the checksum of the digest but got %v", image)
+	}
+	if image.Comment != "namespaces: web; workloads: web/Deployment/nginx" {
+		t.Errorf("Expected the namespaces and workloads as comment but got [%s]", image.Comment)
+	}
+	expected := []spdxRelationship{
+		{SpdxElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSpdxElement: "SPDXRef-Cluster-prod-eu"},
+		{SpdxElementID: "SPDXRef-Image-1", RelationshipType: "CONTAINS", RelatedSpdxElement: "SPDXRef-Image-1-Package-1"},
+		{SpdxElementID: "SPDXRef-Cluster-prod-eu", RelationshipType: "CONTAINS", RelatedSpdxElement: "SPDXRef-Image-1"},
+		{SpdxElementID: "SPDXRef-Cluster-prod-eu", RelationshipType: "CONTAINS", RelatedSpdxElement: "SPDXRef-Image-2"},
+	}
+	if !reflect.DeepEqual(document.Relationships, expected) {
+		t.Errorf("Expected %v but got %v", expected, document.Relationships)
+	}
+	if document.CreationInfo.Creators[0] != "Tool: lcm-1.2.3" || document.CreationInfo.Created != "2024-01-02T03:04:05Z" {
+		t.Errorf("Expected lcm as creator at the time of the scan but got %v", document.CreationInfo)
+	}
+}
@@ -0,0 +1,275 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/stats"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Summary contains the statistics of a single scan
+type Summary struct {
+	Images          int
+	Charts          int
+	Tools           int
+	Registries      []string
+	Requests        map[string]int64
+	Degraded        []string
+	APICalls        int64
+	Failures        int64
+	CacheHits       int64
+	CacheMisses     int64
+	NotModified     int64
+	CacheHitRate    float64
+	Problems        int
+	ChecksFailed    int
+	Violations      int
+	Upgrades        map[string]int // Outdated images per upgrade type
+	OutdatedBuilds  int            // Images with pods that run an older build of a mutable tag
+	PolicyFailures  int            // Images that don't pass the policy of a scanner like Anchore
+	BaseImages      int            // Images with a recommended upgrade of the base image by a scanner like Snyk
+	Exploited       int            // Images with vulnerabilities that are actively exploited according to the KEV catalog
+	AboveEpss       int            // Images with CVEs of at least the EPSS threshold
+	AboveFail       int            // Images with vulnerabilities of at least the fail threshold
+	AboveWarn       int            // Images with vulnerabilities of at least the warn threshold but below the fail threshold
+	OutdatedNodes   int            // Nodes with an older kubelet than the API server
+	SkewedNodes     int            // Nodes with a kubelet version that the version skew policy doesn't support
+	ControlPlanes   []string       // Control planes that run an unsupported or unpatched version of Kubernetes
+	Incompatible    int            // Installed addons that don't support the Kubernetes version of their cluster
+	Drift           int            // Images of workloads that differ between the compared clusters
+	Partial         bool           // Some namespaces or resources could not be read, so the results are incomplete
+	PhaseDurations  map[string]string
+	Duration        string
+	phaseStartTimes map[string]time.Time
+}
+
+func newSummary() *Summary {
+	stats.Reset()
+	return &Summary{
+		PhaseDurations:  map[string]string{},
+		phaseStartTimes: map[string]time.Time{},
+	}
+}
+
+func (s *Summary) startPhase(phase string) {
+	s.phaseStartTimes[phase] = time.Now()
+}
+
+func (s *Summary) endPhase(phase string) {
+	if start, ok := s.phaseStartTimes[phase]; ok {
+		s.PhaseDurations[phase] = time.Since(start).Round(time.Millisecond).String()
+	}
+}
+
+// finish fills in the counters from the scan result and the collected stats
+func (s *Summary) finish(result ScanResult, start time.Time) {
+	s.Images = len(result.ContainerInfo)
+	s.Charts = len(result.ChartInfo)
+	s.Tools = len(result.ToolInfo)
+	s.Registries = stats.Hosts()
+	s.Requests = stats.HostRequests()
+	s.Degraded = stats.Degraded()
+	s.APICalls = stats.Get(stats.Requests)
+	s.Failures = stats.Get(stats.RequestFailures)
+	s.CacheHits = stats.Get(stats.CacheHits)
+	s.CacheMisses = stats.Get(stats.CacheMisses)
+	s.NotModified = stats.Get(stats.NotModified)
+	if lookups := s.CacheHits + s.CacheMisses; lookups > 0 {
+		s.CacheHitRate = float64(s.CacheHits) / float64(lookups)
+	}
+	s.Problems = len(result.Problems)
+	s.ChecksFailed = countChecksFailed(result)
+	s.Violations = len(result.PolicyViolations)
+	s.Upgrades = countUpgrades(result.ContainerInfo)
+	s.OutdatedBuilds = countOutdatedBuilds(result.ContainerInfo)
+	s.PolicyFailures = countPolicyFailures(result.ContainerInfo)
+	s.BaseImages = countBaseImageUpgrades(result.ContainerInfo)
+	s.Exploited = countExploited(result.ContainerInfo)
+	s.AboveEpss = countAboveEpss(result.ContainerInfo)
+	s.AboveFail = result.countThresholds(result.Thresholds.Fails)
+	s.AboveWarn = result.countThresholds(result.Thresholds.Warns)
+	s.OutdatedNodes, s.SkewedNodes = countNodes(result.NodeInfo)
+	s.ControlPlanes = outdatedControlPlanes(result)
+	s.Incompatible = countIncompatibleAddons(result.AddonInfo)
+	s.Drift = len(result.Drift)
+	s.Partial = len(result.Skipped) > 0
+	s.Duration = time.Since(start).Round(time.Millisecond).String()
+}
+
+// countChecksFailed returns the number of images, charts and tools that are reported without the result of their check
+func countChecksFailed(result ScanResult) int {
+	failed := 0
+	for _, container := range result.ContainerInfo {
+		if container.CheckFailed() {
+			failed++
+		}
+	}
+	for _, chart := range result.ChartInfo {
+		if chart.CheckFailed() {
+			failed++
+		}
+	}
+	for _, tool := range result.ToolInfo {
+		if tool.CheckFailed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// countUpgrades returns the number of outdated images per upgrade type
+func countUpgrades(info []ContainerInfo) map[string]int {
+	upgrades := map[string]int{}
+	for _, container := range info {
+		if container.Upgrade != "" {
+			upgrades[container.Upgrade]++
+		}
+	}
+	return upgrades
+}
+
+// outdatedControlPlanes returns the control planes that run an unsupported or unpatched version as cluster:version,
+// the cluster is empty for a single cluster without a name
+func outdatedControlPlanes(result ScanResult) []string {
+	controlPlanes := map[string]ControlPlaneInfo{result.Cluster.Name: result.ControlPlane}
+	if len(result.Clusters) > 0 {
+		controlPlanes = map[string]ControlPlaneInfo{}
+		for _, cluster := range result.Clusters {
+			controlPlanes[cluster.Cluster.Name] = cluster.ControlPlane
+		}
+	}
+	var outdated []string
+	for name, controlPlane := range controlPlanes {
+		if controlPlane.Version != "" && (!controlPlane.Supported || controlPlane.Unpatched()) {
+			outdated = append(outdated, name+":"+controlPlane.Version)
+		}
+	}
+	sort.Strings(outdated)
+	return outdated
+}
+
+// countOutdatedBuilds returns the number of images with pods that run an older build of the tag
+func countOutdatedBuilds(info []ContainerInfo) int {
+	outdated := 0
+	for _, container := range info {
+		if len(container.OutdatedBuilds) > 0 {
+			outdated++
+		}
+	}
+	return outdated
+}
+
+// countPolicyFailures returns the number of images that don't pass the policy of a scanner
+func countPolicyFailures(info []ContainerInfo) int {
+	failed := 0
+	for _, container := range info {
+		if container.PolicyFailed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// countBaseImageUpgrades returns the number of images with a recommended upgrade of the base image
+func countBaseImageUpgrades(info []ContainerInfo) int {
+	upgrades := 0
+	for _, container := range info {
+		if len(container.BaseImages) > 0 {
+			upgrades++
+		}
+	}
+	return upgrades
+}
+
+// countExploited returns the number of images with actively exploited vulnerabilities
+func countExploited(info []ContainerInfo) int {
+	exploited := 0
+	for _, container := range info {
+		if len(container.Exploited) > 0 {
+			exploited++
+		}
+	}
+	return exploited
+}
+
+// countAboveEpss returns the number of images with CVEs of at least the EPSS threshold
+func countAboveEpss(info []ContainerInfo) int {
+	above := 0
+	for _, container := range info {
+		if len(container.AboveEpss) > 0 {
+			above++
+		}
+	}
+	return above
+}
+
+var summaryPhases = []string{SectionKubernetes, SectionImages, SectionVulnerabilities, SectionCharts, SectionTools}
+
+func prettyPrintSummary(s Summary) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Summary", "Value"})
+	table.SetColumnAlignment([]int{3, 3})
+	table.SetAutoWrapText(false)
+
+	table.Append([]string{"Images scanned", fmt.Sprint(s.Images)})
+	table.Append([]string{"Charts scanned", fmt.Sprint(s.Charts)})
+	table.Append([]string{"Tools scanned", fmt.Sprint(s.Tools)})
+	table.Append([]string{"Registries contacted", fmt.Sprintf("%d %s", len(s.Registries), strings.Join(s.Registries, " "))})
+	if len(s.Degraded) > 0 {
+		table.Append([]string{"Degraded endpoints", fmt.Sprintf("%d %s", len(s.Degraded), strings.Join(s.Degraded, " "))})
+	}
+	table.Append([]string{"API calls", fmt.Sprint(s.APICalls)})
+	for _, host := range s.Registries {
+		table.Append([]string{"API calls " + host, fmt.Sprint(s.Requests[host])})
+	}
+	table.Append([]string{"Failed calls", fmt.Sprint(s.Failures)})
+	table.Append([]string{"Cache hit rate", fmt.Sprintf("%.0f%% (%d hits, %d misses)", s.CacheHitRate*100, s.CacheHits, s.CacheMisses)})
+	table.Append([]string{"Not modified responses", fmt.Sprint(s.NotModified)})
+	table.Append([]string{"Scan problems", fmt.Sprint(s.Problems)})
+	if s.Partial {
+		table.Append([]string{"Partial results", "yes, not allowed to read everything"})
+	}
+	table.Append([]string{"Checks failed", fmt.Sprint(s.ChecksFailed)})
+	table.Append([]string{"Policy violations", fmt.Sprint(s.Violations)})
+	table.Append([]string{"Outdated builds", fmt.Sprint(s.OutdatedBuilds)})
+	if s.PolicyFailures > 0 {
+		table.Append([]string{"Failed scanner policies", fmt.Sprint(s.PolicyFailures)})
+	}
+	if s.BaseImages > 0 {
+		table.Append([]string{"Base image upgrades", fmt.Sprint(s.BaseImages)})
+	}
+	if s.Exploited > 0 {
+		table.Append([]string{"Actively exploited", fmt.Sprint(s.Exploited)})
+	}
+	if s.AboveEpss > 0 {
+		table.Append([]string{"Above the EPSS threshold", fmt.Sprint(s.AboveEpss)})
+	}
+	if s.AboveFail > 0 || s.AboveWarn > 0 {
+		table.Append([]string{"Severity thresholds", fmt.Sprintf("%d above fail, %d above warn", s.AboveFail, s.AboveWarn)})
+	}
+	if s.OutdatedNodes > 0 || s.SkewedNodes > 0 {
+		table.Append([]string{"Outdated nodes", fmt.Sprintf("%d outdated, %d with unsupported skew", s.OutdatedNodes, s.SkewedNodes)})
+	}
+	if s.Incompatible > 0 {
+		table.Append([]string{"Incompatible addons", fmt.Sprint(s.Incompatible)})
+	}
+	if s.Drift > 0 {
+		table.Append([]string{"Drift between clusters", fmt.Sprint(s.Drift)})
+	}
+	if len(s.ControlPlanes) > 0 {
+		table.Append([]string{"Outdated control planes", fmt.Sprintf("%d %s", len(s.ControlPlanes), strings.Join(s.ControlPlanes, " "))})
+	}
+	table.Append([]string{"Outdated images", fmt.Sprintf("%d major, %d minor, %d patch", s.Upgrades[versioning.Major], s.Upgrades[versioning.Minor], s.Upgrades[versioning.Patch])})
+	for _, phase := range summaryPhases {
+		if duration, ok := s.PhaseDurations[phase]; ok {
+			table.Append([]string{"Duration " + phase, duration})
+		}
+	}
+	table.Append([]string{"Duration total", s.Duration})
+	table.Render()
+}
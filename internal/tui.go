@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	tuiHelp     = `Commands:
+  images | charts | tools | problems  Switch the table
+  filter <text>                       Only show rows containing the text, without text the filter is removed
+  sort name | version | latest | status | severity | cves
+  show <row>                          Show the details of a row, for images the tags and CVEs
+  rescan                              Run the scan again
+  help                                Show this help
+  quit                                Exit`
+)
+
+// tui keeps the state of the interactive terminal UI
+type tui struct {
+	ctx    context.Context
+	config config.Config
+	in     *bufio.Scanner
+	out    io.Writer
+	result ScanResult
+
+	view   string
+	filter string
+	sortBy string
+	header []string
+	// rows are the rows that are shown, the rows of images end with the full path of the image after the columns of the header
+	rows [][]string
+}
+
+// StartTUI runs the scan with live progress and lets the user explore the results in the terminal
+func StartTUI(ctx context.Context, config config.Config, in io.Reader, out io.Writer) ScanResult {
+	t := &tui{
+		ctx:    ctx,
+		config: config,
+		in:     bufio.NewScanner(in),
+		out:    out,
+		view:   SectionImages,
+		sortBy: "name",
+	}
+	t.scan()
+
+	for {
+		t.render()
+		fmt.Fprint(t.out, "lcm> ")
+		if !t.in.Scan() {
+			return t.result
+		}
+		command := strings.Fields(t.in.Text())
+		if len(command) == 0 {
+			continue
+		}
+		if !t.handle(command[0], command[1:]) {
+			return t.result
+		}
+	}
+}
+
+func (t *tui) scan() {
+	t.result = ExecuteWithProgress(t.ctx, t.config, func(phase string, done, total int) {
+		fmt.Fprintf(t.out, "\rScanning %-16s %d/%d", phase, done, total)
+	})
+	fmt.Fprintln(t.out)
+}
+
+// handle executes the command and returns false when the TUI needs to stop
+func (t *tui) handle(command string, args []string) bool {
+	switch command {
+	case "images":
+		t.view = SectionImages
+	case "charts":
+		t.view = SectionCharts
+	case "tools":
+		t.view = SectionTools
+	case "problems":
+		t.view = "Problems"
+	case "filter":
+		t.filter = strings.Join(args, " ")
+	case "sort":
+		if len(args) == 1 {
+			t.sortBy = args[0]
+		}
+	case "show":
+		t.show(args)
+	case "rescan":
+		t.scan()
+	case "quit", "exit", "q":
+		return false
+	default:
+		fmt.Fprintln(t.out, tuiHelp)
+		t.wait()
+	}
+	return true
+}
+
+func (t *tui) render() {
+	fmt.Fprint(t.out, clearScreen)
+	fmt.Fprintf(t.out, "%s | filter: %q | sort: %s | problems: %d | type help for commands\n", t.view, t.filter, t.sortBy, len(t.result.Problems))
+
+	header, rows := t.table()
+	t.header = header
+	t.rows = filterRows(rows, t.filter)
+	sortRows(t.rows, header, t.sortBy)
+
+	table := tablewriter.NewWriter(t.out)
+	table.SetHeader(append([]string{"#"}, header...))
+	table.SetAutoWrapText(false)
+	for index, row := range t.rows {
+		table.Append(append([]string{strconv.Itoa(index + 1)}, row[:len(header)]...))
+	}
+	table.Render()
+}
+
+func (t *tui) table() ([]string, [][]string) {
+	var rows [][]string
+	switch t.view {
+	case SectionCharts:
+		for _, chart := range t.result.ChartInfo {
+			rows = append(rows, []string{chart.Chart.Name, chart.Chart.Version, chart.LatestVersion, chart.GetStatus()})
+		}
+		return []string{"Chart", "Version", "Latest", "Status"}, rows
+	case SectionTools:
+		for _, tool := range t.result.ToolInfo {
+			rows = append(rows, []string{tool.Tool.Repo, tool.Tool.Version, tool.LatestVersion, tool.GetStatus()})
+		}
+		return []string{"Tool", "Version", "Latest", "Status"}, rows
+	case "Problems":
+		for _, problem := range t.result.Problems {
+			rows = append(rows, []string{problem.Item, problem.Section, string(problem.Code), problem.Error})
+		}
+		return []string{"Item", "Section", "Code", "Scan problem"}, rows
+	default:
+		for _, container := range t.result.ContainerInfo {
+			rows = append(rows, []string{container.Container.Name, container.Container.Version, container.LatestVersion, container.GetStatus(),
+				container.Severity, container.GetCveStatus(), container.Container.FullPath})
+		}
+		return []string{"Image", "Version", "Latest", "Status", "Severity", "Cves"}, rows
+	}
+}
+
+func (t *tui) show(args []string) {
+	if len(args) != 1 {
+		return
+	}
+	index, err := strconv.Atoi(args[0])
+	if err != nil || index < 1 || index > len(t.rows) {
+		fmt.Fprintln(t.out, "Row does not exist")
+		t.wait()
+		return
+	}
+	row := t.rows[index-1]
+	fmt.Fprint(t.out, clearScreen)
+	fmt.Fprintf(t.out, "%s\n\n", strings.Join(row[:len(t.header)], "  "))
+
+	if t.view == SectionImages {
+		t.showImage(row[len(t.header)])
+	}
+	t.wait()
+}
+
+// showImage shows the tags and the CVEs of the image with the full path, the tags are fetched with the credentials of the scan
+func (t *tui) showImage(fullPath string) {
+	for _, container := range t.result.ContainerInfo {
+		if container.Container.FullPath != fullPath {
+			continue
+		}
+		fmt.Fprintf(t.out, "Image: %s\n", container.Container.FullPath)
+
+		tags, err := t.result.registries.GetTagsForImage(t.ctx, container.Container.Name, container.Container.URL)
+		if err != nil {
+			fmt.Fprintf(t.out, "Tags: could not be fetched, %v\n", err)
+		} else {
+			sort.Slice(tags, func(i, j int) bool {
+				return versioning.CompareVersions(tags[i], tags[j]) > 0
+			})
+			fmt.Fprintf(t.out, "Tags (%d): %s\n", len(tags), strings.Join(tags, ", "))
+		}
+
+		fmt.Fprintf(t.out, "Cves (%s):\n", container.GetCveStatus())
+		for _, cve := range container.EpssScores(container.Cves) {
+			fmt.Fprintf(t.out, "  %s\n", cve)
+		}
+		return
+	}
+}
+
+// wait waits for enter so the output stays visible
+func (t *tui) wait() {
+	fmt.Fprint(t.out, "\nPress enter to continue")
+	t.in.Scan()
+}
+
+func filterRows(rows [][]string, filter string) [][]string {
+	if filter == "" {
+		return rows
+	}
+	var filtered [][]string
+	for _, row := range rows {
+		if strings.Contains(strings.ToLower(strings.Join(row, " ")), strings.ToLower(filter)) {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
+// sortRows sorts the rows by the column of the header, the versions by version, the severities and the cves from the highest
+// and the other columns alphabetically. Without a column of the header the rows are sorted by the first column
+func sortRows(rows [][]string, header []string, sortBy string) {
+	column := 0
+	for index, name := range header {
+		if strings.EqualFold(name, sortBy) {
+			column = index
+		}
+	}
+	less := func(a, b string) bool {
+		return a < b
+	}
+	switch header[column] {
+	case "Version", "Latest":
+		less = func(a, b string) bool {
+			return versioning.CompareVersions(a, b) < 0
+		}
+	case "Severity":
+		less = func(a, b string) bool {
+			return scanning.CompareSeverities(a, b) > 0
+		}
+	case "Cves":
+		less = func(a, b string) bool {
+			return leadingNumber(a) > leadingNumber(b)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return less(rows[i][column], rows[j][column])
+	})
+}
+
+// leadingNumber returns the number at the start of the text, like 12 of "12 (incomplete)", or -1 when it doesn't start with a number
+func leadingNumber(text string) int {
+	number, err := strconv.Atoi(strings.SplitN(text, " ", 2)[0])
+	if err != nil {
+		return -1
+	}
+	return number
+}
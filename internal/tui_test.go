@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+func TestFilterRows(t *testing.T) {
+	rows := [][]string{{"library/nginx", "1.19"}, {"library/redis", "6.0"}, {"team/api", "1.19"}}
+	tests := map[string][][]string{
+		"":        rows,
+		"library": {{"library/nginx", "1.19"}, {"library/redis", "6.0"}},
+		"1.19":    {{"library/nginx", "1.19"}, {"team/api", "1.19"}},
+		"REDIS":   {{"library/redis", "6.0"}},
+		"missing": nil,
+	}
+	for filter, expected := range tests {
+		if filtered := filterRows(rows, filter); !reflect.DeepEqual(filtered, expected) {
+			t.Errorf("Expected %v for filter %q but got %v", expected, filter, filtered)
+		}
+	}
+}
+
+func TestSortRows(t *testing.T) {
+	header := []string{"Image", "Version", "Latest", "Status"}
+	tests := map[string][]string{
+		"name":    {"a", "b", "c"},
+		"image":   {"a", "b", "c"},
+		"status":  {"c", "a", "b"},
+		"unknown": {"a", "b", "c"},
+	}
+	for sortBy, expected := range tests {
+		rows := [][]string{{"b", "2.0", "2.0", "SAME"}, {"c", "1.0", "2.0", "MAJOR"}, {"a", "1.0", "1.1", "MINOR"}}
+		sortRows(rows, header, sortBy)
+		var names []string
+		for _, row := range rows {
+			names = append(names, row[0])
+		}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("Expected %v sorted by %s but got %v", expected, sortBy, names)
+		}
+	}
+}
+
+func TestSortRowsByVersionSeverityAndCves(t *testing.T) {
+	header := []string{"Image", "Version", "Latest", "Status", "Severity", "Cves"}
+	tests := map[string][]string{
+		"version":  {"c", "b", "a"},
+		"latest":   {"a", "c", "b"},
+		"severity": {"b", "a", "c"},
+		"cves":     {"a", "c", "b"},
+	}
+	for sortBy, expected := range tests {
+		rows := [][]string{
+			{"a", "10.0", "1.2.0", "MAJOR", "MEDIUM", "12 (incomplete)"},
+			{"b", "9.1", "10.0.0", "SAME", "CRITICAL", versioning.CheckFailed},
+			{"c", "2.0", "9.0.0", "MAJOR", "LOW", "3"},
+		}
+		sortRows(rows, header, sortBy)
+		var names []string
+		for _, row := range rows {
+			names = append(names, row[0])
+		}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("Expected %v sorted by %s but got %v", expected, sortBy, names)
+		}
+	}
+}
+
+func TestShowImageMatchesTheFullPathWithTheRegistriesOfTheScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	provider := filepath.Join(dir, "provider")
+	if err := ioutil.WriteFile(provider, []byte("#!/bin/sh\ncat > /dev/null\necho '{\"tags\": [\"1.9\", \"1.10\"]}'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	ui := &tui{
+		ctx:    context.Background(),
+		in:     bufio.NewScanner(strings.NewReader("\n")),
+		out:    &out,
+		view:   SectionImages,
+		sortBy: "name",
+		result: ScanResult{
+			ContainerInfo: []ContainerInfo{
+				{Container: kubernetes.Container{Name: "team/api", URL: "registry.corp.local", Version: "1.9", FullPath: "registry.corp.local/team/api:1.9"}, Cves: []string{"CVE-1"}},
+				{Container: kubernetes.Container{Name: "team/api", URL: "registry.corp.local", Version: "1.10", FullPath: "registry.corp.local/team/api:1.10"}, Cves: []string{"CVE-2"}},
+			},
+			registries: registries.ImageRegistries{Providers: []registries.ExecRegistry{{Name: "corp", Command: provider, Urls: []string{"registry.corp.local"}}}},
+		},
+	}
+	ui.render()
+	out.Reset()
+	ui.handle("show", []string{"2"})
+
+	for _, expected := range []string{"Image: registry.corp.local/team/api:1.10", "Tags (2): 1.10, 1.9", "CVE-2"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected the details to contain %s but got %s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "CVE-1") {
+		t.Errorf("Expected only the details of the selected tag but got %s", out.String())
+	}
+}
+
+func TestTUICommands(t *testing.T) {
+	var out bytes.Buffer
+	ui := &tui{
+		in:     bufio.NewScanner(strings.NewReader("")),
+		out:    &out,
+		view:   SectionImages,
+		sortBy: "name",
+		result: ScanResult{
+			ContainerInfo: []ContainerInfo{
+				{Container: kubernetes.Container{Name: "library/redis", Version: "6.0"}, LatestVersion: "7.0"},
+				{Container: kubernetes.Container{Name: "library/nginx", Version: "1.19"}, LatestVersion: "1.25"},
+			},
+			ToolInfo: []ToolInfo{{Tool: registries.Tool{Repo: "hashicorp/terraform", Version: "1.0.0"}, LatestVersion: "1.5.0"}},
+		},
+	}
+
+	tests := []struct {
+		command   []string
+		view      string
+		rows      []string
+		continues bool
+	}{
+		{command: []string{"images"}, view: SectionImages, rows: []string{"library/nginx", "library/redis"}, continues: true},
+		{command: []string{"filter", "nginx"}, view: SectionImages, rows: []string{"library/nginx"}, continues: true},
+		{command: []string{"filter"}, view: SectionImages, rows: []string{"library/nginx", "library/redis"}, continues: true},
+		{command: []string{"tools"}, view: SectionTools, rows: []string{"hashicorp/terraform"}, continues: true},
+		{command: []string{"quit"}, view: SectionTools, rows: []string{"hashicorp/terraform"}},
+	}
+	for _, test := range tests {
+		if continues := ui.handle(test.command[0], test.command[1:]); continues != test.continues {
+			t.Errorf("Expected %v to continue %v but got %v", test.command, test.continues, continues)
+		}
+		ui.render()
+		var rows []string
+		for _, row := range ui.rows {
+			rows = append(rows, row[0])
+		}
+		if ui.view != test.view || !reflect.DeepEqual(rows, test.rows) {
+			t.Errorf("Expected %s with %v after %v but got %s with %v", test.view, test.rows, test.command, ui.view, rows)
+		}
+	}
+}
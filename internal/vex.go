@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+	"github.com/arminc/k8s-platform-lcm/pkg/scanning"
+)
+
+// getVexAttestations returns the statements of the OpenVEX attestations of the digests of the image when the attestations are enabled
+func getVexAttestations(ctx context.Context, registries registries.ImageRegistries, container kubernetes.Container, vex scanning.VexConfig, problems *scanProblems) []scanning.VexStatement {
+	if !vex.Attestations {
+		return nil
+	}
+	var statements []scanning.VexStatement
+	for _, digest := range imageDigests(container) {
+		purpose := "attestations of digest " + digest + " of image " + container.Name
+		attestations, err := registries.GetAttestations(audit.WithPurpose(ctx, purpose), container.Name, container.URL, digest)
+		problems.add(SectionVulnerabilities, container.Name, err)
+		for _, attestation := range attestations {
+			if !strings.HasPrefix(attestation.PredicateType, scanning.VexPredicateType) {
+				continue
+			}
+			attested, err := scanning.ParseVexAttestation(attestation.Predicate, container.URL+"/"+container.Name+"@"+digest)
+			problems.add(SectionVulnerabilities, container.Name, err)
+			statements = append(statements, attested...)
+		}
+	}
+	return statements
+}
+
+// imageDigests returns the digest the image is pinned to and the digests that the pods run, sorted so the statements are in the same order every run
+func imageDigests(container kubernetes.Container) []string {
+	seen := map[string]bool{}
+	var digests []string
+	if container.Digest != "" {
+		seen[container.Digest] = true
+		digests = append(digests, container.Digest)
+	}
+	for digest := range container.RunningDigests {
+		if !seen[digest] {
+			seen[digest] = true
+			digests = append(digests, digest)
+		}
+	}
+	sort.Strings(digests)
+	return digests
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+)
+
+// WatchForNewImages checks images as soon as they appear in the cluster until the context is done
+func WatchForNewImages(ctx context.Context, config config.Config) {
+	ctx = kubernetes.WithOptions(ctx, config.GetKubernetesOptions())
+	err := kubernetes.WatchImages(config.Namespaces, config.RunningLocally(), config.GetKubernetesOptions(), config.GetRescanDebounce(), config.GetRescanMaxWait(), ctx.Done(), func(containers []kubernetes.Container) {
+		checkNewImages(ctx, config, containers)
+	})
+	if err != nil {
+		logger.WithError(err).Error("Could not watch for new images")
+	}
+}
+
+// checkNewImages checks the images that are not known yet and adds them to the web data, every check has the scan deadline
+// The registries get the same credentials as the scan, so the new images of private registries can be checked as well
+func checkNewImages(ctx context.Context, config config.Config, containers []kubernetes.Container) {
+	webDataLock.RLock()
+	known := map[string]bool{}
+	for _, info := range WebDataVar.ContainerInfo {
+		known[imageKey(info.Container)] = true
+	}
+	webDataLock.RUnlock()
+
+	var newContainers []kubernetes.Container
+	for _, container := range uniqueContainers(containers) {
+		if !known[imageKey(container)] {
+			newContainers = append(newContainers, container)
+		}
+	}
+	if len(newContainers) == 0 {
+		return
+	}
+
+	logger.WithField("images", len(newContainers)).Info("Checking new images")
+	ctx, cancel := context.WithTimeout(ctx, config.Timeouts.GetScanTimeout())
+	defer cancel()
+	problems := &scanProblems{}
+	noProgress := func(string, int, int) {}
+	imageRegistries := scanRegistries(config, getPullSecretCredentials(ctx, newContainers, config, problems), problems)
+	info := getLatestVersionsForContainers(ctx, newContainers, imageRegistries, config.Workers.Images, problems, noProgress)
+	info = getVulnerabilities(ctx, info, config, imageRegistries, problems, noProgress)
+
+	webDataLock.Lock()
+	defer webDataLock.Unlock()
+	WebDataVar.ContainerInfo = append(WebDataVar.ContainerInfo, info...)
+	sortContainerInfo(WebDataVar.ContainerInfo)
+	WebDataVar.Problems = append(WebDataVar.Problems, problems.problems...)
+	sortProblems(WebDataVar.Problems)
+	WebDataVar.LastTimeFetched = time.Now().Format("15:04:05 02-01-2006")
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/internal/config"
+	"github.com/arminc/k8s-platform-lcm/pkg/kubernetes"
+	"github.com/arminc/k8s-platform-lcm/pkg/registries"
+)
+
+func TestCheckNewImagesUsesTheCredentialsOfTheScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"tags": ["1.0", "1.1"]}`))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	dir, err := ioutil.TempDir("", "lcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths": {"`+host+`": {"auth": "`+auth+`"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dockerConfig := os.Getenv("DOCKER_CONFIG")
+	os.Setenv("DOCKER_CONFIG", dir)
+	defer os.Setenv("DOCKER_CONFIG", dockerConfig)
+
+	var conf config.Config
+	conf.CliFlags.Locally = true
+	conf.Timeouts.Scan = "1m"
+	conf.Workers.Images = 1
+	conf.Workers.Vulnerabilities = 1
+	conf.ImageRegistries.OverrideRegistries = []registries.OverrideRegistry{{
+		Urls:     []string{host},
+		Registry: registries.ImageRegistry{Name: host, URL: server.URL, AuthType: registries.AuthTypeBasic},
+	}}
+	container, err := kubernetes.ImageStringToContainerStruct(host + "/team/app:1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	WebDataVar = WebData{}
+	defer func() { WebDataVar = WebData{} }()
+
+	checkNewImages(context.Background(), conf, []kubernetes.Container{container})
+	if len(WebDataVar.ContainerInfo) != 1 || WebDataVar.ContainerInfo[0].LatestVersion != "1.1" || len(WebDataVar.Problems) != 0 {
+		t.Errorf("Expected the latest version 1.1 with the credentials of the docker config but got %v and %v", WebDataVar.ContainerInfo, WebDataVar.Problems)
+	}
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var logger = log.WithField("component", "audit")
+
+// Config contains the settings of the audit log, without a path no calls are recorded
+// Every external call of a scan is appended as a json line to the file at the path
+type Config struct {
+	Path string `koanf:"path"`
+}
+
+// Entry is a single external call in the audit log
+type Entry struct {
+	Time       string `json:"time"`
+	Run        string `json:"run"`
+	Component  string `json:"component"`
+	Purpose    string `json:"purpose"`
+	Method     string `json:"method"`
+	Endpoint   string `json:"endpoint"`
+	Status     int    `json:"status,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Credential string `json:"credential"`
+	Error      string `json:"error,omitempty"`
+}
+
+var (
+	mu     sync.Mutex
+	writer io.WriteCloser
+	run    string
+)
+
+// Configure opens the audit log from the config, the entries are appended to an existing file
+func Configure(config Config) error {
+	var opened io.WriteCloser
+	if config.Path != "" {
+		file, err := os.OpenFile(config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("Could not open the audit log [%s]: %w", config.Path, err)
+		}
+		opened = file
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if writer != nil {
+		writer.Close()
+	}
+	writer = opened
+	return nil
+}
+
+// StartRun starts a new run, all the entries until the next run are recorded with the start time of the run
+func StartRun(start time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	run = start.UTC().Format(time.RFC3339Nano)
+}
+
+type purposeKey struct{}
+
+// WithPurpose returns the context with the reason for the calls made with it, like the image the tags are fetched for
+func WithPurpose(ctx context.Context, purpose string) context.Context {
+	return context.WithValue(ctx, purposeKey{}, purpose)
+}
+
+// Purpose returns the purpose of the calls made with the context, it is empty when none was set
+func Purpose(ctx context.Context) string {
+	purpose, _ := ctx.Value(purposeKey{}).(string)
+	return purpose
+}
+
+// Enabled returns true when the calls are recorded
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return writer != nil
+}
+
+// RecordRequest records the http call of the component, the response is nil when the call failed
+func RecordRequest(component string, req *http.Request, resp *http.Response, err error, start time.Time) {
+	if !Enabled() {
+		return
+	}
+	entry := Entry{
+		Component:  component,
+		Purpose:    Purpose(req.Context()),
+		Method:     req.Method,
+		Endpoint:   req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		Credential: Credential(req),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
+	Record(entry, err, start)
+}
+
+// Record adds the entry to the audit log, the time, run, duration and the error are filled in
+func Record(entry Entry, err error, start time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	if writer == nil {
+		return
+	}
+	entry.Time = start.UTC().Format(time.RFC3339Nano)
+	entry.Run = run
+	entry.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	line, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		logger.WithError(marshalErr).Warn("Could not record the call in the audit log")
+		return
+	}
+	if _, writeErr := writer.Write(append(line, '\n')); writeErr != nil {
+		logger.WithError(writeErr).Warn("Could not record the call in the audit log")
+	}
+}
+
+// Credential returns the identity that was used for the request without the secret itself,
+// the username for basic auth, the auth scheme for tokens and anonymous without credentials
+func Credential(req *http.Request) string {
+	if username, _, ok := req.BasicAuth(); ok {
+		return "basic:" + username
+	}
+	authorization := req.Header.Get("Authorization")
+	if authorization == "" {
+		return "anonymous"
+	}
+	return strings.ToLower(strings.SplitN(authorization, " ", 2)[0])
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallsAreRecordedWithoutSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "audit.log")
+	if err := Configure(Config{Path: path}); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(Config{})
+	start := time.Now()
+	StartRun(start)
+
+	req, _ := http.NewRequestWithContext(WithPurpose(context.Background(), "latest version of image nginx"), "GET", "https://registry.local/v2/nginx/tags/list?n=100", nil)
+	req.SetBasicAuth("robot", "secret")
+	RecordRequest("registry", req, &http.Response{StatusCode: 200}, nil, start)
+	req, _ = http.NewRequest("GET", "https://scanner.local/api", nil)
+	RecordRequest("scanner", req, nil, errors.New("connection refused"), start)
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Errorf("Expected no secrets in the audit log but got %s", content)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries but got %d", len(lines))
+	}
+	var entry Entry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	expected := Entry{Time: entry.Time, Run: start.UTC().Format(time.RFC3339Nano), Component: "registry", Purpose: "latest version of image nginx",
+		Method: "GET", Endpoint: "https://registry.local/v2/nginx/tags/list", Status: 200, DurationMs: entry.DurationMs, Credential: "basic:robot"}
+	if entry != expected {
+		t.Errorf("Expected %+v but got %+v", expected, entry)
+	}
+	entry = Entry{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Credential != "anonymous" || entry.Error != "connection refused" || entry.Status != 0 {
+		t.Errorf("Expected an anonymous failed call but got %+v", entry)
+	}
+}
@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/metrics"
+	"github.com/arminc/k8s-platform-lcm/pkg/stats"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// TypeNone disables the cache
+	TypeNone = "none"
+	// TypeMemory caches in memory, it is only shared within a single lcm process
+	TypeMemory = "memory"
+	// TypeFile caches in files in a directory, it can be shared between runs on the same machine or CI cache
+	TypeFile = "file"
+	// TypeRedis caches in Redis, it can be shared between lcm replicas
+	TypeRedis = "redis"
+)
+
+var logger = log.WithField("component", "cache")
+
+// Cache stores values by key for a limited time
+type Cache interface {
+	// Get returns the value of the key, false when the key doesn't exist or is expired
+	Get(key string) ([]byte, bool, error)
+	// Set stores the value of the key for the ttl
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// Config contains the settings of the cache used for registry tags, release lookups and scan results
+type Config struct {
+	Type  string      `koanf:"type"`
+	TTL   string      `koanf:"ttl"`
+	File  FileConfig  `koanf:"file"`
+	Redis RedisConfig `koanf:"redis"`
+}
+
+var (
+	mu      sync.RWMutex
+	backend Cache
+	ttl     time.Duration
+	// configuredByConfigure is true when the backend was created by Configure instead of passed to Use
+	configuredByConfigure bool
+)
+
+// Configure creates the cache backend from the config, without a type the cache is disabled
+func Configure(config Config) error {
+	configured, configuredTTL, err := New(config)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// the connections of a replaced Redis cache are closed, a cache of Use belongs to the program that embeds lcm
+	if closer, ok := backend.(io.Closer); ok && configuredByConfigure {
+		closer.Close()
+	}
+	backend = configured
+	ttl = configuredTTL
+	configuredByConfigure = true
+	return nil
+}
+
+// New creates the cache backend and the ttl of its values from the config without changing the cache of Configure,
+// without a type the cache is nil
+func New(config Config) (Cache, time.Duration, error) {
+	var configured Cache
+	switch config.Type {
+	case "", TypeNone:
+	case TypeMemory:
+		configured = NewMemory()
+	case TypeFile:
+		file, err := NewFile(config.File.Path)
+		if err != nil {
+			return nil, 0, err
+		}
+		configured = file
+	case TypeRedis:
+		redis, err := NewRedis(config.Redis)
+		if err != nil {
+			return nil, 0, err
+		}
+		configured = redis
+	default:
+		return nil, 0, fmt.Errorf("Cache type [%s] not valid, use one of none, memory, file or redis", config.Type)
+	}
+
+	configuredTTL := time.Hour
+	if config.TTL != "" {
+		var err error
+		configuredTTL, err = time.ParseDuration(config.TTL)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Cache ttl [%s] not valid: %w", config.TTL, err)
+		}
+	}
+	return configured, configuredTTL, nil
+}
+
+// Use replaces the cache backend with the cache, for programs that embed lcm and have their own cache. A nil cache disables it
+func Use(c Cache, valueTTL time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	backend = c
+	ttl = valueTTL
+	configuredByConfigure = false
+}
+
+type cacheKey struct{}
+
+// contextCache is the cache of a context with the ttl of its values
+type contextCache struct {
+	cache Cache
+	ttl   time.Duration
+}
+
+// WithCache returns a context that makes all lookups with the context use the cache instead of the cache of Configure,
+// a nil cache disables the cache for them
+func WithCache(ctx context.Context, c Cache, valueTTL time.Duration) context.Context {
+	return context.WithValue(ctx, cacheKey{}, contextCache{cache: c, ttl: valueTTL})
+}
+
+// from returns the cache of the context with its ttl, the cache of Configure when the context has none
+func from(ctx context.Context) (Cache, time.Duration) {
+	if c, ok := ctx.Value(cacheKey{}).(contextCache); ok {
+		return c.cache, c.ttl
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return backend, ttl
+}
+
+// GetJSON decodes the cached value of the key into value and returns true when it was found,
+// the lookup is counted for the provider the value belongs to like the registry or scanner
+// Problems with the cache are logged and treated as a miss so they never fail the scan
+func GetJSON(ctx context.Context, provider, key string, value interface{}) bool {
+	c, _ := from(ctx)
+	if c == nil {
+		return false
+	}
+
+	data, found, err := c.Get(key)
+	if err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not read from the cache")
+	}
+	if !found || err != nil {
+		stats.Inc(stats.CacheMisses)
+		metrics.CacheLookup(provider, false)
+		return false
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not decode the cached value")
+		stats.Inc(stats.CacheMisses)
+		metrics.CacheLookup(provider, false)
+		return false
+	}
+	stats.Inc(stats.CacheHits)
+	metrics.CacheLookup(provider, true)
+	logger.WithField("key", key).Debug("Found in the cache")
+	return true
+}
+
+// GetBytes returns the cached value of the key without counting the lookup in the cache hit rate,
+// problems with the cache are logged and treated as a miss
+func GetBytes(ctx context.Context, key string) ([]byte, bool) {
+	c, _ := from(ctx)
+	if c == nil {
+		return nil, false
+	}
+
+	data, found, err := c.Get(key)
+	if err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not read from the cache")
+		return nil, false
+	}
+	return data, found
+}
+
+// SetBytes stores the value for the ttl instead of the configured ttl, problems are logged
+func SetBytes(ctx context.Context, key string, value []byte, valueTTL time.Duration) {
+	c, _ := from(ctx)
+	if c == nil {
+		return
+	}
+
+	if err := c.Set(key, value, valueTTL); err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not write to the cache")
+	}
+}
+
+// SetJSON stores the value as json for the configured ttl, problems are logged
+func SetJSON(ctx context.Context, key string, value interface{}) {
+	c, t := from(ctx)
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not encode the value for the cache")
+		return
+	}
+	if err := c.Set(key, data, t); err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not write to the cache")
+	}
+}
@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileCacheExpires(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	file, err := NewFile(dir)
+	if err != nil {
+		t.Fatalf("Could not create cache %v", err)
+	}
+
+	file.Set("tags/quay.io/app", []byte(`["1.0"]`), time.Hour)
+	file.Set("expired", []byte("old"), -time.Second)
+
+	if value, found, _ := file.Get("tags/quay.io/app"); !found || string(value) != `["1.0"]` {
+		t.Errorf("Expected the cached value but got %q %v", value, found)
+	}
+	if _, found, _ := file.Get("expired"); found {
+		t.Errorf("Expected the expired value to be gone")
+	}
+	if _, found, _ := file.Get("unknown"); found {
+		t.Errorf("Expected unknown keys not to be found")
+	}
+}
+
+func TestFileCacheSweepsExpiredKeys(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	file, err := NewFile(dir)
+	if err != nil {
+		t.Fatalf("Could not create cache %v", err)
+	}
+
+	file.Set("current", []byte("new"), time.Hour)
+	file.Set("expired", []byte("old"), -time.Second)
+	abandoned := filepath.Join(dir, ".tmp-abandoned")
+	ioutil.WriteFile(abandoned, []byte("partial"), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(abandoned, old, old)
+
+	if err := file.Sweep(); err != nil {
+		t.Fatalf("Sweep failed %v", err)
+	}
+	if _, err := os.Stat(file.fileName("expired")); !os.IsNotExist(err) {
+		t.Errorf("Expected the file of the expired key to be removed without reading it")
+	}
+	if _, err := os.Stat(abandoned); !os.IsNotExist(err) {
+		t.Errorf("Expected the abandoned temporary file to be removed")
+	}
+	if value, found, _ := file.Get("current"); !found || string(value) != "new" {
+		t.Errorf("Expected the current value to be kept but got %q %v", value, found)
+	}
+}
+
+// fakeRedis answers GET, SET and DEL like Redis without expiry and sends the commands it received to commands
+func fakeRedis(t *testing.T, listener net.Listener, commands chan<- []string) {
+	var mu sync.Mutex
+	values := map[string]string{}
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			var args []string
+			header, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			count, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+			for i := 0; i < count; i++ {
+				reader.ReadString('\n')
+				arg, _ := reader.ReadString('\n')
+				args = append(args, strings.TrimSuffix(arg, "\r\n"))
+			}
+			if commands != nil {
+				commands <- args
+			}
+			mu.Lock()
+			switch strings.ToUpper(args[0]) {
+			case "SET":
+				values[args[1]] = args[2]
+				conn.Write([]byte("+OK\r\n"))
+			case "DEL":
+				delete(values, args[1])
+				conn.Write([]byte(":1\r\n"))
+			case "GET":
+				value, exists := values[args[1]]
+				if !exists {
+					conn.Write([]byte("$-1\r\n"))
+				} else {
+					conn.Write([]byte("$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"))
+				}
+			default:
+				conn.Write([]byte("-ERR unknown command\r\n"))
+			}
+			mu.Unlock()
+		}
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+}
+
+func TestRedisCache(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen %v", err)
+	}
+	defer listener.Close()
+	fakeRedis(t, listener, nil)
+	redis, err := NewRedis(RedisConfig{Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("Could not create cache %v", err)
+	}
+	defer redis.Close()
+
+	if err := redis.Set("key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set failed %v", err)
+	}
+	if value, found, err := redis.Get("key"); err != nil || !found || string(value) != "value" {
+		t.Errorf("Expected the value but got %q %v %v", value, found, err)
+	}
+	if _, found, err := redis.Get("unknown"); err != nil || found {
+		t.Errorf("Expected unknown keys not to be found but got %v %v", found, err)
+	}
+}
+
+func TestRedisCacheNeverSendsAZeroTTL(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen %v", err)
+	}
+	defer listener.Close()
+	commands := make(chan []string, 10)
+	fakeRedis(t, listener, commands)
+	redis, err := NewRedis(RedisConfig{Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("Could not create cache %v", err)
+	}
+	defer redis.Close()
+
+	if err := redis.Set("short", []byte("value"), 500*time.Microsecond); err != nil {
+		t.Fatalf("Set failed %v", err)
+	}
+	if command := <-commands; !reflect.DeepEqual(command, []string{"set", "lcm:short", "value", "px", "1"}) {
+		t.Errorf("Expected the ttl to be rounded up to 1ms but got %v", command)
+	}
+	if err := redis.Set("expired", []byte("value"), 0); err != nil {
+		t.Fatalf("Set failed %v", err)
+	}
+	if command := <-commands; !reflect.DeepEqual(command, []string{"del", "lcm:expired"}) {
+		t.Errorf("Expected the key without ttl to be removed but got %v", command)
+	}
+}
+
+func TestRedisCacheOverTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: server.TLS.Certificates})
+	if err != nil {
+		t.Fatalf("Could not listen %v", err)
+	}
+	defer listener.Close()
+	fakeRedis(t, listener, nil)
+	redis, err := NewRedis(RedisConfig{Address: listener.Addr().String(), TLS: RedisTLSConfig{Enabled: true, RootCAs: []string{caFile}}})
+	if err != nil {
+		t.Fatalf("Could not create cache %v", err)
+	}
+	defer redis.Close()
+
+	if err := redis.Set("key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set over TLS failed %v", err)
+	}
+	if value, found, err := redis.Get("key"); err != nil || !found || string(value) != "value" {
+		t.Errorf("Expected the value over TLS but got %q %v %v", value, found, err)
+	}
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileConfig contains the settings of the file cache
+type FileConfig struct {
+	Path string `koanf:"path"`
+}
+
+// File is a cache that stores every key in its own file in a directory
+// It doesn't use a single database file like bbolt, because bbolt locks its file for one process while the directory is shared by
+// concurrent CI runs and by the shards on a shared volume. Every file is replaced atomically, so no lock is needed between them
+// The modification time of a file is its expiry time, so expired files are removed by sweeping the directory without reading them
+type File struct {
+	path string
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// sweepInterval is the minimum time between two sweeps of the expired files
+const sweepInterval = 10 * time.Minute
+
+// NewFile creates the file cache and its directory and removes the expired files in the background
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Cache path is required for the file cache")
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("Could not create the cache directory [%s]: %w", path, err)
+	}
+	f := &File{path: path, lastSweep: time.Now()}
+	go f.Sweep()
+	return f, nil
+}
+
+// Get returns the value of the key, false when the key doesn't exist or is expired
+func (f *File) Get(key string) ([]byte, bool, error) {
+	data, err := ioutil.ReadFile(f.fileName(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false, fmt.Errorf("Cache file for [%s] is corrupt: %w", key, err)
+	}
+	if e.expired() {
+		os.Remove(f.fileName(key))
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+// Set stores the value of the key for the ttl, the file is replaced atomically so concurrent runs never read a partial file
+func (f *File) Set(key string, value []byte, ttl time.Duration) error {
+	data, err := json.Marshal(entry{Expires: time.Now().Add(ttl), Value: value})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(f.path, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	expires := time.Now().Add(ttl)
+	if err := os.Chtimes(tmp.Name(), expires, expires); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.fileName(key)); err != nil {
+		return err
+	}
+	f.sweepIfDue()
+	return nil
+}
+
+// sweepIfDue removes the expired files in the background when the last sweep is longer than the sweep interval ago
+func (f *File) sweepIfDue() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.lastSweep) < sweepInterval {
+		return
+	}
+	f.lastSweep = time.Now()
+	go f.Sweep()
+}
+
+// Sweep removes the files of the expired keys and the temporary files of writes that didn't finish, so keys that are never
+// read again don't keep using disk space
+func (f *File) Sweep() error {
+	files, err := ioutil.ReadDir(f.path)
+	if err != nil {
+		return fmt.Errorf("Could not read the cache directory [%s]: %w", f.path, err)
+	}
+	now := time.Now()
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		// temporary files get their expiry time only when they are complete, so only old ones are left behind
+		abandoned := strings.HasPrefix(file.Name(), ".tmp-") && now.Sub(file.ModTime()) > sweepInterval
+		if abandoned || (!strings.HasPrefix(file.Name(), ".tmp-") && now.After(file.ModTime())) {
+			os.Remove(filepath.Join(f.path, file.Name()))
+		}
+	}
+	return nil
+}
+
+func (f *File) fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.path, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory is a cache that only lives as long as the lcm process
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// entry is a cached value with its expiry time
+type entry struct {
+	Expires time.Time
+	Value   []byte
+}
+
+func (e entry) expired() bool {
+	return time.Now().After(e.Expires)
+}
+
+// NewMemory creates an empty memory cache
+func NewMemory() *Memory {
+	return &Memory{entries: map[string]entry{}}
+}
+
+// Get returns the value of the key, false when the key doesn't exist or is expired
+func (m *Memory) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, exists := m.entries[key]
+	if !exists || e.expired() {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+// Set stores the value of the key for the ttl
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry{Expires: time.Now().Add(ttl), Value: value}
+	return nil
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisConfig contains the settings of the Redis cache
+type RedisConfig struct {
+	Address  string         `koanf:"address"`
+	Username string         `koanf:"username"` // Only needed for Redis 6 ACL users
+	Password string         `koanf:"password"`
+	DB       int            `koanf:"db"`
+	Prefix   string         `koanf:"prefix"`
+	PoolSize int            `koanf:"poolSize"` // Defaults to 10 connections per CPU
+	TLS      RedisTLSConfig `koanf:"tls"`
+}
+
+// RedisTLSConfig contains the TLS settings of the connections to Redis
+type RedisTLSConfig struct {
+	Enabled            bool     `koanf:"enabled"`
+	RootCAs            []string `koanf:"rootCAs"` // Added to the system root CAs
+	ServerName         string   `koanf:"serverName"`
+	InsecureSkipVerify bool     `koanf:"insecureSkipVerify"`
+}
+
+// Redis is a cache that stores the keys in Redis so it can be shared between lcm replicas, the connections are pooled
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// redisTimeout is used to connect, read and write
+const redisTimeout = 5 * time.Second
+
+// NewRedis creates the Redis cache, the connections are made on first use
+func NewRedis(config RedisConfig) (*Redis, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("Cache address is required for the redis cache")
+	}
+	if config.Prefix == "" {
+		config.Prefix = "lcm:"
+	}
+	options := &redis.Options{
+		Addr:         config.Address,
+		Username:     config.Username,
+		Password:     config.Password,
+		DB:           config.DB,
+		PoolSize:     config.PoolSize,
+		DialTimeout:  redisTimeout,
+		ReadTimeout:  redisTimeout,
+		WriteTimeout: redisTimeout,
+	}
+	if config.TLS.Enabled {
+		tlsConfig, err := config.TLS.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		options.TLSConfig = tlsConfig
+	}
+	return &Redis{client: redis.NewClient(options), prefix: config.Prefix}, nil
+}
+
+func (c RedisTLSConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if len(c.RootCAs) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range c.RootCAs {
+			pem, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Could not read the Redis root CA [%s]: %w", file, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("Redis root CA [%s] contains no certificates", file)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// Get returns the value of the key, false when the key doesn't exist or is expired
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	value, err := r.client.Get(context.Background(), r.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores the value of the key for the ttl, Redis expires keys in milliseconds so a shorter ttl is rounded up to one
+// millisecond and a ttl that already passed removes the key
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return r.client.Del(context.Background(), r.prefix+key).Err()
+	}
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+	return r.client.Set(context.Background(), r.prefix+key, value, ttl).Err()
+}
+
+// Close closes the connections to Redis
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
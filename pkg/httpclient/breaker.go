@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/stats"
+)
+
+// CircuitBreaker contains the settings for skipping endpoints that keep failing, the breaker is per host
+// After the failure threshold of consecutive failed calls the host is skipped for the open duration, then a single call is tried again
+// A failure threshold of 0 disables the breaker, the open duration is in time.Duration format like 30s
+type CircuitBreaker struct {
+	FailureThreshold int    `koanf:"failureThreshold"`
+	OpenDuration     string `koanf:"openDuration"`
+}
+
+// ErrCircuitOpen is returned for calls to a host that is skipped because it keeps failing
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type breaker struct {
+	threshold    int
+	openDuration time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func (c CircuitBreaker) newBreaker() (*breaker, error) {
+	if c.FailureThreshold < 1 {
+		return nil, nil
+	}
+	openDuration := 30 * time.Second
+	if c.OpenDuration != "" {
+		duration, err := time.ParseDuration(c.OpenDuration)
+		if err != nil {
+			return nil, fmt.Errorf("Circuit breaker open duration [%s] not valid: %w", c.OpenDuration, err)
+		}
+		openDuration = duration
+	}
+	return &breaker{threshold: c.FailureThreshold, openDuration: openDuration, hosts: map[string]*hostState{}}, nil
+}
+
+// allow returns an error when the host is skipped, when the open duration has passed a single trial call is allowed
+func (b *breaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, exists := b.hosts[host]
+	if !exists || state.failures < b.threshold {
+		return nil
+	}
+	if time.Now().Before(state.openUntil) || state.trial {
+		return fmt.Errorf("Endpoint [%s] is degraded, skipping the call: %w", host, ErrCircuitOpen)
+	}
+	state.trial = true
+	return nil
+}
+
+// record counts the result of the call, a success closes the breaker and a failure at the threshold opens it
+func (b *breaker) record(host string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, exists := b.hosts[host]
+	if !exists {
+		state = &hostState{}
+		b.hosts[host] = state
+	}
+	state.trial = false
+	if !failed {
+		state.failures = 0
+		return
+	}
+	state.failures++
+	if state.failures >= b.threshold {
+		if state.failures == b.threshold {
+			logger.WithField("host", host).WithField("failures", state.failures).WithField("openDuration", b.openDuration).Warn("Endpoint keeps failing, skipping it")
+		}
+		state.openUntil = time.Now().Add(b.openDuration)
+		stats.AddDegraded(host)
+	}
+}
+
+func (b *breaker) endTrial(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if state, exists := b.hosts[host]; exists {
+		state.trial = false
+	}
+}
+
+// send calls the host unless the breaker of the host is open
+func (b *breaker) send(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if b == nil {
+		return send(req)
+	}
+	host := req.URL.Host
+	if err := b.allow(host); err != nil {
+		return nil, err
+	}
+	resp, err := send(req)
+	if req.Context().Err() != nil {
+		// a cancelled call says nothing about the endpoint, an unfinished trial is allowed again
+		b.endTrial(host)
+		return resp, err
+	}
+	b.record(host, err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	return resp, err
+}
@@ -0,0 +1,42 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerSkipsFailingHost(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	b, _ := CircuitBreaker{FailureThreshold: 2, OpenDuration: "20ms"}.newBreaker()
+	get := func() error {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := b.send(req, http.DefaultTransport.RoundTrip)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	get()
+	get()
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Fatalf("Expected the host to be skipped after 2 calls but got %v after %v calls", err, calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := get(); err != nil || calls != 3 {
+		t.Fatalf("Expected a trial call after the open duration but got %v after %v calls", err, calls)
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) || calls != 3 {
+		t.Errorf("Expected the host to be skipped again after the failed trial but got %v after %v calls", err, calls)
+	}
+}
@@ -0,0 +1,153 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/stats"
+)
+
+// ConditionalRequests contains the settings for replaying the ETag and Last-Modified validators of earlier responses,
+// when the server answers 304 Not Modified the stored response is used. The responses are stored in the cache so it needs a cache type
+// The ttl is in time.Duration format like 168h, the max body size is in megabytes
+type ConditionalRequests struct {
+	Enabled     bool   `koanf:"enabled"`
+	TTL         string `koanf:"ttl"`
+	MaxBodySize int    `koanf:"maxBodySize"`
+}
+
+type conditional struct {
+	ttl         time.Duration
+	maxBodySize int64
+}
+
+// storedResponse is a response with validators as it is stored in the cache
+type storedResponse struct {
+	ETag         string
+	LastModified string
+	Header       http.Header
+	Body         []byte
+}
+
+func (c ConditionalRequests) newConditional() (*conditional, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	ttl := 7 * 24 * time.Hour
+	if c.TTL != "" {
+		duration, err := time.ParseDuration(c.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("Conditional requests ttl [%s] not valid: %w", c.TTL, err)
+		}
+		ttl = duration
+	}
+	maxBodySize := 5
+	if c.MaxBodySize > 0 {
+		maxBodySize = c.MaxBodySize
+	}
+	return &conditional{ttl: ttl, maxBodySize: int64(maxBodySize) << 20}, nil
+}
+
+// send adds the validators of the stored response to GET requests and returns the stored response when the server answers 304,
+// new responses with validators are stored. Without the conditional settings the request is sent as is
+func (c *conditional) send(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if c == nil || req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return next(req)
+	}
+
+	key := "http/" + req.URL.String()
+	stored, found := c.load(req.Context(), key)
+	if found {
+		// a round tripper must not modify the request so the validators are set on a copy
+		req = req.Clone(req.Context())
+		if stored.ETag != "" {
+			req.Header.Set("If-None-Match", stored.ETag)
+		}
+		if stored.LastModified != "" {
+			req.Header.Set("If-Modified-Since", stored.LastModified)
+		}
+	}
+
+	resp, err := next(req)
+	if err != nil {
+		return resp, err
+	}
+	if found && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		stats.Inc(stats.NotModified)
+		logger.WithField("url", req.URL.String()).Debug("Not modified, using the stored response")
+		return stored.response(req), nil
+	}
+	if resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+		return c.store(req.Context(), key, resp)
+	}
+	return resp, nil
+}
+
+func (c *conditional) load(ctx context.Context, key string) (storedResponse, bool) {
+	var stored storedResponse
+	data, found := cache.GetBytes(ctx, key)
+	if !found {
+		return stored, false
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not decode the stored response")
+		return stored, false
+	}
+	return stored, true
+}
+
+// store reads the body and stores the response, bodies larger than the max body size are passed on without storing them
+func (c *conditional) store(ctx context.Context, key string, resp *http.Response) (*http.Response, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.maxBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > c.maxBodySize {
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	data, err := json.Marshal(storedResponse{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Header:       resp.Header,
+		Body:         body,
+	})
+	if err != nil {
+		logger.WithError(err).WithField("key", key).Warn("Could not encode the response")
+		return resp, nil
+	}
+	cache.SetBytes(ctx, key, data, c.ttl)
+	return resp, nil
+}
+
+// response returns the stored response as the response of the request
+func (s storedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        s.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(s.Body)),
+		ContentLength: int64(len(s.Body)),
+		Request:       req,
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
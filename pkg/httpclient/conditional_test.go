@@ -0,0 +1,48 @@
+package httpclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+)
+
+func TestConditionalRequestUsesStoredResponseWhenNotModified(t *testing.T) {
+	calls, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("tags"))
+	}))
+	defer server.Close()
+
+	cache.Configure(cache.Config{Type: cache.TypeMemory})
+	defer cache.Configure(cache.Config{})
+	c, _ := ConditionalRequests{Enabled: true}.newConditional()
+	get := func() (int, string) {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := c.send(req, http.DefaultTransport.RoundTrip)
+		if err != nil {
+			t.Fatalf("Expected no error but got [%v]", err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	for i := 0; i < 2; i++ {
+		if status, body := get(); status != http.StatusOK || body != "tags" {
+			t.Errorf("Expected the body [tags] with status 200 but got [%s] with status %d", body, status)
+		}
+	}
+	if calls != 2 || notModified != 1 {
+		t.Errorf("Expected 2 calls of which 1 not modified but got %d calls and %d not modified", calls, notModified)
+	}
+}
@@ -0,0 +1,27 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithContext returns a copy of the client that cancels all its requests when the context is done,
+// it is used for libraries that don't accept a context themselves
+func WithContext(ctx context.Context, client *http.Client) *http.Client {
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c := *client
+	c.Transport = contextTransport{ctx: ctx, transport: transport}
+	return &c
+}
+
+type contextTransport struct {
+	ctx       context.Context
+	transport http.RoundTripper
+}
+
+func (c contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.transport.RoundTrip(req.WithContext(c.ctx))
+}
@@ -0,0 +1,363 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/metrics"
+	"github.com/arminc/k8s-platform-lcm/pkg/stats"
+	"github.com/arminc/k8s-platform-lcm/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	// Registry is the component for image and chart registries
+	Registry = "registry"
+	// Scanner is the component for vulnerability scanners
+	Scanner = "scanner"
+	// Tool is the component for tool registries like GitHub
+	Tool = "tool"
+	// Exporter is the component for the systems the results are exported to like Dependency-Track
+	Exporter = "exporter"
+	// Kubernetes is the component for the Kubernetes API, it only uses the retry settings
+	Kubernetes = "kubernetes"
+)
+
+var logger = log.WithField("component", "httpclient")
+
+// Config contains the settings for all the outbound http calls, components inherit them unless overridden
+type Config struct {
+	Proxy               string              `koanf:"proxy"`
+	NoProxy             string              `koanf:"noProxy"`
+	MinTLSVersion       string              `koanf:"minTLSVersion"`
+	RootCAs             []string            `koanf:"rootCAs"`
+	UserAgent           string              `koanf:"userAgent"`           // Only used globally, defaults to lcm/version/cluster
+	RateLimits          RateLimits          `koanf:"rateLimits"`          // Only used globally, the limits are shared by all components
+	CircuitBreaker      CircuitBreaker      `koanf:"circuitBreaker"`      // Only used globally, the breakers are per host and shared by all components
+	ConditionalRequests ConditionalRequests `koanf:"conditionalRequests"` // Only used globally
+	AdaptiveRateLimits  AdaptiveRateLimits  `koanf:"adaptiveRateLimits"`  // Only used globally, the limits of the hosts are shared by all components
+	Retry               Retry               `koanf:"retry"`
+	Overrides           map[string]Config   `koanf:"overrides"`
+	Hosts               []HostTLS           `koanf:"hosts"` // Only used globally
+}
+
+// HostTLS contains the TLS settings for a single registry or scanner host, like a registry with a certificate of an internal CA
+type HostTLS struct {
+	Host               string   `koanf:"host"`    // Can include the port, without a port it is used for all ports of the host
+	RootCAs            []string `koanf:"rootCAs"` // Added to the root CAs of the component
+	InsecureSkipVerify bool     `koanf:"insecureSkipVerify"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Transports are the transports of all components created from a config with their retries, rate limits and circuit breakers,
+// programs that embed lcm can create their own with New and use them in the http clients they pass to the registries and scanners
+type Transports struct {
+	transports     map[string]http.RoundTripper
+	hostTransports map[string]map[string]http.RoundTripper
+	retries        map[string]retryPolicy
+	fallback       http.RoundTripper
+	limits         *limiter
+	breakers       *breaker
+	conditionals   *conditional
+	quotas         *quota
+	userAgent      string
+}
+
+var (
+	mu sync.RWMutex
+	// configured are the transports of Configure that the transports of Transport use
+	configured = &Transports{
+		transports:     map[string]http.RoundTripper{},
+		hostTransports: map[string]map[string]http.RoundTripper{},
+		retries:        map[string]retryPolicy{},
+		fallback:       http.DefaultTransport,
+	}
+)
+
+// DefaultUserAgent returns the user agent that identifies lcm as lcm/version/cluster, without a cluster name it is lcm/version
+func DefaultUserAgent(version, cluster string) string {
+	if cluster == "" {
+		return "lcm/" + version
+	}
+	return "lcm/" + version + "/" + cluster
+}
+
+// UserAgent returns the configured user agent that is set on all outbound calls
+func UserAgent() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return configured.userAgent
+}
+
+// Configure creates the transports for all components from the config, the transports of Transport use them from then on
+func Configure(config Config) error {
+	transports, err := New(config)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	configured = transports
+	return nil
+}
+
+// New creates the transports for all components from the config without changing the ones of Configure
+func New(config Config) (*Transports, error) {
+	defaultTransport, err := config.newTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	transports := map[string]http.RoundTripper{}
+	configuredHosts := map[string]map[string]http.RoundTripper{}
+	for _, component := range []string{Registry, Scanner, Tool, Exporter} {
+		componentConfig := config
+		override, exists := config.Overrides[component]
+		if exists {
+			componentConfig = config.merge(override)
+			transport, err := componentConfig.newTransport()
+			if err != nil {
+				return nil, fmt.Errorf("Http settings for [%s] not valid: %w", component, err)
+			}
+			transports[component] = transport
+		} else {
+			transports[component] = defaultTransport
+		}
+
+		configuredHosts[component] = map[string]http.RoundTripper{}
+		for _, hostTLS := range config.Hosts {
+			transport, err := componentConfig.newHostTransport(hostTLS)
+			if err != nil {
+				return nil, fmt.Errorf("Http settings for host [%s] not valid: %w", hostTLS.Host, err)
+			}
+			configuredHosts[component][hostTLS.Host] = transport
+		}
+	}
+	for _, hostTLS := range config.Hosts {
+		if hostTLS.InsecureSkipVerify {
+			logger.WithField("host", hostTLS.Host).Warn("TLS certificates of the host are not verified")
+		}
+	}
+
+	policies := map[string]retryPolicy{}
+	for _, component := range []string{Registry, Scanner, Tool, Exporter, Kubernetes} {
+		policy, err := config.Retry.merge(config.Overrides[component].Retry).newPolicy()
+		if err != nil {
+			return nil, fmt.Errorf("Http settings for [%s] not valid: %w", component, err)
+		}
+		policies[component] = policy
+	}
+
+	configuredBreakers, err := config.CircuitBreaker.newBreaker()
+	if err != nil {
+		return nil, err
+	}
+
+	configuredConditionals, err := config.ConditionalRequests.newConditional()
+	if err != nil {
+		return nil, err
+	}
+
+	configuredQuotas, err := config.AdaptiveRateLimits.newQuota()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transports{
+		transports:     transports,
+		hostTransports: configuredHosts,
+		retries:        policies,
+		fallback:       defaultTransport,
+		limits:         newLimiter(config.RateLimits),
+		breakers:       configuredBreakers,
+		conditionals:   configuredConditionals,
+		quotas:         configuredQuotas,
+		userAgent:      config.UserAgent,
+	}, nil
+}
+
+// Transport returns the transport for the component, it always uses the latest settings of Configure
+// Every attempt of a retried call goes through the rate limits and is counted separately
+func Transport(component string) http.RoundTripper {
+	return componentTransport{component: component}
+}
+
+// Transport returns the transport for the component with these transports, like Transport does with the ones of Configure
+func (t *Transports) Transport(component string) http.RoundTripper {
+	return componentTransport{component: component, transports: t}
+}
+
+// componentTransport sends the calls of the component through the transports, through the ones of Configure without transports
+type componentTransport struct {
+	component  string
+	transports *Transports
+}
+
+func (c componentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := c.transports
+	if t == nil {
+		mu.RLock()
+		t = configured
+		mu.RUnlock()
+	}
+	transport, exists := t.transports[c.component]
+	if !exists {
+		transport = t.fallback
+	}
+	if hostTransport, exists := hostTransportFor(t.hostTransports[c.component], req.URL); exists {
+		transport = hostTransport
+	}
+	limiter, breaker, conditional, quota, agent := t.limits, t.breakers, t.conditionals, t.quotas, t.userAgent
+	policy, exists := t.retries[c.component]
+	if !exists {
+		policy = noRetries
+	}
+
+	ctx, span := tracing.StartClient(req.Context(), req.Method+" "+req.URL.Host)
+	defer span.End()
+	req = req.Clone(ctx)
+	if agent != "" {
+		req.Header.Set("User-Agent", agent)
+	}
+	span.SetAttribute("lcm.component", c.component)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+	resp, err := conditional.send(req, func(req *http.Request) (*http.Response, error) {
+		return policy.do(req, func(req *http.Request) (*http.Response, error) {
+			return breaker.send(req, func(req *http.Request) (*http.Response, error) {
+				if limiter != nil {
+					if err := limiter.wait(req); err != nil {
+						return nil, err
+					}
+				}
+				if err := quota.wait(req); err != nil {
+					return nil, err
+				}
+
+				stats.Inc(stats.Requests)
+				stats.IncHost(req.URL.Host)
+				start := time.Now()
+				resp, err := transport.RoundTrip(req)
+				audit.RecordRequest(c.component, req, resp, err, start)
+				quota.observe(req, resp)
+				failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+				if failed {
+					stats.Inc(stats.RequestFailures)
+				}
+				metrics.ObserveCall(c.component, req.URL.Host, start, failed)
+				return resp, err
+			})
+		})
+	})
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.SetAttribute("http.status_code", strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("Response code was [%v]", resp.StatusCode))
+		}
+	}
+	return resp, err
+}
+
+// hostTransportFor returns the transport of the host with the port, or else of the host without the port
+func hostTransportFor(hosts map[string]http.RoundTripper, u *url.URL) (http.RoundTripper, bool) {
+	if transport, exists := hosts[u.Host]; exists {
+		return transport, true
+	}
+	transport, exists := hosts[u.Hostname()]
+	return transport, exists
+}
+
+// merge returns the config with the settings of the override on top
+func (c Config) merge(override Config) Config {
+	merged := c
+	if override.Proxy != "" {
+		merged.Proxy = override.Proxy
+	}
+	if override.NoProxy != "" {
+		merged.NoProxy = override.NoProxy
+	}
+	if override.MinTLSVersion != "" {
+		merged.MinTLSVersion = override.MinTLSVersion
+	}
+	if len(override.RootCAs) > 0 {
+		merged.RootCAs = override.RootCAs
+	}
+	return merged
+}
+
+func (c Config) newTransport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.Proxy != "" {
+		if _, err := url.Parse(c.Proxy); err != nil {
+			return nil, fmt.Errorf("Proxy [%s] not valid: %w", c.Proxy, err)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  c.Proxy,
+			HTTPSProxy: c.Proxy,
+			NoProxy:    c.NoProxy,
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	tlsConfig := &tls.Config{}
+	if c.MinTLSVersion != "" {
+		version, exists := tlsVersions[c.MinTLSVersion]
+		if !exists {
+			return nil, fmt.Errorf("Min TLS version [%s] not valid, use one of 1.0, 1.1, 1.2 or 1.3", c.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if len(c.RootCAs) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			logger.WithError(err).Warn("Could not load the system root CAs, only using the configured root CAs")
+			pool = x509.NewCertPool()
+		}
+		for _, file := range c.RootCAs {
+			pem, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Could not read root CA [%s]: %w", file, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("Root CA [%s] does not contain any PEM certificates", file)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// newHostTransport creates the transport of the config with the root CAs and the verification setting of the host on top
+func (c Config) newHostTransport(hostTLS HostTLS) (*http.Transport, error) {
+	merged := c
+	merged.RootCAs = append(append([]string{}, c.RootCAs...), hostTLS.RootCAs...)
+	transport, err := merged.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = hostTLS.InsecureSkipVerify
+	return transport, nil
+}
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserAgentIsSetOnAllCalls(t *testing.T) {
+	var agent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	if err := Configure(Config{UserAgent: DefaultUserAgent("1.0.0", "production")}); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(Config{})
+	client := &http.Client{Transport: Transport(Registry)}
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("User-Agent", "go-github")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	resp.Body.Close()
+	if agent != "lcm/1.0.0/production" {
+		t.Errorf("Expected the User-Agent lcm/1.0.0/production but got %s", agent)
+	}
+	if req.Header.Get("User-Agent") != "go-github" {
+		t.Errorf("Expected the request of the caller to be unchanged but got %s", req.Header.Get("User-Agent"))
+	}
+}
+
+func TestNewTransportsDoNotChangeTheConfiguredOnes(t *testing.T) {
+	var agent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	if err := Configure(Config{UserAgent: "lcm/1.0.0/production"}); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(Config{})
+	embedded, err := New(Config{UserAgent: "platform/2.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for transport, expected := range map[http.RoundTripper]string{Transport(Registry): "lcm/1.0.0/production", embedded.Transport(Registry): "platform/2.0.0"} {
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("Expected no error but got [%v]", err)
+		}
+		resp.Body.Close()
+		if agent != expected {
+			t.Errorf("Expected the User-Agent %s but got %s", expected, agent)
+		}
+	}
+}
+
+func TestDefaultUserAgentWithoutCluster(t *testing.T) {
+	if agent := DefaultUserAgent("1.0.0", ""); agent != "lcm/1.0.0" {
+		t.Errorf("Expected lcm/1.0.0 but got %s", agent)
+	}
+}
+
+func TestHostTLSSettings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(Config{})
+
+	tests := map[string]struct {
+		hosts   []HostTLS
+		failure bool
+	}{
+		"not configured":       {failure: true},
+		"ca bundle":            {hosts: []HostTLS{{Host: host, RootCAs: []string{caFile}}}},
+		"insecure skip verify": {hosts: []HostTLS{{Host: host, InsecureSkipVerify: true}}},
+		"other host":           {hosts: []HostTLS{{Host: "registry.corp.local", InsecureSkipVerify: true}}, failure: true},
+	}
+	for name, test := range tests {
+		if err := Configure(Config{Hosts: test.hosts}); err != nil {
+			t.Fatal(err)
+		}
+		for _, component := range []string{Registry, Scanner} {
+			client := &http.Client{Transport: Transport(component)}
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if test.failure && err == nil {
+				t.Errorf("Expected a TLS error for %s of %s but got none", component, name)
+			}
+			if !test.failure && err != nil {
+				t.Errorf("Expected no error for %s of %s but got [%v]", component, name, err)
+			}
+		}
+	}
+}
+
+func TestHostTLSWithoutPort(t *testing.T) {
+	transport := &http.Transport{}
+	u, _ := url.Parse("https://registry.corp.local:5000/v2/")
+	if found, exists := hostTransportFor(map[string]http.RoundTripper{"registry.corp.local": transport}, u); !exists || found != transport {
+		t.Errorf("Expected the transport of the host without the port")
+	}
+}
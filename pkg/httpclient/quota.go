@@ -0,0 +1,183 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdaptiveRateLimits contains the settings for following the rate limit headers of the endpoints, like those of Docker Hub and GitHub
+// When the limit of a host is used up or a call got a 429 the calls to the host wait until the limit resets, when the limit is almost used up
+// the calls are spread over the time until the reset. Calls that would wait longer than the max wait fail right away, it is in time.Duration format like 1m
+type AdaptiveRateLimits struct {
+	Enabled bool   `koanf:"enabled"`
+	MaxWait string `koanf:"maxWait"`
+}
+
+// ErrRateLimited is returned for calls to a host whose rate limit is used up for longer than the max wait
+var ErrRateLimited = errors.New("rate limit is used up")
+
+// lowRemaining is the part of the limit from where the calls to a host are spread until the reset
+const lowRemaining = 0.1
+
+type quota struct {
+	maxWait time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]time.Time
+}
+
+func (a AdaptiveRateLimits) newQuota() (*quota, error) {
+	if !a.Enabled {
+		return nil, nil
+	}
+	maxWait := time.Minute
+	if a.MaxWait != "" {
+		duration, err := time.ParseDuration(a.MaxWait)
+		if err != nil {
+			return nil, fmt.Errorf("Adaptive rate limits max wait [%s] not valid: %w", a.MaxWait, err)
+		}
+		maxWait = duration
+	}
+	return &quota{maxWait: maxWait, hosts: map[string]time.Time{}}, nil
+}
+
+// wait blocks until the host can be called again, it returns an error right away when that is later than the max wait
+func (q *quota) wait(req *http.Request) error {
+	if q == nil {
+		return nil
+	}
+	host := req.URL.Host
+	q.mu.Lock()
+	until := q.hosts[host]
+	q.mu.Unlock()
+
+	wait := time.Until(until)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > q.maxWait {
+		return fmt.Errorf("Rate limit of [%s] is used up until [%s], skipping the call: %w", host, until.Format(time.RFC3339), ErrRateLimited)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe reads the rate limit headers of the response and sets the time from when the host can be called again
+func (q *quota) observe(req *http.Request, resp *http.Response) {
+	if q == nil || resp == nil {
+		return
+	}
+	now := time.Now()
+	until, ok := nextCall(resp, now)
+	if !ok {
+		return
+	}
+	host := req.URL.Host
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if until.After(q.hosts[host]) {
+		entry := logger.WithField("host", host).WithField("until", until.Format(time.RFC3339))
+		if resp.StatusCode == http.StatusTooManyRequests {
+			entry.Info("Endpoint is rate limiting the calls, waiting for the reset")
+		} else {
+			entry.Debug("Rate limit of the endpoint is almost used up, slowing down")
+		}
+		q.hosts[host] = until
+	}
+}
+
+// nextCall returns when the host can be called again according to the response, it is false when the response doesn't limit the calls
+func nextCall(resp *http.Response, now time.Time) (time.Time, bool) {
+	reset, exact, hasReset := rateLimitReset(resp.Header, now)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if wait, exists := retryAfter(resp.Header, now); exists {
+			return now.Add(wait), true
+		}
+		if hasReset {
+			return reset, true
+		}
+		return now.Add(10 * time.Second), true
+	}
+
+	remaining, hasRemaining := rateLimitValue(resp.Header, "RateLimit-Remaining")
+	if !hasRemaining || !hasReset {
+		return time.Time{}, false
+	}
+	if remaining <= 0 {
+		return reset, true
+	}
+	limit, hasLimit := rateLimitValue(resp.Header, "RateLimit-Limit")
+	// the window of Docker Hub is not the time until the reset, so the calls are only spread with a reset header
+	if exact && hasLimit && float64(remaining) < float64(limit)*lowRemaining {
+		return now.Add(reset.Sub(now) / time.Duration(remaining+1)), true
+	}
+	return time.Time{}, false
+}
+
+// rateLimitValue returns the number of a rate limit header with or without the X- prefix,
+// Docker Hub adds the window to the number like 100;w=21600
+func rateLimitValue(header http.Header, name string) (int, bool) {
+	value := header.Get(name)
+	if value == "" {
+		value = header.Get("X-" + name)
+	}
+	if value == "" {
+		return 0, false
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(strings.SplitN(value, ";", 2)[0]))
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// rateLimitReset returns when the limit resets, the reset header is either a unix time like GitHub uses or the seconds until the reset.
+// Without a reset header the window of Docker Hub is used, the limit has reset at the latest by then and exact is false
+func rateLimitReset(header http.Header, now time.Time) (time.Time, bool, bool) {
+	if reset, exists := rateLimitValue(header, "RateLimit-Reset"); exists {
+		if reset > 1000000000 {
+			return time.Unix(int64(reset), 0), true, true
+		}
+		return now.Add(time.Duration(reset) * time.Second), true, true
+	}
+	for _, name := range []string{"RateLimit-Remaining", "X-RateLimit-Remaining", "RateLimit-Limit", "X-RateLimit-Limit"} {
+		for _, part := range strings.Split(header.Get(name), ";") {
+			if window := strings.TrimPrefix(strings.TrimSpace(part), "w="); window != strings.TrimSpace(part) {
+				if seconds, err := strconv.Atoi(window); err == nil {
+					return now.Add(time.Duration(seconds) * time.Second), false, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false, false
+}
+
+// retryAfter returns the wait of a Retry-After header in seconds or as an http date
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestQuotaWaitsForTheResetOfAUsedUpLimit(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("RateLimit-Limit", "100;w=21600")
+		w.Header().Set("RateLimit-Remaining", "0;w=21600")
+	}))
+	defer server.Close()
+
+	q, _ := AdaptiveRateLimits{Enabled: true, MaxWait: "2s"}.newQuota()
+	get := func() error {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		if err := q.wait(req); err != nil {
+			return err
+		}
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		q.observe(req, resp)
+		return nil
+	}
+
+	get()
+	start := time.Now()
+	if err := get(); err != nil || time.Since(start) < 900*time.Millisecond {
+		t.Fatalf("Expected the call to wait for the Retry-After but got %v after %v", err, time.Since(start))
+	}
+	if err := get(); !errors.Is(err, ErrRateLimited) || calls != 2 {
+		t.Errorf("Expected the call to fail fast when the limit is used up for the whole window but got %v after %v calls", err, calls)
+	}
+}
+
+func TestNextCallSpreadsTheCallsWhenTheLimitIsAlmostUsedUp(t *testing.T) {
+	now := time.Now()
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Limit", "5000")
+	resp.Header.Set("X-RateLimit-Remaining", "9")
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(100*time.Second).Unix(), 10))
+
+	until, ok := nextCall(resp, now)
+	if !ok || until.Sub(now) < 9*time.Second || until.Sub(now) > 11*time.Second {
+		t.Errorf("Expected the next call in about 10s but got %v", until.Sub(now))
+	}
+
+	resp.Header.Set("X-RateLimit-Remaining", "4000")
+	if _, ok := nextCall(resp, now); ok {
+		t.Errorf("Expected no limit with enough remaining calls")
+	}
+}
@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimits contains the token buckets shared by all the components, overall and per host
+type RateLimits struct {
+	Overall RateLimit `koanf:"overall"`
+	PerHost RateLimit `koanf:"perHost"`
+}
+
+// RateLimit contains the token bucket settings, zero requests per second means no limit
+type RateLimit struct {
+	RequestsPerSecond float64 `koanf:"requestsPerSecond"`
+	Burst             int     `koanf:"burst"`
+}
+
+func (r RateLimit) enabled() bool {
+	return r.RequestsPerSecond > 0
+}
+
+func (r RateLimit) newLimiter() *rate.Limiter {
+	burst := r.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(r.RequestsPerSecond), burst)
+}
+
+type limiter struct {
+	overall *rate.Limiter
+	perHost RateLimit
+
+	mu    sync.Mutex
+	hosts map[string]*rate.Limiter
+}
+
+func newLimiter(limits RateLimits) *limiter {
+	if !limits.Overall.enabled() && !limits.PerHost.enabled() {
+		return nil
+	}
+	l := &limiter{
+		perHost: limits.PerHost,
+		hosts:   map[string]*rate.Limiter{},
+	}
+	if limits.Overall.enabled() {
+		l.overall = limits.Overall.newLimiter()
+	}
+	return l
+}
+
+// wait blocks until the request is allowed by the overall and the host token bucket
+func (l *limiter) wait(req *http.Request) error {
+	if l.overall != nil {
+		if err := l.overall.Wait(req.Context()); err != nil {
+			return err
+		}
+	}
+	if host := l.forHost(req.URL.Host); host != nil {
+		return host.Wait(req.Context())
+	}
+	return nil
+}
+
+func (l *limiter) forHost(host string) *rate.Limiter {
+	if !l.perHost.enabled() {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hostLimiter, exists := l.hosts[host]
+	if !exists {
+		hostLimiter = l.perHost.newLimiter()
+		l.hosts[host] = hostLimiter
+	}
+	return hostLimiter
+}
@@ -0,0 +1,49 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		limits   RateLimits
+		hosts    []string
+		cancel   bool
+		minWait  time.Duration
+		maxWait  time.Duration
+		failures int
+	}{
+		"disabled":           {hosts: []string{"a", "a", "a"}, maxWait: 50 * time.Millisecond},
+		"burst":              {limits: RateLimits{Overall: RateLimit{RequestsPerSecond: 1, Burst: 3}}, hosts: []string{"a", "b", "c"}, maxWait: 50 * time.Millisecond},
+		"wait after burst":   {limits: RateLimits{Overall: RateLimit{RequestsPerSecond: 10, Burst: 1}}, hosts: []string{"a", "b", "c"}, minWait: 150 * time.Millisecond, maxWait: time.Second},
+		"per host":           {limits: RateLimits{PerHost: RateLimit{RequestsPerSecond: 1}}, hosts: []string{"a", "b", "c"}, maxWait: 50 * time.Millisecond},
+		"wait for same host": {limits: RateLimits{PerHost: RateLimit{RequestsPerSecond: 10}}, hosts: []string{"a", "a"}, minWait: 50 * time.Millisecond, maxWait: time.Second},
+		"cancelled":          {limits: RateLimits{Overall: RateLimit{RequestsPerSecond: 0.1}}, hosts: []string{"a", "a"}, cancel: true, maxWait: 50 * time.Millisecond, failures: 1},
+	}
+	for name, test := range tests {
+		l := newLimiter(test.limits)
+		if (l == nil) != (name == "disabled") {
+			t.Errorf("Expected a limiter only when a limit is enabled for %s", name)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		start := time.Now()
+		failures := 0
+		for index, host := range test.hosts {
+			if test.cancel && index > 0 {
+				cancel()
+			}
+			req, _ := http.NewRequestWithContext(ctx, "GET", "https://"+host+"/v2/", nil)
+			if l != nil && l.wait(req) != nil {
+				failures++
+			}
+		}
+		cancel()
+		waited := time.Since(start)
+		if waited < test.minWait || waited > test.maxWait || failures != test.failures {
+			t.Errorf("Expected %s to wait between %v and %v with %d failures but waited %v with %d failures", name, test.minWait, test.maxWait, test.failures, waited, failures)
+		}
+	}
+}
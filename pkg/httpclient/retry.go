@@ -0,0 +1,195 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+)
+
+// Retry contains the settings for retrying failed calls with exponential backoff and jitter
+// Attempts includes the first call so 1 means no retries, the backoffs are in time.Duration format like 500ms or 10s
+type Retry struct {
+	Attempts             int    `koanf:"attempts"`
+	InitialBackoff       string `koanf:"initialBackoff"`
+	MaxBackoff           string `koanf:"maxBackoff"`
+	RetryableStatusCodes []int  `koanf:"retryableStatusCodes"`
+}
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryPolicy is the parsed version of the retry settings
+type retryPolicy struct {
+	attempts       int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	statusCodes    map[int]bool
+}
+
+// noRetries is used until the retries are configured
+var noRetries = retryPolicy{attempts: 1}
+
+// merge returns the retry settings with the settings of the override on top
+func (r Retry) merge(override Retry) Retry {
+	merged := r
+	if override.Attempts != 0 {
+		merged.Attempts = override.Attempts
+	}
+	if override.InitialBackoff != "" {
+		merged.InitialBackoff = override.InitialBackoff
+	}
+	if override.MaxBackoff != "" {
+		merged.MaxBackoff = override.MaxBackoff
+	}
+	if len(override.RetryableStatusCodes) > 0 {
+		merged.RetryableStatusCodes = override.RetryableStatusCodes
+	}
+	return merged
+}
+
+func (r Retry) newPolicy() (retryPolicy, error) {
+	policy := retryPolicy{
+		attempts:       r.Attempts,
+		initialBackoff: 500 * time.Millisecond,
+		maxBackoff:     10 * time.Second,
+		statusCodes:    map[int]bool{},
+	}
+	if policy.attempts < 1 {
+		policy.attempts = 1
+	}
+	if r.InitialBackoff != "" {
+		backoff, err := time.ParseDuration(r.InitialBackoff)
+		if err != nil {
+			return policy, fmt.Errorf("Retry initial backoff [%s] not valid: %w", r.InitialBackoff, err)
+		}
+		policy.initialBackoff = backoff
+	}
+	if r.MaxBackoff != "" {
+		backoff, err := time.ParseDuration(r.MaxBackoff)
+		if err != nil {
+			return policy, fmt.Errorf("Retry max backoff [%s] not valid: %w", r.MaxBackoff, err)
+		}
+		policy.maxBackoff = backoff
+	}
+
+	statusCodes := r.RetryableStatusCodes
+	if len(statusCodes) == 0 {
+		statusCodes = defaultRetryableStatusCodes
+	}
+	for _, code := range statusCodes {
+		policy.statusCodes[code] = true
+	}
+	return policy, nil
+}
+
+// do sends the request and retries it on network errors and retryable status codes until the attempts are used up
+// Requests with a body are only retried when the body can be read again
+func (p retryPolicy) do(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := send(req)
+		if attempt >= p.attempts || !p.retryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		wait := p.backoff(attempt, resp)
+		entry := logger.WithField("url", req.URL.String()).WithField("attempt", attempt).WithField("wait", wait)
+		if err != nil {
+			entry = entry.WithError(err)
+		} else {
+			entry = entry.WithField("status", resp.StatusCode)
+			resp.Body.Close()
+		}
+		entry.Debug("Retrying request")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			// a round tripper must not modify the request so the body is set on a copy
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func (p retryPolicy) retryable(resp *http.Response, err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRateLimited) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return p.statusCodes[resp.StatusCode]
+}
+
+// backoff returns a random duration up to the exponential backoff of the attempt, a Retry-After header in seconds or as a date takes precedence
+func (p retryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, exists := retryAfter(resp.Header, time.Now()); exists {
+			if wait > p.maxBackoff {
+				wait = p.maxBackoff
+			}
+			return wait
+		}
+	}
+
+	backoff := p.initialBackoff << uint(attempt-1)
+	if backoff > p.maxBackoff || backoff <= 0 {
+		backoff = p.maxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// WithRetries wraps the transport with the retry settings of the component,
+// it is used for clients that bring their own transport like the Kubernetes client
+func WithRetries(component string, transport http.RoundTripper) http.RoundTripper {
+	return retryTransport{component: component, transport: transport}
+}
+
+type retryTransport struct {
+	component string
+	transport http.RoundTripper
+}
+
+func (r retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return policyFor(r.component).do(req, func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := r.transport.RoundTrip(req)
+		audit.RecordRequest(r.component, req, resp, err, start)
+		return resp, err
+	})
+}
+
+func policyFor(component string) retryPolicy {
+	mu.RLock()
+	defer mu.RUnlock()
+	policy, exists := configured.retries[component]
+	if !exists {
+		return noRetries
+	}
+	return policy
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRetryRetriesRetryableStatusCodesWithBody(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "request" {
+			t.Errorf("Expected the body on every attempt but got %q", body)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy, _ := Retry{Attempts: 3, InitialBackoff: "1ms", MaxBackoff: "1ms"}.newPolicy()
+	client := &http.Client{Transport: retryTransport{transport: http.DefaultTransport}}
+	configured.retries = map[string]retryPolicy{"": policy}
+	defer func() { configured.retries = map[string]retryPolicy{} }()
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("request"))
+	if err != nil {
+		t.Fatalf("Request failed %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("Expected status 200 after 3 calls but got status %v after %v calls", resp.StatusCode, calls)
+	}
+}
+
+func TestRetryDoesNotRetryOtherStatusCodes(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	policy, _ := Retry{Attempts: 3, InitialBackoff: "1ms"}.newPolicy()
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := policy.do(req, http.DefaultTransport.RoundTrip)
+	if err != nil {
+		t.Fatalf("Request failed %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("Expected 1 call but got %v", calls)
+	}
+}
@@ -0,0 +1,89 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// ConfigMapKey is the key in the ConfigMap that holds the lcm config
+	ConfigMapKey                = "config.yaml"
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// LifecycleScanResource is the custom resource that can hold the lcm config in its spec
+var LifecycleScanResource = schema.GroupVersionResource{
+	Group:    "lcm.arminc.github.io",
+	Version:  "v1alpha1",
+	Resource: "lifecyclescans",
+}
+
+// GetConfigFromConfigMap fetches the lcm config from a ConfigMap, reference is in the form of namespace/name or name
+func GetConfigFromConfigMap(reference string, useLocally bool) ([]byte, error) {
+	namespace, name := splitReference(reference)
+	logger.WithField("namespace", namespace).WithField("configMap", name).Debug("Fetching config from ConfigMap")
+
+	client, err := getKubernetesClient(context.Background(), useLocally)
+	if err != nil {
+		return nil, err
+	}
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	data, exists := configMap.Data[ConfigMapKey]
+	if !exists {
+		return nil, fmt.Errorf("ConfigMap [%s/%s] does not contain the key [%s]", namespace, name, ConfigMapKey)
+	}
+	return []byte(data), nil
+}
+
+// GetConfigFromLifecycleScan fetches the lcm config from the spec of a LifecycleScan resource, reference is in the form of namespace/name or name
+func GetConfigFromLifecycleScan(reference string, useLocally bool) ([]byte, error) {
+	namespace, name := splitReference(reference)
+	logger.WithField("namespace", namespace).WithField("lifecycleScan", name).Debug("Fetching config from LifecycleScan")
+
+	config, err := getRestConfig(context.Background(), useLocally)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := client.Resource(LifecycleScanResource).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	spec, exists := resource.Object["spec"]
+	if !exists {
+		return nil, fmt.Errorf("LifecycleScan [%s/%s] does not have a spec", namespace, name)
+	}
+	return json.Marshal(spec) // json is valid yaml
+}
+
+func splitReference(reference string) (string, string) {
+	parts := strings.SplitN(reference, "/", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return currentNamespace(), reference
+}
+
+func currentNamespace() string {
+	namespace, err := ioutil.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return "default"
+	}
+	return strings.TrimSpace(string(namespace))
+}
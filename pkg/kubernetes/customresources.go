@@ -0,0 +1,147 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// SetCustomResources sets the custom resources that are scanned for images by default, none are scanned without them
+func SetCustomResources(resources []CustomResource) {
+	setDefault(func(options *Options) { options.CustomResources = resources })
+}
+
+// CustomResource finds the images in the namespaced custom resources of a resource with JSONPath expressions
+type CustomResource struct {
+	Name string `koanf:"name"`
+	// GroupVersion is the API group and version of the resource like argoproj.io/v1alpha1
+	GroupVersion string `koanf:"groupVersion"`
+	// Resource is the plural name of the resource like workflowtemplates
+	Resource string `koanf:"resource"`
+	// Paths are JSONPath templates like {.spec.templates[*].container.image} that return images, separated by spaces when there are more,
+	// text around the expressions is kept so an image can be composed like {.spec.hub}/proxyv2:{.spec.tag}
+	Paths []string `koanf:"paths"`
+}
+
+// DefaultCustomResources returns the custom resources of popular operators that contain images which only show up in pods at runtime,
+// a custom resource in the config with the same name replaces the default
+func DefaultCustomResources() []CustomResource {
+	argoTemplates := func(prefix string) []string {
+		return []string{
+			"{" + prefix + ".templates[*].container.image}",
+			"{" + prefix + ".templates[*].script.image}",
+			"{" + prefix + ".templates[*].initContainers[*].image}",
+			"{" + prefix + ".templates[*].sidecars[*].image}",
+		}
+	}
+	return []CustomResource{
+		{Name: "argo-workflowtemplates", GroupVersion: "argoproj.io/v1alpha1", Resource: "workflowtemplates", Paths: argoTemplates(".spec")},
+		{Name: "argo-cronworkflows", GroupVersion: "argoproj.io/v1alpha1", Resource: "cronworkflows", Paths: argoTemplates(".spec.workflowSpec")},
+		{
+			Name:         "istio-operator",
+			GroupVersion: "install.istio.io/v1alpha1",
+			Resource:     "istiooperators",
+			Paths:        []string{"{.spec.hub}/proxyv2:{.spec.tag}", "{.spec.hub}/pilot:{.spec.tag}"},
+		},
+		{
+			Name:         "strimzi-kafkas",
+			GroupVersion: "kafka.strimzi.io/v1beta2",
+			Resource:     "kafkas",
+			Paths: []string{
+				"{.spec.kafka.image}",
+				"{.spec.zookeeper.image}",
+				"{.spec.entityOperator.topicOperator.image}",
+				"{.spec.entityOperator.userOperator.image}",
+				"{.spec.kafkaExporter.image}",
+				"{.spec.cruiseControl.image}",
+			},
+		},
+		{Name: "strimzi-kafkaconnects", GroupVersion: "kafka.strimzi.io/v1beta2", Resource: "kafkaconnects", Paths: []string{"{.spec.image}"}},
+		{Name: "strimzi-kafkamirrormaker2s", GroupVersion: "kafka.strimzi.io/v1beta2", Resource: "kafkamirrormaker2s", Paths: []string{"{.spec.image}"}},
+	}
+}
+
+// MergeCustomResources returns the default custom resources with the custom resources of the config, a custom resource with the name of a default replaces it
+func MergeCustomResources(defaults, resources []CustomResource) []CustomResource {
+	merged := []CustomResource{}
+	replaced := map[string]bool{}
+	for _, resource := range resources {
+		replaced[resource.Name] = true
+	}
+	for _, resource := range defaults {
+		if !replaced[resource.Name] {
+			merged = append(merged, resource)
+		}
+	}
+	return append(merged, resources...)
+}
+
+// Validate returns an error when the resource is incomplete or a path is not a valid JSONPath template
+func (c CustomResource) Validate() error {
+	if c.Name == "" || c.GroupVersion == "" || c.Resource == "" {
+		return fmt.Errorf("Custom resource [%s] needs a name, groupVersion and resource", c.Name)
+	}
+	for _, path := range c.Paths {
+		if err := jsonpath.New(c.Name).Parse(path); err != nil {
+			return fmt.Errorf("Path [%s] of custom resource [%s] not valid: %w", path, c.Name, err)
+		}
+	}
+	return nil
+}
+
+// images returns the images that the paths find in the custom resource, a path that doesn't match returns nothing
+func (c CustomResource) images(object map[string]interface{}) []string {
+	var images []string
+	for _, path := range c.Paths {
+		parser := jsonpath.New(c.Name)
+		if err := parser.Parse(path); err != nil {
+			continue
+		}
+		var found bytes.Buffer
+		if err := parser.Execute(&found, object); err != nil {
+			// a field of the path is missing, like the image of a component that runs the default image of the operator
+			continue
+		}
+		for _, image := range strings.Fields(found.String()) {
+			images = appendMissing(images, image)
+		}
+	}
+	return images
+}
+
+// collectCustomResourceImages adds the images found in the custom resources of the namespace, the custom resources
+// of a definition that is not installed or that may not be read are skipped
+func collectCustomResourceImages(ctx context.Context, client *kubernetes.Clientset, namespace string, images *collectedImages) error {
+	ctx = audit.WithPurpose(ctx, "custom resources of namespace "+namespace)
+	var skipped skippedErrors
+	for _, resource := range optionsFrom(ctx).CustomResources {
+		resource := resource
+		err := listWorkloads(ctx, client.CoreV1().RESTClient(), resource.GroupVersion, namespace, resource.Resource, func() (interface{}, func() string) {
+			list := &unstructured.UnstructuredList{}
+			return list, func() string {
+				for _, item := range list.Items {
+					workload := workloadName(namespace, item.GetKind(), item.GetName())
+					for _, image := range resource.images(item.Object) {
+						images.addImage(namespace, workload, image)
+					}
+				}
+				return list.GetContinue()
+			}
+		})
+		if isNotFound(err) {
+			logger.WithField("namespace", namespace).WithField("resource", resource.Name).Debug("The custom resource is not installed")
+			continue
+		}
+		if err = skipped.keep(err); err != nil {
+			return err
+		}
+	}
+	return utilerrors.NewAggregate(skipped)
+}
@@ -0,0 +1,8 @@
+// Package kubernetes collects the images and Helm charts that are running in a Kubernetes cluster.
+//
+// GetContainersFromNamespaces returns every unique image with the namespaces it runs in,
+// GetHelmChartsFromNamespaces returns the deployed charts and WatchImages reports new images as workloads change.
+// When some namespaces fail the results that could be fetched are returned together with an aggregated error.
+//
+//	containers, err := kubernetes.GetContainersFromNamespaces(ctx, []string{"default"}, true)
+package kubernetes
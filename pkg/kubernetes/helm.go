@@ -0,0 +1,68 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Chart is helm chart info
+type Chart struct {
+	Name    string
+	Version string
+}
+
+// GetHelmChartsFromNamespaces fetches all charts from the namespaces
+// When some namespaces fail the charts that could be fetched are returned together with an aggregated error
+// Helm doesn't accept a context so the context is checked before every namespace
+func GetHelmChartsFromNamespaces(ctx context.Context, namespaces []string, useLocally bool) ([]Chart, error) {
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	namespaces, err = getNamespaces(ctx, namespaces, client)
+	if err != nil && !IsSkipped(err) {
+		return nil, err
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	var charts []Chart
+	for _, namespace := range namespaces {
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("Could not fetch charts in namespace [%s]: %w", namespace, ctx.Err()))
+			continue
+		}
+		settings := cli.New()
+		if target, ok := targetFrom(ctx); ok {
+			settings.KubeConfig = target.Kubeconfig
+			settings.KubeContext = target.Context
+		}
+		actionConfig := new(action.Configuration)
+
+		err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), logger.Infof)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed to get Helm action config for namespace [%s]: %w", namespace, err))
+			continue
+		}
+
+		client := action.NewList(actionConfig)
+		chartsInNamespace, err := client.Run()
+		if err != nil {
+			errs = append(errs, skipForbidden(fmt.Errorf("Failed to run helm command in namespace [%s]: %w", namespace, err), namespace, "helm releases"))
+			continue
+		}
+		for _, chart := range chartsInNamespace {
+			charts = append(charts, Chart{
+				Name:    chart.Name,
+				Version: chart.Chart.Metadata.Version,
+			})
+		}
+	}
+	return charts, utilerrors.NewAggregate(errs)
+}
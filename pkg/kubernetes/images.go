@@ -0,0 +1,53 @@
+package kubernetes
+
+import (
+	"github.com/docker/distribution/reference"
+)
+
+// ImageStringToContainerStruct converts image string to container information
+// An image that is only pinned by digest gets version 0 until the digest is resolved to a tag
+func ImageStringToContainerStruct(containerString string) (Container, error) {
+	image, err := reference.ParseNormalizedNamed(containerString)
+	if err != nil {
+		return Container{}, err
+	}
+
+	digest := ""
+	if digested, ok := image.(reference.Digested); ok {
+		digest = digested.Digest().String()
+	}
+
+	version := "0" // no tag, tag 'latest' and only a digest can't be compared
+	tag := ""
+	if tagged, ok := image.(reference.Tagged); ok {
+		tag = tagged.Tag()
+		if tag != "latest" {
+			version = tag
+		}
+	} else if digest == "" {
+		tag = "latest"
+	}
+
+	return Container{
+		FullPath: containerString,
+		URL:      reference.Domain(image),
+		Name:     reference.Path(image),
+		Version:  version,
+		Tag:      tag,
+		Digest:   digest,
+	}, nil
+
+}
+
+// Reference returns the reference of the image with the registry, the tag and the digest when it's pinned by digest,
+// like docker.io/library/nginx:latest or docker.io/library/nginx:1.25@sha256:..., it is what the scanners that pull the image scan
+func (c Container) Reference() string {
+	reference := c.URL + "/" + c.Name
+	if c.Tag != "" {
+		reference += ":" + c.Tag
+	}
+	if c.Digest != "" {
+		reference += "@" + c.Digest
+	}
+	return reference
+}
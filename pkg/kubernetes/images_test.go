@@ -43,3 +43,33 @@ func TestPodStringToPodStructWithPort(t *testing.T) {
 		t.Errorf("With port %v", pod)
 	}
 }
+
+func TestPodStringToPodStructWithNestedGroups(t *testing.T) {
+	pod, _ := ImageStringToContainerStruct("registry.gitlab.com/group/subgroup/project/app:1.3")
+	if pod.URL != "registry.gitlab.com" || pod.Name != "group/subgroup/project/app" || pod.Version != "1.3" {
+		t.Errorf("With nested groups %v", pod)
+	}
+}
+
+func TestPodStringToPodStructWithPortAndNestedGroups(t *testing.T) {
+	pod, _ := ImageStringToContainerStruct("gitlab.corp.local:5050/group/subgroup/project:1.3")
+	if pod.URL != "gitlab.corp.local:5050" || pod.Name != "group/subgroup/project" || pod.Version != "1.3" {
+		t.Errorf("With port and nested groups %v", pod)
+	}
+}
+
+func TestPodStringToPodStructWithDigest(t *testing.T) {
+	digest := "sha256:2d4e459f4ecb5329407ae3e47cbc107a2fbace221354ca75960af4c047b3cb13"
+	pod, err := ImageStringToContainerStruct("registry.corp.local:5000/team/app@" + digest)
+	if err != nil || pod.URL != "registry.corp.local:5000" || pod.Name != "team/app" || pod.Version != "0" || pod.Digest != digest {
+		t.Errorf("With digest %v %v", pod, err)
+	}
+}
+
+func TestPodStringToPodStructWithTagAndDigest(t *testing.T) {
+	digest := "sha256:2d4e459f4ecb5329407ae3e47cbc107a2fbace221354ca75960af4c047b3cb13"
+	pod, err := ImageStringToContainerStruct("nginx:1.19.2@" + digest)
+	if err != nil || pod.URL != "docker.io" || pod.Name != "library/nginx" || pod.Version != "1.19.2" || pod.Digest != digest {
+		t.Errorf("With tag and digest %v %v", pod, err)
+	}
+}
@@ -0,0 +1,527 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var logger = log.WithField("component", "kubernetes")
+
+// Container holds the info of the container running in the cluster
+type Container struct {
+	FullPath    string
+	URL         string
+	Name        string
+	Version     string
+	Digest      string // Only set when the image is pinned by digest like app@sha256:...
+	Tag         string // The tag of the image, latest without a tag and empty when the image is only pinned by digest
+	Namespaces  []string
+	PullSecrets []string // The imagePullSecrets of the pods as namespace/name
+	// RunningDigests are the digests of the images that the pods run according to the kubelet, with the pods as namespace/name per digest
+	RunningDigests map[string][]string
+	// Workloads are the workloads that use the image as namespace/kind/name, like default/Deployment/web, pods without a workload are namespace/Pod/name
+	Workloads []string
+	// Replicas are the number of running pods per workload that use the image, a workload without running pods like a
+	// deployment that is scaled to zero is left out
+	Replicas map[string]int
+}
+
+// SetTimeout sets the default timeout for the calls to the Kubernetes API
+func SetTimeout(t time.Duration) {
+	setDefault(func(options *Options) { options.Timeout = t })
+}
+
+// SetPageSize sets the default maximum number of pods fetched per call to the Kubernetes API
+func SetPageSize(size int64) {
+	setDefault(func(options *Options) { options.PageSize = size })
+}
+
+// SetExcludedNamespaces sets the default names or regular expressions like pr-.* of the namespaces that are never scanned
+func SetExcludedNamespaces(namespaces []string) {
+	setDefault(func(options *Options) { options.ExcludedNamespaces = namespaces })
+}
+
+// SetLabelSelector sets the default label selector like app.kubernetes.io/part-of=platform that the scanned pods and workloads must match
+func SetLabelSelector(selector string) {
+	setDefault(func(options *Options) { options.LabelSelector = selector })
+}
+
+// GetContainersFromNamespaces fetches all containers, init containers and ephemeral containers with the options
+// When some namespaces or images fail the containers that could be fetched are returned together with an aggregated error
+func GetContainersFromNamespaces(ctx context.Context, namespaces []string, useLocally bool, options Options) ([]Container, error) {
+	ctx = WithOptions(ctx, options)
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	namespaces, err = getNamespaces(ctx, namespaces, client)
+	if err != nil && !IsSkipped(err) {
+		return nil, err
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	// every image is only returned once together with all the namespaces it runs in
+	images := newCollectedImages()
+	streams := newImageStreams(client.CoreV1().RESTClient())
+	for _, namespace := range namespaces {
+		owners := map[string]string{}
+		if options.Workloads {
+			if owners, err = collectWorkloadImages(ctx, client, namespace, images); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(options.CustomResources) > 0 {
+			if err := collectCustomResourceImages(ctx, client, namespace, images); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if options.OpenShift {
+			if err := streams.load(ctx, namespace); err != nil {
+				errs = append(errs, err)
+			}
+			if err := collectDeploymentConfigImages(ctx, client, namespace, images, streams); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := collectRunningImages(ctx, client, namespace, images, owners); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if options.OpenShift {
+		errs = append(errs, streams.resolveImages(ctx, images)...)
+	}
+
+	containers, parseErrs := images.containers()
+	errs = append(errs, parseErrs...)
+	logger.Info("Finished fecthing all containers")
+	return containers, utilerrors.NewAggregate(errs)
+}
+
+func getKubernetesClient(ctx context.Context, useLocally bool) (*kubernetes.Clientset, error) {
+	config, err := getRestConfig(ctx, useLocally)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load kubernetes config: %w", err)
+	}
+	return clientset, nil
+}
+
+// getRestConfig returns the config for the target of the context, or the local or in cluster config when there is no target
+func getRestConfig(ctx context.Context, useLocally bool) (*rest.Config, error) {
+	if target, ok := targetFrom(ctx); ok {
+		logger.WithField("kubeconfig", target.Kubeconfig).WithField("context", target.Context).Debug("Accessing Kubernetes with the target")
+		config, err := target.restConfig()
+		if err != nil {
+			return nil, fmt.Errorf("Could not load kubernetes config for context [%s]: %w", target.Context, err)
+		}
+		config.Timeout = optionsFrom(ctx).Timeout
+		config.UserAgent = httpclient.UserAgent()
+		config.WrapTransport = withRetries
+		return config, nil
+	}
+
+	if useLocally {
+		logger.Debug("Accessing Kubernetes locally")
+		kubeconfig := filepath.Join(homeDir(), ".kube", "config")
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("Could not find kubernetes config: %w", err)
+		}
+		config.Timeout = optionsFrom(ctx).Timeout
+		config.WrapTransport = withRetries
+		return config, nil
+	}
+
+	logger.Debug("Accessing Kubernetes inside the cluster")
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("Could not find kubernetes config in the cluster: %w", err)
+	}
+	config.Timeout = optionsFrom(ctx).Timeout
+	config.UserAgent = httpclient.UserAgent()
+	config.WrapTransport = withRetries
+	return config, nil
+}
+
+// withRetries retries the calls to the Kubernetes API with the shared retry settings
+func withRetries(transport http.RoundTripper) http.RoundTripper {
+	return httpclient.WithRetries(httpclient.Kubernetes, transport)
+}
+
+// collectedImages are the images found in the namespaces, every image with the namespaces it runs in, the imagePullSecrets of the pods,
+// the pods per digest that the pods run, the workloads that use the image and the names of their running pods
+type collectedImages struct {
+	namespaces  map[string][]string
+	pullSecrets map[string][]string
+	digests     map[string]map[string][]string
+	workloads   map[string][]string
+	pods        map[string]map[string]map[string]bool
+}
+
+func newCollectedImages() *collectedImages {
+	return &collectedImages{
+		namespaces:  map[string][]string{},
+		pullSecrets: map[string][]string{},
+		digests:     map[string]map[string][]string{},
+		workloads:   map[string][]string{},
+		pods:        map[string]map[string]map[string]bool{},
+	}
+}
+
+// containers returns a container for every image with everything that was collected of it sorted by the full path,
+// the images that can't be parsed are returned as errors
+func (c *collectedImages) containers() ([]Container, []error) {
+	var errs []error
+	containers := []Container{}
+	for key, namespaces := range c.namespaces {
+		container, err := ImageStringToContainerStruct(key)
+		if err != nil {
+			errs = append(errs, &lcmerrors.ParseError{Err: fmt.Errorf("Could not parse image [%s]: %w", key, err)})
+			continue
+		}
+		sort.Strings(namespaces)
+		sort.Strings(c.pullSecrets[key])
+		sort.Strings(c.workloads[key])
+		container.Namespaces = namespaces
+		container.PullSecrets = c.pullSecrets[key]
+		container.RunningDigests = c.digests[key]
+		container.Workloads = c.workloads[key]
+		container.Replicas = c.replicas(key)
+		containers = append(containers, container)
+	}
+	// the images come from a map so they are sorted to always return them in the same order
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].FullPath < containers[j].FullPath
+	})
+	return containers, errs
+}
+
+// add adds the images of the pod spec of the workload in the namespace
+func (c *collectedImages) add(namespace, workload string, spec corev1.PodSpec) {
+	for _, image := range imagesFromPodSpec(spec) {
+		c.addImage(namespace, workload, image)
+		for _, secret := range spec.ImagePullSecrets {
+			c.pullSecrets[image] = appendMissing(c.pullSecrets[image], namespace+"/"+secret.Name)
+		}
+	}
+}
+
+// addImage adds the image of the workload in the namespace
+func (c *collectedImages) addImage(namespace, workload, image string) {
+	c.namespaces[image] = appendMissing(c.namespaces[image], namespace)
+	c.workloads[image] = appendMissing(c.workloads[image], workload)
+}
+
+// rename moves everything of the image to the new name, the image is merged when the new name was found as well
+func (c *collectedImages) rename(image, name string) {
+	for _, namespace := range c.namespaces[image] {
+		c.namespaces[name] = appendMissing(c.namespaces[name], namespace)
+	}
+	for _, secret := range c.pullSecrets[image] {
+		c.pullSecrets[name] = appendMissing(c.pullSecrets[name], secret)
+	}
+	for _, workload := range c.workloads[image] {
+		c.workloads[name] = appendMissing(c.workloads[name], workload)
+	}
+	for digest, pods := range c.digests[image] {
+		if c.digests[name] == nil {
+			c.digests[name] = map[string][]string{}
+		}
+		for _, pod := range pods {
+			c.digests[name][digest] = appendMissing(c.digests[name][digest], pod)
+		}
+	}
+	for workload, pods := range c.pods[image] {
+		for pod := range pods {
+			c.addRunning(name, workload, pod)
+		}
+	}
+	delete(c.namespaces, image)
+	delete(c.pullSecrets, image)
+	delete(c.workloads, image)
+	delete(c.digests, image)
+	delete(c.pods, image)
+}
+
+// addRunning adds the pod of the workload that runs the image, the pods are kept by name so the pods of a list that is
+// fetched again after it expired are not counted twice
+func (c *collectedImages) addRunning(image, workload, pod string) {
+	if c.pods[image] == nil {
+		c.pods[image] = map[string]map[string]bool{}
+	}
+	if c.pods[image][workload] == nil {
+		c.pods[image][workload] = map[string]bool{}
+	}
+	c.pods[image][workload][pod] = true
+}
+
+// replicas returns the number of running pods per workload of the image
+func (c *collectedImages) replicas(image string) map[string]int {
+	if len(c.pods[image]) == 0 {
+		return nil
+	}
+	replicas := map[string]int{}
+	for workload, pods := range c.pods[image] {
+		replicas[workload] = len(pods)
+	}
+	return replicas
+}
+
+// addPod adds the images of the pod in the namespace with the digests it runs and counts the pod as a replica of its workload
+func (c *collectedImages) addPod(namespace string, pod corev1.Pod, owners map[string]string) {
+	workload := podWorkload(pod, owners)
+	c.add(namespace, workload, pod.Spec)
+	// the finished pods of jobs are not replicas of the workload anymore
+	if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+		for _, image := range imagesFromPodSpec(pod.Spec) {
+			c.addRunning(image, workload, pod.Name)
+		}
+	}
+	for image, digest := range runningDigests(pod) {
+		if c.digests[image] == nil {
+			c.digests[image] = map[string][]string{}
+		}
+		c.digests[image][digest] = appendMissing(c.digests[image][digest], namespace+"/"+pod.Name)
+	}
+}
+
+// collectRunningImages adds the images of the pods in the namespace to images together with the namespace, the imagePullSecrets,
+// the digests they run and the workload that owns the pod, the owners are the workloads of the replica sets and jobs in the namespace
+// The pods are fetched in pages and only the images are kept, so memory doesn't grow with the number of pods,
+// when the pod cache is started the pods are read from the cache instead
+// When a page fails the images of the earlier pages are kept and the error is returned
+func collectRunningImages(ctx context.Context, client *kubernetes.Clientset, namespace string, images *collectedImages, owners map[string]string) error {
+	start := time.Now()
+	ctx = audit.WithPurpose(ctx, "pods of namespace "+namespace)
+	ctx, span := tracing.Start(ctx, "namespace "+namespace)
+	span.SetAttribute("k8s.namespace.name", namespace)
+	defer span.End()
+	logger.WithField("namespace", namespace).Info("Fetching containers for namespace")
+	if pods, cached, err := cachedPods(ctx, namespace); cached {
+		if err != nil {
+			span.SetError(err)
+			return fmt.Errorf("Could not read the cached pods in namespace [%s]: %w", namespace, err)
+		}
+		for _, pod := range pods {
+			images.addPod(namespace, *pod, owners)
+		}
+		logger.WithField("namespace", namespace).WithField("pods", len(pods)).WithField("duration", time.Since(start)).Debug("Read cached containers in namespace")
+		return nil
+	}
+	podCount := 0
+	settings := optionsFrom(ctx)
+	options := metav1.ListOptions{Limit: settings.PageSize, LabelSelector: settings.LabelSelector}
+	for {
+		pods := &corev1.PodList{}
+		err := client.CoreV1().RESTClient().Get().
+			Context(ctx).
+			Namespace(namespace).
+			Resource("pods").
+			VersionedParams(&options, scheme.ParameterCodec).
+			Do().
+			Into(pods)
+		if apierrors.IsResourceExpired(err) && options.Continue != "" {
+			// The pods changed too much while paging, start over, the images already found are not added twice
+			logger.WithField("namespace", namespace).Warn("Pod list expired while paging, fetching the pods again")
+			options.Continue = ""
+			continue
+		}
+		if err != nil {
+			span.SetError(err)
+			return skipForbidden(fmt.Errorf("Could not fetch pods in namespace [%s]: %w", namespace, err), namespace, "pods")
+		}
+
+		podCount += len(pods.Items)
+		for _, pod := range pods.Items {
+			images.addPod(namespace, pod, owners)
+		}
+		options.Continue = pods.Continue
+		if options.Continue == "" {
+			break
+		}
+	}
+	logger.WithField("namespace", namespace).WithField("pods", podCount).WithField("duration", time.Since(start)).Debug("Fetched containers in namespace")
+	return nil
+}
+
+// imagesFromPodSpec returns the images of all containers, init containers and ephemeral containers like the ones of kubectl debug
+func imagesFromPodSpec(spec corev1.PodSpec) []string {
+	var images []string
+	for _, container := range spec.Containers {
+		images = append(images, container.Image)
+	}
+	for _, container := range spec.InitContainers {
+		images = append(images, container.Image)
+	}
+	for _, container := range spec.EphemeralContainers {
+		images = append(images, container.Image)
+	}
+	return images
+}
+
+// runningDigests returns the digest of the image of every container of the pod that runs, the image id in the status
+// is like docker-pullable://nginx@sha256:... or docker.io/library/nginx@sha256:...
+func runningDigests(pod corev1.Pod) map[string]string {
+	specImages := map[string]string{}
+	for _, container := range append(append([]corev1.Container{}, pod.Spec.Containers...), pod.Spec.InitContainers...) {
+		specImages[container.Name] = container.Image
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		specImages[container.Name] = container.Image
+	}
+	digests := map[string]string{}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.ContainerStatuses...), pod.Status.InitContainerStatuses...)
+	for _, status := range append(statuses, pod.Status.EphemeralContainerStatuses...) {
+		at := strings.LastIndex(status.ImageID, "@")
+		if image, exists := specImages[status.Name]; exists && at >= 0 && strings.HasPrefix(status.ImageID[at+1:], "sha256:") {
+			digests[image] = status.ImageID[at+1:]
+		}
+	}
+	return digests
+}
+
+func appendMissing(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// GetNamespaces returns the names of all namespaces in the cluster
+func GetNamespaces(ctx context.Context, useLocally bool) ([]string, error) {
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return nil, err
+	}
+	return getAllNamespaces(ctx, client)
+}
+
+// SelectNamespaces returns the namespaces that are scanned, the namespaces can be names or regular expressions
+// and without any namespaces all namespaces of the cluster are returned, the excluded namespaces are left out
+func SelectNamespaces(ctx context.Context, namespaces []string, useLocally bool) ([]string, error) {
+	if onlyNames(namespaces) {
+		return excludeNamespaces(namespaces, optionsFrom(ctx).ExcludedNamespaces), nil
+	}
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return nil, err
+	}
+	return getNamespaces(ctx, namespaces, client)
+}
+
+func getNamespaces(ctx context.Context, namespaces []string, client *kubernetes.Clientset) ([]string, error) {
+	if onlyNames(namespaces) {
+		logger.WithField("namespaces", namespaces).Info("Get all containers from the namespaces")
+		return excludeNamespaces(namespaces, optionsFrom(ctx).ExcludedNamespaces), nil
+	}
+	logger.WithField("namespaces", namespaces).Debug("Fetching all namespaces from Kubernetes to match the namespaces")
+	all, err := getAllNamespaces(ctx, client)
+	if isForbidden(err) {
+		// a service account that may only read its own namespace still scans that namespace
+		all = []string{ownNamespace(ctx)}
+		err = skipForbidden(err, "", "namespaces")
+		logger.WithField("namespace", all[0]).Warn("Not allowed to list the namespaces, only the own namespace is scanned")
+	}
+	if err != nil && !IsSkipped(err) {
+		return nil, err
+	}
+	selected := []string{}
+	for _, namespace := range all {
+		if len(namespaces) == 0 || matchesNamespace(namespaces, namespace) {
+			selected = append(selected, namespace)
+		}
+	}
+	return excludeNamespaces(selected, optionsFrom(ctx).ExcludedNamespaces), err
+}
+
+// onlyNames returns true when the namespaces are all names, so they don't have to be matched against the namespaces of the cluster
+func onlyNames(namespaces []string) bool {
+	for _, namespace := range namespaces {
+		if regexp.QuoteMeta(namespace) != namespace {
+			return false
+		}
+	}
+	return len(namespaces) > 0
+}
+
+func getAllNamespaces(ctx context.Context, client *kubernetes.Clientset) ([]string, error) {
+	var ns []string
+	namespaces := &corev1.NamespaceList{}
+	err := client.CoreV1().RESTClient().Get().
+		Context(ctx).
+		Resource("namespaces").
+		VersionedParams(&metav1.ListOptions{}, scheme.ParameterCodec).
+		Do().
+		Into(namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("Could not fetch namespaces: %w", err)
+	}
+
+	for _, namespace := range namespaces.Items {
+		ns = append(ns, namespace.GetObjectMeta().GetName())
+	}
+	return ns, nil
+}
+
+// matchesNamespace returns true when the namespace is one of the names or matches one of the regular expressions completely
+func matchesNamespace(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		// the patterns are validated with the config
+		if matched, _ := regexp.MatchString("^(?:"+pattern+")$", namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeNamespaces returns the namespaces without the ones that match the excluded names or regular expressions
+func excludeNamespaces(namespaces, excluded []string) []string {
+	if len(excluded) == 0 {
+		return namespaces
+	}
+	selected := []string{}
+	for _, namespace := range namespaces {
+		if matchesNamespace(excluded, namespace) {
+			logger.WithField("namespace", namespace).Debug("Skipping excluded namespace")
+			continue
+		}
+		selected = append(selected, namespace)
+	}
+	return selected
+}
+
+func homeDir() string {
+	if home := os.Getenv("HOME"); home != "" {
+		return home
+	}
+	return os.Getenv("USERPROFILE") // windows
+}
@@ -0,0 +1,525 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+func pod(images ...string) corev1.Pod {
+	var containers []corev1.Container
+	for _, image := range images {
+		containers = append(containers, corev1.Container{Image: image})
+	}
+	return corev1.Pod{Spec: corev1.PodSpec{Containers: containers}}
+}
+
+func TestCollectRunningImagesPages(t *testing.T) {
+	pages := map[string]corev1.PodList{
+		"":      {ListMeta: metav1.ListMeta{Continue: "page2"}, Items: []corev1.Pod{pod("nginx:1.0"), pod("nginx:1.0", "redis:5")}},
+		"page2": {Items: []corev1.Pod{pod("redis:5", "busybox")}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if limit := req.URL.Query().Get("limit"); limit != "2" {
+			t.Errorf("Expected limit 2 but got [%s]", limit)
+		}
+		if selector := req.URL.Query().Get("labelSelector"); selector != "app.kubernetes.io/part-of=platform" {
+			t.Errorf("Expected the label selector but got [%s]", selector)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pages[req.URL.Query().Get("continue")])
+	}))
+	defer server.Close()
+
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := newCollectedImages()
+	images.namespaces["nginx:1.0"] = []string{"other"}
+	ctx := WithOptions(context.Background(), Options{PageSize: 2, LabelSelector: "app.kubernetes.io/part-of=platform"})
+	if err := collectRunningImages(ctx, client, "default", images, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"nginx:1.0": {"other", "default"},
+		"redis:5":   {"default"},
+		"busybox":   {"default"},
+	}
+	if !reflect.DeepEqual(images.namespaces, expected) {
+		t.Errorf("Expected %v but got %v", expected, images.namespaces)
+	}
+}
+
+func TestRunningDigests(t *testing.T) {
+	running := pod("nginx:latest", "redis:5")
+	running.Spec.Containers[0].Name = "web"
+	running.Spec.Containers[1].Name = "cache"
+	running.Spec.EphemeralContainers = []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox:1.31"}}}
+	running.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "web", ImageID: "docker-pullable://nginx@sha256:abc"},
+		{Name: "cache", ImageID: ""},
+	}
+	running.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{{Name: "debugger", ImageID: "docker.io/library/busybox@sha256:def"}}
+	if images := imagesFromPodSpec(running.Spec); len(images) != 3 || images[2] != "busybox:1.31" {
+		t.Errorf("Expected the image of the ephemeral container but got %v", images)
+	}
+	expected := map[string]string{"nginx:latest": "sha256:abc", "busybox:1.31": "sha256:def"}
+	if digests := runningDigests(running); !reflect.DeepEqual(digests, expected) {
+		t.Errorf("Expected %v but got %v", expected, digests)
+	}
+}
+
+func TestPodWorkload(t *testing.T) {
+	controller := true
+	owned := func(kind, name string) corev1.Pod {
+		p := pod("nginx:1.0")
+		p.Namespace = "web"
+		p.Name = "web-1"
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+		return p
+	}
+	owners := map[string]string{"ReplicaSet/web-5d8f": "Deployment/web", "Job/backup-2791": "CronJob/backup"}
+
+	hashed := owned("ReplicaSet", "api-6b9c7d")
+	hashed.Labels = map[string]string{"pod-template-hash": "6b9c7d"}
+
+	deploymentConfig := owned("ReplicationController", "app-3")
+	deploymentConfig.Annotations = map[string]string{"openshift.io/deployment-config.name": "app"}
+
+	apiServer := owned("Node", "control-1")
+	apiServer.Namespace = "kube-system"
+	apiServer.Name = "kube-apiserver-control-1"
+	apiServer.Spec.NodeName = "control-1"
+	apiServer.Annotations = map[string]string{"kubernetes.io/config.mirror": "5c9f"}
+
+	standalone := pod("nginx:1.0")
+	standalone.Namespace = "web"
+	standalone.Name = "debug"
+	for expected, p := range map[string]corev1.Pod{
+		"web/Deployment/web":                   owned("ReplicaSet", "web-5d8f"),
+		"web/ReplicaSet/old":                   owned("ReplicaSet", "old"),
+		"web/StatefulSet/db":                   owned("StatefulSet", "db"),
+		"web/CronJob/backup":                   owned("Job", "backup-2791"),
+		"web/Deployment/api":                   hashed,
+		"web/DeploymentConfig/app":             deploymentConfig,
+		"web/Pod/debug":                        standalone,
+		"kube-system/StaticPod/kube-apiserver": apiServer,
+	} {
+		if workload := podWorkload(p, owners); workload != expected {
+			t.Errorf("Expected %s but got %s", expected, workload)
+		}
+	}
+}
+
+func TestReplicasCountTheRunningPodsOfTheWorkload(t *testing.T) {
+	controller := true
+	owners := map[string]string{"ReplicaSet/web-5d8f": "Deployment/web"}
+	images := newCollectedImages()
+	// the first pod is read twice like when the list expired while paging
+	for _, name := range []string{"web-5d8f-a", "web-5d8f-b", "web-5d8f-a", "debug"} {
+		p := pod("nginx:1.0")
+		p.Namespace = "web"
+		p.Name = name
+		if name != "debug" {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f", Controller: &controller}}
+		}
+		images.addPod("web", p, owners)
+	}
+	finished := pod("nginx:1.0")
+	finished.Namespace = "web"
+	finished.Name = "migrate"
+	finished.Status.Phase = corev1.PodSucceeded
+	images.addPod("web", finished, owners)
+
+	expected := map[string]int{"web/Deployment/web": 2, "web/Pod/debug": 1}
+	if replicas := images.replicas("nginx:1.0"); !reflect.DeepEqual(replicas, expected) {
+		t.Errorf("Expected %v but got %v", expected, replicas)
+	}
+	images.rename("nginx:1.0", "docker.io/library/nginx:1.0")
+	if replicas := images.replicas("docker.io/library/nginx:1.0"); !reflect.DeepEqual(replicas, expected) {
+		t.Errorf("Expected the replicas to be renamed but got %v", replicas)
+	}
+}
+
+func TestCollectWorkloadImages(t *testing.T) {
+	controller := true
+	template := func(image string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: image}}}}
+	}
+	lists := map[string]interface{}{
+		"/apis/apps/v1/namespaces/web/deployments": appsv1.DeploymentList{Items: []appsv1.Deployment{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web"}, Spec: appsv1.DeploymentSpec{Template: template("nginx:1.0")}},
+		}},
+		"/apis/apps/v1/namespaces/web/replicasets": appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web-old", OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &controller}}}, Spec: appsv1.ReplicaSetSpec{Template: template("nginx:0.9")}},
+		}},
+		"/apis/batch/v1beta1/namespaces/web/cronjobs": batchv1beta1.CronJobList{Items: []batchv1beta1.CronJob{
+			{ObjectMeta: metav1.ObjectMeta{Name: "backup"}, Spec: batchv1beta1.CronJobSpec{JobTemplate: batchv1beta1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template("backup:2")}}}},
+		}},
+		"/apis/batch/v1/namespaces/web/jobs": batchv1.JobList{Items: []batchv1.Job{
+			{ObjectMeta: metav1.ObjectMeta{Name: "backup-2791", OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", Controller: &controller}}}, Spec: batchv1.JobSpec{Template: template("backup:1")}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "migrate"}, Spec: batchv1.JobSpec{Template: template("migrate:1")}},
+		}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		list, exists := lists[req.URL.Path]
+		if !exists && strings.HasSuffix(req.URL.Path, "/cronjobs") {
+			// the cluster only serves cron jobs in batch/v1beta1
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+		if !exists {
+			list = metav1.List{}
+		}
+		json.NewEncoder(w).Encode(list)
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := newCollectedImages()
+	owners, err := collectWorkloadImages(context.Background(), client, "web", images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"nginx:1.0": {"web/Deployment/web"},
+		"backup:2":  {"web/CronJob/backup"},
+		"migrate:1": {"web/Job/migrate"},
+	}
+	if !reflect.DeepEqual(images.workloads, expected) {
+		t.Errorf("Expected %v but got %v", expected, images.workloads)
+	}
+	if owners["ReplicaSet/web-old"] != "Deployment/web" || owners["Job/backup-2791"] != "CronJob/backup" {
+		t.Errorf("Expected the owners of the replica set and the job but got %v", owners)
+	}
+}
+
+func TestOpenShiftImages(t *testing.T) {
+	registry := "image-registry.openshift-image-registry.svc:5000"
+	responses := map[string]string{
+		"/apis/apps.openshift.io/v1/namespaces/web/deploymentconfigs": `{"items": [
+			{"metadata": {"name": "app"}, "spec": {
+				"template": {"spec": {"containers": [{"name": "app", "image": " "}, {"name": "proxy", "image": "nginx:1.0"}]}},
+				"triggers": [{"type": "ImageChange", "imageChangeParams": {"containerNames": ["app"], "from": {"kind": "ImageStreamTag", "name": "app:1.2"}}}]}},
+			{"metadata": {"name": "worker"}, "spec": {
+				"template": {"spec": {"containers": [{"name": "worker", "image": " "}]}},
+				"triggers": [{"type": "ImageChange", "imageChangeParams": {"containerNames": ["worker"], "from": {"kind": "ImageStreamTag", "namespace": "shared", "name": "worker:latest"},
+					"lastTriggeredImage": "` + registry + `/shared/worker@sha256:b"}}]}}]}`,
+		"/apis/image.openshift.io/v1/namespaces/web/imagestreams": `{"items": [{"metadata": {"name": "app"},
+			"spec": {"tags": [{"name": "1.2", "from": {"kind": "DockerImage", "name": "quay.io/org/app:1.2"}}]},
+			"status": {"dockerImageRepository": "` + registry + `/web/app", "tags": [{"tag": "1.2", "items": [{"dockerImageReference": "quay.io/org/app@sha256:a", "image": "sha256:a"}]}]}}]}`,
+		"/apis/image.openshift.io/v1/namespaces/shared/imagestreams/worker": `{"metadata": {"name": "worker"},
+			"status": {"dockerImageRepository": "` + registry + `/shared/worker", "tags": [{"tag": "latest", "items": [{"dockerImageReference": "` + registry + `/shared/worker@sha256:c", "image": "sha256:c"},
+				{"dockerImageReference": "` + registry + `/shared/worker@sha256:b", "image": "sha256:b"}]}]}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response, exists := responses[req.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := newCollectedImages()
+	streams := newImageStreams(client.CoreV1().RESTClient())
+	if err := streams.load(context.Background(), "web"); err != nil {
+		t.Fatal(err)
+	}
+	if err := collectDeploymentConfigImages(context.Background(), client, "web", images, streams); err != nil {
+		t.Fatal(err)
+	}
+	images.add("web", "web/Pod/app-1", pod(registry+"/web/app@sha256:a").Spec)
+	if errs := streams.resolveImages(context.Background(), images); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	expected := map[string][]string{
+		"quay.io/org/app:1.2":                {"web/DeploymentConfig/app", "web/Pod/app-1"},
+		"nginx:1.0":                          {"web/DeploymentConfig/app"},
+		registry + "/shared/worker@sha256:b": {"web/DeploymentConfig/worker"},
+	}
+	if !reflect.DeepEqual(images.workloads, expected) {
+		t.Errorf("Expected %v but got %v", expected, images.workloads)
+	}
+	// a cluster without the OpenShift groups has no deployment configs
+	if err := collectDeploymentConfigImages(context.Background(), client, "other", images, streams); err != nil {
+		t.Errorf("Expected no error without deployment configs but got %v", err)
+	}
+}
+
+func TestCollectCustomResourceImages(t *testing.T) {
+	responses := map[string]string{
+		"/apis/argoproj.io/v1alpha1/namespaces/jobs/workflowtemplates": `{"apiVersion": "argoproj.io/v1alpha1", "kind": "WorkflowTemplateList", "metadata": {}, "items": [
+			{"apiVersion": "argoproj.io/v1alpha1", "kind": "WorkflowTemplate", "metadata": {"name": "report"}, "spec": {"templates": [
+				{"name": "main", "steps": [[{"name": "fetch", "template": "fetch"}]]},
+				{"name": "fetch", "container": {"image": "curl:8.0"}},
+				{"name": "render", "script": {"image": "python:3.12"}, "sidecars": [{"image": "redis:7"}]}]}}]}`,
+		"/apis/install.istio.io/v1alpha1/namespaces/jobs/istiooperators": `{"apiVersion": "install.istio.io/v1alpha1", "kind": "IstioOperatorList", "metadata": {}, "items": [
+			{"apiVersion": "install.istio.io/v1alpha1", "kind": "IstioOperator", "metadata": {"name": "mesh"}, "spec": {"hub": "docker.io/istio", "tag": "1.20.0"}},
+			{"apiVersion": "install.istio.io/v1alpha1", "kind": "IstioOperator", "metadata": {"name": "defaults"}, "spec": {}}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response, exists := responses[req.URL.Path]
+		if !exists {
+			// the custom resource is not installed
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := newCollectedImages()
+	ctx := WithOptions(context.Background(), Options{CustomResources: DefaultCustomResources()})
+	if err := collectCustomResourceImages(ctx, client, "jobs", images); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"curl:8.0":                       {"jobs/WorkflowTemplate/report"},
+		"python:3.12":                    {"jobs/WorkflowTemplate/report"},
+		"redis:7":                        {"jobs/WorkflowTemplate/report"},
+		"docker.io/istio/proxyv2:1.20.0": {"jobs/IstioOperator/mesh"},
+		"docker.io/istio/pilot:1.20.0":   {"jobs/IstioOperator/mesh"},
+	}
+	if !reflect.DeepEqual(images.workloads, expected) {
+		t.Errorf("Expected %v but got %v", expected, images.workloads)
+	}
+	if err := (CustomResource{Name: "broken", GroupVersion: "tekton.dev/v1", Resource: "tasks", Paths: []string{"{.spec.steps[*].image"}}).Validate(); err == nil {
+		t.Errorf("Expected an error for a path that is not valid")
+	}
+}
+
+func TestGetNamespacesMatchesAndExcludes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		namespaces := corev1.NamespaceList{}
+		for _, name := range []string{"default", "pr-12", "team-a", "team-b", "team-b-pr-3"} {
+			namespaces.Items = append(namespaces.Items, corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		}
+		json.NewEncoder(w).Encode(namespaces)
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithOptions(context.Background(), Options{ExcludedNamespaces: []string{"pr-.*", ".*-pr-[0-9]+"}})
+	for _, test := range []struct {
+		namespaces []string
+		expected   []string
+	}{
+		{nil, []string{"default", "team-a", "team-b"}},
+		{[]string{"team-.*"}, []string{"team-a", "team-b"}},
+		{[]string{"team-b", "pr-12"}, []string{"team-b"}},
+	} {
+		namespaces, err := getNamespaces(ctx, test.namespaces, client)
+		if err != nil || !reflect.DeepEqual(namespaces, test.expected) {
+			t.Errorf("Expected %v for %v but got %v and %v", test.expected, test.namespaces, namespaces, err)
+		}
+	}
+}
+
+func TestForbiddenNamespacesAndPodsAreSkipped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL.Path == "/api/v1/namespaces/lcm/pods" {
+			json.NewEncoder(w).Encode(corev1.PodList{Items: []corev1.Pod{pod("nginx:1.0")}})
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonForbidden, Code: http.StatusForbidden})
+	}))
+	defer server.Close()
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(kubeconfig.Name())
+	kubeconfig.WriteString(`apiVersion: v1
+kind: Config
+clusters: [{name: test, cluster: {server: "` + server.URL + `"}}]
+users: [{name: test, user: {}}]
+contexts: [{name: test, context: {cluster: test, user: test, namespace: lcm}}]
+current-context: test
+`)
+	kubeconfig.Close()
+	ctx := WithTarget(context.Background(), Target{Kubeconfig: kubeconfig.Name()})
+
+	options := DefaultOptions()
+	options.Workloads = false
+	// without permission to list the namespaces only the namespace of the context is scanned
+	containers, err := GetContainersFromNamespaces(ctx, nil, false, options)
+	if len(containers) != 1 || containers[0].Namespaces[0] != "lcm" {
+		t.Errorf("Expected the images of the own namespace but got %v", containers)
+	}
+	if errs := err.(utilerrors.Aggregate).Errors(); len(errs) != 1 || !IsSkipped(errs[0]) {
+		t.Errorf("Expected the namespaces to be skipped but got %v", err)
+	}
+
+	// a namespace without permission to list the pods is skipped
+	_, err = GetContainersFromNamespaces(ctx, []string{"lcm", "web"}, false, options)
+	var skipped *SkippedError
+	if errs := err.(utilerrors.Aggregate).Errors(); len(errs) != 1 || !errors.As(errs[0], &skipped) || skipped.Skipped() != "pods in namespace [web]" {
+		t.Errorf("Expected the pods of web to be skipped but got %v", err)
+	}
+}
+
+func TestCollectRunningImagesFromTheCache(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, p := range []corev1.Pod{pod("nginx:1.0"), pod("redis:5")} {
+		p := p
+		p.Name = p.Spec.Containers[0].Image
+		p.Namespace = "default"
+		indexer.Add(&p)
+	}
+	other := pod("busybox")
+	other.Name = "other"
+	other.Namespace = "other"
+	indexer.Add(&other)
+	podCache.listers = map[string]corelisters.PodLister{metav1.NamespaceAll: corelisters.NewPodLister(indexer)}
+	defer func() { podCache.listers = nil }()
+
+	images := newCollectedImages()
+	// the client is not used when the pods are cached
+	if err := collectRunningImages(context.Background(), nil, "default", images, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{"nginx:1.0": {"default"}, "redis:5": {"default"}}
+	if !reflect.DeepEqual(images.namespaces, expected) {
+		t.Errorf("Expected %v but got %v", expected, images.namespaces)
+	}
+}
+
+func TestPodCacheOnlyWatchesTheScannedNamespaces(t *testing.T) {
+	stop := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v1/namespaces/team-a/pods" {
+			t.Errorf("Expected only the pods of team-a to be watched but got [%s]", req.URL.Path)
+		}
+		if req.URL.Query().Get("watch") == "true" {
+			select {
+			case <-req.Context().Done():
+			case <-stop:
+			}
+			return
+		}
+		p := pod("nginx:1.0")
+		p.Name = "web"
+		p.Namespace = "team-a"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: []corev1.Pod{p}})
+	}))
+	defer server.Close()
+	defer close(stop)
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := startPodCache(client, []string{"team-a", "pr-1"}, Options{Timeout: time.Second, ExcludedNamespaces: []string{"pr-.*"}}, stop); err != nil {
+		t.Fatal(err)
+	}
+	pods, cached, err := cachedPods(context.Background(), "team-a")
+	if !cached || err != nil || len(pods) != 1 {
+		t.Errorf("Expected the cached pod of team-a but got %v, %v and %v", pods, cached, err)
+	}
+	if _, cached, _ := cachedPods(context.Background(), "team-b"); cached {
+		t.Errorf("Expected the pods of team-b not to be cached")
+	}
+}
+
+func TestPodCacheGivesUpWithoutPermissionToWatchThePods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonForbidden, Code: http.StatusForbidden})
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := startPodCache(client, nil, Options{Timeout: 200 * time.Millisecond}, stop); err == nil {
+		t.Error("Expected the pod cache not to sync")
+	}
+	if _, cached, _ := cachedPods(context.Background(), "default"); cached {
+		t.Errorf("Expected the pods to be listed without the cache")
+	}
+}
+
+func TestStoppingAPreviousPodCacheKeepsTheNextOne(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("watch") == "true" {
+			select {
+			case <-req.Context().Done():
+			case <-done:
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}})
+	}))
+	defer server.Close()
+	defer close(done)
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, next := make(chan struct{}), make(chan struct{})
+	defer close(next)
+	for _, stop := range []chan struct{}{previous, next} {
+		if err := startPodCache(client, []string{"team-a"}, Options{Timeout: time.Second}, stop); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(previous)
+	time.Sleep(50 * time.Millisecond)
+	if _, cached, _ := cachedPods(context.Background(), "team-a"); !cached {
+		t.Error("Expected the pods of team-a to stay cached by the next pod cache")
+	}
+}
@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElection contains the settings to elect a single leader between replicas with a Kubernetes lease
+// Without a namespace the namespace lcm is running in is used, without an identity the hostname which is the pod name
+type LeaderElection struct {
+	Namespace     string
+	Name          string
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// RunLeaderElection takes part in the election until the context is done, onStartedLeading is called when this replica
+// becomes the leader with a context that is cancelled when the leadership is lost, after which onStoppedLeading is called
+func RunLeaderElection(ctx context.Context, election LeaderElection, useLocally bool, onStartedLeading func(context.Context), onStoppedLeading func()) error {
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return err
+	}
+	if election.Namespace == "" {
+		election.Namespace = currentNamespace()
+	}
+	if election.Identity == "" {
+		if election.Identity, err = os.Hostname(); err != nil {
+			return err
+		}
+	}
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, election.Namespace, election.Name,
+		client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: election.Identity})
+	if err != nil {
+		return err
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            election.Name,
+		LeaseDuration:   election.LeaseDuration,
+		RenewDeadline:   election.RenewDeadline,
+		RetryPeriod:     election.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: onStartedLeading,
+			OnStoppedLeading: onStoppedLeading,
+			OnNewLeader: func(identity string) {
+				logger.WithField("leader", identity).Info("Leader elected")
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.WithField("lease", election.Namespace+"/"+election.Name).WithField("identity", election.Identity).Info("Taking part in the leader election")
+	elector.Run(ctx)
+	return nil
+}
@@ -0,0 +1,108 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Node contains the versions of the components of a node as reported by its kubelet
+type Node struct {
+	Name                    string
+	KubeletVersion          string
+	KubeProxyVersion        string
+	ContainerRuntimeVersion string // Like containerd://1.7.2
+	KernelVersion           string
+	OSImage                 string
+}
+
+// GetNodes returns the nodes of the cluster sorted by name, together with the version of the API server
+func GetNodes(ctx context.Context, useLocally bool) ([]Node, string, error) {
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return nil, "", err
+	}
+	serverVersion, err := getServerVersion(ctx, client)
+	if err != nil {
+		return nil, "", err
+	}
+	nodes, err := getNodes(ctx, client)
+	return nodes, serverVersion, err
+}
+
+// GetServerVersion returns the version of the API server like v1.29.3
+func GetServerVersion(ctx context.Context, useLocally bool) (string, error) {
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return "", err
+	}
+	return getServerVersion(ctx, client)
+}
+
+// getServerVersion returns the git version of the API server like v1.29.3
+func getServerVersion(ctx context.Context, client *kubernetes.Clientset) (string, error) {
+	body, err := client.Discovery().RESTClient().Get().
+		Context(audit.WithPurpose(ctx, "server version")).
+		AbsPath("/version").
+		Do().
+		Raw()
+	if err != nil {
+		return "", fmt.Errorf("Could not fetch the server version: %w", err)
+	}
+	var info version.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", fmt.Errorf("Could not decode the server version: %w", err)
+	}
+	return info.GitVersion, nil
+}
+
+func getNodes(ctx context.Context, client *kubernetes.Clientset) ([]Node, error) {
+	ctx = audit.WithPurpose(ctx, "nodes")
+	var nodes []Node
+	options := metav1.ListOptions{Limit: optionsFrom(ctx).PageSize}
+	for {
+		list := &corev1.NodeList{}
+		err := client.CoreV1().RESTClient().Get().
+			Context(ctx).
+			Resource("nodes").
+			VersionedParams(&options, scheme.ParameterCodec).
+			Do().
+			Into(list)
+		if apierrors.IsResourceExpired(err) && options.Continue != "" {
+			nodes = nil
+			options.Continue = ""
+			continue
+		}
+		if err != nil {
+			return nodes, fmt.Errorf("Could not fetch nodes: %w", err)
+		}
+		for _, node := range list.Items {
+			info := node.Status.NodeInfo
+			nodes = append(nodes, Node{
+				Name:                    node.Name,
+				KubeletVersion:          info.KubeletVersion,
+				KubeProxyVersion:        info.KubeProxyVersion,
+				ContainerRuntimeVersion: info.ContainerRuntimeVersion,
+				KernelVersion:           info.KernelVersion,
+				OSImage:                 info.OSImage,
+			})
+		}
+		options.Continue = list.Continue
+		if options.Continue == "" {
+			break
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes, nil
+}
@@ -0,0 +1,342 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// deploymentConfigAnnotation is set by OpenShift on the pods of a deployment config with the name of the deployment config
+const deploymentConfigAnnotation = "openshift.io/deployment-config.name"
+
+// SetOpenShiftEnabled sets if the deployment configs are scanned and the images of the integrated registry are resolved through the image streams by default
+func SetOpenShiftEnabled(enabled bool) {
+	setDefault(func(options *Options) { options.OpenShift = enabled })
+}
+
+// deploymentConfigList is the part of the apps.openshift.io/v1 DeploymentConfigList that is used
+type deploymentConfigList struct {
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []deploymentConfig `json:"items"`
+}
+
+type deploymentConfig struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		Template *corev1.PodTemplateSpec `json:"template,omitempty"`
+		Triggers []deploymentTrigger     `json:"triggers,omitempty"`
+	} `json:"spec"`
+}
+
+// deploymentTrigger replaces the images of the containers when the image stream tag it follows changes
+type deploymentTrigger struct {
+	Type              string `json:"type"`
+	ImageChangeParams *struct {
+		ContainerNames     []string               `json:"containerNames,omitempty"`
+		From               corev1.ObjectReference `json:"from"`
+		LastTriggeredImage string                 `json:"lastTriggeredImage,omitempty"`
+	} `json:"imageChangeParams,omitempty"`
+}
+
+// imageStreamList is the part of the image.openshift.io/v1 ImageStreamList that is used
+type imageStreamList struct {
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []imageStream `json:"items"`
+}
+
+type imageStream struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		Tags []struct {
+			Name string                  `json:"name"`
+			From *corev1.ObjectReference `json:"from,omitempty"`
+		} `json:"tags,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		DockerImageRepository       string `json:"dockerImageRepository"`
+		PublicDockerImageRepository string `json:"publicDockerImageRepository,omitempty"`
+		// Tags contain the history of every tag, the newest image first
+		Tags []struct {
+			Tag   string `json:"tag"`
+			Items []struct {
+				DockerImageReference string `json:"dockerImageReference"`
+				Image                string `json:"image"`
+			} `json:"items"`
+		} `json:"tags,omitempty"`
+	} `json:"status"`
+}
+
+// backingImage returns the image that the tag or the digest of the image stream points to, the external image the tag
+// is imported from when the newest image of the tag is asked for, otherwise the image as referenced by the image stream
+// An empty string is returned when the image stream doesn't have the tag or the digest
+func (s *imageStream) backingImage(tag, digest string) string {
+	for _, statusTag := range s.Status.Tags {
+		if tag != "" && statusTag.Tag != tag {
+			continue
+		}
+		for i, item := range statusTag.Items {
+			if digest != "" && item.Image != digest {
+				continue
+			}
+			if from := s.importedFrom(statusTag.Tag); from != "" && i == 0 {
+				return from
+			}
+			return item.DockerImageReference
+		}
+	}
+	return ""
+}
+
+// importedFrom returns the external image like quay.io/org/app:1.2 that the tag is imported from
+func (s *imageStream) importedFrom(tag string) string {
+	for _, specTag := range s.Spec.Tags {
+		if specTag.Name == tag && specTag.From != nil && specTag.From.Kind == "DockerImage" {
+			return specTag.From.Name
+		}
+	}
+	return ""
+}
+
+// imageStreams looks up the image streams that the images of the integrated registry and the deployment triggers refer to
+type imageStreams struct {
+	client rest.Interface
+	// streams are the image streams by namespace/name, nil when the image stream doesn't exist
+	streams map[string]*imageStream
+	// repositories are the image streams by their repository in the integrated registry
+	repositories map[string]*imageStream
+	// registries are the hosts of the integrated registry like image-registry.openshift-image-registry.svc:5000
+	registries map[string]bool
+}
+
+func newImageStreams(client rest.Interface) *imageStreams {
+	return &imageStreams{
+		client:       client,
+		streams:      map[string]*imageStream{},
+		repositories: map[string]*imageStream{},
+		registries:   map[string]bool{},
+	}
+}
+
+func (s *imageStreams) store(namespace, name string, stream *imageStream) {
+	s.streams[namespace+"/"+name] = stream
+	if stream == nil {
+		return
+	}
+	for _, repository := range []string{stream.Status.DockerImageRepository, stream.Status.PublicDockerImageRepository} {
+		if repository == "" {
+			continue
+		}
+		s.repositories[repository] = stream
+		s.registries[strings.SplitN(repository, "/", 2)[0]] = true
+	}
+}
+
+// load fetches the image streams of the namespace, a cluster without the image.openshift.io group has no image streams
+// The label selector is not used because the pods can use the image streams of any label
+func (s *imageStreams) load(ctx context.Context, namespace string) error {
+	ctx = audit.WithPurpose(ctx, "image streams of namespace "+namespace)
+	err := listPages(ctx, s.client, "image.openshift.io/v1", namespace, "imagestreams", "", func() (interface{}, func() string) {
+		streams := &imageStreamList{}
+		return streams, func() string {
+			for i := range streams.Items {
+				s.store(namespace, streams.Items[i].Name, &streams.Items[i])
+			}
+			return streams.Continue
+		}
+	})
+	if isNotFound(err) {
+		logger.WithField("namespace", namespace).Debug("The cluster has no image streams")
+		return nil
+	}
+	return err
+}
+
+// get returns the image stream, also of a namespace that is not scanned, or nil when it doesn't exist
+func (s *imageStreams) get(ctx context.Context, namespace, name string) (*imageStream, error) {
+	if stream, exists := s.streams[namespace+"/"+name]; exists {
+		return stream, nil
+	}
+	body, err := s.client.Get().
+		Context(audit.WithPurpose(ctx, "image stream "+namespace+"/"+name)).
+		AbsPath("/apis/image.openshift.io/v1", "namespaces", namespace, "imagestreams", name).
+		Do().
+		Raw()
+	if isNotFound(err) {
+		s.store(namespace, name, nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, skipForbidden(fmt.Errorf("Could not fetch image stream [%s/%s]: %w", namespace, name, err), namespace, "image streams")
+	}
+	stream := &imageStream{}
+	if err := json.Unmarshal(body, stream); err != nil {
+		return nil, fmt.Errorf("Could not decode image stream [%s/%s]: %w", namespace, name, err)
+	}
+	s.store(namespace, name, stream)
+	return stream, nil
+}
+
+// resolveReference returns the image that the trigger source points to, an ImageStreamTag like app:1.2, an ImageStreamImage
+// like app@sha256:... or a DockerImage, the namespace is used when the reference has none
+func (s *imageStreams) resolveReference(ctx context.Context, namespace string, from corev1.ObjectReference) (string, error) {
+	if from.Namespace != "" {
+		namespace = from.Namespace
+	}
+	var name, tag, digest string
+	switch from.Kind {
+	case "DockerImage":
+		return from.Name, nil
+	case "ImageStreamTag":
+		name, tag = splitLast(from.Name, ":")
+	case "ImageStreamImage":
+		name, digest = splitLast(from.Name, "@")
+	default:
+		return "", nil
+	}
+	stream, err := s.get(ctx, namespace, name)
+	if err != nil || stream == nil {
+		return "", err
+	}
+	return stream.backingImage(tag, digest), nil
+}
+
+// resolveImage returns the image like quay.io/org/app:1.2 that an image of the integrated registry like
+// image-registry.openshift-image-registry.svc:5000/web/app@sha256:... points to, or the image itself when it's not of an image stream
+func (s *imageStreams) resolveImage(ctx context.Context, image string) (string, error) {
+	repository, digest := splitLast(image, "@")
+	tag := ""
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+	if tag == "" && digest == "" {
+		tag = "latest"
+	}
+	stream, known := s.repositories[repository]
+	if !known {
+		// the image streams of namespaces that are not scanned are fetched when the image is of the integrated registry
+		parts := strings.Split(repository, "/")
+		if len(parts) != 3 || !s.registries[parts[0]] {
+			return image, nil
+		}
+		var err error
+		if stream, err = s.get(ctx, parts[1], parts[2]); err != nil || stream == nil {
+			return image, err
+		}
+	}
+	if backing := stream.backingImage(tag, digest); backing != "" {
+		return backing, nil
+	}
+	return image, nil
+}
+
+// resolveImages replaces the images of the integrated registry with the images the image streams point to
+// The images that can't be resolved are kept as they are
+func (s *imageStreams) resolveImages(ctx context.Context, images *collectedImages) []error {
+	var errs []error
+	var found []string
+	for image := range images.namespaces {
+		found = append(found, image)
+	}
+	for _, image := range found {
+		resolved, err := s.resolveImage(ctx, image)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if resolved != image {
+			logger.WithField("image", image).WithField("resolved", resolved).Debug("Resolved the image through the image stream")
+			images.rename(image, resolved)
+		}
+	}
+	return errs
+}
+
+// collectDeploymentConfigImages adds the images of the pod templates of the deployment configs in the namespace, the images
+// of the containers with an image change trigger are the images that were deployed last or the image the trigger points to,
+// a cluster without the apps.openshift.io group has no deployment configs
+func collectDeploymentConfigImages(ctx context.Context, client *kubernetes.Clientset, namespace string, images *collectedImages, streams *imageStreams) error {
+	ctx = audit.WithPurpose(ctx, "deployment configs of namespace "+namespace)
+	var resolveErr error
+	err := listWorkloads(ctx, client.CoreV1().RESTClient(), "apps.openshift.io/v1", namespace, "deploymentconfigs", func() (interface{}, func() string) {
+		deploymentConfigs := &deploymentConfigList{}
+		return deploymentConfigs, func() string {
+			for _, deploymentConfig := range deploymentConfigs.Items {
+				if deploymentConfig.Spec.Template == nil {
+					continue
+				}
+				spec, err := triggeredPodSpec(ctx, namespace, deploymentConfig, streams)
+				if err != nil {
+					resolveErr = err
+				}
+				images.add(namespace, workloadName(namespace, "DeploymentConfig", deploymentConfig.Name), spec)
+			}
+			return deploymentConfigs.Continue
+		}
+	})
+	if isNotFound(err) {
+		logger.WithField("namespace", namespace).Debug("The cluster has no deployment configs")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resolveErr
+}
+
+// triggeredPodSpec returns the pod spec of the deployment config with the images of the image change triggers,
+// containers without an image, because the trigger never ran and can't be resolved, are left out
+func triggeredPodSpec(ctx context.Context, namespace string, deploymentConfig deploymentConfig, streams *imageStreams) (corev1.PodSpec, error) {
+	triggered := map[string]string{}
+	var resolveErr error
+	for _, trigger := range deploymentConfig.Spec.Triggers {
+		params := trigger.ImageChangeParams
+		if trigger.Type != "ImageChange" || params == nil {
+			continue
+		}
+		image := params.LastTriggeredImage
+		if image == "" {
+			resolved, err := streams.resolveReference(ctx, namespace, params.From)
+			if err != nil {
+				resolveErr = err
+			}
+			image = resolved
+		}
+		for _, name := range params.ContainerNames {
+			if image != "" {
+				triggered[name] = image
+			}
+		}
+	}
+
+	spec := *deploymentConfig.Spec.Template.Spec.DeepCopy()
+	withImages := func(containers []corev1.Container) []corev1.Container {
+		var kept []corev1.Container
+		for _, container := range containers {
+			if image, exists := triggered[container.Name]; exists {
+				container.Image = image
+			}
+			if strings.TrimSpace(container.Image) != "" {
+				kept = append(kept, container)
+			}
+		}
+		return kept
+	}
+	spec.Containers = withImages(spec.Containers)
+	spec.InitContainers = withImages(spec.InitContainers)
+	return spec, resolveErr
+}
+
+// splitLast splits value at the last separator, the second part is empty when the separator is missing
+func splitLast(value, separator string) (string, string) {
+	if i := strings.LastIndex(value, separator); i >= 0 {
+		return value[:i], value[i+len(separator):]
+	}
+	return value, ""
+}
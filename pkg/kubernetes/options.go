@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Options are the settings of the calls to the Kubernetes API and of the images that are collected, so programs that embed lcm
+// can scan clusters with different settings in one process. The Set functions change the defaults that calls without options use
+type Options struct {
+	// Timeout is used for all the calls to the Kubernetes API
+	Timeout time.Duration
+	// PageSize is the maximum number of pods fetched per call, so only one page of pods is kept in memory at a time
+	PageSize int64
+	// ExcludedNamespaces are the names or regular expressions of the namespaces that are never scanned
+	ExcludedNamespaces []string
+	// LabelSelector limits the pods and workloads that are scanned to the ones with matching labels, all of them when it is empty
+	LabelSelector string
+	// Workloads adds the images of the pod templates of the workloads, so workloads that are scaled to zero are included
+	Workloads bool
+	// OpenShift adds the images of the deployment configs and resolves the images of image streams to the images they point to
+	OpenShift bool
+	// CustomResources are the custom resources that are scanned for images next to the workloads
+	CustomResources []CustomResource
+}
+
+var (
+	defaultsLock sync.RWMutex
+	defaults     = Options{Timeout: 30 * time.Second, PageSize: 500, Workloads: true}
+)
+
+// DefaultOptions returns the options of the calls without options, as changed by the Set functions
+func DefaultOptions() Options {
+	defaultsLock.RLock()
+	defer defaultsLock.RUnlock()
+	return defaults
+}
+
+// setDefault changes the default options with set
+func setDefault(set func(*Options)) {
+	defaultsLock.Lock()
+	defer defaultsLock.Unlock()
+	set(&defaults)
+}
+
+type optionsKey struct{}
+
+// WithOptions returns a context that makes all calls with the context use the options instead of the default options
+func WithOptions(ctx context.Context, options Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, options)
+}
+
+// optionsFrom returns the options of the context, the default options when the context has none
+func optionsFrom(ctx context.Context) Options {
+	if options, ok := ctx.Value(optionsKey{}).(Options); ok {
+		return options
+	}
+	return DefaultOptions()
+}
@@ -0,0 +1,127 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// podCache keeps the pods of the cluster in memory with shared informers while the server runs,
+// so a scan reads the pods from memory instead of listing them from the Kubernetes API every time
+// The listers are kept per namespace, the lister of all namespaces is kept under metav1.NamespaceAll
+// The generation changes every time the cache is started, so stopping a previous cache doesn't remove the listers of the next one
+var podCache struct {
+	sync.RWMutex
+	listers    map[string]corelisters.PodLister
+	generation int
+}
+
+// StartPodCache starts the informers for the pods that match the label selector of the options in the scanned namespaces and waits until
+// they have synced, the scans without a target use them until stop is closed
+// When the informers don't sync within the timeout of the options, for example because lcm may not watch the pods, they are stopped
+// and an error is returned so the pods are listed for every scan instead
+func StartPodCache(namespaces []string, useLocally bool, options Options, stop <-chan struct{}) error {
+	client, err := getKubernetesClient(WithOptions(context.Background(), options), useLocally)
+	if err != nil {
+		return err
+	}
+	return startPodCache(client, namespaces, options, stop)
+}
+
+func startPodCache(client *kubernetes.Clientset, namespaces []string, settings Options, stop <-chan struct{}) error {
+	scoped, err := podCacheNamespaces(client, namespaces, settings)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+	stopInformers := func() { once.Do(func() { close(done) }) }
+	listers := map[string]corelisters.PodLister{}
+	var synced []cache.InformerSynced
+	for _, namespace := range scoped {
+		factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace), informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = settings.LabelSelector
+		}))
+		pods := factory.Core().V1().Pods()
+		synced = append(synced, pods.Informer().HasSynced)
+		listers[namespace] = pods.Lister()
+		factory.Start(done)
+	}
+	logger.WithField("namespaces", scoped).Info("Starting the pod cache")
+
+	// the sync is given up after the timeout or when stop is closed
+	syncStop := make(chan struct{})
+	go func() {
+		select {
+		case <-stop:
+		case <-time.After(settings.Timeout):
+		case <-done:
+		}
+		close(syncStop)
+	}()
+	if !cache.WaitForCacheSync(syncStop, synced...) {
+		stopInformers()
+		return fmt.Errorf("Could not sync the pod cache within [%s]", settings.Timeout)
+	}
+
+	podCache.Lock()
+	podCache.listers = listers
+	podCache.generation++
+	generation := podCache.generation
+	podCache.Unlock()
+	go func() {
+		<-stop
+		stopInformers()
+		podCache.Lock()
+		if podCache.generation == generation {
+			podCache.listers = nil
+		}
+		podCache.Unlock()
+	}()
+	logger.Info("Pod cache synced")
+	return nil
+}
+
+// podCacheNamespaces returns the namespaces that get an informer, metav1.NamespaceAll when all namespaces are scanned without excludes
+// Namespaces that are created later and match a regular expression are not cached, their pods are listed for every scan
+func podCacheNamespaces(client *kubernetes.Clientset, namespaces []string, options Options) ([]string, error) {
+	if len(namespaces) == 0 && len(options.ExcludedNamespaces) == 0 {
+		return []string{metav1.NamespaceAll}, nil
+	}
+	ctx, cancel := context.WithTimeout(WithOptions(context.Background(), options), options.Timeout)
+	defer cancel()
+	selected, err := getNamespaces(ctx, namespaces, client)
+	if err != nil {
+		return nil, fmt.Errorf("Could not select the namespaces of the pod cache: %w", err)
+	}
+	return selected, nil
+}
+
+// cachedPods returns the pods of the namespace from the pod cache, false when the cache doesn't hold the pods of the namespace
+// because it isn't started, the namespace isn't cached or the context has a target
+func cachedPods(ctx context.Context, namespace string) ([]*corev1.Pod, bool, error) {
+	if _, ok := targetFrom(ctx); ok {
+		return nil, false, nil
+	}
+	podCache.RLock()
+	lister, exists := podCache.listers[namespace]
+	if !exists {
+		lister, exists = podCache.listers[metav1.NamespaceAll]
+	}
+	podCache.RUnlock()
+	if !exists {
+		return nil, false, nil
+	}
+	pods, err := lister.Pods(namespace).List(labels.Everything())
+	return pods, true, err
+}
@@ -0,0 +1,133 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetPullSecrets returns the docker configs of the imagePullSecrets per repository of the containers as url/name, the imagePullSecrets
+// of the pods that run the repository and of the default service account of their namespaces, so the secrets of a namespace
+// are only used for the images that run in it
+// Secrets that don't exist or can't be read because of missing permissions are skipped, the other failures are returned as an aggregated error
+func GetPullSecrets(ctx context.Context, containers []Container, useLocally bool) (map[string][][]byte, error) {
+	namespaces := map[string]bool{}
+	for _, container := range containers {
+		for _, namespace := range container.Namespaces {
+			namespaces[namespace] = true
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+	client, err := getKubernetesClient(ctx, useLocally)
+	if err != nil {
+		return nil, err
+	}
+	return getPullSecrets(ctx, client, containers, namespaces)
+}
+
+func getPullSecrets(ctx context.Context, client *kubernetes.Clientset, containers []Container, namespaces map[string]bool) (map[string][][]byte, error) {
+	var errs []error
+	accountSecrets := map[string][]string{}
+	for _, namespace := range sortedKeys(namespaces) {
+		account := &corev1.ServiceAccount{}
+		err := client.CoreV1().RESTClient().Get().Context(ctx).Namespace(namespace).Resource("serviceaccounts").Name("default").Do().Into(account)
+		if skipPullSecretError(err, "serviceaccount", namespace+"/default") {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Could not fetch the default service account in namespace [%s]: %w", namespace, err))
+			continue
+		}
+		for _, secret := range account.ImagePullSecrets {
+			accountSecrets[namespace] = append(accountSecrets[namespace], namespace+"/"+secret.Name)
+		}
+	}
+
+	// every secret is only fetched once, also when multiple repositories use it
+	secrets := map[string][]byte{}
+	configs := map[string][][]byte{}
+	for _, container := range containers {
+		references := map[string]bool{}
+		for _, reference := range container.PullSecrets {
+			references[reference] = true
+		}
+		for _, namespace := range container.Namespaces {
+			for _, reference := range accountSecrets[namespace] {
+				references[reference] = true
+			}
+		}
+		repository := container.URL + "/" + container.Name
+		for _, reference := range sortedKeys(references) {
+			config, fetched := secrets[reference]
+			if !fetched {
+				var err error
+				config, err = getPullSecret(ctx, client, reference)
+				if err != nil && !skipPullSecretError(err, "secret", reference) {
+					errs = append(errs, err)
+				}
+				secrets[reference] = config
+			}
+			if config != nil {
+				configs[repository] = append(configs[repository], config)
+			}
+		}
+	}
+	logger.WithField("secrets", len(secrets)).WithField("repositories", len(configs)).Debug("Fetched imagePullSecrets")
+	return configs, utilerrors.NewAggregate(errs)
+}
+
+// getPullSecret returns the docker config of the secret, or nil when the secret isn't a docker config
+func getPullSecret(ctx context.Context, client *kubernetes.Clientset, reference string) ([]byte, error) {
+	namespace, name := splitReference(reference)
+	secret := &corev1.Secret{}
+	err := client.CoreV1().RESTClient().Get().Context(ctx).Namespace(namespace).Resource("secrets").Name(name).Do().Into(secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Could not fetch imagePullSecret [%s]: %w", reference, err)
+	}
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		return secret.Data[corev1.DockerConfigJsonKey], nil
+	case corev1.SecretTypeDockercfg:
+		return secret.Data[corev1.DockerConfigKey], nil
+	}
+	logger.WithField("secret", reference).WithField("type", secret.Type).Debug("imagePullSecret is not a docker config")
+	return nil, nil
+}
+
+// pullSecretsForbidden makes sure the missing permission to read the imagePullSecrets is only logged once per resource
+var pullSecretsForbidden sync.Map
+
+// skipPullSecretError returns true when the resource doesn't exist or lcm isn't allowed to read it, which is logged instead of reported
+func skipPullSecretError(err error, resource, reference string) bool {
+	if apierrors.IsNotFound(err) {
+		logger.WithField(resource, reference).Debug("Could not find the " + resource + " of the imagePullSecrets")
+		return true
+	}
+	if apierrors.IsForbidden(err) {
+		if _, logged := pullSecretsForbidden.LoadOrStore(resource, true); !logged {
+			logger.WithField(resource, reference).Debug("Not allowed to read the " + resource + "s of the imagePullSecrets, the registry credentials of the config are used")
+		}
+		return true
+	}
+	return false
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestPullSecretsOfPodsAndDefaultServiceAccount(t *testing.T) {
+	resources := map[string]interface{}{
+		"/api/v1/namespaces/apps/serviceaccounts/default": corev1.ServiceAccount{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}}},
+		"/api/v1/namespaces/apps/secrets/registry": corev1.Secret{
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths": {}}`)},
+		},
+		"/api/v1/namespaces/apps/secrets/legacy": corev1.Secret{
+			Type: corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{corev1.DockerConfigKey: []byte(`{}`)},
+		},
+		"/api/v1/namespaces/apps/secrets/tls": corev1.Secret{Type: corev1.SecretTypeTLS},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL.Path == "/api/v1/namespaces/other/serviceaccounts/default" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonForbidden, Code: http.StatusForbidden})
+			return
+		}
+		resource, exists := resources[req.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+		json.NewEncoder(w).Encode(resource)
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containers := []Container{
+		{URL: "registry.example.com", Name: "apps/api", Namespaces: []string{"apps"}, PullSecrets: []string{"apps/legacy", "apps/tls", "apps/missing"}},
+		{URL: "registry.example.com", Name: "other/api", Namespaces: []string{"other"}},
+	}
+	configs, err := getPullSecrets(context.Background(), client, containers, map[string]bool{"apps": true, "other": true})
+	if err != nil {
+		t.Fatalf("Expected missing and forbidden resources to be skipped but got [%v]", err)
+	}
+	expected := map[string][][]byte{"registry.example.com/apps/api": {[]byte(`{}`), []byte(`{"auths": {}}`)}}
+	if !reflect.DeepEqual(configs, expected) {
+		t.Errorf("Expected only the docker configs of the pods and the service account of the namespace of the image but got %q", configs)
+	}
+}
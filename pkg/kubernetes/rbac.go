@@ -0,0 +1,74 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// SkippedError is returned for the resources that the service account is not allowed to read, the scan continues without them
+// so the results only contain what could be read
+type SkippedError struct {
+	// Namespace is empty for resources of the cluster like the namespaces
+	Namespace string
+	// Resource is what was skipped, like pods or deployments
+	Resource string
+	Err      error
+}
+
+func (e *SkippedError) Error() string { return e.Err.Error() }
+func (e *SkippedError) Unwrap() error { return e.Err }
+
+// Skipped returns what was skipped like pods in namespace [web]
+func (e *SkippedError) Skipped() string {
+	if e.Namespace == "" {
+		return e.Resource
+	}
+	return fmt.Sprintf("%s in namespace [%s]", e.Resource, e.Namespace)
+}
+
+// IsSkipped returns true when the error is a SkippedError, also when it's wrapped
+func IsSkipped(err error) bool {
+	var skipped *SkippedError
+	return errors.As(err, &skipped)
+}
+
+// isForbidden returns true when the API server doesn't allow the call, also when the error is wrapped
+func isForbidden(err error) bool {
+	var status *apierrors.StatusError
+	return errors.As(err, &status) && apierrors.IsForbidden(status)
+}
+
+// skipForbidden returns a SkippedError with a warning when the call was forbidden, other errors are returned as they are
+func skipForbidden(err error, namespace, resource string) error {
+	if !isForbidden(err) {
+		return err
+	}
+	skipped := &SkippedError{Namespace: namespace, Resource: resource, Err: err}
+	logger.WithField("skipped", skipped.Skipped()).Warn("Not allowed to read, the results are partial")
+	return skipped
+}
+
+// skippedErrors collects the skipped resources so the next ones are still read, other errors are returned
+type skippedErrors []error
+
+func (s *skippedErrors) keep(err error) error {
+	if IsSkipped(err) {
+		*s = append(*s, err)
+		return nil
+	}
+	return err
+}
+
+// ownNamespace returns the namespace of the service account when running in the cluster and the namespace of the context
+// of the kubeconfig otherwise, it is used when the namespaces can't be listed
+func ownNamespace(ctx context.Context) string {
+	target, _ := targetFrom(ctx)
+	namespace, _, err := target.clientConfig().Namespace()
+	if err != nil || namespace == "" {
+		return "default"
+	}
+	return namespace
+}
@@ -0,0 +1,39 @@
+package kubernetes
+
+import (
+	"context"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Target selects the cluster by kubeconfig file and context so multiple clusters can be scanned from one process
+// Without a kubeconfig the default kubeconfig is used, without a context the current context of the kubeconfig
+type Target struct {
+	Kubeconfig string
+	Context    string
+}
+
+type targetKey struct{}
+
+// WithTarget returns a context that makes all calls with the context go to the cluster of the target
+func WithTarget(ctx context.Context, target Target) context.Context {
+	return context.WithValue(ctx, targetKey{}, target)
+}
+
+func targetFrom(ctx context.Context) (Target, bool) {
+	target, ok := ctx.Value(targetKey{}).(Target)
+	return target, ok
+}
+
+func (t Target) restConfig() (*rest.Config, error) {
+	return t.clientConfig().ClientConfig()
+}
+
+// clientConfig loads the kubeconfig of the target, it falls back to the config inside the cluster when there is no kubeconfig
+func (t Target) clientConfig() clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = t.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: t.Context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
@@ -0,0 +1,142 @@
+package kubernetes
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WatchImages watches pods and deployments in the namespaces, all namespaces when none are provided, without the excluded namespaces of the options
+// The namespaces can be names or regular expressions like the scanned namespaces, with regular expressions all namespaces are watched
+// and only the events of the matching namespaces are collected
+// The images seen are collected with their namespaces and imagePullSecrets and passed to onImages once no new images appeared for the
+// debounce duration, or once the first of them waited for maxWait while new images keep appearing
+func WatchImages(namespaces []string, useLocally bool, settings Options, debounce, maxWait time.Duration, stop <-chan struct{}, onImages func([]Container)) error {
+	client, err := getKubernetesClient(WithOptions(context.Background(), settings), useLocally)
+	if err != nil {
+		return err
+	}
+	namespaces, watched := watchScope(namespaces, settings.ExcludedNamespaces)
+
+	collector := newImageCollector(debounce, maxWait, watched, onImages)
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    collector.add,
+		UpdateFunc: collector.update,
+	}
+
+	for _, namespace := range namespaces {
+		logger.WithField("namespace", namespace).Info("Watching pods and deployments for new images")
+		factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace), informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = settings.LabelSelector
+		}))
+		factory.Core().V1().Pods().Informer().AddEventHandler(handler)
+		factory.Apps().V1().Deployments().Informer().AddEventHandler(handler)
+		factory.Start(stop)
+	}
+	return nil
+}
+
+// watchScope returns the namespaces to watch and whether the events of a namespace are collected, the names are watched
+// without the excluded ones and regular expressions or no namespaces at all watch all namespaces
+func watchScope(namespaces, excluded []string) ([]string, func(string) bool) {
+	if onlyNames(namespaces) {
+		return excludeNamespaces(namespaces, excluded), func(string) bool { return true }
+	}
+	return []string{metav1.NamespaceAll}, func(namespace string) bool {
+		if len(namespaces) > 0 && !matchesNamespace(namespaces, namespace) {
+			return false
+		}
+		return !matchesNamespace(excluded, namespace)
+	}
+}
+
+// imageCollector collects images from watch events and flushes them after the debounce duration, or after the max wait
+// since the first image of the batch was collected
+type imageCollector struct {
+	debounce time.Duration
+	maxWait  time.Duration
+	watched  func(namespace string) bool
+	onImages func([]Container)
+
+	mu     sync.Mutex
+	images *collectedImages
+	first  time.Time
+	timer  *time.Timer
+}
+
+func newImageCollector(debounce, maxWait time.Duration, watched func(namespace string) bool, onImages func([]Container)) *imageCollector {
+	return &imageCollector{
+		debounce: debounce,
+		maxWait:  maxWait,
+		watched:  watched,
+		onImages: onImages,
+		images:   newCollectedImages(),
+	}
+}
+
+// podSpecOf returns the namespace, the workload and the pod spec of a pod or a deployment
+func podSpecOf(obj interface{}) (string, string, corev1.PodSpec, bool) {
+	switch resource := obj.(type) {
+	case *corev1.Pod:
+		return resource.Namespace, podWorkload(*resource, nil), resource.Spec, true
+	case *appsv1.Deployment:
+		return resource.Namespace, workloadName(resource.Namespace, "Deployment", resource.Name), resource.Spec.Template.Spec, true
+	}
+	return "", "", corev1.PodSpec{}, false
+}
+
+// update only collects the images when they or the imagePullSecrets changed, the status updates of the pods don't postpone the flush
+func (c *imageCollector) update(old, obj interface{}) {
+	_, _, oldSpec, oldOk := podSpecOf(old)
+	_, _, spec, ok := podSpecOf(obj)
+	if oldOk && ok && reflect.DeepEqual(imagesFromPodSpec(oldSpec), imagesFromPodSpec(spec)) && reflect.DeepEqual(oldSpec.ImagePullSecrets, spec.ImagePullSecrets) {
+		return
+	}
+	c.add(obj)
+}
+
+func (c *imageCollector) add(obj interface{}) {
+	namespace, workload, spec, ok := podSpecOf(obj)
+	if !ok || !c.watched(namespace) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images.add(namespace, workload, spec)
+	if c.timer == nil {
+		c.first = time.Now()
+	} else {
+		c.timer.Stop()
+	}
+	delay := c.debounce
+	if c.maxWait > 0 {
+		if left := c.maxWait - time.Since(c.first); left < delay {
+			delay = left
+		}
+	}
+	c.timer = time.AfterFunc(delay, c.flush)
+}
+
+func (c *imageCollector) flush() {
+	c.mu.Lock()
+	images := c.images
+	c.images = newCollectedImages()
+	c.timer = nil
+	c.mu.Unlock()
+
+	containers, errs := images.containers()
+	for _, err := range errs {
+		logger.WithError(err).Warn("Could not parse watched image")
+	}
+	if len(containers) > 0 {
+		c.onImages(containers)
+	}
+}
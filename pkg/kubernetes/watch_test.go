@@ -0,0 +1,94 @@
+package kubernetes
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchScopeMatchesAndExcludes(t *testing.T) {
+	tests := []struct {
+		namespaces []string
+		watched    []string
+		collected  map[string]bool
+	}{
+		{nil, []string{metav1.NamespaceAll}, map[string]bool{"default": true, "pr-12": false}},
+		{[]string{"team-a", "pr-12"}, []string{"team-a"}, map[string]bool{"team-a": true}},
+		{[]string{"team-.*"}, []string{metav1.NamespaceAll}, map[string]bool{"team-a": true, "default": false, "pr-12": false}},
+	}
+	for _, test := range tests {
+		watched, collected := watchScope(test.namespaces, []string{"pr-.*"})
+		if !reflect.DeepEqual(watched, test.watched) {
+			t.Errorf("Expected to watch %v for %v but got %v", test.watched, test.namespaces, watched)
+		}
+		for namespace, expected := range test.collected {
+			if collected(namespace) != expected {
+				t.Errorf("Expected collected %t for namespace %s of %v", expected, namespace, test.namespaces)
+			}
+		}
+	}
+}
+
+func TestImageCollectorSkipsTheNamespacesThatAreNotWatched(t *testing.T) {
+	_, watched := watchScope([]string{"team-.*"}, []string{"pr-.*"})
+	collector := newImageCollector(time.Hour, 0, watched, nil)
+	for namespace, image := range map[string]string{"team-a": "nginx:1.0", "pr-12": "redis:5", "default": "busybox"} {
+		p := pod(image)
+		p.Namespace = namespace
+		collector.add(&p)
+	}
+	collector.timer.Stop()
+
+	if !reflect.DeepEqual(collector.images.namespaces, map[string][]string{"nginx:1.0": {"team-a"}}) {
+		t.Errorf("Expected only the image of team-a but got %v", collector.images.namespaces)
+	}
+}
+
+func TestImageCollectorOnlyCollectsUpdatesThatChangeTheImages(t *testing.T) {
+	collector := newImageCollector(time.Hour, 0, func(string) bool { return true }, nil)
+	old := pod("nginx:1.0")
+	old.Namespace = "web"
+	status := old
+	status.Status.Phase = corev1.PodRunning
+	collector.update(&old, &status)
+	if collector.timer != nil || len(collector.images.namespaces) != 0 {
+		t.Errorf("Expected a status update not to be collected but got %v", collector.images.namespaces)
+	}
+
+	upgraded := pod("nginx:1.1")
+	upgraded.Namespace = "web"
+	collector.update(&old, &upgraded)
+	collector.timer.Stop()
+	if !reflect.DeepEqual(collector.images.namespaces, map[string][]string{"nginx:1.1": {"web"}}) {
+		t.Errorf("Expected the new image to be collected but got %v", collector.images.namespaces)
+	}
+}
+
+func TestImageCollectorFlushesAfterTheMaxWait(t *testing.T) {
+	flushed := make(chan []Container, 1)
+	collector := newImageCollector(time.Hour, 50*time.Millisecond, func(string) bool { return true }, func(containers []Container) {
+		flushed <- containers
+	})
+	// new images keep appearing, which would postpone the flush forever without the max wait
+	stop := time.After(time.Second)
+	for i := 0; ; i++ {
+		p := pod(fmt.Sprintf("app:%d", i))
+		p.Namespace = "web"
+		p.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry"}}
+		collector.add(&p)
+		select {
+		case containers := <-flushed:
+			if len(containers) == 0 || containers[0].Namespaces[0] != "web" || containers[0].PullSecrets[0] != "web/registry" {
+				t.Errorf("Expected the images with their namespace and pull secrets but got %v", containers)
+			}
+			return
+		case <-stop:
+			t.Fatal("Expected the images to be flushed after the max wait")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
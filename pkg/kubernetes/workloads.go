@@ -0,0 +1,219 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// SetWorkloadsEnabled sets if the pod templates of the deployments, stateful sets, daemon sets, replica sets, cron jobs and jobs are scanned next to the pods by default
+func SetWorkloadsEnabled(enabled bool) {
+	setDefault(func(options *Options) { options.Workloads = enabled })
+}
+
+// collectWorkloadImages adds the images of the pod templates of the workloads in the namespace to images and returns the owning
+// workloads of the replica sets and jobs as kind/name, the replica sets of a deployment and the jobs of a cron job are skipped
+// because the old ones keep the images of earlier revisions
+func collectWorkloadImages(ctx context.Context, client *kubernetes.Clientset, namespace string, images *collectedImages) (map[string]string, error) {
+	start := time.Now()
+	ctx = audit.WithPurpose(ctx, "workloads of namespace "+namespace)
+	owners := map[string]string{}
+	apps := client.AppsV1().RESTClient()
+	var skipped skippedErrors
+
+	err := listWorkloads(ctx, apps, "apps/v1", namespace, "deployments", func() (interface{}, func() string) {
+		deployments := &appsv1.DeploymentList{}
+		return deployments, func() string {
+			for _, deployment := range deployments.Items {
+				images.add(namespace, workloadName(namespace, "Deployment", deployment.Name), deployment.Spec.Template.Spec)
+			}
+			return deployments.Continue
+		}
+	})
+	if err = skipped.keep(err); err != nil {
+		return owners, err
+	}
+
+	err = listWorkloads(ctx, apps, "apps/v1", namespace, "statefulsets", func() (interface{}, func() string) {
+		statefulSets := &appsv1.StatefulSetList{}
+		return statefulSets, func() string {
+			for _, statefulSet := range statefulSets.Items {
+				images.add(namespace, workloadName(namespace, "StatefulSet", statefulSet.Name), statefulSet.Spec.Template.Spec)
+			}
+			return statefulSets.Continue
+		}
+	})
+	if err = skipped.keep(err); err != nil {
+		return owners, err
+	}
+
+	err = listWorkloads(ctx, apps, "apps/v1", namespace, "daemonsets", func() (interface{}, func() string) {
+		daemonSets := &appsv1.DaemonSetList{}
+		return daemonSets, func() string {
+			for _, daemonSet := range daemonSets.Items {
+				images.add(namespace, workloadName(namespace, "DaemonSet", daemonSet.Name), daemonSet.Spec.Template.Spec)
+			}
+			return daemonSets.Continue
+		}
+	})
+	if err = skipped.keep(err); err != nil {
+		return owners, err
+	}
+
+	err = listWorkloads(ctx, apps, "apps/v1", namespace, "replicasets", func() (interface{}, func() string) {
+		replicaSets := &appsv1.ReplicaSetList{}
+		return replicaSets, func() string {
+			for _, replicaSet := range replicaSets.Items {
+				if owner := metav1.GetControllerOf(&replicaSet); owner != nil && owner.Kind == "Deployment" {
+					owners["ReplicaSet/"+replicaSet.Name] = "Deployment/" + owner.Name
+					continue
+				}
+				images.add(namespace, workloadName(namespace, "ReplicaSet", replicaSet.Name), replicaSet.Spec.Template.Spec)
+			}
+			return replicaSets.Continue
+		}
+	})
+	if err = skipped.keep(err); err != nil {
+		return owners, err
+	}
+
+	if err = collectJobImages(ctx, client, namespace, images, owners, &skipped); err != nil {
+		return owners, err
+	}
+	logger.WithField("namespace", namespace).WithField("duration", time.Since(start)).Debug("Fetched workloads in namespace")
+	return owners, utilerrors.NewAggregate(skipped)
+}
+
+// collectJobImages adds the images of the cron jobs, also the suspended ones, and of the jobs that don't belong to a cron job,
+// the cron jobs or jobs that may not be read are added to skipped
+func collectJobImages(ctx context.Context, client *kubernetes.Clientset, namespace string, images *collectedImages, owners map[string]string, skipped *skippedErrors) error {
+	batch := client.BatchV1().RESTClient()
+	cronJobs := func() (interface{}, func() string) {
+		// batch/v1 and batch/v1beta1 share the fields that are used so the v1beta1 type is used for both
+		cronJobs := &batchv1beta1.CronJobList{}
+		return cronJobs, func() string {
+			for _, cronJob := range cronJobs.Items {
+				images.add(namespace, workloadName(namespace, "CronJob", cronJob.Name), cronJob.Spec.JobTemplate.Spec.Template.Spec)
+			}
+			return cronJobs.Continue
+		}
+	}
+	err := listWorkloads(ctx, batch, "batch/v1", namespace, "cronjobs", cronJobs)
+	if isNotFound(err) {
+		// clusters before 1.21 only serve cron jobs in batch/v1beta1
+		err = listWorkloads(ctx, batch, "batch/v1beta1", namespace, "cronjobs", cronJobs)
+	}
+	if err = skipped.keep(err); err != nil {
+		return err
+	}
+
+	err = listWorkloads(ctx, batch, "batch/v1", namespace, "jobs", func() (interface{}, func() string) {
+		jobs := &batchv1.JobList{}
+		return jobs, func() string {
+			for _, job := range jobs.Items {
+				if owner := metav1.GetControllerOf(&job); owner != nil && owner.Kind == "CronJob" {
+					owners["Job/"+job.Name] = "CronJob/" + owner.Name
+					continue
+				}
+				images.add(namespace, workloadName(namespace, "Job", job.Name), job.Spec.Template.Spec)
+			}
+			return jobs.Continue
+		}
+	})
+	return skipped.keep(err)
+}
+
+// listWorkloads fetches the workloads of the resource of the group version that match the label selector in pages,
+// page returns a new list for the page and a func that adds the workloads of the page and returns the continue token
+func listWorkloads(ctx context.Context, client rest.Interface, groupVersion, namespace, resource string, page func() (interface{}, func() string)) error {
+	return listPages(ctx, client, groupVersion, namespace, resource, optionsFrom(ctx).LabelSelector, page)
+}
+
+// listPages fetches the resources of the group version that match the selector in pages like listWorkloads
+// The list is decoded from the raw response so also versions that are newer than the client and resources of API groups
+// that the client doesn't know, like the OpenShift ones, can be read
+func listPages(ctx context.Context, client rest.Interface, groupVersion, namespace, resource, selector string, page func() (interface{}, func() string)) error {
+	options := metav1.ListOptions{Limit: optionsFrom(ctx).PageSize, LabelSelector: selector}
+	for {
+		list, add := page()
+		body, err := client.Get().
+			Context(ctx).
+			AbsPath("/apis", groupVersion, "namespaces", namespace, resource).
+			VersionedParams(&options, scheme.ParameterCodec).
+			Do().
+			Raw()
+		if apierrors.IsResourceExpired(err) && options.Continue != "" {
+			// the images already found are not added twice
+			options.Continue = ""
+			continue
+		}
+		if err != nil {
+			return skipForbidden(fmt.Errorf("Could not fetch %s in namespace [%s]: %w", resource, namespace, err), namespace, resource)
+		}
+		if err := json.Unmarshal(body, list); err != nil {
+			return fmt.Errorf("Could not decode %s in namespace [%s]: %w", resource, namespace, err)
+		}
+		options.Continue = add()
+		if options.Continue == "" {
+			return nil
+		}
+	}
+}
+
+// mirrorPodAnnotation is set on the mirror pods that the kubelet creates for the static pods of its manifest directory,
+// like the control plane components kube-apiserver, etcd, kube-controller-manager and kube-scheduler of kubeadm
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// StaticPodKind is the kind of the workloads of static pods, the name is the name of the pod without the node, so the
+// static pods of all control plane nodes are the same workload like kube-system/StaticPod/kube-apiserver
+const StaticPodKind = "StaticPod"
+
+// podWorkload returns the workload that owns the pod, the deployment for the pods of a replica set of a deployment,
+// the cron job for the pods of a job of a cron job, the deployment config for the pods of its replication controllers
+// and the static pod for the mirror pods of the kubelet
+// Without the owners of the workloads the deployment is taken from the name of the replica set, which is the name of the
+// deployment followed by the pod-template-hash label of the pod
+func podWorkload(pod corev1.Pod, owners map[string]string) string {
+	if _, mirror := pod.Annotations[mirrorPodAnnotation]; mirror {
+		return workloadName(pod.Namespace, StaticPodKind, strings.TrimSuffix(pod.Name, "-"+pod.Spec.NodeName))
+	}
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil {
+		return workloadName(pod.Namespace, "Pod", pod.Name)
+	}
+	if workload, exists := owners[owner.Kind+"/"+owner.Name]; exists {
+		return pod.Namespace + "/" + workload
+	}
+	if deploymentConfig := pod.Annotations[deploymentConfigAnnotation]; owner.Kind == "ReplicationController" && deploymentConfig != "" {
+		return workloadName(pod.Namespace, "DeploymentConfig", deploymentConfig)
+	}
+	if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; owner.Kind == "ReplicaSet" && hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+		return workloadName(pod.Namespace, "Deployment", strings.TrimSuffix(owner.Name, "-"+hash))
+	}
+	return workloadName(pod.Namespace, owner.Kind, owner.Name)
+}
+
+func workloadName(namespace, kind, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+// isNotFound returns true when the error is a not found error of the API server, also when it's wrapped
+func isNotFound(err error) bool {
+	var status *apierrors.StatusError
+	return errors.As(err, &status) && apierrors.IsNotFound(status)
+}
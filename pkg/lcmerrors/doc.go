@@ -0,0 +1,3 @@
+// Package lcmerrors contains the typed errors of lcm and their machine readable codes,
+// so automation can tell missing credentials apart from an image that was deleted upstream
+package lcmerrors
@@ -0,0 +1,133 @@
+package lcmerrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Code is the machine readable code of an error
+type Code string
+
+const (
+	// CodeAuth is used when the credentials are missing or rejected
+	CodeAuth Code = "auth"
+	// CodeRateLimited is used when the endpoint rejected the call because of a rate limit
+	CodeRateLimited Code = "rate-limited"
+	// CodeNotFound is used when the image, chart or tool doesn't exist, for example because it was deleted upstream
+	CodeNotFound Code = "not-found"
+	// CodeParse is used when a response or an image reference could not be parsed
+	CodeParse Code = "parse"
+	// CodeTimeout is used when the call didn't finish before the deadline
+	CodeTimeout Code = "timeout"
+	// CodeCancelled is used when the scan was stopped before the call finished
+	CodeCancelled Code = "cancelled"
+	// CodeUnavailable is used when the endpoint can't be reached, returned a server error or is skipped because it keeps failing
+	CodeUnavailable Code = "unavailable"
+	// CodeUnknown is used for all other errors
+	CodeUnknown Code = "unknown"
+)
+
+// AuthError is returned when the credentials are missing or rejected
+type AuthError struct{ Err error }
+
+func (e *AuthError) Error() string { return e.Err.Error() }
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// Code returns CodeAuth
+func (e *AuthError) Code() Code { return CodeAuth }
+
+// RateLimitedError is returned when the endpoint rejected the call because of a rate limit
+type RateLimitedError struct{ Err error }
+
+func (e *RateLimitedError) Error() string { return e.Err.Error() }
+func (e *RateLimitedError) Unwrap() error { return e.Err }
+
+// Code returns CodeRateLimited
+func (e *RateLimitedError) Code() Code { return CodeRateLimited }
+
+// NotFoundError is returned when the image, chart or tool doesn't exist
+type NotFoundError struct{ Err error }
+
+func (e *NotFoundError) Error() string { return e.Err.Error() }
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+// Code returns CodeNotFound
+func (e *NotFoundError) Code() Code { return CodeNotFound }
+
+// ParseError is returned when a response or an image reference could not be parsed
+type ParseError struct{ Err error }
+
+func (e *ParseError) Error() string { return e.Err.Error() }
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Code returns CodeParse
+func (e *ParseError) Code() Code { return CodeParse }
+
+// UnavailableError is returned when the endpoint returned a server error
+type UnavailableError struct{ Err error }
+
+func (e *UnavailableError) Error() string { return e.Err.Error() }
+func (e *UnavailableError) Unwrap() error { return e.Err }
+
+// Code returns CodeUnavailable
+func (e *UnavailableError) Code() Code { return CodeUnavailable }
+
+// FromStatus wraps the error of an unexpected http status code in the typed error of the status code
+func FromStatus(status int, err error) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &AuthError{Err: err}
+	case status == http.StatusNotFound:
+		return &NotFoundError{Err: err}
+	case status == http.StatusTooManyRequests:
+		return &RateLimitedError{Err: err}
+	case status >= http.StatusInternalServerError:
+		return &UnavailableError{Err: err}
+	}
+	return err
+}
+
+// CodeOf returns the code of the error, it is empty without an error
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var coder interface{ Code() Code }
+	if errors.As(err, &coder) {
+		return coder.Code()
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCancelled
+	case errors.Is(err, httpclient.ErrCircuitOpen):
+		return CodeUnavailable
+	case errors.Is(err, httpclient.ErrRateLimited):
+		return CodeRateLimited
+	case errors.As(err, &syntaxErr) || errors.As(err, &typeErr):
+		return CodeParse
+	case apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err):
+		return CodeAuth
+	case apierrors.IsNotFound(err):
+		return CodeNotFound
+	case apierrors.IsTooManyRequests(err):
+		return CodeRateLimited
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return CodeTimeout
+		}
+		return CodeUnavailable
+	}
+	return CodeUnknown
+}
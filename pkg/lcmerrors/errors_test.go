@@ -0,0 +1,47 @@
+package lcmerrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCodeOf(t *testing.T) {
+	err := errors.New("failed")
+	var value []string
+	parseErr := json.Unmarshal([]byte("{"), &value)
+
+	tests := []struct {
+		name string
+		err  error
+		code Code
+	}{
+		{"no error", nil, ""},
+		{"unauthorized", FromStatus(http.StatusUnauthorized, err), CodeAuth},
+		{"forbidden", FromStatus(http.StatusForbidden, err), CodeAuth},
+		{"not found", FromStatus(http.StatusNotFound, err), CodeNotFound},
+		{"rate limited", FromStatus(http.StatusTooManyRequests, err), CodeRateLimited},
+		{"server error", FromStatus(http.StatusBadGateway, err), CodeUnavailable},
+		{"other status", FromStatus(http.StatusTeapot, err), CodeUnknown},
+		{"wrapped", fmt.Errorf("Could not fetch tags: %w", &NotFoundError{Err: err}), CodeNotFound},
+		{"deadline", fmt.Errorf("Get: %w", context.DeadlineExceeded), CodeTimeout},
+		{"cancelled", context.Canceled, CodeCancelled},
+		{"circuit open", fmt.Errorf("Get: %w", httpclient.ErrCircuitOpen), CodeUnavailable},
+		{"rate limit used up", fmt.Errorf("Get: %w", httpclient.ErrRateLimited), CodeRateLimited},
+		{"json", parseErr, CodeParse},
+		{"kubernetes forbidden", apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", err), CodeAuth},
+		{"unknown", err, CodeUnknown},
+	}
+	for _, test := range tests {
+		if code := CodeOf(test.err); code != test.code {
+			t.Errorf("%s: expected [%s] but got [%s]", test.name, test.code, code)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The metrics live as long as the process, unlike the stats which are reset at the start of every scan
+var (
+	callDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "lcm",
+		Name:      "call_duration_seconds",
+		Help:      "Duration of the calls to registries, scanners and tools per provider",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"component", "provider"})
+
+	callErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lcm",
+		Name:      "call_errors_total",
+		Help:      "Calls to registries, scanners and tools per provider that failed or returned a server error",
+	}, []string{"component", "provider"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lcm",
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups per provider, the result is hit or miss",
+	}, []string{"provider", "result"})
+
+	workersInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lcm",
+		Name:      "workers_in_flight",
+		Help:      "Workers that are busy with an item per scan phase",
+	}, []string{"phase"})
+
+	outdatedImages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lcm",
+		Name:      "outdated_images",
+		Help:      "Images of the last scan with a newer version per cluster and upgrade type, the upgrade is major, minor or patch",
+	}, []string{"cluster", "upgrade"})
+
+	versionsBehind = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lcm",
+		Name:      "image_versions_behind",
+		Help:      "Newer releases than the running version of the outdated images of the last scan",
+	}, []string{"cluster", "image", "version"})
+
+	imageCreated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lcm",
+		Name:      "image_created_timestamp_seconds",
+		Help:      "When the running version of the images of the last scan was built",
+	}, []string{"cluster", "image", "version"})
+
+	latestImageCreated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lcm",
+		Name:      "latest_image_created_timestamp_seconds",
+		Help:      "When the latest version of the images of the last scan was built",
+	}, []string{"cluster", "image", "version"})
+)
+
+// registry holds the metrics of lcm, it is not the default registry so programs that embed the packages of lcm don't get its metrics
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	registry.MustRegister(callDuration, callErrors, cacheLookups, workersInFlight, outdatedImages, versionsBehind, imageCreated, latestImageCreated)
+}
+
+// Gatherer returns the registry with the metrics of lcm, so a program that embeds lcm can serve them next to its own
+func Gatherer() prometheus.Gatherer {
+	return registry
+}
+
+// ObserveCall records the duration of the call to the provider since start and counts it as an error when it failed
+func ObserveCall(component, provider string, start time.Time, failed bool) {
+	callDuration.WithLabelValues(component, provider).Observe(time.Since(start).Seconds())
+	if failed {
+		callErrors.WithLabelValues(component, provider).Inc()
+	}
+}
+
+// CacheLookup counts a cache lookup for the provider
+func CacheLookup(provider string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(provider, result).Inc()
+}
+
+// WorkerStarted marks a worker of the phase as busy, the returned func marks it as done
+func WorkerStarted(phase string) func() {
+	gauge := workersInFlight.WithLabelValues(phase)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// SetOutdatedImages replaces the number of outdated images per cluster and upgrade type of the previous scan, the types
+// without images are set to 0
+func SetOutdatedImages(upgrades map[string]map[string]int) {
+	outdatedImages.Reset()
+	for cluster, counts := range upgrades {
+		for _, upgrade := range []string{"MAJOR", "MINOR", "PATCH"} {
+			outdatedImages.WithLabelValues(cluster, strings.ToLower(upgrade)).Set(float64(counts[upgrade]))
+		}
+	}
+}
+
+// SetVersionsBehind replaces the versions behind of the images of the previous scan, the key is the cluster, the name and the version of the image
+func SetVersionsBehind(behind map[[3]string]int) {
+	versionsBehind.Reset()
+	for image, count := range behind {
+		versionsBehind.WithLabelValues(image[0], image[1], image[2]).Set(float64(count))
+	}
+}
+
+// ImageCreated is when an image of the last scan in the cluster and its latest version were built
+type ImageCreated struct {
+	Cluster       string
+	Image         string
+	Version       string
+	LatestVersion string
+	Created       time.Time
+	LatestCreated time.Time
+}
+
+// SetImagesCreated replaces the creation times of the images of the previous scan, the zero times are skipped
+func SetImagesCreated(images []ImageCreated) {
+	imageCreated.Reset()
+	latestImageCreated.Reset()
+	for _, image := range images {
+		if !image.Created.IsZero() {
+			imageCreated.WithLabelValues(image.Cluster, image.Image, image.Version).Set(float64(image.Created.Unix()))
+		}
+		if !image.LatestCreated.IsZero() {
+			latestImageCreated.WithLabelValues(image.Cluster, image.Image, image.LatestVersion).Set(float64(image.LatestCreated.Unix()))
+		}
+	}
+}
+
+// Handler serves the metrics of lcm in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(registry, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+}
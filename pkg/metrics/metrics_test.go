@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordedValues(t *testing.T) {
+	CacheLookup("docker.io", true)
+	CacheLookup("docker.io", false)
+	CacheLookup("docker.io", false)
+	done := WorkerStarted("images")
+	SetOutdatedImages(map[string]map[string]int{"production": {"MAJOR": 2, "PATCH": 1}})
+	SetVersionsBehind(map[[3]string]int{{"production", "library/nginx", "1.19"}: 4})
+
+	tests := map[string]struct {
+		collector prometheus.Collector
+		expected  float64
+	}{
+		"cache hits":     {cacheLookups.WithLabelValues("docker.io", "hit"), 1},
+		"cache misses":   {cacheLookups.WithLabelValues("docker.io", "miss"), 2},
+		"busy workers":   {workersInFlight.WithLabelValues("images"), 1},
+		"major upgrades": {outdatedImages.WithLabelValues("production", "major"), 2},
+		"minor upgrades": {outdatedImages.WithLabelValues("production", "minor"), 0},
+		"behind":         {versionsBehind.WithLabelValues("production", "library/nginx", "1.19"), 4},
+	}
+	for name, test := range tests {
+		if value := testutil.ToFloat64(test.collector); value != test.expected {
+			t.Errorf("Expected %v %s but got %v", test.expected, name, value)
+		}
+	}
+	done()
+	if value := testutil.ToFloat64(workersInFlight.WithLabelValues("images")); value != 0 {
+		t.Errorf("Expected no busy workers once done but got %v", value)
+	}
+}
+
+func TestHandlerServesTheMetricsOfLcm(t *testing.T) {
+	ObserveCall("registry", "quay.io", time.Now(), true)
+	SetImagesCreated([]ImageCreated{{Cluster: "production", Image: "library/nginx", Version: "1.19", Created: time.Unix(1600000000, 0)}})
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	for _, expected := range []string{
+		`lcm_call_errors_total{component="registry",provider="quay.io"} 1`,
+		`lcm_image_created_timestamp_seconds{cluster="production",image="library/nginx",version="1.19"} 1.6e+09`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("Expected the metrics to contain %s but got %s", expected, body)
+		}
+	}
+}
+
+func TestMetricsAreNotInTheDefaultRegistry(t *testing.T) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if strings.HasPrefix(family.GetName(), "lcm_") {
+			t.Errorf("Expected the metrics of lcm in their own registry but %s is in the default registry", family.GetName())
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"context"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/audit"
+	"github.com/arminc/k8s-platform-lcm/pkg/metrics"
+	"github.com/arminc/k8s-platform-lcm/pkg/tracing"
+)
+
+// Exec describes a run of an executable for the traces, the metrics and the audit log
+type Exec struct {
+	Component  string // The component the run counts for, like httpclient.Registry or httpclient.Scanner
+	Target     string // The registry or scanner the run is measured as
+	Command    string
+	Credential string // How the executable gets its credentials, like plugin or helper
+}
+
+// Observe runs the executable with run, the run is traced, measured and audited like the calls to the registry and scanner APIs
+func Observe(ctx context.Context, e Exec, run func(context.Context) error) error {
+	ctx, span := tracing.StartClient(ctx, "exec "+e.Target)
+	start := time.Now()
+	err := run(ctx)
+	metrics.ObserveCall(e.Component, e.Target, start, err != nil)
+	audit.Record(audit.Entry{Component: e.Component, Purpose: audit.Purpose(ctx), Method: "exec", Endpoint: e.Command, Credential: e.Credential}, err, start)
+	span.SetError(err)
+	span.End()
+	return err
+}
@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SubcommandPrefix is the prefix of executables on the PATH that can be invoked as lcm subcommands
+const SubcommandPrefix = "lcm-"
+
+var logger = log.WithField("component", "plugins")
+
+// Plugins contains the external executables that extend lcm
+type Plugins struct {
+	Collectors []Plugin `koanf:"collectors"`
+	Reporters  []Plugin `koanf:"reporters"`
+}
+
+// Plugin is an external executable
+// A collector writes a json list of extra images to stdout, a reporter reads the json scan result from stdin
+type Plugin struct {
+	Name    string   `koanf:"name"`
+	Command string   `koanf:"command"`
+	Args    []string `koanf:"args"`
+	// Env are added to the environment of lcm as KEY=value, like secrets that shouldn't be in the arguments
+	Env []string `koanf:"env"`
+}
+
+// FindSubcommand finds the lcm-<name> executable on the PATH
+func FindSubcommand(name string) (string, bool) {
+	path, err := exec.LookPath(SubcommandPrefix + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// RunSubcommand runs the subcommand executable with the arguments and returns its exit code
+func RunSubcommand(path string, args []string) int {
+	logger.WithField("subcommand", path).Debug("Running subcommand")
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		logger.WithError(err).WithField("subcommand", path).Error("Could not run subcommand")
+		return 1
+	}
+	return 0
+}
+
+// Collect runs the collector and returns the images it found
+func (p Plugin) Collect(ctx context.Context) ([]string, error) {
+	logger.WithField("plugin", p.Name).Debug("Running collector")
+	output, err := p.run(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	if err := json.Unmarshal(output, &images); err != nil {
+		return nil, fmt.Errorf("Collector [%s] did not return a json list of images: %w", p.Name, err)
+	}
+	return images, nil
+}
+
+// Report runs the reporter with the scan result as input
+func (p Plugin) Report(ctx context.Context, result interface{}) error {
+	logger.WithField("plugin", p.Name).Debug("Running reporter")
+	input, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = p.run(ctx, input)
+	return err
+}
+
+// Exchange runs the plugin with the input as json on stdin and decodes the json on stdout into the output
+func (p Plugin) Exchange(ctx context.Context, input interface{}, output interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	result, err := p.run(ctx, data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(result, output); err != nil {
+		return fmt.Errorf("Plugin [%s] did not return valid json: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Decode runs the plugin without input and decodes the json on stdout into the output
+func (p Plugin) Decode(ctx context.Context, output interface{}) error {
+	result, err := p.run(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(result, output); err != nil {
+		return fmt.Errorf("Plugin [%s] did not return valid json: %w", p.Name, err)
+	}
+	return nil
+}
+
+// run runs the plugin, it is killed when the context is done
+func (p Plugin) run(ctx context.Context, input []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	if len(p.Env) > 0 {
+		cmd.Env = append(os.Environ(), p.Env...)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Plugin [%s] failed: %w, stderr [%s]", p.Name, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
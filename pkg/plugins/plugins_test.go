@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeScript writes an executable shell script to the directory and returns its path
+func writeScript(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCollect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := map[string]struct {
+		plugin Plugin
+		images []string
+		err    string
+	}{
+		"images":       {plugin: Plugin{Command: writeScript(t, dir, "images", `echo '["nginx:1.25", "redis:7"]'`)}, images: []string{"nginx:1.25", "redis:7"}},
+		"arguments":    {plugin: Plugin{Command: writeScript(t, dir, "arguments", `echo "[\"$1:$2\"]"`), Args: []string{"nginx", "1.25"}}, images: []string{"nginx:1.25"}},
+		"environment":  {plugin: Plugin{Command: writeScript(t, dir, "environment", `echo "[\"$IMAGE\"]"`), Env: []string{"IMAGE=nginx:1.25"}}, images: []string{"nginx:1.25"}},
+		"invalid json": {plugin: Plugin{Name: "invalid", Command: writeScript(t, dir, "invalid", `echo 'nginx:1.25'`)}, err: "Collector [invalid] did not return a json list of images"},
+		"failure":      {plugin: Plugin{Name: "failure", Command: writeScript(t, dir, "failure", "echo 'no access' >&2\nexit 3")}, err: "Plugin [failure] failed: exit status 3, stderr [no access]"},
+		"missing":      {plugin: Plugin{Name: "missing", Command: filepath.Join(dir, "missing")}, err: "Plugin [missing] failed"},
+	}
+	for name, test := range tests {
+		images, err := test.plugin.Collect(context.Background())
+		if test.err == "" && err != nil {
+			t.Errorf("Expected no error for %s but got [%v]", name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("Expected the error [%s] for %s but got [%v]", test.err, name, err)
+		}
+		if !reflect.DeepEqual(images, test.images) {
+			t.Errorf("Expected %v for %s but got %v", test.images, name, images)
+		}
+	}
+}
+
+func TestReportAndExchangeSendTheInputAsJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "report.json")
+	reporter := Plugin{Name: "reporter", Command: writeScript(t, dir, "reporter", "cat > "+output)}
+	if err := reporter.Report(context.Background(), map[string]string{"status": "ok"}); err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if content, _ := ioutil.ReadFile(output); string(content) != `{"status":"ok"}` {
+		t.Errorf("Expected the result as json on stdin but got %s", content)
+	}
+
+	echo := Plugin{Name: "echo", Command: writeScript(t, dir, "echo", "cat")}
+	var response map[string]string
+	if err := echo.Exchange(context.Background(), map[string]string{"image": "nginx"}, &response); err != nil || response["image"] != "nginx" {
+		t.Errorf("Expected the output to be decoded but got %v and [%v]", response, err)
+	}
+}
+
+func TestPluginIsStoppedWithTheContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	plugin := Plugin{Name: "slow", Command: writeScript(t, dir, "slow", "sleep 10\necho '[]'")}
+	if _, err := plugin.Collect(ctx); err == nil {
+		t.Errorf("Expected an error when the context is done")
+	}
+}
+
+func TestRunSubcommandReturnsTheExitCode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeScript(t, dir, SubcommandPrefix+"test", `exit $1`)
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+
+	subcommand, found := FindSubcommand("test")
+	if !found {
+		t.Fatalf("Expected the subcommand to be found on the PATH")
+	}
+	for _, code := range []int{0, 4} {
+		if exitCode := RunSubcommand(subcommand, []string{strconv.Itoa(code)}); exitCode != code {
+			t.Errorf("Expected exit code %d but got %d", code, exitCode)
+		}
+	}
+	if _, found := FindSubcommand("missing"); found {
+		t.Errorf("Expected no subcommand without an executable")
+	}
+}
@@ -0,0 +1,277 @@
+package registries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+const (
+	// Acr is the name for Azure Container Registry
+	Acr = "Acr"
+
+	azureResource  = "https://management.azure.com/"
+	azureLoginURL  = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	azureIMDSToken = imdsURL + "/metadata/identity/oauth2/token"
+)
+
+// AcrRegistry contains the settings for Azure Container Registry, it is used for all the images of <name>.azurecr.io
+// With a client secret the service principal is used, otherwise the Workload Identity of the pod when AZURE_FEDERATED_TOKEN_FILE
+// is set or else the managed identity of the node, the client id selects a user assigned managed identity
+type AcrRegistry struct {
+	TenantID     string `koanf:"tenantId"`
+	ClientID     string `koanf:"clientId"`
+	ClientSecret string `koanf:"clientSecret"`
+}
+
+// acrHost matches the login server of a registry like myregistry.azurecr.io, also in the sovereign clouds
+var acrHost = regexp.MustCompile(`^[a-z0-9]+\.azurecr\.(io|cn|us|de)$`)
+
+// acrRegistry is a single registry in Azure
+type acrRegistry struct {
+	config AcrRegistry
+	url    string
+}
+
+// registryFor returns the ACR registry when the url belongs to ACR
+func (a AcrRegistry) registryFor(url string) (acrRegistry, bool) {
+	if !acrHost.MatchString(url) {
+		return acrRegistry{}, false
+	}
+	return acrRegistry{config: a, url: url}, true
+}
+
+// GetLatestVersion fetches the latest version of the image from ACR
+func (a acrRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", a.url).WithField("image", name).Debug("Get latest version for ACR image")
+	tags, err := a.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, false), nil
+}
+
+type acrTagsResponse struct {
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+// GetTags lists the tags of the repository with the ACR REST API
+func (a acrRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	cacheKey := fmt.Sprintf("tags/%s/%s", a.url, name)
+	var tags []string
+	if cache.GetJSON(ctx, a.url, cacheKey, &tags) {
+		return tags, nil
+	}
+
+	token, err := a.accessToken(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("Could not login to [%s]: %w", a.url, err)
+	}
+	tags = []string{}
+	path := fmt.Sprintf("/acr/v1/%s/_tags?n=100", name)
+	for page := 1; path != ""; page++ {
+		if page > maxTagPages {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: more than [%d] pages", a.url, maxTagPages)
+		}
+		fullURL, err := pageURL("https://"+a.url, path)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", a.url, err)
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := clientFrom(ctx).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", a.url, err)
+		}
+		var response acrTagsResponse
+		err = decodeOK(resp, &response)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", a.url, err)
+		}
+		for _, tag := range response.Tags {
+			tags = append(tags, tag.Name)
+		}
+		path, err = getNextLink(resp)
+		if err == ErrNoMorePages {
+			path = ""
+		}
+	}
+	cache.SetJSON(ctx, cacheKey, tags)
+	return tags, nil
+}
+
+// accessToken exchanges the Azure AD token for a refresh token of the registry and that for an access token of the repository
+func (a acrRegistry) accessToken(ctx context.Context, name string) (string, error) {
+	refreshToken, err := a.refreshToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"service":       {a.url},
+		"scope":         {fmt.Sprintf("repository:%s:metadata_read", name)},
+		"refresh_token": {refreshToken},
+	}
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := postForm(ctx, "https://"+a.url+"/oauth2/token", form, &response); err != nil {
+		return "", &lcmerrors.AuthError{Err: fmt.Errorf("Could not get an access token of the registry: %w", err)}
+	}
+	return response.AccessToken, nil
+}
+
+type azureToken struct {
+	token   string
+	expires time.Time
+}
+
+var (
+	azureTokensLock sync.Mutex
+	acrRefreshToken = map[string]azureToken{}
+	azureADTokens   = map[AcrRegistry]azureToken{}
+)
+
+func (a acrRegistry) refreshToken(ctx context.Context) (string, error) {
+	azureTokensLock.Lock()
+	defer azureTokensLock.Unlock()
+	if token, exists := acrRefreshToken[a.url]; exists && time.Now().Add(5*time.Minute).Before(token.expires) {
+		return token.token, nil
+	}
+
+	adToken, err := a.config.azureADToken(ctx)
+	if err != nil {
+		return "", &lcmerrors.AuthError{Err: fmt.Errorf("Could not get an Azure AD token: %w", err)}
+	}
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {a.url},
+		"access_token": {adToken.token},
+	}
+	if a.config.TenantID != "" {
+		form.Set("tenant", a.config.TenantID)
+	}
+	var response struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := postForm(ctx, "https://"+a.url+"/oauth2/exchange", form, &response); err != nil {
+		return "", &lcmerrors.AuthError{Err: fmt.Errorf("Could not exchange the Azure AD token: %w", err)}
+	}
+	// the refresh token is valid for longer, it is renewed together with the Azure AD token to keep it simple
+	acrRefreshToken[a.url] = azureToken{token: response.RefreshToken, expires: adToken.expires}
+	return response.RefreshToken, nil
+}
+
+type azureTokenResponse struct {
+	AccessToken string      `json:"access_token"`
+	ExpiresIn   json.Number `json:"expires_in"`
+}
+
+// azureADToken gets the token of the service principal, the Workload Identity or the managed identity, the caller holds the lock
+func (a AcrRegistry) azureADToken(ctx context.Context) (azureToken, error) {
+	if token, exists := azureADTokens[a]; exists && time.Now().Add(5*time.Minute).Before(token.expires) {
+		return token, nil
+	}
+
+	tenantID, clientID := a.TenantID, a.ClientID
+	if tenantID == "" {
+		tenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	if clientID == "" {
+		clientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	var response azureTokenResponse
+	var err error
+	if tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); a.ClientSecret != "" || tokenFile != "" {
+		form := url.Values{
+			"grant_type": {"client_credentials"},
+			"client_id":  {clientID},
+			"scope":      {azureResource + ".default"},
+		}
+		if a.ClientSecret != "" {
+			form.Set("client_secret", a.ClientSecret)
+		} else {
+			assertion, readErr := ioutil.ReadFile(tokenFile)
+			if readErr != nil {
+				return azureToken{}, fmt.Errorf("Could not read the federated token [%s]: %w", tokenFile, readErr)
+			}
+			form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+			form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+		}
+		err = postForm(ctx, fmt.Sprintf(azureLoginURL, tenantID), form, &response)
+	} else {
+		response, err = managedIdentityToken(ctx, a.ClientID)
+	}
+	if err != nil {
+		return azureToken{}, err
+	}
+	expiresIn, _ := response.ExpiresIn.Int64()
+	token := azureToken{token: response.AccessToken, expires: time.Now().Add(time.Duration(expiresIn) * time.Second)}
+	azureADTokens[a] = token
+	return token, nil
+}
+
+// managedIdentityToken gets the token of the managed identity of the node from the instance metadata service
+func managedIdentityToken(ctx context.Context, clientID string) (azureTokenResponse, error) {
+	query := url.Values{"api-version": {"2018-02-01"}, "resource": {azureResource}}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", azureIMDSToken+"?"+query.Encode(), nil)
+	if err != nil {
+		return azureTokenResponse{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	body, err := imdsGet(req)
+	if err != nil {
+		return azureTokenResponse{}, err
+	}
+	var response azureTokenResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return azureTokenResponse{}, &lcmerrors.ParseError{Err: err}
+	}
+	return response, nil
+}
+
+// postForm posts the form with the registry client and decodes the json response
+func postForm(ctx context.Context, url string, form url.Values, response interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeOK(resp, response)
+}
+
+// decodeOK decodes the json body of the response, a response code other than 200 is returned as an error
+func decodeOK(resp *http.Response, response interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return &lcmerrors.ParseError{Err: err}
+	}
+	return nil
+}
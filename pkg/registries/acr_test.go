@@ -0,0 +1,63 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcrTagsWithTokenExchange(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/exchange":
+			if r.FormValue("access_token") != "ad-token" {
+				t.Errorf("Expected the Azure AD token to be exchanged but got %s", r.FormValue("access_token"))
+			}
+			fmt.Fprint(w, `{"refresh_token": "refresh"}`)
+		case "/oauth2/token":
+			if r.FormValue("refresh_token") != "refresh" || r.FormValue("scope") != "repository:team/app:metadata_read" {
+				t.Errorf("Expected a token for the repository but got %v", r.Form)
+			}
+			fmt.Fprint(w, `{"access_token": "access"}`)
+		case "/acr/v1/team/app/_tags":
+			if r.Header.Get("Authorization") != "Bearer access" {
+				t.Errorf("Expected the access token but got %s", r.Header.Get("Authorization"))
+			}
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</acr/v1/team/app/_tags?last=1.0.0&n=100>; rel="next"`)
+				fmt.Fprint(w, `{"tags": [{"name": "1.0.0"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"tags": [{"name": "1.1.0"}]}`)
+		default:
+			t.Errorf("Unexpected call to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	config := AcrRegistry{ClientID: "id", ClientSecret: "secret"}
+	azureADTokens[config] = azureToken{token: "ad-token", expires: time.Now().Add(time.Hour)}
+	registry := acrRegistry{config: config, url: strings.TrimPrefix(server.URL, "https://")}
+	tags, err := registry.GetTags(context.Background(), "team/app")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"1.0.0", "1.1.0"}) {
+		t.Errorf("Expected the tags of both pages but got %v", tags)
+	}
+}
+
+func TestAcrRegistryFor(t *testing.T) {
+	if _, exists := (AcrRegistry{}).registryFor("myregistry.azurecr.io"); !exists {
+		t.Errorf("Expected myregistry.azurecr.io to be an ACR registry")
+	}
+	if _, exists := (AcrRegistry{}).registryFor("azurecr.io.example.com"); exists {
+		t.Errorf("Expected azurecr.io.example.com not to be an ACR registry")
+	}
+}
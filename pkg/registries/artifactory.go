@@ -0,0 +1,109 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// Artifactory is the name for JFrog Artifactory docker repositories
+const Artifactory = "Artifactory"
+
+// ArtifactoryRegistry contains the settings of a docker repository in JFrog Artifactory, the tags are listed with the docker API of Artifactory
+// so local, remote and virtual repositories all work and the repository doesn't need its own docker host
+type ArtifactoryRegistry struct {
+	URL              string   `koanf:"url"`        // Url of Artifactory like https://corp.jfrog.io/artifactory
+	Repository       string   `koanf:"repository"` // Key of the docker repository
+	Urls             []string `koanf:"urls"`       // Image hosts that are looked up in this repository
+	Username         string   `koanf:"username"`
+	Password         string   `koanf:"password"`
+	APIKey           string   `koanf:"apiKey"`
+	AccessToken      string   `koanf:"accessToken"`
+	AllowAllReleases bool     `koanf:"allowAllReleases"`
+}
+
+// handles returns true when the images of the url are looked up in the repository
+func (a ArtifactoryRegistry) handles(url string) bool {
+	for _, u := range a.Urls {
+		if u == url {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLatestVersion fetches the latest version of the image from Artifactory
+func (a ArtifactoryRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", a.URL).WithField("image", name).Debug("Get latest version for Artifactory image")
+	tags, err := a.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, a.AllowAllReleases), nil
+}
+
+// GetTags lists the tags of the image with the List Docker Tags API of the repository, remote repositories only know the cached tags
+func (a ArtifactoryRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	cacheKey := fmt.Sprintf("tags/%s/%s/%s", a.URL, a.Repository, name)
+	var tags []string
+	if cache.GetJSON(ctx, a.URL, cacheKey, &tags) {
+		return tags, nil
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(a.URL, "/") + fmt.Sprintf("/api/docker/%s/v2/%s/tags/list", a.Repository, name))
+	if err != nil {
+		return nil, fmt.Errorf("Artifactory url [%s] not valid: %w", a.URL, err)
+	}
+	tags = []string{}
+	next := base
+	for page := 1; next != nil; page++ {
+		if page > maxTagPages {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: more than [%d] pages", a.URL, maxTagPages)
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", next.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticate(req)
+		resp, err := clientFrom(ctx).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", a.URL, err)
+		}
+		var response tagsResponse
+		if err := decodeOK(resp, &response); err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", a.URL, err)
+		}
+		tags = append(tags, response.Tags...)
+
+		next = nil
+		if link, err := getNextLink(resp); err == nil {
+			// the link can be relative to the host of Artifactory
+			if linkURL, err := url.Parse(link); err == nil {
+				next = base.ResolveReference(linkURL)
+			}
+		}
+	}
+	cache.SetJSON(ctx, cacheKey, tags)
+	return tags, nil
+}
+
+// authenticate adds the access token, the API key or the username and password to the request, in that order
+func (a ArtifactoryRegistry) authenticate(req *http.Request) {
+	switch {
+	case a.AccessToken != "":
+		req.Header.Set("Authorization", "Bearer "+a.AccessToken)
+	case a.APIKey != "":
+		req.Header.Set("X-JFrog-Art-Api", a.APIKey)
+	case a.Username != "":
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+}
+
+func (a ArtifactoryRegistry) allowsAllReleases() bool {
+	return a.AllowAllReleases
+}
@@ -0,0 +1,42 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestArtifactoryTagsWithAccessToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifactory/api/docker/docker-virtual/v2/team/app/tags/list" {
+			t.Errorf("Expected the docker API of the repository but got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected the access token but got %s", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</artifactory/api/docker/docker-virtual/v2/team/app/tags/list?last=1.0.0&n=1>; rel="next"`)
+			fmt.Fprint(w, `{"tags": ["1.0.0"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"tags": ["1.1.0"]}`)
+	}))
+	defer server.Close()
+
+	artifactory := ArtifactoryRegistry{URL: server.URL + "/artifactory", Repository: "docker-virtual", Urls: []string{"docker.corp.local"}, AccessToken: "token", APIKey: "unused"}
+	registries := ImageRegistries{Artifactory: []ArtifactoryRegistry{artifactory}}
+	found, exists := registries.FindArtifactoryByURL("docker.corp.local")
+	if !exists {
+		t.Fatalf("Expected the image host to be looked up in Artifactory")
+	}
+	tags, err := found.GetTags(context.Background(), "team/app")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"1.0.0", "1.1.0"}) {
+		t.Errorf("Expected the tags of both pages but got %v", tags)
+	}
+}
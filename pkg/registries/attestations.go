@@ -0,0 +1,110 @@
+package registries
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// Attester is implemented by the registries that can fetch the attestations that cosign attached to an image
+type Attester interface {
+	Attestations(ctx context.Context, name, digest string) ([]Attestation, error)
+}
+
+// Attestation is the in-toto statement of an attestation of an image, like an OpenVEX document with the predicate type https://openvex.dev/ns/v0.2.0
+type Attestation struct {
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// attestationManifest is the manifest of the attestations of cosign with a layer per attestation
+type attestationManifest struct {
+	Layers []struct {
+		Digest string `json:"digest"`
+	} `json:"layers"`
+}
+
+// dsseEnvelope is a layer of the attestations of cosign, the payload is the base64 encoded in-toto statement
+type dsseEnvelope struct {
+	Payload string `json:"payload"`
+}
+
+// GetAttestations returns the attestations of the image with the digest, it returns no attestations without an error
+// when the registry can't fetch them or the image has none
+func (i ImageRegistries) GetAttestations(ctx context.Context, name, url, digest string) ([]Attestation, error) {
+	ctx = i.context(ctx)
+	name, url = i.upstreamOf(name, url)
+	registry, err := i.determinRegistry(ctx, name, url)
+	if err != nil {
+		return nil, err
+	}
+	attester, ok := registry.(Attester)
+	if !ok {
+		logger.WithField("image", name).WithField("registry", url).Debug("Registry can't fetch attestations")
+		return nil, nil
+	}
+	return attester.Attestations(ctx, i.findImageNameOverride(name), digest)
+}
+
+// Attestations returns the attestations that cosign attached to the image with the sha256-<digest>.att tag,
+// the signatures of the attestations are not verified
+func (r ImageRegistry) Attestations(ctx context.Context, name, digest string) ([]Attestation, error) {
+	name = r.repositoryName(name)
+	cacheKey := fmt.Sprintf("attestations/%s/%s@%s", r.URL, name, digest)
+	var attestations []Attestation
+	if cache.GetJSON(ctx, r.URL, cacheKey, &attestations) {
+		return attestations, nil
+	}
+
+	token := ""
+	tag := strings.Replace(digest, ":", "-", 1) + ".att"
+	var image attestationManifest
+	if _, err := r.getJSON(ctx, fmt.Sprintf("/v2/%s/manifests/%s", name, tag), strings.Join(imageMediaTypes, ", "), &token, &image); err != nil {
+		if lcmerrors.CodeOf(err) == lcmerrors.CodeNotFound {
+			cache.SetJSON(ctx, cacheKey, []Attestation{})
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Could not fetch the attestations of [%s@%s]: %w", name, digest, err)
+	}
+	attestations = []Attestation{}
+	for _, layer := range image.Layers {
+		var envelope dsseEnvelope
+		if _, err := r.getJSON(ctx, fmt.Sprintf("/v2/%s/blobs/%s", name, layer.Digest), "", &token, &envelope); err != nil {
+			return nil, fmt.Errorf("Could not fetch the attestation [%s] of [%s@%s]: %w", layer.Digest, name, digest, err)
+		}
+		payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+		if err != nil {
+			return nil, &lcmerrors.ParseError{Err: fmt.Errorf("Could not decode the attestation [%s] of [%s@%s]: %w", layer.Digest, name, digest, err)}
+		}
+		var attestation Attestation
+		if err := json.Unmarshal(payload, &attestation); err != nil {
+			return nil, &lcmerrors.ParseError{Err: fmt.Errorf("Could not decode the attestation [%s] of [%s@%s]: %w", layer.Digest, name, digest, err)}
+		}
+		attestations = append(attestations, attestation)
+	}
+	cache.SetJSON(ctx, cacheKey, attestations)
+	return attestations, nil
+}
+
+// Attestations returns the attestations of the image with the credentials of the helper
+func (h helperRegistry) Attestations(ctx context.Context, name, digest string) ([]Attestation, error) {
+	registry, err := h.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.Attestations(ctx, name, digest)
+}
+
+// Attestations returns the attestations of the image in Google
+func (g googleRegistry) Attestations(ctx context.Context, name, digest string) ([]Attestation, error) {
+	registry, err := g.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.Attestations(ctx, name, digest)
+}
@@ -0,0 +1,38 @@
+package registries
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAttestationsOfTheDigest(t *testing.T) {
+	statement := base64.StdEncoding.EncodeToString([]byte(`{"_type": "https://in-toto.io/Statement/v0.1", "predicateType": "https://openvex.dev/ns/v0.2.0", "predicate": {"statements": []}}`))
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/team/app/manifests/sha256-abc.att":
+			fmt.Fprint(w, `{"layers": [{"digest": "sha256:vex"}]}`)
+		case "/v2/team/app/blobs/sha256:vex":
+			fmt.Fprintf(w, `{"payloadType": "application/vnd.in-toto+json", "payload": "%s"}`, statement)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	registries := ImageRegistries{OverrideRegistries: []OverrideRegistry{{Urls: []string{url}, Registry: ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone}}}}
+	attestations, err := registries.GetAttestations(context.Background(), "team/app", url, "sha256:abc")
+	if err != nil || len(attestations) != 1 || attestations[0].PredicateType != "https://openvex.dev/ns/v0.2.0" || string(attestations[0].Predicate) != `{"statements": []}` {
+		t.Errorf("Expected the OpenVEX attestation but got %v and [%v]", attestations, err)
+	}
+	attestations, err = registries.GetAttestations(context.Background(), "team/app", url, "sha256:unsigned")
+	if err != nil || len(attestations) != 0 {
+		t.Errorf("Expected no attestations for an image without attestations but got %v and [%v]", attestations, err)
+	}
+}
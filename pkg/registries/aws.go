@@ -0,0 +1,244 @@
+package registries
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// awsCredentials are the credentials used to sign calls to AWS APIs, the credentials of roles expire
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+func (c awsCredentials) expired(now time.Time) bool {
+	return !c.Expiration.IsZero() && now.Add(5*time.Minute).After(c.Expiration)
+}
+
+const (
+	// imdsURL is the instance metadata service of EC2 and Azure that serves the credentials of the instance
+	imdsURL        = "http://169.254.169.254"
+	awsTimeFormat  = "20060102T150405Z"
+	awsDateFormat  = "20060102"
+	awsSigningAlgo = "AWS4-HMAC-SHA256"
+)
+
+// imdsClient never uses a proxy because the metadata service is only reachable from the instance itself
+var imdsClient = &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{}}
+
+var (
+	awsCredentialsLock   sync.Mutex
+	cachedAWSCredentials = map[string]awsCredentials{}
+)
+
+// awsCredentialsFor returns the static credentials when they are configured, otherwise the credentials from the
+// environment, the IRSA web identity of the service account or the instance profile in that order
+func awsCredentialsFor(ctx context.Context, static awsCredentials, region string) (awsCredentials, error) {
+	if static.AccessKeyID != "" {
+		return static, nil
+	}
+	if key := os.Getenv("AWS_ACCESS_KEY_ID"); key != "" {
+		return awsCredentials{AccessKeyID: key, SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"), SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	awsCredentialsLock.Lock()
+	defer awsCredentialsLock.Unlock()
+	if credentials, exists := cachedAWSCredentials[region]; exists && !credentials.expired(time.Now()) {
+		return credentials, nil
+	}
+	var credentials awsCredentials
+	var err error
+	if roleARN, tokenFile := os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"); roleARN != "" && tokenFile != "" {
+		credentials, err = assumeRoleWithWebIdentity(ctx, region, roleARN, tokenFile)
+	} else {
+		credentials, err = instanceProfileCredentials(ctx)
+	}
+	if err != nil {
+		return awsCredentials{}, &lcmerrors.AuthError{Err: fmt.Errorf("Could not get AWS credentials: %w", err)}
+	}
+	cachedAWSCredentials[region] = credentials
+	return credentials, nil
+}
+
+type assumeRoleResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// assumeRoleWithWebIdentity exchanges the service account token of IRSA for the credentials of the role
+func assumeRoleWithWebIdentity(ctx context.Context, region, roleARN, tokenFile string) (awsCredentials, error) {
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("Could not read the web identity token [%s]: %w", tokenFile, err)
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "lcm"
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	// the token is sent in the body so it doesn't end up in logs or caches of the url
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://sts.%s.amazonaws.com/", region), strings.NewReader(form.Encode()))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return awsCredentials{}, lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code of STS was not 200 but [%v]", resp.StatusCode))
+	}
+	var response assumeRoleResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return awsCredentials{}, &lcmerrors.ParseError{Err: err}
+	}
+	c := response.Credentials
+	return awsCredentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken, Expiration: c.Expiration}, nil
+}
+
+type instanceProfileResponse struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// instanceProfileCredentials gets the credentials of the role of the instance profile from the metadata service with IMDSv2
+func instanceProfileCredentials(ctx context.Context) (awsCredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, "PUT", imdsURL+"/latest/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := imdsGet(req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+
+	path := imdsURL + "/latest/meta-data/iam/security-credentials/"
+	role, err := imdsRequest(ctx, path, token)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	body, err := imdsRequest(ctx, path+strings.TrimSpace(strings.Split(role, "\n")[0]), token)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	var response instanceProfileResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return awsCredentials{}, &lcmerrors.ParseError{Err: err}
+	}
+	return awsCredentials{AccessKeyID: response.AccessKeyID, SecretAccessKey: response.SecretAccessKey, SessionToken: response.Token, Expiration: response.Expiration}, nil
+}
+
+func imdsRequest(ctx context.Context, url, token string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return imdsGet(req)
+}
+
+func imdsGet(req *http.Request) (string, error) {
+	resp, err := imdsClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Could not reach the instance metadata service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Response code of the instance metadata service was not 200 but [%v]", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	return string(body), err
+}
+
+// signV4 signs the request for the AWS service with signature version 4, the host, content type and x-amz headers are signed
+func signV4(req *http.Request, body []byte, credentials awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(awsTimeFormat)
+	date := now.UTC().Format(awsDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		lower := strings.ToLower(key)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{awsSigningAlgo, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", awsSigningAlgo, credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
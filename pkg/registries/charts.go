@@ -1,9 +1,11 @@
 package registries
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
 )
 
 // HelmRegistries contains all the information regarding helm registries
@@ -25,24 +27,24 @@ type HelmRegistry struct {
 }
 
 // GetLatestVersionFromHelm fetches the latest version of the helm chart
-func (h HelmRegistries) GetLatestVersionFromHelm(chart string) string {
-	log.WithField("chart", chart).Debug("Fetching version for chart")
+func (h HelmRegistries) GetLatestVersionFromHelm(ctx context.Context, chart string) (string, error) {
+	logger.WithField("chart", chart).Debug("Fetching version for chart")
 
 	for _, registry := range h.OverrideRegistries {
 		for _, chartOverride := range registry.Charts {
 			match, err := regexp.MatchString(chartOverride, chart)
 			if err != nil {
-				log.WithError(err).Fatal("Chart regexp not valid")
+				return versioning.Failure, fmt.Errorf("Chart regexp [%s] not valid: %w", chartOverride, err)
 			}
 			if match {
 				chartName := h.OverrideChartNames[chart]
 				if chartName == "" {
 					chartName = chart
 				}
-				return registry.getChartVersions(chartName)
+				return registry.getChartVersions(ctx, chartName)
 			}
 		}
 	}
 
-	return h.useHelmHub(chart)
+	return h.useHelmHub(ctx, chart)
 }
@@ -0,0 +1,116 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+)
+
+// TagCreator is implemented by the registries that can find when the image of a tag was built
+type TagCreator interface {
+	TagCreated(ctx context.Context, name, tag string) (time.Time, error)
+}
+
+// imageMediaTypes are accepted for the manifest of one image of a multi-arch image
+var imageMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// manifest contains the parts of an image manifest or an index of the manifests of a multi-arch image
+type manifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// imageConfig contains the creation time of the image config blob
+type imageConfig struct {
+	Created time.Time `json:"created"`
+}
+
+// GetTagCreated returns when the image of the tag was built, it returns the zero time without an error
+// when the registry of the image can't tell
+func (i ImageRegistries) GetTagCreated(ctx context.Context, name, url, tag string) (time.Time, error) {
+	ctx = i.context(ctx)
+	name, url = i.upstreamOf(name, url)
+	registry, err := i.determinRegistry(ctx, name, url)
+	if err != nil {
+		return time.Time{}, err
+	}
+	creator, ok := registry.(TagCreator)
+	if !ok {
+		logger.WithField("image", name).WithField("registry", url).Debug("Registry can't tell when images are built")
+		return time.Time{}, nil
+	}
+	return creator.TagCreated(ctx, i.findImageNameOverride(name), tag)
+}
+
+// TagCreated returns the creation time of the config of the image of the tag, for a multi-arch image it is the linux/amd64 image
+// or else the first image of the index
+func (r ImageRegistry) TagCreated(ctx context.Context, name, tag string) (time.Time, error) {
+	name = r.repositoryName(name)
+	cacheKey := fmt.Sprintf("created/%s/%s/%s", r.URL, name, tag)
+	var created time.Time
+	if cache.GetJSON(ctx, r.URL, cacheKey, &created) {
+		return created, nil
+	}
+
+	token := ""
+	var image manifest
+	if _, err := r.getJSON(ctx, fmt.Sprintf("/v2/%s/manifests/%s", name, tag), strings.Join(manifestMediaTypes, ", "), &token, &image); err != nil {
+		return time.Time{}, fmt.Errorf("Could not fetch the manifest of [%s:%s]: %w", name, tag, err)
+	}
+	if image.Config.Digest == "" && len(image.Manifests) > 0 {
+		digest := image.Manifests[0].Digest
+		for _, m := range image.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				digest = m.Digest
+				break
+			}
+		}
+		image = manifest{}
+		if _, err := r.getJSON(ctx, fmt.Sprintf("/v2/%s/manifests/%s", name, digest), strings.Join(imageMediaTypes, ", "), &token, &image); err != nil {
+			return time.Time{}, fmt.Errorf("Could not fetch the manifest [%s] of [%s:%s]: %w", digest, name, tag, err)
+		}
+	}
+	if image.Config.Digest == "" {
+		// schema 1 manifests don't have a config
+		return time.Time{}, nil
+	}
+
+	var config imageConfig
+	if _, err := r.getJSON(ctx, fmt.Sprintf("/v2/%s/blobs/%s", name, image.Config.Digest), "", &token, &config); err != nil {
+		return time.Time{}, fmt.Errorf("Could not fetch the config of [%s:%s]: %w", name, tag, err)
+	}
+	cache.SetJSON(ctx, cacheKey, config.Created)
+	return config.Created, nil
+}
+
+// TagCreated returns when the image of the tag was built with the credentials of the helper
+func (h helperRegistry) TagCreated(ctx context.Context, name, tag string) (time.Time, error) {
+	registry, err := h.registry(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return registry.TagCreated(ctx, name, tag)
+}
+
+// TagCreated returns when the image of the tag in Google was built
+func (g googleRegistry) TagCreated(ctx context.Context, name, tag string) (time.Time, error) {
+	registry, err := g.registry(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return registry.TagCreated(ctx, name, tag)
+}
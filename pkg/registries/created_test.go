@@ -0,0 +1,35 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTagCreatedOfAMultiArchImage(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/nginx/manifests/1.20.1":
+			fmt.Fprint(w, `{"manifests": [{"digest": "sha256:arm", "platform": {"os": "linux", "architecture": "arm64"}}, {"digest": "sha256:amd", "platform": {"os": "linux", "architecture": "amd64"}}]}`)
+		case "/v2/library/nginx/manifests/sha256:amd":
+			fmt.Fprint(w, `{"config": {"digest": "sha256:config"}}`)
+		case "/v2/library/nginx/blobs/sha256:config":
+			fmt.Fprint(w, `{"created": "2021-06-01T10:00:00Z"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	registries := ImageRegistries{OverrideRegistries: []OverrideRegistry{{Urls: []string{url}, Registry: ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone}}}}
+	created, err := registries.GetTagCreated(context.Background(), "library/nginx", url, "1.20.1")
+	if err != nil || !created.Equal(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the creation time of the amd64 image but got %v and %v", created, err)
+	}
+}
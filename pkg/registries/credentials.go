@@ -0,0 +1,144 @@
+package registries
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// Credential is the username and password of a registry from a docker config, like an imagePullSecret
+type Credential struct {
+	Username string
+	Password string
+}
+
+type dockerConfigAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// dockerConfigFile is the part of a docker config.json with the credentials, the credentials of the registries in credHelpers
+// and without auths when there is a credsStore are kept by the helper
+type dockerConfigFile struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+// DockerConfigCredentials parses the auths of a docker config.json or of a legacy .dockercfg, the credentials are keyed by the host of the registry
+func DockerConfigCredentials(content []byte) (map[string]Credential, error) {
+	config, err := parseDockerConfig(content)
+	if err != nil {
+		return nil, err
+	}
+	return authCredentials(config.Auths)
+}
+
+func parseDockerConfig(content []byte) (dockerConfigFile, error) {
+	var config dockerConfigFile
+	if err := json.Unmarshal(content, &config); err != nil {
+		return config, &lcmerrors.ParseError{Err: err}
+	}
+	if config.Auths == nil && config.CredsStore == "" && config.CredHelpers == nil {
+		// the legacy .dockercfg only contains the auths, other settings of a config.json like HttpHeaders are skipped
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return config, &lcmerrors.ParseError{Err: err}
+		}
+		config.Auths = map[string]dockerConfigAuth{}
+		for registry, entry := range entries {
+			var auth dockerConfigAuth
+			if json.Unmarshal(entry, &auth) == nil {
+				config.Auths[registry] = auth
+			}
+		}
+	}
+	return config, nil
+}
+
+func authCredentials(auths map[string]dockerConfigAuth) (map[string]Credential, error) {
+	credentials := map[string]Credential{}
+	for registry, auth := range auths {
+		credential := Credential{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, &lcmerrors.ParseError{Err: fmt.Errorf("Auth of registry [%s] is not valid base64: %w", registry, err)}
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return nil, &lcmerrors.ParseError{Err: fmt.Errorf("Auth of registry [%s] is not username:password", registry)}
+			}
+			credential = Credential{Username: parts[0], Password: parts[1]}
+		}
+		if credential.Username == "" && credential.Password == "" {
+			continue
+		}
+		credentials[credentialHost(registry)] = credential
+	}
+	return credentials, nil
+}
+
+// credentialHost returns the host of the registry of a docker config or of an image, all the hosts of docker hub are docker.io
+func credentialHost(registry string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	host = strings.SplitN(host, "/", 2)[0]
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "docker.io"
+	}
+	return host
+}
+
+// WithCredentials returns the registries with the credentials added, they are used for the registries that don't have credentials
+// in the config and for the images of other hosts. Credentials that were added before are kept
+func (i ImageRegistries) WithCredentials(credentials map[string]Credential) ImageRegistries {
+	merged := map[string]Credential{}
+	for host, credential := range credentials {
+		merged[host] = credential
+	}
+	for host, credential := range i.credentials {
+		merged[host] = credential
+	}
+	i.credentials = merged
+	return i
+}
+
+// WithRepositoryCredentials returns the registries with the credentials of repositories added, keyed by the repository as url/name
+// and the host. They are only used for the images of the repository and come before the credentials of WithCredentials, so the
+// imagePullSecrets of one namespace aren't used for the images of another namespace
+func (i ImageRegistries) WithRepositoryCredentials(credentials map[string]map[string]Credential) ImageRegistries {
+	merged := map[string]map[string]Credential{}
+	for repository, hosts := range i.repositories {
+		merged[repository] = hosts
+	}
+	for repository, hosts := range credentials {
+		if _, exists := merged[repository]; !exists {
+			merged[repository] = hosts
+		}
+	}
+	i.repositories = merged
+	return i
+}
+
+// withCredential adds the added credentials of the repository or the host to the registry when it doesn't have credentials in the config
+func (i ImageRegistries) withCredential(ctx context.Context, repository string, registry ImageRegistry) ImageRegistry {
+	if registry.Username != "" || registry.Password != "" {
+		return registry
+	}
+	credential, exists := i.credentialFor(ctx, repository, registry.URL)
+	if !exists {
+		return registry
+	}
+	registry.Username = credential.Username
+	registry.Password = credential.Password
+	if registry.AuthType == AuthTypeNone || registry.AuthType == "" {
+		registry.AuthType = AuthTypeToken
+	}
+	return registry
+}
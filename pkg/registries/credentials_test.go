@@ -0,0 +1,90 @@
+package registries
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDockerConfigCredentials(t *testing.T) {
+	config := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "dXNlcjpzZWNyZXQ="},
+		"registry.corp.local:5000": {"username": "lcm", "password": "token"},
+		"empty.corp.local": {}
+	}}`
+	credentials, err := DockerConfigCredentials([]byte(config))
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := map[string]Credential{
+		"docker.io":                {Username: "user", Password: "secret"},
+		"registry.corp.local:5000": {Username: "lcm", Password: "token"},
+	}
+	if !reflect.DeepEqual(credentials, expected) {
+		t.Errorf("Expected %v but got %v", expected, credentials)
+	}
+}
+
+func TestLegacyDockercfgCredentials(t *testing.T) {
+	credentials, err := DockerConfigCredentials([]byte(`{"registry.corp.local": {"auth": "dXNlcjpzZWNyZXQ="}}`))
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if credentials["registry.corp.local"] != (Credential{Username: "user", Password: "secret"}) {
+		t.Errorf("Expected the credentials of the legacy format but got %v", credentials)
+	}
+}
+
+func TestCredentialsAreOnlyUsedWithoutConfiguredCredentials(t *testing.T) {
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	registries.Quay.Username = "configured"
+	registries = registries.WithCredentials(map[string]Credential{
+		"docker.io":           {Username: "user", Password: "secret"},
+		"quay.io":             {Username: "other", Password: "secret"},
+		"registry.corp.local": {Username: "lcm", Password: "token"},
+	})
+
+	tests := map[string]struct {
+		url      string
+		username string
+		base     string
+	}{
+		"docker hub":     {url: "docker.io", username: "user", base: "registry.hub.docker.com"},
+		"configured":     {url: "quay.io", username: "configured", base: "quay.io"},
+		"other registry": {url: "registry.corp.local", username: "lcm", base: "registry.corp.local"},
+	}
+	for name, test := range tests {
+		provider, err := registries.determinRegistry(context.Background(), "team/app", test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registry := provider.(ImageRegistry)
+		if registry.Username != test.username || registry.URL != test.base {
+			t.Errorf("Expected %s to use %s on %s but got %s on %s", name, test.username, test.base, registry.Username, registry.URL)
+		}
+	}
+}
+
+func TestRepositoryCredentialsAreOnlyUsedForTheirRepository(t *testing.T) {
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	registries = registries.WithRepositoryCredentials(map[string]map[string]Credential{
+		"registry.corp.local/team-a/app": {"registry.corp.local": {Username: "team-a", Password: "secret"}},
+	})
+	registries = registries.WithCredentials(map[string]Credential{"registry.corp.local": {Username: "developer", Password: "secret"}})
+
+	tests := map[string]string{
+		"team-a/app": "team-a",
+		"team-b/app": "developer",
+	}
+	for name, username := range tests {
+		provider, err := registries.determinRegistry(context.Background(), name, "registry.corp.local")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if registry := provider.(ImageRegistry); registry.Username != username {
+			t.Errorf("Expected %s to use the credentials of %s but got %s", name, username, registry.Username)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// CredentialHelper runs a docker credential helper like ecr-login, gcloud or acr-env to get short-lived credentials for the registries of the hosts
+// The helper is run as docker-credential-<helper> get, so it has to be on the PATH
+type CredentialHelper struct {
+	Helper           string   `koanf:"helper"`
+	Hosts            []string `koanf:"hosts"`    // Hosts or patterns like *.dkr.ecr.*.amazonaws.com
+	AuthType         string   `koanf:"authType"` // Can be basic or token, default is token
+	AllowAllReleases bool     `koanf:"allowAllReleases"`
+}
+
+// handles returns true when the helper is configured for the registry url
+func (c CredentialHelper) handles(url string) bool {
+	for _, host := range c.Hosts {
+		if match, _ := path.Match(host, url); match {
+			return true
+		}
+	}
+	return false
+}
+
+// helperRegistry is a single registry with the credentials of a credential helper
+type helperRegistry struct {
+	config CredentialHelper
+	url    string
+}
+
+// GetLatestVersion fetches the latest version of the image with the credentials of the helper
+func (h helperRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", h.url).WithField("helper", h.config.Helper).WithField("image", name).Debug("Get latest version with credential helper")
+	tags, err := h.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, h.config.AllowAllReleases), nil
+}
+
+// GetTags fetches all the tags of the image with the credentials of the helper
+func (h helperRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	registry, err := h.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.GetTags(ctx, name)
+}
+
+// TagsForDigest returns the tags of the image that point to the digest with the credentials of the helper
+func (h helperRegistry) TagsForDigest(ctx context.Context, name, digest string) ([]string, error) {
+	registry, err := h.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.TagsForDigest(ctx, name, digest)
+}
+
+// registry returns the docker registry with the credentials that the helper returns for the host
+func (h helperRegistry) registry(ctx context.Context) (ImageRegistry, error) {
+	credential, found, err := helperCredential(ctx, h.config.Helper, credentialHost(h.url))
+	if err != nil {
+		return ImageRegistry{}, &lcmerrors.AuthError{Err: err}
+	}
+	if !found {
+		return ImageRegistry{}, &lcmerrors.AuthError{Err: fmt.Errorf("Credential helper [%s] has no credentials for [%s]", h.config.Helper, h.url)}
+	}
+	authType := h.config.AuthType
+	if authType == "" {
+		authType = AuthTypeToken
+	}
+	return ImageRegistry{Name: h.url, URL: h.url, AuthType: authType, Username: credential.Username, Password: credential.Password, AllowAllReleases: h.config.AllowAllReleases}, nil
+}
+
+func (h helperRegistry) allowsAllReleases() bool {
+	return h.config.AllowAllReleases
+}
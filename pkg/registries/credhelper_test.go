@@ -0,0 +1,49 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCredentialHelperCredentialsAreUsedForTheHosts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "AWS" || password != "short-lived" {
+			t.Errorf("Expected the credentials of the helper but got %s", username)
+		}
+		fmt.Fprint(w, `{"tags": ["1.0.0", "1.1.0"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	helper := "#!/bin/sh\necho '{\"Username\": \"AWS\", \"Secret\": \"short-lived\"}'\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker-credential-fake-ecr"), []byte(helper), 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	registries.CredentialHelpers = []CredentialHelper{{Helper: "fake-ecr", Hosts: []string{"127.0.0.1:*"}, AuthType: AuthTypeBasic}}
+	url := strings.TrimPrefix(server.URL, "https://")
+	if _, exists := registries.FindCredentialHelperByURL("registry.corp.local"); exists {
+		t.Errorf("Expected no credential helper for other hosts")
+	}
+	version, err := registries.GetLatestVersionForImage(context.Background(), "team/app", url)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if version != "1.1.0" {
+		t.Errorf("Expected version 1.1.0 but got %s", version)
+	}
+}
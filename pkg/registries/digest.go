@@ -0,0 +1,170 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// maxDigestLookups is the maximum number of tags of an image whose digest is looked up to resolve a digest, the highest versions are looked up first
+const maxDigestLookups = 50
+
+// manifestMediaTypes are accepted when the digest of a tag is looked up, the index types first so the digest of a multi-arch image is the digest of the index
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// DigestResolver is implemented by the registries that can find the tags of an image that point to a digest
+type DigestResolver interface {
+	TagsForDigest(ctx context.Context, name, digest string) ([]string, error)
+}
+
+// TagDigester is implemented by the registries that can find the digest a tag points to
+type TagDigester interface {
+	TagDigest(ctx context.Context, name, tag string) (string, error)
+}
+
+// GetTagDigest returns the digest the tag of the image currently points to, it returns an empty digest without an error
+// when the registry of the image can't look up digests
+func (i ImageRegistries) GetTagDigest(ctx context.Context, name, url, tag string) (string, error) {
+	ctx = i.context(ctx)
+	name, url = i.upstreamOf(name, url)
+	registry, err := i.determinRegistry(ctx, name, url)
+	if err != nil {
+		return "", err
+	}
+	digester, ok := registry.(TagDigester)
+	if !ok {
+		logger.WithField("image", name).WithField("registry", url).Debug("Registry can't look up the digests of tags")
+		return "", nil
+	}
+	return digester.TagDigest(ctx, i.findImageNameOverride(name), tag)
+}
+
+// IsMutableTag returns true when the tag is one of the mutable tags that can point to a new build, like latest or stable
+func (i ImageRegistries) IsMutableTag(tag string) bool {
+	for _, mutable := range i.MutableTags {
+		if match, err := regexp.MatchString("^(?:"+mutable+")$", tag); err == nil && match {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTagsForDigest returns the tags of the image that point to the digest, it returns no tags without an error
+// when the registry of the image can't resolve digests
+func (i ImageRegistries) GetTagsForDigest(ctx context.Context, name, url, digest string) ([]string, error) {
+	ctx = i.context(ctx)
+	name, url = i.upstreamOf(name, url)
+	registry, err := i.determinRegistry(ctx, name, url)
+	if err != nil {
+		return nil, err
+	}
+	resolver, ok := registry.(DigestResolver)
+	if !ok {
+		logger.WithField("image", name).WithField("registry", url).Debug("Registry can't resolve digests")
+		return nil, nil
+	}
+	return resolver.TagsForDigest(ctx, i.findImageNameOverride(name), digest)
+}
+
+// TagsForDigest looks up the digest of the version tags of the image, from the highest version down, and returns the tags that point to the digest
+func (r ImageRegistry) TagsForDigest(ctx context.Context, name, digest string) ([]string, error) {
+	name = r.repositoryName(name)
+	cacheKey := fmt.Sprintf("digest/%s/%s/%s", r.URL, name, digest)
+	var matches []string
+	if cache.GetJSON(ctx, r.URL, cacheKey, &matches) {
+		return matches, nil
+	}
+
+	tags, err := r.GetTags(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{}
+	for _, tag := range tags {
+		if strings.Contains(tag, ".") {
+			candidates = append(candidates, tag)
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return versioning.CompareVersions(candidates[a], candidates[b]) == 1
+	})
+	if len(candidates) > maxDigestLookups {
+		candidates = candidates[:maxDigestLookups]
+	}
+
+	token := ""
+	matches = []string{}
+	for _, tag := range candidates {
+		tagDigest, err := r.manifestDigest(ctx, name, tag, &token)
+		if err != nil {
+			return nil, fmt.Errorf("Could not resolve digest [%s] with [%s]: %w", digest, r.URL, err)
+		}
+		if tagDigest == digest {
+			matches = append(matches, tag)
+		}
+	}
+	cache.SetJSON(ctx, cacheKey, matches)
+	return matches, nil
+}
+
+// TagDigest returns the digest the tag points to, for a multi-arch image it is the digest of the index
+func (r ImageRegistry) TagDigest(ctx context.Context, name, tag string) (string, error) {
+	token := ""
+	digest, err := r.manifestDigest(ctx, r.repositoryName(name), tag, &token)
+	if err != nil {
+		return "", fmt.Errorf("Could not fetch the digest of [%s:%s] from [%s]: %w", name, tag, r.URL, err)
+	}
+	return digest, nil
+}
+
+// manifestDigest returns the digest of the manifest of the tag with a HEAD request, so the manifest itself isn't downloaded
+func (r ImageRegistry) manifestDigest(ctx context.Context, name, tag string, token *string) (string, error) {
+	client, req, err := r.getClientAndRequest(ctx, fmt.Sprintf("/v2/%s/manifests/%s", name, tag), token)
+	if err != nil {
+		return "", err
+	}
+	req.Method = http.MethodHead
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		r.forgetToken(req.URL.String())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// TagDigest returns the digest the tag points to with the credentials of the helper
+func (h helperRegistry) TagDigest(ctx context.Context, name, tag string) (string, error) {
+	registry, err := h.registry(ctx)
+	if err != nil {
+		return "", err
+	}
+	return registry.TagDigest(ctx, name, tag)
+}
+
+// TagDigest returns the digest the tag of the image in Google points to
+func (g googleRegistry) TagDigest(ctx context.Context, name, tag string) (string, error) {
+	registry, err := g.registry(ctx)
+	if err != nil {
+		return "", err
+	}
+	return registry.TagDigest(ctx, name, tag)
+}
@@ -0,0 +1,43 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDigestIsResolvedToItsTags(t *testing.T) {
+	digests := map[string]string{
+		"1.2.0":  "sha256:old",
+		"1.2.1":  "sha256:pinned",
+		"1.2":    "sha256:pinned",
+		"1.3.0":  "sha256:new",
+		"latest": "sha256:new",
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/team/app/tags/list" {
+			fmt.Fprint(w, `{"tags": ["1.2.0", "1.2.1", "1.2", "1.3.0", "latest"]}`)
+			return
+		}
+		if r.Method != http.MethodHead || !strings.Contains(r.Header.Get("Accept"), "manifest.list.v2+json") {
+			t.Errorf("Expected a HEAD request for the manifest list but got %s with %s", r.Method, r.Header.Get("Accept"))
+		}
+		w.Header().Set("Docker-Content-Digest", digests[strings.TrimPrefix(r.URL.Path, "/v2/team/app/manifests/")])
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	tags, err := registries.GetTagsForDigest(context.Background(), "team/app", strings.TrimPrefix(server.URL, "https://"), "sha256:pinned")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"1.2.1", "1.2"}) {
+		t.Errorf("Expected the tags 1.2.1 and 1.2 but got %v", tags)
+	}
+}
@@ -0,0 +1,9 @@
+// Package registries finds the latest versions of images, Helm charts and tools.
+//
+// ImageRegistries looks up image tags in Docker Hub, Quay, Gcr, Zalando, ghcr.io, GitLab or any other Docker registry,
+// HelmRegistries looks up charts in the Helm hub or a chart repository index and ToolRegistries looks up releases on GitHub.
+//
+//	var images registries.ImageRegistries
+//	images.DefaultRegistries()
+//	latest, err := images.GetLatestVersionForImage(ctx, "library/nginx", "docker.io")
+package registries
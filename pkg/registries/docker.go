@@ -0,0 +1,280 @@
+package registries
+
+// Parts of the code here are coming from github.com/heroku/docker-registry-client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+const (
+	// DockerHub is the default name for the DockerHub registry
+	DockerHub = "DockerHub"
+	// Quay is the default name for the Quay registry
+	Quay = "Quay"
+	// Gcr is the default name for the Gcr registry
+	Gcr = "Gcr"
+	// GcrK8s is the default name for the GcrK8s registry
+	GcrK8s = "GcrK8s"
+	// Zalando is the default name for the Zalando registry
+	Zalando = "Zalando"
+	// Ghcr is the default name for the GitHub Container Registry
+	Ghcr = "Ghcr"
+	// Gitlab is the default name for the GitLab Container Registry
+	Gitlab = "Gitlab"
+	// Nexus is the name for Sonatype Nexus docker repositories
+	Nexus = "Nexus"
+	// AuthTypeBasic is the basic auth type
+	AuthTypeBasic = "basic"
+	// AuthTypeToken is the token auth type
+	AuthTypeToken = "token"
+	// AuthTypeNone is no auth
+	AuthTypeNone = "none"
+)
+
+// ErrNoMorePages defines that there are no more pages
+var ErrNoMorePages = errors.New("no more pages")
+
+// maxTagPages is the maximum number of pages of tags of an image, it stops registries that keep returning a next page
+const maxTagPages = 1000
+
+// minPageSize is the smallest default page size of the registries, a page without a Link header with at least this many tags
+// can be cut by the registry so the tags after the last tag are fetched as well
+const minPageSize = 100
+
+type tagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// ImageRegistry contains all the information about the registry
+type ImageRegistry struct {
+	Name             string `koanf:"name"`
+	URL              string `koanf:"url"`
+	AuthType         string `koanf:"authType"`
+	Username         string `koanf:"username"`
+	Password         string `koanf:"password"`
+	Default          bool   `koanf:"default"`
+	PageSize         int    `koanf:"pageSize"` // Sent as n to ask for pages of this size, only a hint as the registry can return smaller pages. Default is the page size of the registry
+	AllowAllReleases bool
+}
+
+// GetLatestVersion fetches the latest version of the docker image from Docker registry
+func (r ImageRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", r.Name).WithField("image", name).Debug("Get latest version for Docker image")
+	tags, err := r.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, r.AllowAllReleases), nil
+}
+
+// GetTags fetches all the tags of the docker image from Docker registry
+func (r ImageRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	name = r.repositoryName(name)
+	cacheKey := fmt.Sprintf("tags/%s/%s", r.URL, name)
+	var tags []string
+	if cache.GetJSON(ctx, r.URL, cacheKey, &tags) {
+		return tags, nil
+	}
+
+	// the token is scoped to the image so it is only shared between the pages of this image
+	token := ""
+	pathSuffix := fmt.Sprintf("/v2/%s/tags/list", name)
+	if r.PageSize > 0 {
+		pathSuffix += fmt.Sprintf("?n=%d", r.PageSize)
+	}
+	tags, err := r.fetch(ctx, pathSuffix, &token)
+	if err != nil {
+		return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", r.URL, err)
+	}
+	cache.SetJSON(ctx, cacheKey, tags)
+	return tags, nil
+}
+
+// repositoryName adds library/ to single names (without /) of docker hub
+func (r ImageRegistry) repositoryName(name string) string {
+	if r.Name == DockerHub && !strings.Contains(name, "/") {
+		return "library/" + name
+	}
+	return name
+}
+
+// fetch follows the Link headers of the tag list, a page of a registry without Link headers that can be cut is continued with the last tag.
+// Registries that ignore the last parameter return tags that were already fetched, which ends the tag list
+func (r ImageRegistry) fetch(ctx context.Context, pathSuffix string, token *string) ([]string, error) {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for page := 1; page <= maxTagPages; page++ {
+		var response tagsResponse
+		next, err := r.getPaginatedJSON(ctx, pathSuffix, token, &response)
+		if err == ErrNoMorePages {
+			next = continueAfterLast(pathSuffix, response.Tags)
+		} else if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, tag := range response.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+				added++
+			}
+		}
+		if next == "" || next == pathSuffix || added == 0 {
+			return tags, nil
+		}
+		pathSuffix = next
+	}
+	return nil, fmt.Errorf("Tag list has more than [%d] pages", maxTagPages)
+}
+
+// continueAfterLast returns the page after the last tag, with the n and last parameters of the distribution spec, when the page
+// can be cut. That is a page with the n tags that were asked for, or with at least as many tags as the smallest default page size
+// because the registry can return smaller pages than asked for. It is empty when the page has all the remaining tags
+func continueAfterLast(pathSuffix string, tags []string) string {
+	pageURL, err := url.Parse(pathSuffix)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	query := pageURL.Query()
+	size, err := strconv.Atoi(query.Get("n"))
+	full := err == nil && size > 0 && len(tags) >= size
+	if !full && len(tags) < minPageSize {
+		return ""
+	}
+	query.Set("last", tags[len(tags)-1])
+	pageURL.RawQuery = query.Encode()
+	return pageURL.String()
+}
+
+func (r ImageRegistry) getPaginatedJSON(ctx context.Context, pathSuffix string, token *string, response interface{}) (string, error) {
+	resp, err := r.getJSON(ctx, pathSuffix, "", token, response)
+	if err != nil {
+		return "", err
+	}
+	return getNextLink(resp)
+}
+
+// getJSON decodes the response of the path into response, the accept header is only sent when it is not empty
+func (r ImageRegistry) getJSON(ctx context.Context, pathSuffix, accept string, token *string, response interface{}) (*http.Response, error) {
+	client, req, err := r.getClientAndRequest(ctx, pathSuffix, token)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != 200 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			r.forgetToken(req.URL.String())
+		}
+		return nil, lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	err = decoder.Decode(response)
+	if err != nil {
+		return nil, &lcmerrors.ParseError{Err: err}
+	}
+	return resp, nil
+}
+
+func (r ImageRegistry) getClientAndRequest(ctx context.Context, pathSuffix string, token *string) (*http.Client, *http.Request, error) {
+	url, err := pageURL(r.baseURL(), pathSuffix)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.WithField("url", url).Debugf("Try fetching url")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.AuthType == AuthTypeBasic {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+
+	if r.AuthType == AuthTypeToken && *token == "" {
+		logger.Debug("Need to fetch the auth token")
+		newToken, err := r.getToken(ctx, url)
+		if err != nil {
+			return nil, nil, err
+		}
+		*token = newToken
+	}
+	if *token != "" {
+		logger.Debug("Using cached token")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", *token))
+	}
+	return clientFrom(ctx), req, nil
+}
+
+// baseURL returns the url of the registry with https, unless the url already starts with http:// or https://
+func (r ImageRegistry) baseURL() string {
+	if strings.HasPrefix(r.URL, "http://") || strings.HasPrefix(r.URL, "https://") {
+		return r.URL
+	}
+	return "https://" + r.URL
+}
+
+// pageURL returns the url of the path on the registry, the next links of some registries are a full url which has to be on the same host
+// so the credentials are not sent elsewhere
+func pageURL(base, path string) (string, error) {
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		return base + path, nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	linkURL, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("Next page [%s] not valid: %w", path, err)
+	}
+	if linkURL.Host != baseURL.Host {
+		return "", fmt.Errorf("Next page [%s] is not on the registry [%s]", path, baseURL.Host)
+	}
+	return path, nil
+}
+
+// Matches an RFC 5988 (https://tools.ietf.org/html/rfc5988#section-5)
+// Link header. For example,
+//
+//	<http://r.example.com/v2/_catalog?n=5&last=tag5>; type="application/json"; rel="next"
+//
+// The URL is _supposed_ to be wrapped by angle brackets `< ... >`,
+// but e.g., quay.io does not include them. Similarly, params like
+// `rel="next"` may not have quoted values in the wild.
+var nextLinkRE = regexp.MustCompile(`^ *<?([^;>]+)>? *(?:;[^;]*)*; *rel="?next"?(?:;.*)?`)
+
+func getNextLink(resp *http.Response) (string, error) {
+	for _, link := range resp.Header[http.CanonicalHeaderKey("Link")] {
+		parts := nextLinkRE.FindStringSubmatch(link)
+		if parts != nil {
+			return parts[1], nil
+		}
+	}
+	return "", ErrNoMorePages
+}
+
+func (r ImageRegistry) allowsAllReleases() bool {
+	return r.AllowAllReleases
+}
@@ -0,0 +1,94 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTagsArePagedWithLinksAndLastTag(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("last") {
+		case "":
+			// a full page without a Link header
+			fmt.Fprint(w, `{"tags": ["1.0.0", "1.1.0"]}`)
+		case "1.1.0":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v2/team/app/tags/list?n=2&last=1.3.0>; rel="next"`, server.URL))
+			fmt.Fprint(w, `{"tags": ["1.2.0", "1.3.0"]}`)
+		case "1.3.0":
+			fmt.Fprint(w, `{"tags": ["2.0.0"]}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	registry := ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone, PageSize: 2}
+	tags, err := registry.GetTags(context.Background(), "team/app")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0", "2.0.0"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected the tags of all pages %v but got %v", expected, tags)
+	}
+}
+
+func TestNextPageOnAnotherHostIsRefused(t *testing.T) {
+	if _, err := pageURL("https://registry.corp.local", "https://evil.example.com/v2/app/tags/list?last=1"); err == nil {
+		t.Errorf("Expected an error for a next page on another host")
+	}
+	next, err := pageURL("https://registry.corp.local", "/v2/app/tags/list?last=1")
+	if err != nil || next != "https://registry.corp.local/v2/app/tags/list?last=1" {
+		t.Errorf("Expected the path on the registry but got %s and %v", next, err)
+	}
+}
+
+func TestTagsArePagedWithoutPageSize(t *testing.T) {
+	var firstPage, secondPage []string
+	for index := 0; index < minPageSize; index++ {
+		firstPage = append(firstPage, fmt.Sprintf(`"1.%d.0"`, index))
+	}
+	for index := 0; index < 20; index++ {
+		secondPage = append(secondPage, fmt.Sprintf(`"2.%d.0"`, index))
+	}
+	tests := map[string]struct {
+		pages map[string]string
+		tags  int
+	}{
+		"cut without link": {pages: map[string]string{"": strings.Join(firstPage, ","), "1.99.0": strings.Join(secondPage, ",")}, tags: minPageSize + 20},
+		"last is ignored":  {pages: map[string]string{"": strings.Join(firstPage, ","), "1.99.0": strings.Join(firstPage, ",")}, tags: minPageSize},
+		"single page":      {pages: map[string]string{"": strings.Join(secondPage, ",")}, tags: 20},
+	}
+	for name, test := range tests {
+		requests := 0
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.URL.Query().Get("n") != "" {
+				t.Errorf("Expected no page size for %s but got %s", name, r.URL.RawQuery)
+			}
+			page, exists := test.pages[r.URL.Query().Get("last")]
+			if !exists {
+				page = ""
+			}
+			fmt.Fprintf(w, `{"tags": [%s]}`, page)
+		}))
+		restore := useTestServer(server)
+
+		url := strings.TrimPrefix(server.URL, "https://")
+		registry := ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone}
+		tags, err := registry.GetTags(context.Background(), "team/app")
+		if err != nil || len(tags) != test.tags {
+			t.Errorf("Expected %d tags for %s but got %d and [%v] after %d requests", test.tags, name, len(tags), err, requests)
+		}
+		restore()
+		server.Close()
+	}
+}
@@ -0,0 +1,167 @@
+package registries
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+)
+
+// helperCredentialsTTL is how long the credentials of a credential helper are reused, helpers like ecr-login return short-lived tokens
+const helperCredentialsTTL = 5 * time.Minute
+
+// DockerConfigPath returns the path of the docker config.json of the user, in the directory of DOCKER_CONFIG when it is set
+func DockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// WithDockerConfig returns the registries with the credentials of the docker config.json added, also of its credsStore and credHelpers
+// A docker config that doesn't exist is skipped, the credentials that were added before are kept
+func (i ImageRegistries) WithDockerConfig(path string) (ImageRegistries, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		logger.WithField("path", path).Debug("No docker config found")
+		return i, nil
+	}
+	if err != nil {
+		return i, fmt.Errorf("Could not read the docker config [%s]: %w", path, err)
+	}
+	config, err := parseDockerConfig(content)
+	if err != nil {
+		return i, fmt.Errorf("Could not parse the docker config [%s]: %w", path, err)
+	}
+	credentials, err := authCredentials(config.Auths)
+	if err != nil {
+		return i, fmt.Errorf("Could not parse the docker config [%s]: %w", path, err)
+	}
+
+	i = i.WithCredentials(credentials)
+	helpers := map[string]string{}
+	for registry, helper := range config.CredHelpers {
+		helpers[credentialHost(registry)] = helper
+	}
+	for host, helper := range i.credentialHelpers {
+		helpers[host] = helper
+	}
+	i.credentialHelpers = helpers
+	if i.credentialStore == "" {
+		i.credentialStore = config.CredsStore
+	}
+	logger.WithField("path", path).WithField("registries", len(credentials)).WithField("helpers", len(config.CredHelpers)).Debug("Loaded docker config")
+	return i, nil
+}
+
+// credentialFor returns the added credentials of the repository for the host of the url, or else the added credentials of the host,
+// or else those of its credential helper or of the credentials store
+func (i ImageRegistries) credentialFor(ctx context.Context, repository, url string) (Credential, bool) {
+	host := credentialHost(url)
+	if credential, exists := i.repositories[repository][host]; exists {
+		return credential, true
+	}
+	if credential, exists := i.credentials[host]; exists {
+		return credential, true
+	}
+	helper, exists := i.credentialHelpers[host]
+	if !exists {
+		helper = i.credentialStore
+	}
+	if helper == "" {
+		return Credential{}, false
+	}
+	credential, found, err := helperCredential(ctx, helper, host)
+	if err != nil {
+		// the registry is still tried without credentials, like docker does
+		logger.WithError(err).WithField("host", host).Warn("Could not get the credentials of the docker config")
+	}
+	return credential, found
+}
+
+type helperResult struct {
+	credential Credential
+	found      bool
+	err        error
+	expires    time.Time
+}
+
+var (
+	helperResultsLock sync.Mutex
+	helperResults     = map[string]helperResult{}
+)
+
+type helperResponse struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+// helperCredential runs docker-credential-<helper> get for the host, found is false when the helper doesn't know the host
+// The results are reused for a few minutes so the helper isn't run for every image, except when the helper was stopped
+// because the context was done, which says nothing about the host
+func helperCredential(ctx context.Context, helper, host string) (Credential, bool, error) {
+	helperResultsLock.Lock()
+	defer helperResultsLock.Unlock()
+	key := helper + "/" + host
+	if result, exists := helperResults[key]; exists && time.Now().Before(result.expires) {
+		return result.credential, result.found, result.err
+	}
+
+	serverURL := host
+	if host == "docker.io" {
+		serverURL = "https://index.docker.io/v1/"
+	}
+	command := "docker-credential-" + helper
+	var stdout, stderr bytes.Buffer
+	notFound := false
+	err := plugins.Observe(ctx, plugins.Exec{Component: httpclient.Registry, Target: command, Command: command, Credential: "helper"}, func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, command, "get")
+		cmd.Stdin = strings.NewReader(serverURL)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		// a helper without credentials for the host is not a failure
+		notFound = err != nil && strings.Contains(stdout.String()+stderr.String(), "credentials not found")
+		if notFound {
+			return nil
+		}
+		return err
+	})
+
+	if err != nil && ctx.Err() != nil {
+		return Credential{}, false, fmt.Errorf("Credential helper [%s] was stopped: %w", helper, ctx.Err())
+	}
+
+	result := helperResult{expires: time.Now().Add(helperCredentialsTTL)}
+	var response helperResponse
+	switch {
+	case notFound:
+		logger.WithField("helper", helper).WithField("host", host).Debug("Credential helper has no credentials for the host")
+	case err != nil:
+		result.err = fmt.Errorf("Credential helper [%s] failed: %w, stderr [%s]", helper, err, strings.TrimSpace(stderr.String()))
+	case json.Unmarshal(stdout.Bytes(), &response) != nil:
+		result.err = fmt.Errorf("Credential helper [%s] did not return valid json", helper)
+	case response.Username == "<token>":
+		// an identity token can only be exchanged for registry tokens with the OAuth2 flow of the registry itself
+		result.err = fmt.Errorf("Credential helper [%s] returned an identity token which is not supported", helper)
+	default:
+		result.credential = Credential{Username: response.Username, Password: response.Secret}
+		result.found = true
+	}
+	helperResults[key] = result
+	return result.credential, result.found, result.err
+}
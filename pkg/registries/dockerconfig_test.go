@@ -0,0 +1,79 @@
+package registries
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDockerConfigWithCredentialHelpers(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	// the helper returns credentials for the hosts that start with private and nothing for the others
+	helper := "#!/bin/sh\nread host\ncase $host in private*) echo '{\"Username\": \"helper\", \"Secret\": \"'$host'\"}';; *) echo 'credentials not found in native keychain'; exit 1;; esac\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker-credential-test"), []byte(helper), 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+	config := `{
+		"auths": {"registry.corp.local": {"auth": "dXNlcjpzZWNyZXQ="}, "private.corp.local": {}},
+		"credHelpers": {"private.corp.local": "test"},
+		"credsStore": "test"
+	}`
+	configPath := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	registries, err := registries.WithDockerConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	tests := map[string]Credential{
+		"registry.corp.local":  {Username: "user", Password: "secret"},
+		"private.corp.local":   {Username: "helper", Password: "private.corp.local"},
+		"private.store.local":  {Username: "helper", Password: "private.store.local"},
+		"registry.other.local": {},
+	}
+	for url, expected := range tests {
+		credential, exists := registries.credentialFor(context.Background(), url+"/app", url)
+		if credential != expected || exists != (expected.Username != "") {
+			t.Errorf("Expected %v for %s but got %v", expected, url, credential)
+		}
+	}
+}
+
+func TestMissingDockerConfigIsSkipped(t *testing.T) {
+	var registries ImageRegistries
+	if _, err := registries.WithDockerConfig(filepath.Join(os.TempDir(), "missing", "config.json")); err != nil {
+		t.Errorf("Expected no error but got [%v]", err)
+	}
+}
+
+func TestStoppedCredentialHelperIsNotReused(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lcm")
+	defer os.RemoveAll(dir)
+	helper := "#!/bin/sh\nread host\necho '{\"Username\": \"helper\", \"Secret\": \"secret\"}'\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker-credential-stopped"), []byte(helper), 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, found, err := helperCredential(ctx, "stopped", "stopped.corp.local"); found || err == nil {
+		t.Fatalf("Expected the stopped helper to fail but got found %v and [%v]", found, err)
+	}
+	credential, found, err := helperCredential(context.Background(), "stopped", "stopped.corp.local")
+	if err != nil || !found || credential.Username != "helper" {
+		t.Errorf("Expected the helper to run again but got %v, found %v and [%v]", credential, found, err)
+	}
+}
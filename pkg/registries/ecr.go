@@ -0,0 +1,155 @@
+package registries
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// Ecr is the name for Amazon ECR registries
+const Ecr = "Ecr"
+
+// EcrRegistry contains the settings for Amazon ECR, it is used for all the images of *.dkr.ecr.<region>.amazonaws.com
+// Without access keys the credentials come from the AWS environment variables, the IRSA web identity of the service account
+// or the instance profile of the node
+type EcrRegistry struct {
+	AccessKeyID     string `koanf:"accessKeyId"`
+	SecretAccessKey string `koanf:"secretAccessKey"`
+	SessionToken    string `koanf:"sessionToken"`
+}
+
+// ecrHost matches the registry url of an account like 123456789012.dkr.ecr.eu-west-1.amazonaws.com
+var ecrHost = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// ecrRegistry is the registry of a single account and region
+type ecrRegistry struct {
+	config  EcrRegistry
+	url     string
+	account string
+	region  string
+	domain  string
+}
+
+// registryFor returns the ECR registry when the url belongs to ECR
+func (e EcrRegistry) registryFor(url string) (ecrRegistry, bool) {
+	parts := ecrHost.FindStringSubmatch(url)
+	if parts == nil {
+		return ecrRegistry{}, false
+	}
+	return ecrRegistry{config: e, url: url, account: parts[1], region: parts[3], domain: "amazonaws.com" + parts[4]}, true
+}
+
+// GetLatestVersion fetches the latest version of the image from ECR
+func (e ecrRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", e.url).WithField("image", name).Debug("Get latest version for ECR image")
+	tags, err := e.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, false), nil
+}
+
+// GetTags fetches all the tags of the image from ECR with the password of an ECR authorization token
+func (e ecrRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	registry, err := e.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.GetTags(ctx, name)
+}
+
+// TagsForDigest returns the tags of the image in ECR that point to the digest
+func (e ecrRegistry) TagsForDigest(ctx context.Context, name, digest string) ([]string, error) {
+	registry, err := e.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.TagsForDigest(ctx, name, digest)
+}
+
+// registry returns the docker registry of ECR with the password of an ECR authorization token
+func (e ecrRegistry) registry(ctx context.Context) (ImageRegistry, error) {
+	password, err := e.password(ctx)
+	if err != nil {
+		return ImageRegistry{}, fmt.Errorf("Could not login to [%s]: %w", e.url, err)
+	}
+	return ImageRegistry{Name: Ecr, URL: e.url, AuthType: AuthTypeBasic, Username: "AWS", Password: password}, nil
+}
+
+type ecrToken struct {
+	password string
+	expires  time.Time
+}
+
+var (
+	ecrTokensLock sync.Mutex
+	ecrTokens     = map[string]ecrToken{}
+)
+
+type ecrAuthorizationResponse struct {
+	AuthorizationData []struct {
+		AuthorizationToken string  `json:"authorizationToken"`
+		ExpiresAt          float64 `json:"expiresAt"`
+	} `json:"authorizationData"`
+}
+
+// password returns the password of the authorization token of the account, tokens are valid for 12 hours and reused until shortly before they expire
+func (e ecrRegistry) password(ctx context.Context) (string, error) {
+	ecrTokensLock.Lock()
+	defer ecrTokensLock.Unlock()
+	if token, exists := ecrTokens[e.url]; exists && time.Now().Add(5*time.Minute).Before(token.expires) {
+		return token.password, nil
+	}
+
+	static := awsCredentials{AccessKeyID: e.config.AccessKeyID, SecretAccessKey: e.config.SecretAccessKey, SessionToken: e.config.SessionToken}
+	credentials, err := awsCredentialsFor(ctx, static, e.region)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(map[string][]string{"registryIds": {e.account}})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://api.ecr.%s.%s/", e.region, e.domain), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+	signV4(req, body, credentials, e.region, "ecr", time.Now())
+
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code of GetAuthorizationToken was not 200 but [%v]", resp.StatusCode))
+	}
+	var response ecrAuthorizationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", &lcmerrors.ParseError{Err: err}
+	}
+	if len(response.AuthorizationData) == 0 {
+		return "", &lcmerrors.AuthError{Err: fmt.Errorf("No authorization token for account [%s]", e.account)}
+	}
+	data := response.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(data.AuthorizationToken)
+	if err != nil {
+		return "", &lcmerrors.ParseError{Err: err}
+	}
+	// the token is AWS:password
+	password := strings.TrimPrefix(string(decoded), "AWS:")
+	ecrTokens[e.url] = ecrToken{password: password, expires: time.Unix(int64(data.ExpiresAt), 0)}
+	return password, nil
+}
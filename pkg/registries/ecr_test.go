@@ -0,0 +1,30 @@
+package registries
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla of the AWS signature version 4 test suite
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	credentials := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, credentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if authorization := req.Header.Get("Authorization"); authorization != expected {
+		t.Errorf("Expected %s but got %s", expected, authorization)
+	}
+}
+
+func TestEcrRegistryFor(t *testing.T) {
+	registry, exists := EcrRegistry{}.registryFor("123456789012.dkr.ecr.eu-west-1.amazonaws.com")
+	if !exists || registry.account != "123456789012" || registry.region != "eu-west-1" || registry.domain != "amazonaws.com" {
+		t.Errorf("Expected the account and region of the ECR url but got %+v", registry)
+	}
+	if _, exists := (EcrRegistry{}).registryFor("quay.io"); exists {
+		t.Errorf("Expected quay.io not to be an ECR registry")
+	}
+}
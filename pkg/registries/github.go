@@ -0,0 +1,103 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	"github.com/google/go-github/v28/github"
+	"golang.org/x/oauth2"
+)
+
+// GitHubConfig configuration for GitHub
+type GitHubConfig struct {
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+	Token    string `koanf:"token"`
+}
+
+func (g GitHubConfig) isUserNamePasswordSet() bool {
+	return (g.Username != "" && g.Password != "")
+}
+func (g GitHubConfig) isTokenSet() bool {
+	return g.Token != ""
+}
+
+// GetLatestVersion gets the latest version for a tool from GitHub
+func (g GitHubConfig) GetLatestVersion(ctx context.Context, owner, repo, version string) (string, error) {
+	cacheKey := fmt.Sprintf("release/github/%s/%s", owner, repo)
+	var latest string
+	if cache.GetJSON(ctx, "github", cacheKey, &latest) {
+		return latest, nil
+	}
+
+	latest, err := g.getLatestVersion(ctx, owner, repo)
+	if err != nil {
+		return latest, err
+	}
+	cache.SetJSON(ctx, cacheKey, latest)
+	return latest, nil
+}
+
+func (g GitHubConfig) getLatestVersion(ctx context.Context, owner, repo string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, toolTimeoutFrom(ctx))
+	defer cancel()
+	client := g.getClient(ctx)
+
+	release, response, err := client.Repositories.GetLatestRelease(ctx, owner, repo)
+	if err != nil {
+		if _, ok := err.(*github.RateLimitError); ok {
+			return versioning.Failure, &lcmerrors.RateLimitedError{Err: fmt.Errorf("Hit the GitHub rate limit: %w", err)}
+		}
+		// If the repository isn't working with releases, just get the latest tag
+		return getTags(ctx, owner, repo, client)
+	}
+	if response.StatusCode != 200 {
+		return versioning.Failure, lcmerrors.FromStatus(response.StatusCode, fmt.Errorf("Response code was not oke but [%v]", response.StatusCode))
+	}
+	return release.GetTagName(), nil
+}
+
+func getTags(ctx context.Context, owner string, repo string, client *github.Client) (string, error) {
+	opt := &github.ListOptions{PerPage: 10}
+
+	var allTags []string
+	for {
+		tags, resp, err := client.Repositories.ListTags(ctx, owner, repo, opt)
+		if err != nil {
+			return versioning.Notfound, fmt.Errorf("Could not fetch tags: %w", err)
+		}
+		for _, tag := range tags {
+			allTags = append(allTags, *tag.Name)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return versioning.FindHighestVersionInList(allTags, false), nil
+}
+
+func (g GitHubConfig) getClient(ctx context.Context) *github.Client {
+	transport := httpclient.Transport(httpclient.Tool)
+	if g.isUserNamePasswordSet() {
+		auth := github.BasicAuthTransport{
+			Username:  g.Username,
+			Password:  g.Password,
+			Transport: transport,
+		}
+		return github.NewClient(auth.Client())
+	} else if g.isTokenSet() {
+		auth := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: g.Token},
+		)
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+		return github.NewClient(oauth2.NewClient(ctx, auth))
+	}
+	return github.NewClient(&http.Client{Transport: transport})
+}
@@ -0,0 +1,257 @@
+package registries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// Google is the name for Google Container Registry and Artifact Registry
+	Google = "Google"
+
+	googleScope    = "https://www.googleapis.com/auth/cloud-platform"
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	// googleMetadataURL serves the access token of the service account of the node or of the Workload Identity of the pod
+	googleMetadataURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GoogleRegistry contains the settings for Google Container Registry and Artifact Registry, it is used for the images of
+// gcr.io, <region>.gcr.io and <region>-docker.pkg.dev. Without a credentials file the GOOGLE_APPLICATION_CREDENTIALS,
+// the application default credentials of gcloud or the Workload Identity from the metadata server are used
+type GoogleRegistry struct {
+	CredentialsFile string `koanf:"credentialsFile"`
+}
+
+// googleHost matches gcr.io, the regional gcr.io hosts and the docker repositories of Artifact Registry but not the public k8s.gcr.io
+var googleHost = regexp.MustCompile(`^((us|eu|asia|marketplace)\.)?gcr\.io$|^[a-z0-9-]+-docker\.pkg\.dev$`)
+
+// googleRegistry is a single registry host of Google
+type googleRegistry struct {
+	config GoogleRegistry
+	url    string
+	// anonymous is used when no Google credentials are found, like for public images when running locally
+	anonymous ImageRegistry
+}
+
+// registryFor returns the Google registry when the url belongs to Google, the gcr registry is used without credentials
+func (g GoogleRegistry) registryFor(url string, anonymous ImageRegistry) (googleRegistry, bool) {
+	if !googleHost.MatchString(url) {
+		return googleRegistry{}, false
+	}
+	if anonymous.URL == url && anonymous.Username != "" {
+		// the configured username and password of gcr.io, like a _json_key, are used as before
+		return googleRegistry{}, false
+	}
+	if anonymous.URL != url {
+		anonymous = ImageRegistry{Name: Google, URL: url, AuthType: AuthTypeToken}
+	}
+	return googleRegistry{config: g, url: url, anonymous: anonymous}, true
+}
+
+// GetLatestVersion fetches the latest version of the image from Google
+func (g googleRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", g.url).WithField("image", name).Debug("Get latest version for Google image")
+	tags, err := g.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, g.anonymous.AllowAllReleases), nil
+}
+
+// GetTags fetches all the tags of the image with an access token of the Google credentials
+func (g googleRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	registry, err := g.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.GetTags(ctx, name)
+}
+
+// TagsForDigest returns the tags of the image in Google that point to the digest
+func (g googleRegistry) TagsForDigest(ctx context.Context, name, digest string) ([]string, error) {
+	registry, err := g.registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.TagsForDigest(ctx, name, digest)
+}
+
+// registry returns the docker registry with an access token of the Google credentials, or without credentials when there are none
+func (g googleRegistry) registry(ctx context.Context) (ImageRegistry, error) {
+	source, err := googleTokenSourceFor(ctx, g.config.CredentialsFile)
+	if err != nil {
+		return ImageRegistry{}, &lcmerrors.AuthError{Err: fmt.Errorf("Could not load the Google credentials: %w", err)}
+	}
+	if source == nil {
+		return g.anonymous, nil
+	}
+	token, err := source.Token()
+	if err != nil {
+		return ImageRegistry{}, &lcmerrors.AuthError{Err: fmt.Errorf("Could not get a Google access token: %w", err)}
+	}
+	return ImageRegistry{Name: Google, URL: g.url, AuthType: AuthTypeToken, Username: "oauth2accesstoken", Password: token.AccessToken}, nil
+}
+
+var (
+	googleSourcesLock sync.Mutex
+	googleSources     = map[string]oauth2.TokenSource{}
+)
+
+// googleTokenSourceFor returns the token source of the first credentials that are found, the tokens are reused until they expire
+// It returns nil when there are no Google credentials at all
+func googleTokenSourceFor(ctx context.Context, credentialsFile string) (oauth2.TokenSource, error) {
+	googleSourcesLock.Lock()
+	defer googleSourcesLock.Unlock()
+	if source, exists := googleSources[credentialsFile]; exists {
+		return source, nil
+	}
+
+	// the token calls go through the registry client so they use the proxy and the rate limits, the source outlives the call
+	ctx = context.WithValue(context.Background(), oauth2.HTTPClient, clientFrom(ctx))
+	source, err := googleCredentials(ctx, credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		source = oauth2.ReuseTokenSource(nil, source)
+	}
+	googleSources[credentialsFile] = source
+	return source, nil
+}
+
+func googleCredentials(ctx context.Context, credentialsFile string) (oauth2.TokenSource, error) {
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if credentialsFile != "" {
+		return googleCredentialsFromFile(ctx, credentialsFile)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		adc := filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+		if _, err := os.Stat(adc); err == nil {
+			return googleCredentialsFromFile(ctx, adc)
+		}
+	}
+	if onGoogleCloud() {
+		return metadataTokenSource{}, nil
+	}
+	return nil, nil
+}
+
+type googleCredentialsFile struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// googleCredentialsFromFile supports the json keys of service accounts and the application default credentials of gcloud
+func googleCredentialsFromFile(ctx context.Context, path string) (oauth2.TokenSource, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the credentials file [%s]: %w", path, err)
+	}
+	var credentials googleCredentialsFile
+	if err := json.Unmarshal(content, &credentials); err != nil {
+		return nil, fmt.Errorf("Could not parse the credentials file [%s]: %w", path, err)
+	}
+	switch credentials.Type {
+	case "service_account":
+		tokenURL := credentials.TokenURI
+		if tokenURL == "" {
+			tokenURL = googleTokenURL
+		}
+		config := &jwt.Config{
+			Email:        credentials.ClientEmail,
+			PrivateKey:   []byte(credentials.PrivateKey),
+			PrivateKeyID: credentials.PrivateKeyID,
+			Scopes:       []string{googleScope},
+			TokenURL:     tokenURL,
+		}
+		return config.TokenSource(ctx), nil
+	case "authorized_user":
+		config := &oauth2.Config{
+			ClientID:     credentials.ClientID,
+			ClientSecret: credentials.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: googleTokenURL},
+			Scopes:       []string{googleScope},
+		}
+		return config.TokenSource(ctx, &oauth2.Token{RefreshToken: credentials.RefreshToken}), nil
+	}
+	return nil, fmt.Errorf("Credentials type [%s] of [%s] not supported", credentials.Type, path)
+}
+
+// metadataClient never uses a proxy because the metadata server is only reachable from the node itself
+var metadataClient = &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{}}
+
+// onGoogleCloud returns true when the metadata server can be reached, it is only checked once
+func onGoogleCloud() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://metadata.google.internal/computeMetadata/v1/", nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Metadata-Flavor") == "Google"
+}
+
+type metadataTokenSource struct{}
+
+type metadataToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// Token gets the access token of the service account from the metadata server, with Workload Identity this is the service account of the pod
+func (metadataTokenSource) Token() (*oauth2.Token, error) {
+	req, err := http.NewRequest("GET", googleMetadataURL+"?scopes="+googleScope, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Could not reach the metadata server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Response code of the metadata server was not 200 but [%v]", resp.StatusCode)
+	}
+	var token metadataToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, &lcmerrors.ParseError{Err: err}
+	}
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func (g googleRegistry) allowsAllReleases() bool {
+	return g.anonymous.AllowAllReleases
+}
@@ -0,0 +1,22 @@
+package registries
+
+import "testing"
+
+func TestGoogleRegistryFor(t *testing.T) {
+	gcr := ImageRegistry{Name: Gcr, URL: "gcr.io", AuthType: AuthTypeNone}
+	for _, url := range []string{"gcr.io", "eu.gcr.io", "europe-west4-docker.pkg.dev"} {
+		if _, exists := (GoogleRegistry{}).registryFor(url, gcr); !exists {
+			t.Errorf("Expected %s to be a Google registry", url)
+		}
+	}
+	for _, url := range []string{"k8s.gcr.io", "quay.io", "docker.pkg.dev"} {
+		if _, exists := (GoogleRegistry{}).registryFor(url, gcr); exists {
+			t.Errorf("Expected %s not to be a Google registry", url)
+		}
+	}
+
+	gcr.Username, gcr.Password = "_json_key", "{}"
+	if _, exists := (GoogleRegistry{}).registryFor("gcr.io", gcr); exists {
+		t.Errorf("Expected the configured gcr.io credentials to be used instead of the Google credentials")
+	}
+}
@@ -0,0 +1,121 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// Harbor is the name for Harbor registries
+const Harbor = "Harbor"
+
+// HarborRegistry contains the settings of a Harbor registry, the tags are listed with the Harbor API v2.0
+// The username and password are normally of a robot account like robot$lcm with the permission to list artifacts
+type HarborRegistry struct {
+	URL              string   `koanf:"url"`
+	Username         string   `koanf:"username"`
+	Password         string   `koanf:"password"`
+	Projects         []string `koanf:"projects"` // Only the images of these projects are looked up in Harbor, default is all projects
+	AllowAllReleases bool     `koanf:"allowAllReleases"`
+}
+
+// handles returns true when the registry is configured for the url and the project of the image
+func (h HarborRegistry) handles(url, name string) bool {
+	if h.URL != url {
+		return false
+	}
+	if len(h.Projects) == 0 {
+		return true
+	}
+	project, _ := splitHarborName(name)
+	for _, p := range h.Projects {
+		if p == project {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHarborName splits the image name in the project and the repository in the project, the repository can have more parts
+func splitHarborName(name string) (string, string) {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 1 {
+		return "library", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// GetLatestVersion fetches the latest version of the image from Harbor
+func (h HarborRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", h.URL).WithField("image", name).Debug("Get latest version for Harbor image")
+	tags, err := h.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, h.AllowAllReleases), nil
+}
+
+type harborArtifact struct {
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+// GetTags pages through the artifacts of the repository and returns the tags of all of them, untagged artifacts are skipped
+func (h HarborRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	cacheKey := fmt.Sprintf("tags/%s/%s", h.URL, name)
+	var tags []string
+	if cache.GetJSON(ctx, h.URL, cacheKey, &tags) {
+		return tags, nil
+	}
+
+	project, repository := splitHarborName(name)
+	// the slashes of nested repositories have to be encoded twice
+	path := fmt.Sprintf("/api/v2.0/projects/%s/repositories/%s/artifacts?with_tag=true&page_size=100&page=1",
+		url.PathEscape(project), url.PathEscape(url.PathEscape(repository)))
+	tags = []string{}
+	for page := 1; path != ""; page++ {
+		if page > maxTagPages {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: more than [%d] pages", h.URL, maxTagPages)
+		}
+		fullURL, err := pageURL("https://"+h.URL, path)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", h.URL, err)
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if h.Username != "" {
+			req.SetBasicAuth(h.Username, h.Password)
+		}
+		resp, err := clientFrom(ctx).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", h.URL, err)
+		}
+		var artifacts []harborArtifact
+		if err := decodeOK(resp, &artifacts); err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", h.URL, err)
+		}
+		for _, artifact := range artifacts {
+			for _, tag := range artifact.Tags {
+				tags = append(tags, tag.Name)
+			}
+		}
+		path, err = getNextLink(resp)
+		if err == ErrNoMorePages {
+			path = ""
+		}
+	}
+	cache.SetJSON(ctx, cacheKey, tags)
+	return tags, nil
+}
+
+func (h HarborRegistry) allowsAllReleases() bool {
+	return h.AllowAllReleases
+}
@@ -0,0 +1,47 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHarborTagsArePagedPerProject(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v2.0/projects/platform/repositories/team%252Fapp/artifacts" {
+			t.Errorf("Expected the artifacts of the nested repository but got %s", r.URL.EscapedPath())
+		}
+		if username, password, _ := r.BasicAuth(); username != "robot$lcm" || password != "secret" {
+			t.Errorf("Expected the robot account but got %s", username)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("Link", `</api/v2.0/projects/platform/repositories/team%252Fapp/artifacts?with_tag=true&page_size=100&page=2>; rel="next"`)
+			fmt.Fprint(w, `[{"tags": [{"name": "1.0.0"}, {"name": "stable"}]}, {"tags": null}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"tags": [{"name": "1.1.0"}]}]`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	harbor := HarborRegistry{URL: strings.TrimPrefix(server.URL, "https://"), Username: "robot$lcm", Password: "secret", Projects: []string{"platform"}}
+	registries := ImageRegistries{Harbor: []HarborRegistry{harbor}}
+	if _, exists := registries.FindHarborByURL(harbor.URL, "other/app"); exists {
+		t.Errorf("Expected images of other projects not to be looked up in Harbor")
+	}
+	registry, exists := registries.FindHarborByURL(harbor.URL, "platform/team/app")
+	if !exists {
+		t.Fatalf("Expected the image of the project to be looked up in Harbor")
+	}
+	tags, err := registry.GetTags(context.Background(), "platform/team/app")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"1.0.0", "stable", "1.1.0"}) {
+		t.Errorf("Expected the tags of both pages but got %v", tags)
+	}
+}
@@ -1,12 +1,12 @@
 package registries
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 
-	"github.com/arminc/k8s-platform-lcm/internal/versioning"
-	log "github.com/sirupsen/logrus"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
 )
 
 // Charts is data structure coming from hub.helm.sh
@@ -34,29 +34,27 @@ type ChartSearchResult struct {
 	Id string `json:"id"`
 }
 
-func (h HelmRegistries) useHelmHub(chart string) string {
+func (h HelmRegistries) useHelmHub(ctx context.Context, chart string) (string, error) {
 	chartName := h.OverrideChartNames[chart]
 	if chartName == "" {
 		var err error
-		chartName, err = findChart(chart)
+		chartName, err = findChart(ctx, chart)
 		if err != nil {
-			log.WithError(err).WithField("chart", chart).Error("Failed to search chart info")
-			return versioning.Failure
+			return versioning.Failure, fmt.Errorf("Failed to search chart info: %w", err)
 		}
 	}
 
-	versions, err := getChartVersions(chartName)
+	versions, err := getChartVersions(ctx, chartName)
 	if err != nil {
-		log.WithError(err).WithField("chart", chart).Error("Failed to fetch chart info")
-		return versioning.Failure
+		return versioning.Failure, fmt.Errorf("Failed to fetch chart info: %w", err)
 	}
 
-	return versioning.FindHighestVersionInList(versions, false)
+	return versioning.FindHighestVersionInList(versions, false), nil
 }
 
-func findChart(chart string) (string, error) {
+func findChart(ctx context.Context, chart string) (string, error) {
 	url := fmt.Sprintf("https://hub.helm.sh/api/chartsvc/v1/charts/search?q=%s", chart)
-	resp, err := http.Get(url)
+	resp, err := get(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -66,20 +64,20 @@ func findChart(chart string) (string, error) {
 	searchData := SearchResultData{}
 	err = json.NewDecoder(resp.Body).Decode(&searchData)
 	if err != nil {
-		return "", err
+		return "", &lcmerrors.ParseError{Err: err}
 	}
 
 	if len(searchData.Data) == 0 {
-		return "", fmt.Errorf("Could not find the chart")
+		return "", &lcmerrors.NotFoundError{Err: fmt.Errorf("Could not find the chart [%s]", chart)}
 	} else if len(searchData.Data) == 1 {
 		return searchData.Data[0].Id, nil
 	}
 	return "", fmt.Errorf("More than one result %v", searchData)
 }
 
-func getChartVersions(chart string) ([]string, error) {
+func getChartVersions(ctx context.Context, chart string) ([]string, error) {
 	url := fmt.Sprintf("https://hub.helm.sh/api/chartsvc/v1/charts/%s/versions", chart)
-	resp, err := http.Get(url)
+	resp, err := get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +87,7 @@ func getChartVersions(chart string) ([]string, error) {
 	chartsData := Charts{}
 	err = json.NewDecoder(resp.Body).Decode(&chartsData)
 	if err != nil {
-		return nil, err
+		return nil, &lcmerrors.ParseError{Err: err}
 	}
 
 	var versions []string
@@ -0,0 +1,47 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// IndexEntries is the index.yaml of a Helm chart repository
+type IndexEntries struct {
+	Entries map[string][]IndexEntry `yaml:"entries"`
+}
+
+// IndexEntry contains the version information of a chart in the index.yaml
+type IndexEntry struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+func (r HelmOverrideRegistry) getChartVersions(ctx context.Context, chart string) (string, error) {
+	resp, err := get(ctx, r.HelmRegistry.URL)
+	if err != nil {
+		return versioning.Failure, fmt.Errorf("Failed to get chart info from [%s]: %w", r.HelmRegistry.URL, err)
+	}
+	defer resp.Body.Close()
+
+	index := IndexEntries{}
+	err = yaml.NewDecoder(resp.Body).Decode(&index)
+	if err != nil {
+		return versioning.Failure, &lcmerrors.ParseError{Err: fmt.Errorf("Failed to unmarshal chart info from [%s]: %w", r.HelmRegistry.URL, err)}
+	}
+
+	var versions []string
+	entries := index.Entries[chart]
+	if entries == nil {
+		return versioning.Notfound, nil
+	}
+	for _, entry := range entries {
+		versions = append(versions, entry.Version)
+	}
+
+	return versioning.FindHighestVersionInList(versions, r.AllowAllReleases), nil
+}
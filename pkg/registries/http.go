@@ -0,0 +1,103 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// httpClient is used for all the calls to image and chart registries
+var httpClient = &http.Client{Timeout: defaultTimeout, Transport: httpclient.Transport(httpclient.Registry)}
+
+// toolTimeout is used for all the calls to tool registries like GitHub
+var toolTimeout = defaultTimeout
+
+// SetTimeouts sets the default timeouts for the calls to the image and chart registries and to the tool registries
+func SetTimeouts(registry, tool time.Duration) {
+	httpClient.Timeout = registry
+	toolTimeout = tool
+}
+
+// SetHTTPClient replaces the default client of the calls to the image and chart registries.
+// The client of lcm sends the calls through the transport of httpclient with its retries, rate limits and audit log
+func SetHTTPClient(client *http.Client) {
+	httpClient = client
+}
+
+// Options are the settings of the calls to the registries, so programs that embed lcm can use their own settings
+// without changing the defaults of SetTimeouts, SetHTTPClient and the cache package. Unset options use the defaults
+type Options struct {
+	// HTTPClient is used for the calls to the image and chart registries
+	HTTPClient *http.Client
+	// ToolTimeout is used for the calls to the tool registries like GitHub
+	ToolTimeout time.Duration
+	// Cache stores the tags and releases for the CacheTTL
+	Cache    cache.Cache
+	CacheTTL time.Duration
+}
+
+type optionsKey struct{}
+
+// WithOptions returns a context that makes all calls to the registries with the context use the options,
+// ImageRegistries.WithOptions does the same for the calls of the image registries
+func WithOptions(ctx context.Context, options Options) context.Context {
+	if options.Cache != nil {
+		ctx = cache.WithCache(ctx, options.Cache, options.CacheTTL)
+	}
+	return context.WithValue(ctx, optionsKey{}, options)
+}
+
+// WithOptions returns the registries with the options, all calls of the registries use them instead of the defaults
+func (i ImageRegistries) WithOptions(options Options) ImageRegistries {
+	i.options = &options
+	return i
+}
+
+// context returns the context that makes the calls use the options of the registries
+func (i ImageRegistries) context(ctx context.Context) context.Context {
+	if i.options == nil {
+		return ctx
+	}
+	return WithOptions(ctx, *i.options)
+}
+
+// clientFrom returns the http client of the options of the context, the default client without one
+func clientFrom(ctx context.Context) *http.Client {
+	if options, ok := ctx.Value(optionsKey{}).(Options); ok && options.HTTPClient != nil {
+		return options.HTTPClient
+	}
+	return httpClient
+}
+
+// toolTimeoutFrom returns the tool timeout of the options of the context, the default timeout without one
+func toolTimeoutFrom(ctx context.Context) time.Duration {
+	if options, ok := ctx.Value(optionsKey{}).(Options); ok && options.ToolTimeout > 0 {
+		return options.ToolTimeout
+	}
+	return toolTimeout
+}
+
+// get fetches the url with the registry http client, the request is cancelled when the context is done
+// and a response code other than 200 is returned as an error
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,10 @@
+package registries
+
+import "net/http/httptest"
+
+// useTestServer sends the calls of httpClient to the TLS test server, which trusts its certificate, until the returned func is called
+func useTestServer(server *httptest.Server) func() {
+	client := httpClient
+	httpClient = server.Client()
+	return func() { httpClient = client }
+}
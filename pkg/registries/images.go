@@ -0,0 +1,454 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	log "github.com/sirupsen/logrus"
+)
+
+var logger = log.WithField("component", "registries")
+
+// ImageRegistries contains all the information regarding image registries
+type ImageRegistries struct {
+	DockerHub          ImageRegistry                    `koanf:"dockerHub"`
+	Quay               ImageRegistry                    `koanf:"quay"`
+	Gcr                ImageRegistry                    `koanf:"gcr"`
+	GcrK8s             ImageRegistry                    `koanf:"gcrK8s"`
+	Zalando            ImageRegistry                    `koanf:"zalando"`
+	Ghcr               ImageRegistry                    `koanf:"ghcr"`
+	Gitlab             ImageRegistry                    `koanf:"gitlab"`
+	Ecr                EcrRegistry                      `koanf:"ecr"`
+	Google             GoogleRegistry                   `koanf:"google"`
+	Acr                AcrRegistry                      `koanf:"acr"`
+	Harbor             []HarborRegistry                 `koanf:"harbor"`
+	QuayAPI            QuayRegistry                     `koanf:"quayApi"`
+	Nexus              []NexusRegistry                  `koanf:"nexus"`
+	Artifactory        []ArtifactoryRegistry            `koanf:"artifactory"`
+	OverrideImages     []OverrideImage                  `koanf:"override"`
+	OverrideRegistries []OverrideRegistry               `koanf:"overrideRegistries"`
+	OverrideImageNames map[string]string                `koanf:"overrideImageNames"`
+	Providers          []ExecRegistry                   `koanf:"providers"`
+	CredentialHelpers  []CredentialHelper               `koanf:"credentialHelpers"`
+	Mirrors            []Mirror                         `koanf:"mirrors"`
+	VersionRules       []VersionRule                    `koanf:"versionRules"`
+	Prereleases        string                           `koanf:"prereleases"` // Can be include or exclude, default is exclude
+	PullSecrets        bool                             `koanf:"pullSecrets"` // Use the imagePullSecrets of the pods and the default service accounts, default is false
+	TagAge             bool                             `koanf:"tagAge"`      // Fetch when the running and the latest version were built, default is false
+	MutableTags        []string                         `koanf:"mutableTags"` // Tags that can point to a new build, names or regular expressions. Default is latest and stable
+	credentials        map[string]Credential            // Added with WithCredentials, like the docker config of the developer
+	repositories       map[string]map[string]Credential // Added with WithRepositoryCredentials, the imagePullSecrets of the pods per repository
+	options            *Options                         // Added with WithOptions, the defaults are used without them
+	credentialHelpers  map[string]string                // The credential helpers of the hosts from the docker config
+	credentialStore    string                           // The credential helper of all other hosts from the docker config
+}
+
+// OverrideImage contains information about which registry to use, it overrides the URL used in kubernetes
+type OverrideImage struct {
+	Images           []string      `koanf:"images"`
+	Registry         ImageRegistry `koanf:"registry"`
+	RegistryName     string        `koanf:"registryName"`
+	AllowAllReleases bool          `koanf:"allowAllReleases"`
+}
+
+// OverrideRegistry contains information about which registry to use, it overrides the URL used in kubernetes
+type OverrideRegistry struct {
+	Urls             []string      `koanf:"urls"`
+	Registry         ImageRegistry `koanf:"registry"`
+	RegistryName     string        `koanf:"registryName"`
+	AllowAllReleases bool          `koanf:"allowAllReleases"`
+}
+
+// Mirror maps the images of a mirror or pull-through cache to the upstream registry they come from, so the versions are looked up upstream
+// The url and the upstream can have a path, like harbor.corp.local/dockerhub for a proxy cache project of Harbor
+type Mirror struct {
+	URL      string `koanf:"url"`
+	Upstream string `koanf:"upstream"`
+}
+
+// DefaultRegistries sets default values for registries
+func (i *ImageRegistries) DefaultRegistries() {
+	i.DockerHub.Name = DockerHub
+	i.DockerHub.URL = "registry.hub.docker.com"
+	i.DockerHub.AuthType = AuthTypeToken
+	// anonymous pulls of Docker Hub have a low rate limit, a personal access token raises it
+	if i.DockerHub.Password == "" && os.Getenv("DOCKERHUB_TOKEN") != "" {
+		i.DockerHub.Username = os.Getenv("DOCKERHUB_USERNAME")
+		i.DockerHub.Password = os.Getenv("DOCKERHUB_TOKEN")
+	}
+
+	i.Quay.Name = Quay
+	i.Quay.URL = "quay.io"
+	if i.Quay.AuthType == "" {
+		i.Quay.AuthType = AuthTypeNone
+	}
+
+	i.Gcr.Name = Gcr
+	i.Gcr.URL = "gcr.io"
+	if i.Gcr.AuthType == "" {
+		i.Gcr.AuthType = AuthTypeNone
+	}
+
+	i.GcrK8s.Name = GcrK8s
+	i.GcrK8s.URL = "k8s.gcr.io"
+	if i.GcrK8s.AuthType == "" {
+		i.GcrK8s.AuthType = AuthTypeNone
+	}
+
+	i.Zalando.Name = Zalando
+	i.Zalando.URL = "registry.opensource.zalan.do"
+	if i.Zalando.AuthType == "" {
+		i.Zalando.AuthType = AuthTypeNone
+	}
+
+	// ghcr.io hands out anonymous tokens for public packages, a personal access token or GITHUB_TOKEN gives access to private packages
+	i.Ghcr.Name = Ghcr
+	i.Ghcr.URL = "ghcr.io"
+	i.Ghcr.AuthType = AuthTypeToken
+	if i.Ghcr.Password == "" {
+		i.Ghcr.Password = os.Getenv("GITHUB_TOKEN")
+	}
+	if i.Ghcr.Password != "" && i.Ghcr.Username == "" {
+		// GitHub only checks the token, the username can be anything
+		i.Ghcr.Username = "lcm"
+	}
+
+	// registry.gitlab.com hands out tokens for a deploy token or a personal access token with read_registry,
+	// the url can be changed to the registry of a self-hosted GitLab like gitlab.corp:5050
+	i.Gitlab.Name = Gitlab
+	if i.Gitlab.URL == "" {
+		i.Gitlab.URL = "registry.gitlab.com"
+	}
+	i.Gitlab.AuthType = AuthTypeToken
+	// in a GitLab CI job the registry credentials of the job are used for the registry of its GitLab, also when it is self-hosted
+	if ciRegistry := os.Getenv("CI_REGISTRY"); ciRegistry != "" && os.Getenv("CI_REGISTRY_PASSWORD") != "" {
+		job := Credential{Username: os.Getenv("CI_REGISTRY_USER"), Password: os.Getenv("CI_REGISTRY_PASSWORD")}
+		if i.Gitlab.Password == "" && ciRegistry == i.Gitlab.URL {
+			i.Gitlab.Username = job.Username
+			i.Gitlab.Password = job.Password
+		}
+		*i = i.WithCredentials(map[string]Credential{ciRegistry: job})
+	}
+}
+
+// GetLatestVersionForImage gets the latest version for image
+func (i ImageRegistries) GetLatestVersionForImage(ctx context.Context, name, url string) (string, error) {
+	ctx = i.context(ctx)
+	version, _, err := i.getLatestVersion(ctx, name, url, "", nil)
+	return version, err
+}
+
+// GetLatestVersionForTag gets the latest version for image with the same flavor as the tag, like 1.20.1-alpine for 1.19.2-alpine
+func (i ImageRegistries) GetLatestVersionForTag(ctx context.Context, name, url, tag string) (string, error) {
+	version, _, err := i.GetLatestVersionForTags(ctx, name, url, []string{tag})
+	return version, err
+}
+
+// GetLatestVersionForTags gets the latest version for image with the same flavor as the running tags and how many newer releases
+// there are for every tag, the tags need to have the same flavor
+func (i ImageRegistries) GetLatestVersionForTags(ctx context.Context, name, url string, tags []string) (string, []int, error) {
+	ctx = i.context(ctx)
+	variant := ""
+	if len(tags) > 0 {
+		_, variant = versioning.SplitVariant(tags[0])
+	}
+	return i.getLatestVersion(ctx, name, url, variant, tags)
+}
+
+func (i ImageRegistries) getLatestVersion(ctx context.Context, name, url, variant string, running []string) (string, []int, error) {
+	behind := make([]int, len(running))
+	name, url = i.upstreamOf(name, url)
+	registry, err := i.determinRegistry(ctx, name, url)
+	if err != nil {
+		return versioning.Failure, behind, err
+	}
+	rule, exists, err := i.findVersionRule(name)
+	if err != nil {
+		return versioning.Failure, behind, err
+	}
+	if !exists {
+		rule = VersionRule{AllowAllReleases: allowsAllReleases(registry)}
+	}
+	tags, err := registry.GetTags(ctx, i.findImageNameOverride(name))
+	if err != nil {
+		return versioning.Notfound, behind, err
+	}
+	if rule.VersionRegex != "" {
+		return rule.latestExtractedVersion(tags, running, i.Prereleases)
+	}
+	candidates, err := rule.candidates(tags, i.Prereleases)
+	if err != nil {
+		return versioning.Failure, behind, err
+	}
+	for index, tag := range running {
+		behind[index] = rule.behind(candidates, tag)
+	}
+	return rule.latestVersion(candidates, variant), behind, nil
+}
+
+// ComparableVersion returns the version in the tag with the version regex of the image, it is the tag itself
+// when the image has no version regex and empty when the tag doesn't match the version regex
+func (i ImageRegistries) ComparableVersion(name, url, tag string) string {
+	name, _ = i.upstreamOf(name, url)
+	rule, exists, err := i.findVersionRule(name)
+	if err != nil || !exists || rule.VersionRegex == "" {
+		return tag
+	}
+	versionRegex, err := regexp.Compile(rule.VersionRegex)
+	if err != nil {
+		return ""
+	}
+	return rule.extractVersion(versionRegex, tag)
+}
+
+// GetTagsForImage gets all the tags for image
+func (i ImageRegistries) GetTagsForImage(ctx context.Context, name, url string) ([]string, error) {
+	ctx = i.context(ctx)
+	name, url = i.upstreamOf(name, url)
+	registry, err := i.determinRegistry(ctx, name, url)
+	if err != nil {
+		return nil, err
+	}
+	return registry.GetTags(ctx, i.findImageNameOverride(name))
+}
+
+func (i ImageRegistries) determinRegistry(ctx context.Context, name, url string) (RegistryProvider, error) {
+	repository := url + "/" + name
+	registry, exists, err := i.FindRegistryByOverrideByImage(name)
+	if err != nil || exists {
+		return i.withCredential(ctx, repository, registry), err
+	}
+
+	registry, exists = i.FindRegistryByOverrideByURL(url)
+	if exists {
+		return i.withCredential(ctx, repository, registry), nil
+	}
+
+	if provider, exists := i.FindProviderByURL(url); exists {
+		return provider, nil
+	}
+
+	if harbor, exists := i.FindHarborByURL(url, name); exists {
+		return harbor, nil
+	}
+
+	if nexus, exists := i.FindNexusByURL(url); exists {
+		return nexus.registry(), nil
+	}
+
+	if artifactory, exists := i.FindArtifactoryByURL(url); exists {
+		return artifactory, nil
+	}
+
+	if i.QuayAPI.handles(url) {
+		return quayRegistry{config: i.QuayAPI, url: url}, nil
+	}
+
+	registry, exists = i.GetDefaultRegistry()
+	if exists {
+		return i.withCredential(ctx, repository, registry), nil
+	}
+
+	if helper, exists := i.FindCredentialHelperByURL(url); exists {
+		return helperRegistry{config: helper, url: url}, nil
+	}
+
+	if ecr, exists := i.Ecr.registryFor(url); exists {
+		return ecr, nil
+	}
+
+	if google, exists := i.Google.registryFor(url, i.Gcr); exists {
+		return google, nil
+	}
+
+	if acr, exists := i.Acr.registryFor(url); exists {
+		return acr, nil
+	}
+
+	registry = i.FindRegistryByURL(url)
+	if registry.Name == DockerHub && credentialHost(url) != "docker.io" {
+		// the images of other hosts are only looked up on their own host when there are credentials for it
+		if _, exists := i.credentialFor(ctx, repository, url); exists {
+			registry = ImageRegistry{Name: url, URL: url, AuthType: AuthTypeToken}
+		}
+	}
+	return i.withCredential(ctx, repository, registry), nil
+}
+
+// upstreamOf returns the name and url of the image in the upstream registry when the image comes from a mirror
+func (i ImageRegistries) upstreamOf(name, url string) (string, string) {
+	image := url + "/" + name
+	for _, mirror := range i.Mirrors {
+		prefix := strings.TrimSuffix(mirror.URL, "/") + "/"
+		if !strings.HasPrefix(image, prefix) {
+			continue
+		}
+		upstream := strings.SplitN(strings.TrimSuffix(mirror.Upstream, "/"), "/", 2)
+		name = strings.TrimPrefix(image, prefix)
+		if len(upstream) == 2 {
+			name = upstream[1] + "/" + name
+		}
+		logger.WithField("image", image).WithField("upstream", upstream[0]+"/"+name).Debug("Image comes from a mirror")
+		return name, upstream[0]
+	}
+	return name, url
+}
+
+func (i ImageRegistries) findImageNameOverride(name string) string {
+	overrideName := i.OverrideImageNames[name]
+	if overrideName == "" {
+		return name
+	}
+	return overrideName
+}
+
+// FindRegistryByOverrideByImage finds if the image has a registry override
+func (i ImageRegistries) FindRegistryByOverrideByImage(name string) (ImageRegistry, bool, error) {
+	for _, overrideImage := range i.OverrideImages {
+		for _, image := range overrideImage.Images {
+			match, err := regexp.MatchString(image, name)
+			if err != nil {
+				return ImageRegistry{}, false, fmt.Errorf("Image regexp [%s] not valid: %w", image, err)
+			}
+			if match {
+				if overrideImage.RegistryName != "" {
+					registry := i.FindRegistryByName(overrideImage.RegistryName)
+					registry.AllowAllReleases = overrideImage.AllowAllReleases
+					return registry, true, nil
+				}
+				registry := overrideImage.Registry
+				registry.AllowAllReleases = overrideImage.AllowAllReleases
+				return registry, true, nil
+			}
+		}
+	}
+	return ImageRegistry{}, false, nil
+}
+
+// FindRegistryByOverrideByURL finds if the URL has a registry override
+func (i ImageRegistries) FindRegistryByOverrideByURL(url string) (ImageRegistry, bool) {
+	for _, overrideRegistry := range i.OverrideRegistries {
+		for _, regURL := range overrideRegistry.Urls {
+			if regURL == url {
+				if overrideRegistry.RegistryName != "" {
+					return i.FindRegistryByName(overrideRegistry.RegistryName), true
+				}
+				return overrideRegistry.Registry, true
+			}
+		}
+	}
+	return ImageRegistry{}, false
+}
+
+// FindProviderByURL finds the exec registry provider that is configured for the URL
+func (i ImageRegistries) FindProviderByURL(url string) (ExecRegistry, bool) {
+	for _, provider := range i.Providers {
+		if provider.handles(url) {
+			return provider, true
+		}
+	}
+	return ExecRegistry{}, false
+}
+
+// FindHarborByURL finds the Harbor registry that is configured for the URL and the project of the image
+func (i ImageRegistries) FindHarborByURL(url, name string) (HarborRegistry, bool) {
+	for _, harbor := range i.Harbor {
+		if harbor.handles(url, name) {
+			return harbor, true
+		}
+	}
+	return HarborRegistry{}, false
+}
+
+// FindNexusByURL finds the Nexus repository that is configured for the URL
+func (i ImageRegistries) FindNexusByURL(url string) (NexusRegistry, bool) {
+	for _, nexus := range i.Nexus {
+		if nexus.handles(url) {
+			return nexus, true
+		}
+	}
+	return NexusRegistry{}, false
+}
+
+// FindArtifactoryByURL finds the Artifactory repository that is configured for the URL
+func (i ImageRegistries) FindArtifactoryByURL(url string) (ArtifactoryRegistry, bool) {
+	for _, artifactory := range i.Artifactory {
+		if artifactory.handles(url) {
+			return artifactory, true
+		}
+	}
+	return ArtifactoryRegistry{}, false
+}
+
+// FindCredentialHelperByURL finds the credential helper that is configured for the URL
+func (i ImageRegistries) FindCredentialHelperByURL(url string) (CredentialHelper, bool) {
+	for _, helper := range i.CredentialHelpers {
+		if helper.handles(url) {
+			return helper, true
+		}
+	}
+	return CredentialHelper{}, false
+}
+
+// GetDefaultRegistry finds the default configured registry
+func (i ImageRegistries) GetDefaultRegistry() (ImageRegistry, bool) {
+	if i.Quay.Default {
+		return i.Quay, true
+	} else if i.Gcr.Default {
+		return i.Gcr, true
+	} else if i.GcrK8s.Default {
+		return i.GcrK8s, true
+	} else if i.Zalando.Default {
+		return i.Zalando, true
+	} else if i.Ghcr.Default {
+		return i.Ghcr, true
+	} else if i.Gitlab.Default {
+		return i.Gitlab, true
+	} else if i.DockerHub.Default {
+		return i.DockerHub, true
+	}
+	return ImageRegistry{}, false
+}
+
+// FindRegistryByURL finds the configured registry by URL, a registry on a non-standard port like registry.corp.local:5000
+// is used without auth on that host and port, default is DockerHub
+func (i ImageRegistries) FindRegistryByURL(url string) ImageRegistry {
+	if i.Quay.URL == url {
+		return i.Quay
+	} else if i.Gcr.URL == url {
+		return i.Gcr
+	} else if i.GcrK8s.URL == url {
+		return i.GcrK8s
+	} else if i.Zalando.URL == url {
+		return i.Zalando
+	} else if i.Ghcr.URL == url {
+		return i.Ghcr
+	} else if i.Gitlab.URL == url {
+		return i.Gitlab
+	} else if strings.Contains(url, ":") {
+		return ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone}
+	}
+	return i.DockerHub
+}
+
+// FindRegistryByName finds the configured registry by name, default is DockerHub
+func (i ImageRegistries) FindRegistryByName(name string) ImageRegistry {
+	if i.Quay.Name == name {
+		return i.Quay
+	} else if i.Gcr.Name == name {
+		return i.Gcr
+	} else if i.GcrK8s.Name == name {
+		return i.GcrK8s
+	} else if i.Zalando.Name == name {
+		return i.Zalando
+	} else if i.Ghcr.Name == name {
+		return i.Ghcr
+	} else if i.Gitlab.Name == name {
+		return i.Gitlab
+	}
+	return i.DockerHub
+}
@@ -0,0 +1,169 @@
+package registries
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+)
+
+func TestGhcrUsesGitHubToken(t *testing.T) {
+	os.Setenv("GITHUB_TOKEN", "ghp_test")
+	defer os.Unsetenv("GITHUB_TOKEN")
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	registry := registries.FindRegistryByURL("ghcr.io")
+	if registry.Name != Ghcr || registry.AuthType != AuthTypeToken || registry.Password != "ghp_test" || registry.Username == "" {
+		t.Errorf("Expected ghcr.io with a token from GITHUB_TOKEN but got %+v", registry)
+	}
+}
+
+func TestGitlabUsesCIRegistryCredentials(t *testing.T) {
+	os.Setenv("CI_REGISTRY", "registry.gitlab.com")
+	os.Setenv("CI_REGISTRY_USER", "gitlab-ci-token")
+	os.Setenv("CI_REGISTRY_PASSWORD", "job-token")
+	defer os.Unsetenv("CI_REGISTRY")
+	defer os.Unsetenv("CI_REGISTRY_USER")
+	defer os.Unsetenv("CI_REGISTRY_PASSWORD")
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	registry := registries.FindRegistryByURL("registry.gitlab.com")
+	if registry.Name != Gitlab || registry.Username != "gitlab-ci-token" || registry.Password != "job-token" {
+		t.Errorf("Expected registry.gitlab.com with the credentials of the CI job but got %+v", registry)
+	}
+}
+
+func TestSelfHostedGitlabUsesCIRegistryCredentials(t *testing.T) {
+	os.Setenv("CI_REGISTRY", "gitlab.corp:5050")
+	os.Setenv("CI_REGISTRY_USER", "gitlab-ci-token")
+	os.Setenv("CI_REGISTRY_PASSWORD", "job-token")
+	defer os.Unsetenv("CI_REGISTRY")
+	defer os.Unsetenv("CI_REGISTRY_USER")
+	defer os.Unsetenv("CI_REGISTRY_PASSWORD")
+
+	registries := ImageRegistries{Gitlab: ImageRegistry{URL: "gitlab.corp:5050"}}
+	registries.DefaultRegistries()
+	registry := registries.FindRegistryByURL("gitlab.corp:5050")
+	if registry.Name != Gitlab || registry.Username != "gitlab-ci-token" || registry.Password != "job-token" {
+		t.Errorf("Expected the configured gitlab.corp:5050 with the credentials of the CI job but got %+v", registry)
+	}
+}
+
+func TestCIRegistryCredentialsAreUsedForItsHost(t *testing.T) {
+	var authorization string
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/jwt/auth" {
+			authorization = r.Header.Get("Authorization")
+			fmt.Fprint(w, `{"token": "job", "expires_in": 300}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer job" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/jwt/auth",service="container_registry",scope="repository:group/app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"name": "group/app", "tags": ["1.2.3", "1.3.0"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+	url := strings.TrimPrefix(server.URL, "https://")
+	os.Setenv("CI_REGISTRY", url)
+	os.Setenv("CI_REGISTRY_USER", "gitlab-ci-token")
+	os.Setenv("CI_REGISTRY_PASSWORD", "job-token")
+	defer os.Unsetenv("CI_REGISTRY")
+	defer os.Unsetenv("CI_REGISTRY_USER")
+	defer os.Unsetenv("CI_REGISTRY_PASSWORD")
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	if registries.Gitlab.URL != "registry.gitlab.com" || registries.Gitlab.Password != "" {
+		t.Errorf("Expected registry.gitlab.com without the credentials of another host but got %+v", registries.Gitlab)
+	}
+	version, err := registries.GetLatestVersionForImage(context.Background(), "group/app", url)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte("gitlab-ci-token:job-token"))
+	if version != "1.3.0" || authorization != expected {
+		t.Errorf("Expected version 1.3.0 with the credentials of the CI job but got %s with [%s]", version, authorization)
+	}
+}
+
+func TestRegistryWithPortIsLookedUpOnThatPort(t *testing.T) {
+	var path string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `{"name": "team/app", "tags": ["1.2.3", "1.3.0"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	url := strings.TrimPrefix(server.URL, "https://")
+	version, err := registries.GetLatestVersionForImage(context.Background(), "team/app", url)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if version != "1.3.0" || path != "/v2/team/app/tags/list" {
+		t.Errorf("Expected version 1.3.0 from the registry on the port but got %s from %s", version, path)
+	}
+}
+
+func TestMirrorImagesAreLookedUpUpstream(t *testing.T) {
+	registries := ImageRegistries{Mirrors: []Mirror{
+		{URL: "harbor.corp.local/dockerhub", Upstream: "docker.io"},
+		{URL: "mirror.corp.local", Upstream: "docker.io/library"},
+		{URL: "quay-cache.corp.local/", Upstream: "quay.io"},
+	}}
+	tests := map[string][2]string{
+		"harbor.corp.local/dockerhub/library/nginx": {"library/nginx", "docker.io"},
+		"mirror.corp.local/redis":                   {"library/redis", "docker.io"},
+		"quay-cache.corp.local/prometheus/node":     {"prometheus/node", "quay.io"},
+		"harbor.corp.local/platform/app":            {"platform/app", "harbor.corp.local"},
+	}
+	for image, expected := range tests {
+		parts := strings.SplitN(image, "/", 2)
+		name, url := registries.upstreamOf(parts[1], parts[0])
+		if name != expected[0] || url != expected[1] {
+			t.Errorf("Expected %s to be %s on %s but got %s on %s", image, expected[0], expected[1], name, url)
+		}
+	}
+}
+
+func TestRegistriesUseTheHTTPClientAndCacheOfTheirOptions(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"name": "team/app", "tags": ["1.2.3", "1.3.0"]}`)
+	}))
+	defer server.Close()
+
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	// the default client doesn't trust the certificate of the test server
+	embedded := registries.WithOptions(Options{HTTPClient: server.Client(), Cache: cache.NewMemory(), CacheTTL: time.Hour})
+	url := strings.TrimPrefix(server.URL, "https://")
+	for range []int{1, 2} {
+		version, err := embedded.GetLatestVersionForImage(context.Background(), "team/app", url)
+		if err != nil || version != "1.3.0" {
+			t.Fatalf("Expected version 1.3.0 but got %s and [%v]", version, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the tags from the cache of the options the second time but got %d calls", calls)
+	}
+	if _, err := registries.GetLatestVersionForImage(context.Background(), "team/app", url); err == nil {
+		t.Error("Expected the registries without options to use the default client")
+	}
+}
@@ -0,0 +1,65 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// DefaultKubernetesReleasesURL publishes the latest stable release of Kubernetes in stable.txt and the latest patch of every minor in stable-1.x.txt
+const DefaultKubernetesReleasesURL = "https://dl.k8s.io/release"
+
+// KubernetesReleases contains where the upstream releases of Kubernetes are looked up to check the version of the control plane
+type KubernetesReleases struct {
+	Enabled bool   `koanf:"enabled"`
+	URL     string `koanf:"url"`
+	// SupportedMinors is the number of newest minor versions that still get patches upstream
+	SupportedMinors int `koanf:"supportedMinors"`
+}
+
+// GetLatestRelease returns the latest stable release of Kubernetes like v1.31.2
+func (k KubernetesReleases) GetLatestRelease(ctx context.Context) (string, error) {
+	return k.getStable(ctx, "stable")
+}
+
+// GetLatestPatch returns the latest patch release of the minor version like 1.29
+func (k KubernetesReleases) GetLatestPatch(ctx context.Context, minor string) (string, error) {
+	return k.getStable(ctx, "stable-"+minor)
+}
+
+func (k KubernetesReleases) getStable(ctx context.Context, name string) (string, error) {
+	cacheKey := "release/kubernetes/" + name
+	var release string
+	if cache.GetJSON(ctx, "kubernetes", cacheKey, &release) {
+		return release, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, toolTimeoutFrom(ctx))
+	defer cancel()
+	url := strings.TrimSuffix(k.URL, "/") + "/" + name + ".txt"
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := (&http.Client{Transport: httpclient.Transport(httpclient.Tool)}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Could not fetch the Kubernetes release [%s]: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Could not fetch the Kubernetes release [%s], response code was not 200 but [%v]", name, resp.StatusCode))
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("Could not read the Kubernetes release [%s]: %w", name, err)
+	}
+	release = strings.TrimSpace(string(body))
+	cache.SetJSON(ctx, cacheKey, release)
+	return release, nil
+}
@@ -0,0 +1,34 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKubernetesReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release/stable.txt":
+			fmt.Fprintln(w, "v1.31.2")
+		case "/release/stable-1.29.txt":
+			fmt.Fprintln(w, "v1.29.10")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	releases := KubernetesReleases{URL: server.URL + "/release/"}
+	if latest, err := releases.GetLatestRelease(context.Background()); err != nil || latest != "v1.31.2" {
+		t.Errorf("Expected v1.31.2 but got %s and %v", latest, err)
+	}
+	if patch, err := releases.GetLatestPatch(context.Background(), "1.29"); err != nil || patch != "v1.29.10" {
+		t.Errorf("Expected v1.29.10 but got %s and %v", patch, err)
+	}
+	if _, err := releases.GetLatestPatch(context.Background(), "1.0"); err == nil {
+		t.Errorf("Expected an error for a minor without releases")
+	}
+}
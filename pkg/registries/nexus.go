@@ -0,0 +1,44 @@
+package registries
+
+import "strings"
+
+// NexusRegistry contains the settings of a docker repository in Sonatype Nexus, hosted and group repositories work the same
+// Nexus serves a repository either on its own connector port like nexus.corp.local:8082 or on the path /repository/<name> of Nexus itself
+type NexusRegistry struct {
+	URL              string   `koanf:"url"`        // Host of Nexus with the port of the docker connector when the repository has one
+	Repository       string   `koanf:"repository"` // Name of the repository, only needed when the repository has no connector port
+	Urls             []string `koanf:"urls"`       // Image hosts that are looked up in this repository, default is the url
+	Username         string   `koanf:"username"`
+	Password         string   `koanf:"password"`
+	HTTP             bool     `koanf:"http"` // The connector only serves plain http
+	AllowAllReleases bool     `koanf:"allowAllReleases"`
+}
+
+// handles returns true when the images of the url are looked up in the repository
+func (n NexusRegistry) handles(url string) bool {
+	if len(n.Urls) == 0 {
+		return n.URL == url
+	}
+	for _, u := range n.Urls {
+		if u == url {
+			return true
+		}
+	}
+	return false
+}
+
+// registry returns the docker registry of the repository, Nexus asks for basic auth so the credentials are always sent
+func (n NexusRegistry) registry() ImageRegistry {
+	url := strings.TrimSuffix(n.URL, "/")
+	if n.Repository != "" {
+		url += "/repository/" + n.Repository
+	}
+	if n.HTTP {
+		url = "http://" + url
+	}
+	authType := AuthTypeNone
+	if n.Username != "" {
+		authType = AuthTypeBasic
+	}
+	return ImageRegistry{Name: Nexus, URL: url, AuthType: authType, Username: n.Username, Password: n.Password, AllowAllReleases: n.AllowAllReleases}
+}
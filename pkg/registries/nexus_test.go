@@ -0,0 +1,39 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNexusRepositoryOnPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repository/docker-group/v2/team/app/tags/list" {
+			t.Errorf("Expected the tags of the repository path but got %s", r.URL.Path)
+		}
+		if username, password, _ := r.BasicAuth(); username != "lcm" || password != "secret" {
+			t.Errorf("Expected basic auth but got %s", username)
+		}
+		fmt.Fprint(w, `{"tags": ["1.0.0", "1.1.0"]}`)
+	}))
+	defer server.Close()
+
+	nexus := NexusRegistry{URL: strings.TrimPrefix(server.URL, "http://"), Repository: "docker-group", Urls: []string{"nexus.corp.local:8082"},
+		Username: "lcm", Password: "secret", HTTP: true}
+	registries := ImageRegistries{Nexus: []NexusRegistry{nexus}}
+	found, exists := registries.FindNexusByURL("nexus.corp.local:8082")
+	if !exists {
+		t.Fatalf("Expected the image host to be looked up in Nexus")
+	}
+	tags, err := found.registry().GetTags(context.Background(), "team/app")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"1.0.0", "1.1.0"}) {
+		t.Errorf("Expected the tags of the repository but got %v", tags)
+	}
+}
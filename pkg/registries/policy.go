@@ -0,0 +1,58 @@
+package registries
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RegistryPolicy contains the approved registries of the images, images of other registries are reported as policy violations
+// The registries are hosts, patterns like *.dkr.ecr.*.amazonaws.com or a host with a path like ghcr.io/my-org for the images under the path.
+// Images of Docker Hub are on docker.io
+type RegistryPolicy struct {
+	Allowed []string `koanf:"allowed"` // Without allowed registries all registries are allowed that are not denied
+	Denied  []string `koanf:"denied"`  // Takes precedence over the allowed registries
+}
+
+// Enabled returns true when there are allowed or denied registries
+func (p RegistryPolicy) Enabled() bool {
+	return len(p.Allowed) > 0 || len(p.Denied) > 0
+}
+
+// Validate checks that the patterns of the registries are valid
+func (p RegistryPolicy) Validate() error {
+	for _, registry := range append(append([]string{}, p.Allowed...), p.Denied...) {
+		if _, err := path.Match(registry, ""); err != nil {
+			return fmt.Errorf("Registry policy pattern [%s] not valid: %w", registry, err)
+		}
+	}
+	return nil
+}
+
+// Violation returns why the image of the registry is not allowed, it is empty when the image is allowed
+func (p RegistryPolicy) Violation(name, url string) string {
+	url = credentialHost(url)
+	for _, registry := range p.Denied {
+		if matchesRegistry(registry, name, url) {
+			return fmt.Sprintf("Registry [%s] is denied by [%s]", url, registry)
+		}
+	}
+	if len(p.Allowed) == 0 {
+		return ""
+	}
+	for _, registry := range p.Allowed {
+		if matchesRegistry(registry, name, url) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("Registry [%s] is not one of the allowed registries", url)
+}
+
+// matchesRegistry returns true when the registry is the host of the image or when the image is under the path of the registry
+func matchesRegistry(registry, name, url string) bool {
+	registry = strings.TrimSuffix(registry, "/")
+	if match, _ := path.Match(registry, url); match {
+		return true
+	}
+	return strings.HasPrefix(url+"/"+name, registry+"/")
+}
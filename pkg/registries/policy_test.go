@@ -0,0 +1,30 @@
+package registries
+
+import "testing"
+
+func TestRegistryPolicy(t *testing.T) {
+	policy := RegistryPolicy{
+		Allowed: []string{"registry.corp.local", "*.dkr.ecr.*.amazonaws.com", "ghcr.io/my-org", "docker.io"},
+		Denied:  []string{"docker.io/bitnami"},
+	}
+	tests := map[string][3]string{
+		"registry.corp.local/team/app": {"team/app", "registry.corp.local", ""},
+		"ecr":                          {"app", "123456789012.dkr.ecr.eu-west-1.amazonaws.com", ""},
+		"ghcr.io/my-org/app":           {"my-org/app", "ghcr.io", ""},
+		"ghcr.io/other-org/app":        {"other-org/app", "ghcr.io", "Registry [ghcr.io] is not one of the allowed registries"},
+		"index.docker.io/library/nginx is on docker.io": {"library/nginx", "index.docker.io", ""},
+		"docker.io/bitnami/redis":                       {"bitnami/redis", "docker.io", "Registry [docker.io] is denied by [docker.io/bitnami]"},
+		"quay.io/prometheus/node-exporter":              {"prometheus/node-exporter", "quay.io", "Registry [quay.io] is not one of the allowed registries"},
+	}
+	for test, image := range tests {
+		if violation := policy.Violation(image[0], image[1]); violation != image[2] {
+			t.Errorf("%s: expected [%s] but got [%s]", test, image[2], violation)
+		}
+	}
+	if violation := (RegistryPolicy{}).Violation("app", "quay.io"); violation != "" {
+		t.Errorf("Expected all registries to be allowed without a policy but got [%s]", violation)
+	}
+	if err := (RegistryPolicy{Allowed: []string{"[registry"}}).Validate(); err == nil {
+		t.Errorf("Expected an error for a pattern that is not valid")
+	}
+}
@@ -0,0 +1,82 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// RegistryProvider finds the tags and the latest version of images in a registry
+type RegistryProvider interface {
+	GetLatestVersion(ctx context.Context, name string) (string, error)
+	GetTags(ctx context.Context, name string) ([]string, error)
+}
+
+// ExecRegistry is a registry provider implemented by an external executable, used for registries lcm doesn't support
+// The executable reads an ExecRegistryRequest as json from stdin and writes an ExecRegistryResponse as json to stdout
+type ExecRegistry struct {
+	Name             string   `koanf:"name"`
+	Command          string   `koanf:"command"`
+	Args             []string `koanf:"args"`
+	Urls             []string `koanf:"urls"`
+	AllowAllReleases bool     `koanf:"allowAllReleases"`
+}
+
+// ExecRegistryRequest is the input of an exec registry provider
+type ExecRegistryRequest struct {
+	Image string `json:"image"`
+}
+
+// ExecRegistryResponse is the output of an exec registry provider
+type ExecRegistryResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// GetLatestVersion runs the executable and returns the highest version of its tags
+func (e ExecRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", e.Name).WithField("image", name).Debug("Get latest version from exec registry")
+	tags, err := e.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, e.AllowAllReleases), nil
+}
+
+// GetTags runs the executable and returns the tags of the image
+func (e ExecRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	cacheKey := fmt.Sprintf("tags/exec/%s/%s", e.Name, name)
+	var tags []string
+	if cache.GetJSON(ctx, "exec/"+e.Name, cacheKey, &tags) {
+		return tags, nil
+	}
+
+	plugin := plugins.Plugin{Name: e.Name, Command: e.Command, Args: e.Args}
+	var response ExecRegistryResponse
+	call := plugins.Exec{Component: httpclient.Registry, Target: "exec/" + e.Name, Command: e.Command, Credential: "plugin"}
+	err := plugins.Observe(ctx, call, func(ctx context.Context) error {
+		return plugin.Exchange(ctx, ExecRegistryRequest{Image: name}, &response)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", e.Name, err)
+	}
+	cache.SetJSON(ctx, cacheKey, response.Tags)
+	return response.Tags, nil
+}
+
+// handles returns true when the provider is configured for the registry url
+func (e ExecRegistry) handles(url string) bool {
+	for _, u := range e.Urls {
+		if u == url {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ExecRegistry) allowsAllReleases() bool {
+	return e.AllowAllReleases
+}
@@ -0,0 +1,69 @@
+package registries
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExecRegistryRunsTheExecutable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the provider only knows the tags of team/app and fails for the other images
+	script := `#!/bin/sh
+read request
+case $request in
+*'"image":"team/app"'*) echo '{"tags": ["1.0.0", "1.2.0", "latest"]}';;
+*) echo "unknown image" >&2; exit 1;;
+esac
+`
+	command := filepath.Join(dir, "provider")
+	if err := ioutil.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	registry := ExecRegistry{Name: "exec-test", Command: command}
+
+	tests := map[string]struct {
+		image   string
+		version string
+		failure bool
+	}{
+		"tags":    {image: "team/app", version: "1.2.0"},
+		"failure": {image: "team/other", failure: true},
+	}
+	for name, test := range tests {
+		version, err := registry.GetLatestVersion(context.Background(), test.image)
+		if (err != nil) != test.failure {
+			t.Errorf("Expected failure %v for %s but got [%v]", test.failure, name, err)
+		}
+		if !test.failure && version != test.version {
+			t.Errorf("Expected %s for %s but got %s", test.version, name, version)
+		}
+	}
+}
+
+func TestExecRegistryTagsOfTheImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	command := filepath.Join(dir, "provider")
+	if err := ioutil.WriteFile(command, []byte("#!/bin/sh\ncat > /dev/null\necho '{\"tags\": [\"2.0.0\", \"2.1.0\"]}'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := ExecRegistry{Name: "exec-tags", Command: command}.GetTags(context.Background(), "team/tags")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if expected := []string{"2.0.0", "2.1.0"}; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected %v but got %v", expected, tags)
+	}
+}
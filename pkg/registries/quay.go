@@ -0,0 +1,98 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// QuayRegistry contains the settings for listing the tags with the Quay API instead of the Docker registry API,
+// the API also lists the tags of private repositories with an OAuth token of a Quay application
+type QuayRegistry struct {
+	Enabled bool     `koanf:"enabled"`
+	Urls    []string `koanf:"urls"` // Quay hosts that use the API, default is quay.io
+	Token   string   `koanf:"token"`
+}
+
+// handles returns true when the tags of the url are listed with the Quay API
+func (q QuayRegistry) handles(url string) bool {
+	if !q.Enabled {
+		return false
+	}
+	if len(q.Urls) == 0 {
+		return url == "quay.io"
+	}
+	for _, u := range q.Urls {
+		if u == url {
+			return true
+		}
+	}
+	return false
+}
+
+// quayRegistry is a single Quay host
+type quayRegistry struct {
+	config QuayRegistry
+	url    string
+}
+
+// GetLatestVersion fetches the latest version of the image with the Quay API
+func (q quayRegistry) GetLatestVersion(ctx context.Context, name string) (string, error) {
+	logger.WithField("registry", q.url).WithField("image", name).Debug("Get latest version for Quay image")
+	tags, err := q.GetTags(ctx, name)
+	if err != nil {
+		return versioning.Notfound, err
+	}
+	return versioning.FindHighestVersionInList(tags, false), nil
+}
+
+type quayTagsResponse struct {
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	HasAdditional bool `json:"has_additional"`
+}
+
+// GetTags pages through the active tags of the repository
+func (q quayRegistry) GetTags(ctx context.Context, name string) ([]string, error) {
+	cacheKey := fmt.Sprintf("tags/%s/%s", q.url, name)
+	var tags []string
+	if cache.GetJSON(ctx, q.url, cacheKey, &tags) {
+		return tags, nil
+	}
+
+	tags = []string{}
+	for page := 1; ; page++ {
+		if page > maxTagPages {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: more than [%d] pages", q.url, maxTagPages)
+		}
+		query := url.Values{"onlyActiveTags": {"true"}, "limit": {"100"}, "page": {fmt.Sprint(page)}}
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/api/v1/repository/%s/tag/?%s", q.url, name, query.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+		if q.config.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+q.config.Token)
+		}
+		resp, err := clientFrom(ctx).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", q.url, err)
+		}
+		var response quayTagsResponse
+		if err := decodeOK(resp, &response); err != nil {
+			return nil, fmt.Errorf("Could not fetch tags from [%s]: %w", q.url, err)
+		}
+		for _, tag := range response.Tags {
+			tags = append(tags, tag.Name)
+		}
+		if !response.HasAdditional {
+			break
+		}
+	}
+	cache.SetJSON(ctx, cacheKey, tags)
+	return tags, nil
+}
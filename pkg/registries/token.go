@@ -0,0 +1,230 @@
+package registries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+const (
+	// defaultTokenExpiry is used for tokens without expires_in, the distribution spec says tokens are valid for at least 60 seconds
+	defaultTokenExpiry = 60 * time.Second
+	// tokenExpiryMargin is how long before it expires a token is no longer reused, so it doesn't expire during a call
+	tokenExpiryMargin = 10 * time.Second
+)
+
+type bearerToken struct {
+	token   string
+	expires time.Time
+}
+
+// challenge is the token endpoint of a registry from its WWW-Authenticate header
+type challenge struct {
+	realm   string
+	service string
+	scope   string
+}
+
+var (
+	tokensLock sync.Mutex
+	// tokens are keyed by the registry, the credentials and the scope, so every repository of a registry gets one token exchange
+	tokens = map[string]bearerToken{}
+	// challenges are keyed by the registry, the token endpoint is the same for all its repositories so it is only asked once
+	challenges = map[string]challenge{}
+)
+
+// repositoryPathRE matches the repository of the tag list, manifest and blob paths of the registry API
+var repositoryPathRE = regexp.MustCompile(`/v2/(.+)/(?:tags|manifests|blobs)/`)
+
+type authToken struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// getToken returns a pull token for the repository of the url, tokens are reused until shortly before they expire
+func (r ImageRegistry) getToken(ctx context.Context, url string) (string, error) {
+	scope := repositoryScope(url)
+	key := r.tokenKey(scope)
+	if scope != "" {
+		tokensLock.Lock()
+		token, exists := tokens[key]
+		tokensLock.Unlock()
+		if exists && time.Now().Before(token.expires) {
+			logger.WithField("registry", r.URL).WithField("scope", scope).Debug("Reusing token")
+			return token.token, nil
+		}
+	}
+
+	endpoint, err := r.challenge(ctx, url, scope)
+	if err != nil {
+		return "", err
+	}
+	if scope == "" {
+		scope = endpoint.scope
+	}
+
+	tokenURL := endpoint.tokenURL(scope)
+	logger.WithField("url", tokenURL).Debug("Token url")
+	req, err := http.NewRequestWithContext(ctx, "GET", tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if r.Username != "" || r.Password != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return "", lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not Oke but [%v]", resp.StatusCode))
+	}
+	defer resp.Body.Close()
+
+	var authToken authToken
+	decoder := json.NewDecoder(resp.Body)
+	err = decoder.Decode(&authToken)
+	if err != nil {
+		return "", &lcmerrors.ParseError{Err: err}
+	}
+
+	token := authToken.Token
+	if token == "" {
+		token = authToken.AccessToken
+	}
+	expiresIn := defaultTokenExpiry
+	if authToken.ExpiresIn > 0 {
+		expiresIn = time.Duration(authToken.ExpiresIn) * time.Second
+	}
+	r.storeToken(r.tokenKey(scope), token, expiresIn)
+	return token, nil
+}
+
+// tokenKey returns the key of the token of the scope with the credentials of the registry
+func (r ImageRegistry) tokenKey(scope string) string {
+	return strings.Join([]string{r.URL, r.Username, r.Password, scope}, "|")
+}
+
+func (r ImageRegistry) storeToken(key, token string, expiresIn time.Duration) {
+	tokensLock.Lock()
+	defer tokensLock.Unlock()
+	now := time.Now()
+	for existing, stored := range tokens {
+		if now.After(stored.expires) {
+			delete(tokens, existing)
+		}
+	}
+	if expiresIn <= tokenExpiryMargin {
+		return
+	}
+	tokens[key] = bearerToken{token: token, expires: now.Add(expiresIn - tokenExpiryMargin)}
+}
+
+// forgetToken drops the token of the repository of the url, after the registry rejected it
+func (r ImageRegistry) forgetToken(url string) {
+	tokensLock.Lock()
+	defer tokensLock.Unlock()
+	delete(tokens, r.tokenKey(repositoryScope(url)))
+}
+
+// repositoryScope returns the pull scope of the repository of the url, it is empty when the url is not of a repository
+func repositoryScope(url string) string {
+	parts := repositoryPathRE.FindStringSubmatch(url)
+	if parts == nil {
+		return ""
+	}
+	return fmt.Sprintf("repository:%s:pull", parts[1])
+}
+
+// challenge returns the token endpoint of the registry, it is asked with an unauthenticated call the first time
+func (r ImageRegistry) challenge(ctx context.Context, url, scope string) (challenge, error) {
+	tokensLock.Lock()
+	known, exists := challenges[r.URL]
+	tokensLock.Unlock()
+	if exists && scope != "" {
+		return known, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return challenge{}, err
+	}
+
+	// Check if we need to login and find out the token url
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return challenge{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return challenge{}, fmt.Errorf("Response code was not Unauthorized but [%v]", resp.StatusCode)
+	}
+
+	parsed, err := parseChallenge(resp.Header)
+	if err != nil {
+		return challenge{}, err
+	}
+	tokensLock.Lock()
+	challenges[r.URL] = parsed
+	tokensLock.Unlock()
+	return parsed, nil
+}
+
+// challengeParamRE matches the key="value" parameters of a WWW-Authenticate header, the quotes are optional
+var challengeParamRE = regexp.MustCompile(`(\w+)="?([^",]*)"?`)
+
+// example: Www-Authenticate: Bearer realm="https://auth.docker.io/token",service="r.docker.io",scope="repository:library/ubuntu:pull"
+func parseChallenge(headers http.Header) (challenge, error) {
+	authHeader := headers[http.CanonicalHeaderKey("WWW-Authenticate")]
+	if len(authHeader) != 1 {
+		return challenge{}, fmt.Errorf("Expecting one auth header but got [%v]", authHeader)
+	}
+	logger.WithField("header", authHeader[0]).Debug("Incoming auth header")
+	// ECR answers with Basic realm
+	params := strings.TrimPrefix(strings.TrimPrefix(authHeader[0], "Bearer "), "Basic ")
+	var parsed challenge
+	for _, param := range challengeParamRE.FindAllStringSubmatch(params, -1) {
+		switch param[1] {
+		case "realm":
+			parsed.realm = param[2]
+		case "service":
+			parsed.service = param[2]
+		case "scope":
+			parsed.scope = param[2]
+		}
+	}
+	if parsed.realm == "" {
+		return challenge{}, fmt.Errorf("Auth header [%s] has no realm", authHeader[0])
+	}
+	return parsed, nil
+}
+
+// tokenURL returns the url of the token endpoint for the scope
+func (c challenge) tokenURL(scope string) string {
+	query := url.Values{}
+	if c.service != "" {
+		query.Set("service", c.service)
+	}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+	if len(query) == 0 {
+		return c.realm
+	}
+	separator := "?"
+	if strings.Contains(c.realm, "?") {
+		separator = "&"
+	}
+	return c.realm + separator + query.Encode()
+}
@@ -0,0 +1,56 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTokensAreReusedPerScope(t *testing.T) {
+	probes, exchanges := 0, 0
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			exchanges++
+			fmt.Fprintf(w, `{"token": "%s", "expires_in": 300}`, r.URL.Query().Get("scope"))
+			return
+		}
+		repository := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/"), "/tags/list")
+		if r.Header.Get("Authorization") != "Bearer repository:"+repository+":pull" {
+			probes++
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:%s:pull"`, server.URL, repository))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"tags": ["1.0.0"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	registry := ImageRegistry{Name: url, URL: url, AuthType: AuthTypeToken}
+	for _, name := range []string{"team/app", "team/api", "team/app", "team/api"} {
+		if _, err := registry.GetTags(context.Background(), name); err != nil {
+			t.Fatalf("Expected no error for %s but got [%v]", name, err)
+		}
+	}
+	if probes != 1 || exchanges != 2 {
+		t.Errorf("Expected one probe of the registry and one token exchange per repository but got %v probes and %v exchanges", probes, exchanges)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("WWW-Authenticate", `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/ubuntu:pull"`)
+	parsed, err := parseChallenge(headers)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := "https://auth.docker.io/token?scope=repository%3Alibrary%2Fnginx%3Apull&service=registry.docker.io"
+	if tokenURL := parsed.tokenURL("repository:library/nginx:pull"); tokenURL != expected {
+		t.Errorf("Expected token url %s but got %s", expected, tokenURL)
+	}
+}
@@ -0,0 +1,38 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+// ToolRegistries contains all the tool registries like GitHub
+type ToolRegistries struct {
+	GitHub GitHubConfig `koanf:"gitHub"`
+}
+
+// Tool contains tool info that needs to be checked for a new version
+type Tool struct {
+	Repo    string `koanf:"repo"`
+	Version string `koanf:"version"`
+}
+
+func (t Tool) getRepoAndOwner() (string, string, error) {
+	parts := strings.Split(t.Repo, "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Tool repo [%s] is not in the form of owner/repo", t.Repo)
+	}
+	return parts[0], parts[1], nil
+}
+
+// GetLatestVersionForTool gets the latest version for tool
+func (t ToolRegistries) GetLatestVersionForTool(ctx context.Context, tool Tool) (string, error) {
+	logger.WithField("tool", tool.Repo).Debug("Finding the latest version for tool")
+	owner, repo, err := tool.getRepoAndOwner()
+	if err != nil {
+		return versioning.Failure, err
+	}
+	return t.GitHub.GetLatestVersion(ctx, owner, repo, tool.Version)
+}
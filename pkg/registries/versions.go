@@ -0,0 +1,216 @@
+package registries
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+)
+
+const (
+	// PrereleasesExclude skips the alpha, beta, rc and preview tags of registries that allow all releases, it is the default
+	PrereleasesExclude = "exclude"
+	// PrereleasesInclude compares the prereleases with the other versions, for teams that track them
+	PrereleasesInclude = "include"
+
+	// SchemeSemver compares the tags as semantic versions like 1.2.3, it is the default
+	SchemeSemver = "semver"
+	// SchemeCalver compares the tags as calendar versions like 2021.04.1 or 20240115
+	SchemeCalver = "calver"
+)
+
+// VersionRule restricts which tags of the images are candidates for the latest version, so channel tags, commit SHAs
+// and date-stamped builds don't show up as newer versions, or to stay inside a semver range until a migration is planned.
+// The first rule that matches the image is used
+type VersionRule struct {
+	Images           []string `koanf:"images"`     // Names of the images, you can also use regular expressions like ^kube- for a prefix
+	TagRegex         string   `koanf:"tagRegex"`   // Only the tags that match are candidates, like ^v?\d+\.\d+\.\d+$
+	Constraint       string   `koanf:"constraint"` // Only the versions in the semver range are candidates, like ~1.21 or <2.0.0
+	AllowAllReleases bool     `koanf:"allowAllReleases"`
+	Prereleases      string   `koanf:"prereleases"` // Can be include or exclude, default is the prereleases setting of the registries
+	Scheme           string   `koanf:"scheme"`      // Can be semver or calver, default is semver
+	// VersionRegex extracts the version from longer tags with the first capture group, like ^release-v(\d+\.\d+\.\d+) for release-v1.4.2-hotfix
+	VersionRegex string `koanf:"versionRegex"`
+}
+
+// ValidPrereleases returns an error when the prereleases setting is not include, exclude or empty
+func ValidPrereleases(prereleases string) error {
+	if prereleases != "" && prereleases != PrereleasesInclude && prereleases != PrereleasesExclude {
+		return fmt.Errorf("Prereleases [%s] not valid, use include or exclude", prereleases)
+	}
+	return nil
+}
+
+// ValidScheme returns an error when the scheme is not semver, calver or empty
+func ValidScheme(scheme string) error {
+	if scheme != "" && scheme != SchemeSemver && scheme != SchemeCalver {
+		return fmt.Errorf("Scheme [%s] not valid, use semver or calver", scheme)
+	}
+	return nil
+}
+
+// allReleasesRegistry is implemented by the registries with the allowAllReleases setting
+type allReleasesRegistry interface {
+	allowsAllReleases() bool
+}
+
+func allowsAllReleases(registry RegistryProvider) bool {
+	allReleases, ok := registry.(allReleasesRegistry)
+	return ok && allReleases.allowsAllReleases()
+}
+
+// findVersionRule returns the first version rule of the image
+func (i ImageRegistries) findVersionRule(name string) (VersionRule, bool, error) {
+	for _, rule := range i.VersionRules {
+		for _, image := range rule.Images {
+			match, err := regexp.MatchString(image, name)
+			if err != nil {
+				return VersionRule{}, false, fmt.Errorf("Image regexp [%s] not valid: %w", image, err)
+			}
+			if match {
+				return rule, true, nil
+			}
+		}
+	}
+	return VersionRule{}, false, nil
+}
+
+// extractVersions returns the versions of the tags that match the tag regex with the version regex of the rule, and the tag of every version,
+// the first tag wins when multiple tags have the same version
+func (r VersionRule) extractVersions(tags []string) ([]string, map[string]string, error) {
+	versionRegex, err := regexp.Compile(r.VersionRegex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Version regexp [%s] not valid: %w", r.VersionRegex, err)
+	}
+	if versionRegex.NumSubexp() < 1 {
+		return nil, nil, fmt.Errorf("Version regexp [%s] has no capture group for the version", r.VersionRegex)
+	}
+	tags, err = VersionRule{TagRegex: r.TagRegex}.filter(tags)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions := []string{}
+	tagOf := map[string]string{}
+	for _, tag := range tags {
+		version := r.extractVersion(versionRegex, tag)
+		if _, exists := tagOf[version]; version == "" || exists {
+			continue
+		}
+		tagOf[version] = tag
+		versions = append(versions, version)
+	}
+	return versions, tagOf, nil
+}
+
+// extractVersion returns the first capture group of the version regex in the tag, it is empty when the tag doesn't match
+func (r VersionRule) extractVersion(versionRegex *regexp.Regexp, tag string) string {
+	match := versionRegex.FindStringSubmatch(tag)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+// candidates returns the tags that are candidates for the latest version according to the rule,
+// the prereleases are only candidates when the rule or else the registries include them
+func (r VersionRule) candidates(tags []string, prereleases string) ([]string, error) {
+	candidates, err := r.filter(tags)
+	if err != nil {
+		return nil, err
+	}
+	if r.Prereleases != "" {
+		prereleases = r.Prereleases
+	}
+	if r.Scheme == SchemeCalver || !r.AllowAllReleases || prereleases == PrereleasesInclude {
+		return candidates, nil
+	}
+	releases := []string{}
+	for _, tag := range candidates {
+		if !versioning.IsPrerelease(tag) {
+			releases = append(releases, tag)
+		}
+	}
+	return releases, nil
+}
+
+// latestVersion returns the highest version of the candidates of the variant
+func (r VersionRule) latestVersion(candidates []string, variant string) string {
+	if r.Scheme == SchemeCalver {
+		return versioning.FindHighestCalendarVersion(candidates, variant)
+	}
+	return versioning.FindHighestVersionOfVariant(candidates, variant, r.AllowAllReleases)
+}
+
+// behind returns how many of the candidates are newer releases than the running tag
+func (r VersionRule) behind(candidates []string, tag string) int {
+	if r.Scheme == SchemeCalver {
+		return versioning.CountNewerCalendarVersions(candidates, tag)
+	}
+	return versioning.CountNewerVersions(candidates, tag, r.AllowAllReleases)
+}
+
+// filter returns the tags that match the tag regex and that are versions in the semver range of the rule
+func (r VersionRule) filter(tags []string) ([]string, error) {
+	if r.TagRegex == "" && r.Constraint == "" {
+		return tags, nil
+	}
+	var tagRegex *regexp.Regexp
+	if r.TagRegex != "" {
+		var err error
+		if tagRegex, err = regexp.Compile(r.TagRegex); err != nil {
+			return nil, fmt.Errorf("Tag regexp [%s] not valid: %w", r.TagRegex, err)
+		}
+	}
+	var constraint *semver.Constraints
+	if r.Constraint != "" {
+		var err error
+		if constraint, err = semver.NewConstraint(r.Constraint); err != nil {
+			return nil, fmt.Errorf("Version constraint [%s] not valid: %w", r.Constraint, err)
+		}
+	}
+
+	candidates := []string{}
+	for _, tag := range tags {
+		if tagRegex != nil && !tagRegex.MatchString(tag) {
+			continue
+		}
+		if constraint != nil {
+			// tags that are not a semver version can't be inside the range, the flavor like -alpine is not part of the version
+			core, _ := versioning.SplitVariant(tag)
+			version, err := semver.NewVersion(core)
+			if err != nil || !constraint.Check(version) {
+				continue
+			}
+		}
+		candidates = append(candidates, tag)
+	}
+	return candidates, nil
+}
+
+// latestExtractedVersion returns the tag of the highest version that the version regex extracts from the tags and how many newer versions
+// there are for the running tags, the versions are compared without a flavor
+func (r VersionRule) latestExtractedVersion(tags, running []string, prereleases string) (string, []int, error) {
+	behind := make([]int, len(running))
+	versions, tagOf, err := r.extractVersions(tags)
+	if err != nil {
+		return versioning.Failure, behind, err
+	}
+	// the tag regex is already applied to the tags, the constraint and the prereleases apply to the versions
+	extracted := r
+	extracted.TagRegex = ""
+	candidates, err := extracted.candidates(versions, prereleases)
+	if err != nil {
+		return versioning.Failure, behind, err
+	}
+	versionRegex := regexp.MustCompile(r.VersionRegex)
+	for index, tag := range running {
+		if version := r.extractVersion(versionRegex, tag); version != "" {
+			behind[index] = extracted.behind(candidates, version)
+		}
+	}
+	if tag, exists := tagOf[extracted.latestVersion(candidates, "")]; exists {
+		return tag, behind, nil
+	}
+	return versioning.Notfound, behind, nil
+}
@@ -0,0 +1,114 @@
+package registries
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVersionRuleFiltersTheTags(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tags": ["1.2.3", "1.3.0", "20230101.1", "stable", "1.4.0-rc.1"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	var registries ImageRegistries
+	registries.DefaultRegistries()
+	if version, _ := registries.GetLatestVersionForImage(context.Background(), "team/app", url); version != "20230101.1" {
+		t.Fatalf("Expected the date-stamped build without a rule but got %s", version)
+	}
+
+	registries.VersionRules = []VersionRule{{Images: []string{"^team/"}, TagRegex: `^v?\d+\.\d+\.\d+$`}}
+	version, err := registries.GetLatestVersionForImage(context.Background(), "team/app", url)
+	if err != nil || version != "1.3.0" {
+		t.Errorf("Expected 1.3.0 with the tag regex but got %s and %v", version, err)
+	}
+
+	registries.VersionRules = []VersionRule{{Images: []string{"^team/"}, Constraint: "~1.2"}}
+	version, err = registries.GetLatestVersionForImage(context.Background(), "team/app", url)
+	if err != nil || version != "1.2.3" {
+		t.Errorf("Expected 1.2.3 inside the constraint but got %s and %v", version, err)
+	}
+
+	registries.VersionRules = []VersionRule{{Images: []string{"^team/"}, Scheme: SchemeCalver}}
+	version, err = registries.GetLatestVersionForImage(context.Background(), "team/app", url)
+	if err != nil || version != "20230101.1" {
+		t.Errorf("Expected the calendar version but got %s and %v", version, err)
+	}
+
+	registries.VersionRules[0].TagRegex = "("
+	if _, err := registries.GetLatestVersionForImage(context.Background(), "team/app", url); err == nil {
+		t.Errorf("Expected an error for a tag regex that is not valid")
+	}
+}
+
+func TestPrereleasesAreSkippedUnlessIncluded(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tags": ["1.2.3", "1.3.0-alpine", "1.4.0-rc.1"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	registries := ImageRegistries{OverrideRegistries: []OverrideRegistry{{Urls: []string{url}, Registry: ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone, AllowAllReleases: true}}}}
+	if version, _ := registries.GetLatestVersionForImage(context.Background(), "team/app", url); version != "1.3.0-alpine" {
+		t.Errorf("Expected the variant but not the prerelease but got %s", version)
+	}
+
+	registries.Prereleases = PrereleasesInclude
+	if version, _ := registries.GetLatestVersionForImage(context.Background(), "team/app", url); version != "1.4.0-rc.1" {
+		t.Errorf("Expected the included prerelease but got %s", version)
+	}
+
+	registries.VersionRules = []VersionRule{{Images: []string{"team/app"}, AllowAllReleases: true, Prereleases: PrereleasesExclude}}
+	if version, _ := registries.GetLatestVersionForImage(context.Background(), "team/app", url); version != "1.3.0-alpine" {
+		t.Errorf("Expected the rule to skip the prerelease but got %s", version)
+	}
+}
+
+func TestLatestVersionKeepsTheFlavorOfTheTag(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tags": ["1.19.2-alpine", "1.20.1", "1.20.1-alpine", "1.20.1-windowsservercore"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	registries := ImageRegistries{OverrideRegistries: []OverrideRegistry{{Urls: []string{url}, Registry: ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone}}}}
+	for tag, expected := range map[string]string{"1.19.2-alpine": "1.20.1-alpine", "1.19.2": "1.20.1"} {
+		if version, _ := registries.GetLatestVersionForTag(context.Background(), "library/nginx", url, tag); version != expected {
+			t.Errorf("Expected %s for %s but got %s", expected, tag, version)
+		}
+	}
+
+	version, behind, err := registries.GetLatestVersionForTags(context.Background(), "library/nginx", url, []string{"1.19.2-alpine", "1.20.1-alpine"})
+	if err != nil || version != "1.20.1-alpine" || behind[0] != 1 || behind[1] != 0 {
+		t.Errorf("Expected 1.20.1-alpine one version ahead of 1.19.2-alpine but got %s %v and %v", version, behind, err)
+	}
+}
+
+func TestVersionRegexExtractsTheVersion(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tags": ["release-v1.4.2-hotfix", "release-v1.10.0", "release-v1.9.1-hotfix", "nightly-20240101", "1.99.0"]}`)
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+	registries := ImageRegistries{
+		OverrideRegistries: []OverrideRegistry{{Urls: []string{url}, Registry: ImageRegistry{Name: url, URL: url, AuthType: AuthTypeNone}}},
+		VersionRules:       []VersionRule{{Images: []string{"vendor/appliance"}, VersionRegex: `^release-v(\d+\.\d+\.\d+)`}},
+	}
+	version, behind, err := registries.GetLatestVersionForTags(context.Background(), "vendor/appliance", url, []string{"release-v1.4.2-hotfix"})
+	if err != nil || version != "release-v1.10.0" || behind[0] != 2 {
+		t.Errorf("Expected release-v1.10.0 two versions ahead but got %s %v and %v", version, behind, err)
+	}
+	if comparable := registries.ComparableVersion("vendor/appliance", url, "release-v1.4.2-hotfix"); comparable != "1.4.2" {
+		t.Errorf("Expected the comparable version 1.4.2 but got %s", comparable)
+	}
+}
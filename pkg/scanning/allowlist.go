@@ -0,0 +1,65 @@
+package scanning
+
+import (
+	"fmt"
+	"time"
+)
+
+// AllowedVulnerability is an accepted risk, the vulnerability is not reported until the waiver expires
+type AllowedVulnerability struct {
+	CVE string `koanf:"cve"`
+	// Image limits the waiver to an image like library/nginx or docker.io/library/nginx, without it the waiver is for all images
+	Image string `koanf:"image"`
+	// Expires is the date like 2026-12-31 from which the vulnerability is reported again
+	Expires       string `koanf:"expires"`
+	Justification string `koanf:"justification"`
+}
+
+const expiresLayout = "2006-01-02"
+
+// Validate returns an error when the waiver has no cve, expiry date or justification
+func (a AllowedVulnerability) Validate() error {
+	if a.CVE == "" {
+		return fmt.Errorf("Setting [imageScanners.allowlist] needs a cve for every waiver")
+	}
+	if _, err := time.Parse(expiresLayout, a.Expires); err != nil {
+		return fmt.Errorf("Setting [imageScanners.allowlist] needs an expires date like 2026-12-31 for [%s]: %w", a.CVE, err)
+	}
+	if a.Justification == "" {
+		return fmt.Errorf("Setting [imageScanners.allowlist] needs a justification for [%s]", a.CVE)
+	}
+	return nil
+}
+
+// expired returns true from the day of the expiry date
+func (a AllowedVulnerability) expired(now time.Time) bool {
+	expires, err := time.ParseInLocation(expiresLayout, a.Expires, now.Location())
+	return err != nil || !now.Before(expires)
+}
+
+// matches returns true when the waiver is for the vulnerability of the image with the name or the reference without the tag or digest
+func (a AllowedVulnerability) matches(id, reference, name string) bool {
+	if a.CVE != id {
+		return false
+	}
+	image, _ := splitDigest(reference)
+	repository, _ := splitTag(image)
+	return a.Image == "" || a.Image == name || a.Image == repository
+}
+
+// isAllowed returns true when a waiver that didn't expire yet allows the vulnerability of the image,
+// a vulnerability of an expired waiver is logged as a warning so it's clear why it's reported again
+func (i ImageScanners) isAllowed(id, reference, name string, now time.Time) bool {
+	for _, allowed := range i.Allowlist {
+		if !allowed.matches(id, reference, name) {
+			continue
+		}
+		if allowed.expired(now) {
+			logger.WithField("cve", id).WithField("image", name).WithField("expires", allowed.Expires).Warn("Waiver of the vulnerability expired")
+			continue
+		}
+		logger.WithField("cve", id).WithField("image", name).WithField("justification", allowed.Justification).Debug("Vulnerability allowed by a waiver")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package scanning
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowlistUntilTheWaiverExpires(t *testing.T) {
+	scanners := ImageScanners{Allowlist: []AllowedVulnerability{
+		{CVE: "CVE-2023-4911", Image: "library/nginx", Expires: "2026-12-31", Justification: "Not reachable, glibc tunables are not set"},
+		{CVE: "CVE-2023-1234", Expires: "2026-06-30", Justification: "Fixed in the next release"},
+	}}
+	if err := scanners.Validate(); err != nil {
+		t.Fatalf("Expected a valid allowlist but got [%v]", err)
+	}
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		id, reference, name string
+		now                 time.Time
+		expected            bool
+	}{
+		{"CVE-2023-4911", "docker.io/library/nginx:1.25@sha256:abc", "library/nginx", now, true},
+		{"CVE-2023-4911", "docker.io/library/redis:7", "library/redis", now, false},
+		{"CVE-2023-4911", "docker.io/library/nginx:1.25", "library/nginx", time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"CVE-2023-1234", "registry.io/team/app:1.0", "team/app", now, false},
+		{"CVE-2023-1234", "registry.io/team/app:1.0", "team/app", time.Date(2026, 6, 29, 0, 0, 0, 0, time.UTC), true},
+		{"CVE-2023-5678", "docker.io/library/nginx:1.25", "library/nginx", now, false},
+	}
+	for _, test := range tests {
+		if allowed := scanners.isAllowed(test.id, test.reference, test.name, test.now); allowed != test.expected {
+			t.Errorf("Expected %v for %s of %s on %s but got %v", test.expected, test.id, test.reference, test.now.Format(expiresLayout), allowed)
+		}
+	}
+
+	scanners.Allowlist = append(scanners.Allowlist, AllowedVulnerability{CVE: "CVE-2023-9999", Expires: "2026-12-31"})
+	if err := scanners.Validate(); err == nil {
+		t.Errorf("Expected an error for a waiver without a justification")
+	}
+}
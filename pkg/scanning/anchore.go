@@ -0,0 +1,264 @@
+package scanning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// AnchoreScanner adds the images to Anchore Enterprise or Anchore Engine, waits until Anchore analyzed them and gets their vulnerabilities
+// and the evaluation of the active policy bundle, images that Anchore already analyzed are not analyzed again
+type AnchoreScanner struct {
+	Enabled  bool   `koanf:"enabled"`
+	URL      string `koanf:"url"` // Like https://anchore.corp.local:8228, the v1 API is used
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+	// PollInterval is how often the analysis status is checked, default is 5s
+	PollInterval string `koanf:"pollInterval"`
+	// AnalysisTimeout is how long to wait for the analysis of an image, default is 5m
+	AnalysisTimeout string `koanf:"analysisTimeout"`
+}
+
+type anchoreImage struct {
+	ImageDigest    string `json:"imageDigest"`
+	AnalysisStatus string `json:"analysis_status"`
+}
+
+type anchoreVulnerabilities struct {
+	Vulnerabilities []struct {
+		Vuln     string `json:"vuln"`
+		Severity string `json:"severity"`
+	} `json:"vulnerabilities"`
+}
+
+// anchoreCheck is the evaluation per digest per tag, the detail has a table with a row per check
+type anchoreCheck []map[string]map[string][]struct {
+	Status string `json:"status"`
+	Detail struct {
+		Result struct {
+			Result map[string]struct {
+				Result struct {
+					Header []string        `json:"header"`
+					Rows   [][]interface{} `json:"rows"`
+				} `json:"result"`
+			} `json:"result"`
+		} `json:"result"`
+	} `json:"detail"`
+}
+
+// ScannerID identifies the Anchore scanner by its URL
+func (a AnchoreScanner) ScannerID() string {
+	return "anchore/" + strings.TrimPrefix(strings.TrimPrefix(a.URL, "https://"), "http://")
+}
+
+// Validate returns an error when the durations are not valid
+func (a AnchoreScanner) Validate() error {
+	for name, duration := range map[string]string{"pollInterval": a.PollInterval, "analysisTimeout": a.AnalysisTimeout} {
+		if _, err := time.ParseDuration(duration); duration != "" && err != nil {
+			return fmt.Errorf("Setting [imageScanners.anchore.%s] not valid: %w", name, err)
+		}
+	}
+	if a.Enabled && a.URL == "" {
+		return fmt.Errorf("Setting [imageScanners.anchore.url] is needed for Anchore")
+	}
+	return nil
+}
+
+// GetFindings gets the findings of the image of Docker Hub with the name and version
+func (a AnchoreScanner) GetFindings(ctx context.Context, name, version string) ([]Finding, error) {
+	return a.GetReferenceFindings(ctx, name+":"+version)
+}
+
+// GetReferenceFindings gets the vulnerabilities of the operating system and the packages of the image once Anchore analyzed it
+func (a AnchoreScanner) GetReferenceFindings(ctx context.Context, reference string) ([]Finding, error) {
+	digest, err := a.analyze(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	var vulnerabilities anchoreVulnerabilities
+	if err := a.call(ctx, "GET", "/v1/images/"+digest+"/vuln/all", nil, &vulnerabilities); err != nil {
+		return nil, err
+	}
+	findings := []Finding{}
+	for _, vulnerability := range vulnerabilities.Vulnerabilities {
+		findings = append(findings, Finding{ID: vulnerability.Vuln, Severity: normalizeSeverity(vulnerability.Severity)})
+	}
+	return findings, nil
+}
+
+// GetPolicyEvaluation evaluates the image against the active policy bundle of Anchore, the reasons are the checks with the stop action
+func (a AnchoreScanner) GetPolicyEvaluation(ctx context.Context, reference string) (PolicyEvaluation, error) {
+	evaluation := PolicyEvaluation{Scanner: a.ScannerID()}
+	digest, err := a.analyze(ctx, reference)
+	if err != nil {
+		return evaluation, err
+	}
+	tag, _ := splitDigest(reference)
+	var check anchoreCheck
+	path := "/v1/images/" + digest + "/check?" + url.Values{"tag": {tag}, "detail": {"true"}}.Encode()
+	if err := a.call(ctx, "GET", path, nil, &check); err != nil {
+		return evaluation, err
+	}
+	for _, digests := range check {
+		for _, tags := range digests {
+			for _, evaluations := range tags {
+				for _, result := range evaluations {
+					evaluation.Status = result.Status
+					for _, table := range result.Detail.Result.Result {
+						evaluation.Reasons = append(evaluation.Reasons, stopReasons(table.Result.Header, table.Result.Rows)...)
+					}
+					// the first evaluation is the latest
+					break
+				}
+			}
+		}
+	}
+	if evaluation.Status == "" {
+		return evaluation, &lcmerrors.ParseError{Err: fmt.Errorf("Anchore did not return a policy evaluation for [%s]", reference)}
+	}
+	return evaluation, nil
+}
+
+// stopReasons returns the checks of the table of the evaluation that have the stop action as gate/trigger: output
+func stopReasons(header []string, rows [][]interface{}) []string {
+	columns := map[string]int{}
+	for index, name := range header {
+		columns[name] = index
+	}
+	value := func(row []interface{}, name string) string {
+		if index, exists := columns[name]; exists && index < len(row) {
+			return fmt.Sprint(row[index])
+		}
+		return ""
+	}
+	var reasons []string
+	for _, row := range rows {
+		if strings.EqualFold(value(row, "Gate_Action"), "stop") {
+			reasons = append(reasons, fmt.Sprintf("%s/%s: %s", value(row, "Gate"), value(row, "Trigger"), value(row, "Check_Output")))
+		}
+	}
+	return reasons
+}
+
+// anchoreDigests are the digests of the references Anchore analyzed per Anchore URL and reference, so the findings and the policy
+// evaluation of an image submit and poll it only once
+var (
+	anchoreDigestsLock sync.Mutex
+	anchoreDigests     = map[string]string{}
+)
+
+// analyze adds the image to Anchore and waits until it's analyzed, an image that was analyzed before is returned right away
+func (a AnchoreScanner) analyze(ctx context.Context, reference string) (string, error) {
+	key := a.URL + " " + reference
+	anchoreDigestsLock.Lock()
+	digest, analyzed := anchoreDigests[key]
+	anchoreDigestsLock.Unlock()
+	if analyzed {
+		return digest, nil
+	}
+	digest, err := a.submit(ctx, reference)
+	if err != nil {
+		return "", err
+	}
+	anchoreDigestsLock.Lock()
+	anchoreDigests[key] = digest
+	anchoreDigestsLock.Unlock()
+	return digest, nil
+}
+
+// submit adds the image to Anchore and waits until it's analyzed, an image that Anchore already knows is returned right away
+func (a AnchoreScanner) submit(ctx context.Context, reference string) (string, error) {
+	tag, digest := splitDigest(reference)
+	request := map[string]string{"tag": tag}
+	if digest != "" {
+		request["digest"] = digest
+	}
+	var images []anchoreImage
+	if err := a.call(ctx, "POST", "/v1/images", request, &images); err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(durationOr(a.AnalysisTimeout, 5*time.Minute))
+	for {
+		if len(images) == 0 {
+			return "", &lcmerrors.ParseError{Err: fmt.Errorf("Anchore did not return the image [%s]", reference)}
+		}
+		switch images[0].AnalysisStatus {
+		case "analyzed":
+			return images[0].ImageDigest, nil
+		case "analysis_failed":
+			return "", fmt.Errorf("Anchore could not analyze [%s]", reference)
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("Anchore did not finish the analysis of [%s] in time, status [%s]", reference, images[0].AnalysisStatus)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(durationOr(a.PollInterval, 5*time.Second)):
+		}
+		if err := a.call(ctx, "GET", "/v1/images/"+images[0].ImageDigest, nil, &images); err != nil {
+			return "", err
+		}
+	}
+}
+
+// call calls the API of Anchore with the request as json body when there is one and decodes the json response
+func (a AnchoreScanner) call(ctx context.Context, method, path string, request, response interface{}) error {
+	var body bytes.Buffer
+	if request != nil {
+		if err := json.NewEncoder(&body).Encode(request); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(a.URL, "/")+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(a.Username, a.Password)
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return &lcmerrors.ParseError{Err: fmt.Errorf("Anchore did not return valid json: %w", err)}
+	}
+	return nil
+}
+
+// splitDigest splits the reference in the image with the tag and the digest, the digest is empty when the image is not pinned by digest
+func splitDigest(reference string) (string, string) {
+	if at := strings.LastIndex(reference, "@"); at >= 0 {
+		return reference[:at], reference[at+1:]
+	}
+	return reference, ""
+}
+
+// splitTag splits the image in the repository and the tag, the tag is empty when the image has no tag
+func splitTag(image string) (string, string) {
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		return image[:colon], image[colon+1:]
+	}
+	return image, ""
+}
+
+// durationOr returns the duration or the default when it's empty, the durations are validated with the config
+func durationOr(duration string, defaultDuration time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(duration)
+	if err != nil {
+		return defaultDuration
+	}
+	return parsed
+}
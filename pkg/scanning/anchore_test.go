@@ -0,0 +1,74 @@
+package scanning
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAnchoreWaitsForTheAnalysisAndEvaluatesThePolicy(t *testing.T) {
+	polls, submissions := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/images":
+			submissions++
+			var request map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request["tag"] != "docker.io/library/nginx:1.25" || request["digest"] != "sha256:abc" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`[{"imageDigest": "sha256:abc", "analysis_status": "not_analyzed"}]`))
+		case "GET /v1/images/sha256:abc":
+			polls++
+			w.Write([]byte(`[{"imageDigest": "sha256:abc", "analysis_status": "analyzed"}]`))
+		case "GET /v1/images/sha256:abc/vuln/all":
+			w.Write([]byte(`{"vulnerabilities": [{"vuln": "CVE-2023-5678", "severity": "Critical"}]}`))
+		case "GET /v1/images/sha256:abc/check":
+			if r.URL.Query().Get("tag") != "docker.io/library/nginx:1.25" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`[{"sha256:abc": {"docker.io/library/nginx:1.25": [{"status": "fail", "detail": {"result": {"result": {"sha256:abc": {"result": {
+				"header": ["Gate", "Trigger", "Check_Output", "Gate_Action"],
+				"rows": [["dockerfile", "instruction", "User root found as effective user", "stop"], ["vulnerabilities", "package", "Low vulnerability", "warn"]]
+			}}}}}}]}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	scanner := AnchoreScanner{Enabled: true, URL: server.URL, Username: "admin", Password: "secret", PollInterval: "1ms"}
+	reference := "docker.io/library/nginx:1.25@sha256:abc"
+	findings, err := scanner.GetReferenceFindings(context.Background(), reference)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(findings, []Finding{{ID: "CVE-2023-5678", Severity: "Critical"}}) || polls != 1 {
+		t.Errorf("Expected the finding after one poll but got %v after %d polls", findings, polls)
+	}
+
+	evaluation, err := scanner.GetPolicyEvaluation(context.Background(), reference)
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := PolicyEvaluation{Scanner: scanner.ScannerID(), Status: "fail", Reasons: []string{"dockerfile/instruction: User root found as effective user"}}
+	if !reflect.DeepEqual(evaluation, expected) || !evaluation.Failed() {
+		t.Errorf("Expected %v but got %v", expected, evaluation)
+	}
+	if submissions != 1 || polls != 1 {
+		t.Errorf("Expected the image to be analyzed once for the findings and the policy but it was submitted %d times and polled %d times", submissions, polls)
+	}
+
+	scanner.Password = "wrong"
+	if _, err := scanner.GetPolicyEvaluation(context.Background(), reference); err == nil {
+		t.Errorf("Expected an error without valid credentials")
+	}
+}
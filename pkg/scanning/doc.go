@@ -0,0 +1,5 @@
+// Package scanning fetches the vulnerabilities of images from the configured scanner.
+//
+//	scanners := scanning.ImageScanners{Severity: []string{"High"}, Xray: scanning.XrayConfig{URL: "xray.example.com"}}
+//	cves, err := scanners.GetVulnerabilities(ctx, "library/nginx", "1.17.0")
+package scanning
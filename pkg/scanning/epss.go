@@ -0,0 +1,118 @@
+package scanning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// epssURL is the EPSS API of FIRST
+const epssURL = "https://api.first.org/data/v1/epss"
+
+// epssBatch is how many CVEs are looked up with one call, so the URL doesn't get too long
+const epssBatch = 100
+
+// EpssConfig enriches the CVEs with the EPSS score of FIRST, the probability between 0 and 1 that the CVE is exploited in the next 30 days
+type EpssConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	URL     string `koanf:"url"` // Default is the EPSS API of FIRST
+	// Threshold is the score from which a CVE is listed as likely to be exploited, like 0.1, without it no CVE is listed
+	Threshold float64 `koanf:"threshold"`
+}
+
+type epssResponse struct {
+	Data []struct {
+		CVE  string `json:"cve"`
+		EPSS string `json:"epss"`
+	} `json:"data"`
+}
+
+func (e EpssConfig) url() string {
+	if e.URL == "" {
+		return epssURL
+	}
+	return e.URL
+}
+
+// Validate returns an error when the threshold is not a probability
+func (e EpssConfig) Validate() error {
+	if e.Threshold < 0 || e.Threshold > 1 {
+		return fmt.Errorf("Setting [imageScanners.epss.threshold] not valid, [%v] is not between 0 and 1", e.Threshold)
+	}
+	return nil
+}
+
+// GetScores returns the EPSS scores of the CVEs, a CVE without a score is not in the scores.
+// Every score is cached so only the CVEs that are not in the cache are looked up
+func (e EpssConfig) GetScores(ctx context.Context, cves []string) (map[string]float64, error) {
+	scores := map[string]float64{}
+	if !e.Enabled {
+		return scores, nil
+	}
+	var missing []string
+	for _, cve := range cves {
+		var score float64
+		if cache.GetJSON(ctx, "epss", "epss/"+cve, &score) {
+			if score >= 0 {
+				scores[cve] = score
+			}
+		} else if strings.HasPrefix(cve, "CVE-") {
+			missing = append(missing, cve)
+		}
+	}
+	for start := 0; start < len(missing); start += epssBatch {
+		end := start + epssBatch
+		if end > len(missing) {
+			end = len(missing)
+		}
+		if err := e.lookup(ctx, missing[start:end], scores); err != nil {
+			return scores, err
+		}
+	}
+	return scores, nil
+}
+
+// lookup adds the scores of the CVEs to the scores, a CVE that EPSS doesn't know is cached with score -1
+func (e EpssConfig) lookup(ctx context.Context, cves []string, scores map[string]float64) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", e.url()+"?"+url.Values{"cve": {strings.Join(cves, ",")}}.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return fmt.Errorf("Could not get the EPSS scores from [%s]: %w", e.url(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Could not get the EPSS scores from [%s], response code was not 200 but [%v]", e.url(), resp.StatusCode))
+	}
+	var response epssResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return &lcmerrors.ParseError{Err: fmt.Errorf("Could not parse the EPSS scores of [%s]: %w", e.url(), err)}
+	}
+	found := map[string]float64{}
+	for _, data := range response.Data {
+		score, err := strconv.ParseFloat(data.EPSS, 64)
+		if err != nil {
+			return &lcmerrors.ParseError{Err: fmt.Errorf("Could not parse the EPSS score [%s] of [%s]: %w", data.EPSS, data.CVE, err)}
+		}
+		found[data.CVE] = score
+	}
+	for _, cve := range cves {
+		score, exists := found[cve]
+		if !exists {
+			cache.SetJSON(ctx, "epss/"+cve, -1)
+			continue
+		}
+		cache.SetJSON(ctx, "epss/"+cve, score)
+		scores[cve] = score
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package scanning
+
+import (
+	"context"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+)
+
+// decodeCommand runs the binary of the scanner and decodes its json output, the run is traced, measured and audited like the calls to the scanner APIs
+func decodeCommand(ctx context.Context, scannerID string, plugin plugins.Plugin, output interface{}) error {
+	return plugins.Observe(ctx, scannerExec(scannerID, plugin), func(ctx context.Context) error {
+		return plugin.Decode(ctx, output)
+	})
+}
+
+// exchangeCommand runs the binary of the scanner with the input as json and decodes its json output, the run is traced, measured and audited
+func exchangeCommand(ctx context.Context, scannerID string, plugin plugins.Plugin, input, output interface{}) error {
+	return plugins.Observe(ctx, scannerExec(scannerID, plugin), func(ctx context.Context) error {
+		return plugin.Exchange(ctx, input, output)
+	})
+}
+
+func scannerExec(scannerID string, plugin plugins.Plugin) plugins.Exec {
+	return plugins.Exec{Component: httpclient.Scanner, Target: scannerID, Command: plugin.Command, Credential: "plugin"}
+}
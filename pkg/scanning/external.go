@@ -0,0 +1,75 @@
+package scanning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+)
+
+// ExternalScanner is a scanner implemented by an external executable or an http endpoint
+// Both get a FindingsRequest as json, the executable on stdin and the endpoint as POST body, and return a FindingsResponse as json
+type ExternalScanner struct {
+	Name    string   `koanf:"name"`
+	Command string   `koanf:"command"`
+	Args    []string `koanf:"args"`
+	URL     string   `koanf:"url"`
+}
+
+// FindingsRequest is the input of an external scanner
+type FindingsRequest struct {
+	Image   string `json:"image"`
+	Version string `json:"version"`
+}
+
+// FindingsResponse is the output of an external scanner
+type FindingsResponse struct {
+	Findings []Finding `json:"findings"`
+}
+
+// ScannerID identifies the external scanner by its configured name
+func (e ExternalScanner) ScannerID() string {
+	return "external/" + e.Name
+}
+
+// GetFindings runs the executable or calls the endpoint and returns the findings of the image
+func (e ExternalScanner) GetFindings(ctx context.Context, name, version string) ([]Finding, error) {
+	request := FindingsRequest{Image: name, Version: version}
+	var response FindingsResponse
+	if e.Command != "" {
+		plugin := plugins.Plugin{Name: e.Name, Command: e.Command, Args: e.Args}
+		if err := exchangeCommand(ctx, e.ScannerID(), plugin, request, &response); err != nil {
+			return nil, err
+		}
+		return response.Findings, nil
+	}
+	if e.URL == "" {
+		return nil, fmt.Errorf("Scanner [%s] needs a command or a url", e.Name)
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, &lcmerrors.ParseError{Err: fmt.Errorf("Scanner [%s] did not return valid json: %w", e.Name, err)}
+	}
+	return response.Findings, nil
+}
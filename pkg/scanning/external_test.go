@@ -0,0 +1,49 @@
+package scanning
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExternalScannerRunsTheExecutable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the scanner only knows nginx 1.25 and fails for the other images
+	script := `#!/bin/sh
+read request
+case $request in
+*'"image":"library/nginx","version":"1.25"'*) echo '{"findings": [{"id": "CVE-2023-5678", "severity": "Critical"}]}';;
+*) echo "unknown image" >&2; exit 1;;
+esac
+`
+	command := filepath.Join(dir, "scanner")
+	if err := ioutil.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	scanner := ExternalScanner{Name: "corp", Command: command}
+
+	tests := map[string]struct {
+		version  string
+		findings []Finding
+		failure  bool
+	}{
+		"findings": {version: "1.25", findings: []Finding{{ID: "CVE-2023-5678", Severity: "Critical"}}},
+		"failure":  {version: "1.24", failure: true},
+	}
+	for name, test := range tests {
+		findings, err := scanner.GetFindings(context.Background(), "library/nginx", test.version)
+		if (err != nil) != test.failure {
+			t.Errorf("Expected failure %v for %s but got [%v]", test.failure, name, err)
+		}
+		if !reflect.DeepEqual(findings, test.findings) {
+			t.Errorf("Expected %v for %s but got %v", test.findings, name, findings)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package scanning
+
+import (
+	"context"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+)
+
+// GrypeScanner scans the images with the grype binary of Anchore, grype keeps its vulnerability database up to date by itself
+// The registry credentials of the binary are used, like the docker config or GRYPE_REGISTRY_AUTH_USERNAME and GRYPE_REGISTRY_AUTH_PASSWORD
+type GrypeScanner struct {
+	Enabled bool   `koanf:"enabled"`
+	Binary  string `koanf:"binary"` // Default is grype on the PATH
+	// Args are added to the arguments of the scan, like --only-fixed
+	Args []string `koanf:"args"`
+}
+
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+		} `json:"vulnerability"`
+	} `json:"matches"`
+}
+
+func (g GrypeScanner) binary() string {
+	if g.Binary == "" {
+		return "grype"
+	}
+	return g.Binary
+}
+
+// ScannerID identifies the Grype scanner by its binary
+func (g GrypeScanner) ScannerID() string {
+	return "grype/" + g.binary()
+}
+
+// GetFindings gets the findings of the image of Docker Hub with the name and version
+func (g GrypeScanner) GetFindings(ctx context.Context, name, version string) ([]Finding, error) {
+	return g.GetReferenceFindings(ctx, name+":"+version)
+}
+
+// GetReferenceFindings scans the image with grype, the image is always pulled from the registry and never taken from a local docker daemon
+func (g GrypeScanner) GetReferenceFindings(ctx context.Context, reference string) ([]Finding, error) {
+	args := append([]string{"--quiet", "--output", "json"}, g.Args...)
+	plugin := plugins.Plugin{Name: g.ScannerID(), Command: g.binary(), Args: append(args, "registry:"+reference)}
+	var report grypeReport
+	if err := decodeCommand(ctx, g.ScannerID(), plugin, &report); err != nil {
+		return nil, err
+	}
+
+	findings := []Finding{}
+	for _, match := range report.Matches {
+		findings = append(findings, Finding{ID: match.Vulnerability.ID, Severity: normalizeSeverity(match.Vulnerability.Severity)})
+	}
+	return findings, nil
+}
@@ -0,0 +1,67 @@
+package scanning
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGrypeFindingsOfTheRegistryImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grype")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, "grype")
+	script := `#!/bin/sh
+for last; do :; done
+[ "$last" = "registry:docker.io/library/nginx:1.25" ] || exit 1
+echo '{"matches": [{"vulnerability": {"id": "CVE-2023-5678", "severity": "Critical"}}, {"vulnerability": {"id": "GHSA-abcd", "severity": "Negligible"}}]}'
+`
+	if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := GrypeScanner{Enabled: true, Binary: binary}.GetReferenceFindings(context.Background(), "docker.io/library/nginx:1.25")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := []Finding{{ID: "CVE-2023-5678", Severity: "Critical"}, {ID: "GHSA-abcd", Severity: "Negligible"}}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("Expected %v but got %v", expected, findings)
+	}
+}
+
+func TestOnlySelectsTheScannersOfTheRun(t *testing.T) {
+	scanners := ImageScanners{
+		Trivy:    TrivyScanner{Enabled: true},
+		Grype:    GrypeScanner{Enabled: true},
+		External: []ExternalScanner{{Name: "corp"}, {Name: "other"}},
+	}
+	for _, test := range []struct {
+		only     []string
+		expected []string
+	}{
+		{nil, []string{"trivy/trivy", "grype/grype", "external/corp", "external/other"}},
+		{[]string{"grype"}, []string{"grype/grype"}},
+		{[]string{"external/corp"}, []string{"external/corp"}},
+		{[]string{"external", "trivy"}, []string{"trivy/trivy", "external/corp", "external/other"}},
+		{[]string{"trivy", "xray"}, nil},
+	} {
+		scanners.Only = test.only
+		var ids []string
+		for _, scanner := range scanners.Scanners() {
+			ids = append(ids, scanner.ScannerID())
+		}
+		if err := scanners.Validate(); test.expected == nil {
+			if err == nil {
+				t.Errorf("Expected an error for the unknown scanner of %v", scanners.Only)
+			}
+		} else if err != nil || !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("Expected %v for %v but got %v and [%v]", test.expected, test.only, ids, err)
+		}
+	}
+}
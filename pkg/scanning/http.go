@@ -0,0 +1,67 @@
+package scanning
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+)
+
+// httpClient is used for all the calls to vulnerability scanners
+var httpClient = &http.Client{Timeout: 60 * time.Second, Transport: httpclient.Transport(httpclient.Scanner)}
+
+// SetTimeout sets the default timeout for the calls to the vulnerability scanners
+func SetTimeout(timeout time.Duration) {
+	httpClient.Timeout = timeout
+}
+
+// SetHTTPClient replaces the default client of the calls to the vulnerability scanners.
+// The client of lcm sends the calls through the transport of httpclient with its retries, rate limits and audit log
+func SetHTTPClient(client *http.Client) {
+	httpClient = client
+}
+
+// Options are the settings of the calls to the vulnerability scanners, so programs that embed lcm can use their own settings
+// without changing the defaults of SetTimeout, SetHTTPClient and the cache package. Unset options use the defaults
+type Options struct {
+	// HTTPClient is used for the calls to the vulnerability scanners
+	HTTPClient *http.Client
+	// Cache stores the findings for the CacheTTL
+	Cache    cache.Cache
+	CacheTTL time.Duration
+}
+
+type optionsKey struct{}
+
+// WithOptions returns a context that makes all calls to the vulnerability scanners with the context use the options,
+// ImageScanners.WithOptions does the same for the calls of the image scanners
+func WithOptions(ctx context.Context, options Options) context.Context {
+	if options.Cache != nil {
+		ctx = cache.WithCache(ctx, options.Cache, options.CacheTTL)
+	}
+	return context.WithValue(ctx, optionsKey{}, options)
+}
+
+// WithOptions returns the scanners with the options, all calls of the scanners use them instead of the defaults
+func (i ImageScanners) WithOptions(options Options) ImageScanners {
+	i.options = &options
+	return i
+}
+
+// context returns the context that makes the calls use the options of the scanners
+func (i ImageScanners) context(ctx context.Context) context.Context {
+	if i.options == nil {
+		return ctx
+	}
+	return WithOptions(ctx, *i.options)
+}
+
+// clientFrom returns the http client of the options of the context, the default client without one
+func clientFrom(ctx context.Context) *http.Client {
+	if options, ok := ctx.Value(optionsKey{}).(Options); ok && options.HTTPClient != nil {
+		return options.HTTPClient
+	}
+	return httpClient
+}
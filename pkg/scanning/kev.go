@@ -0,0 +1,69 @@
+package scanning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// kevURL is the json feed of the Known Exploited Vulnerabilities catalog of CISA
+const kevURL = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+
+// KevConfig cross-references the vulnerabilities with the Known Exploited Vulnerabilities catalog of CISA,
+// the vulnerabilities in the catalog are marked as actively exploited
+type KevConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	URL     string `koanf:"url"` // Default is the json feed of CISA, like a mirror for clusters without internet access
+}
+
+type kevCatalog struct {
+	Vulnerabilities []struct {
+		CveID string `json:"cveID"`
+	} `json:"vulnerabilities"`
+}
+
+func (k KevConfig) url() string {
+	if k.URL == "" {
+		return kevURL
+	}
+	return k.URL
+}
+
+// GetExploited downloads the catalog and returns the CVEs that are actively exploited, the catalog is cached like the findings
+func (k KevConfig) GetExploited(ctx context.Context) (map[string]bool, error) {
+	if !k.Enabled {
+		return nil, nil
+	}
+	cacheKey := "kev/" + k.url()
+	var exploited map[string]bool
+	if cache.GetJSON(ctx, "kev", cacheKey, &exploited) {
+		return exploited, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", k.url(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Could not download the KEV catalog [%s]: %w", k.url(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Could not download the KEV catalog [%s], response code was not 200 but [%v]", k.url(), resp.StatusCode))
+	}
+	var catalog kevCatalog
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, &lcmerrors.ParseError{Err: fmt.Errorf("Could not parse the KEV catalog [%s]: %w", k.url(), err)}
+	}
+	exploited = map[string]bool{}
+	for _, vulnerability := range catalog.Vulnerabilities {
+		exploited[vulnerability.CveID] = true
+	}
+	cache.SetJSON(ctx, cacheKey, exploited)
+	return exploited, nil
+}
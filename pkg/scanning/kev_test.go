@@ -0,0 +1,30 @@
+package scanning
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestKevCatalogOfTheExploitedVulnerabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"title": "CISA Catalog of Known Exploited Vulnerabilities", "vulnerabilities": [
+			{"cveID": "CVE-2023-4911", "vendorProject": "GNU", "product": "GNU C Library"},
+			{"cveID": "CVE-2021-44228", "vendorProject": "Apache", "product": "Log4j2"}
+		]}`))
+	}))
+	defer server.Close()
+
+	exploited, err := KevConfig{Enabled: true, URL: server.URL}.GetExploited(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if expected := map[string]bool{"CVE-2023-4911": true, "CVE-2021-44228": true}; !reflect.DeepEqual(exploited, expected) {
+		t.Errorf("Expected %v but got %v", expected, exploited)
+	}
+	if exploited, err := (KevConfig{URL: server.URL}).GetExploited(context.Background()); exploited != nil || err != nil {
+		t.Errorf("Expected no catalog when it's not enabled but got %v and [%v]", exploited, err)
+	}
+}
@@ -0,0 +1,105 @@
+package scanning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// QuayScanner gets the findings of the security scan that Quay runs on every pushed image
+// Images that are not in Quay have no findings so the scanner can be combined with other scanners
+type QuayScanner struct {
+	Enabled bool   `koanf:"enabled"`
+	URL     string `koanf:"url"` // Default is quay.io
+	Token   string `koanf:"token"`
+}
+
+func (q QuayScanner) host() string {
+	if q.URL == "" {
+		return "quay.io"
+	}
+	return q.URL
+}
+
+// ScannerID identifies the Quay scanner by its URL
+func (q QuayScanner) ScannerID() string {
+	return "quay/" + q.host()
+}
+
+type quayTagResponse struct {
+	Tags []struct {
+		ManifestDigest string `json:"manifest_digest"`
+	} `json:"tags"`
+}
+
+type quaySecurityResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Layer struct {
+			Features []struct {
+				Vulnerabilities []struct {
+					Name     string `json:"Name"`
+					Severity string `json:"Severity"`
+				} `json:"Vulnerabilities"`
+			} `json:"Features"`
+		} `json:"Layer"`
+	} `json:"data"`
+}
+
+// GetFindings gets the vulnerabilities of the manifest of the tag, images that are not scanned yet have no findings
+func (q QuayScanner) GetFindings(ctx context.Context, name, version string) ([]Finding, error) {
+	findings := []Finding{}
+	query := url.Values{"specificTag": {version}, "onlyActiveTags": {"true"}}
+	var tags quayTagResponse
+	found, err := q.get(ctx, fmt.Sprintf("/api/v1/repository/%s/tag/?%s", name, query.Encode()), &tags)
+	if err != nil || !found || len(tags.Tags) == 0 {
+		return findings, err
+	}
+
+	var security quaySecurityResponse
+	path := fmt.Sprintf("/api/v1/repository/%s/manifest/%s/security?vulnerabilities=true", name, tags.Tags[0].ManifestDigest)
+	found, err = q.get(ctx, path, &security)
+	if err != nil || !found {
+		return findings, err
+	}
+	if security.Status != "scanned" {
+		logger.WithField("image", name).WithField("status", security.Status).Debug("Image not scanned by Quay")
+		return findings, nil
+	}
+	for _, feature := range security.Data.Layer.Features {
+		for _, vulnerability := range feature.Vulnerabilities {
+			findings = append(findings, Finding{ID: vulnerability.Name, Severity: vulnerability.Severity})
+		}
+	}
+	return findings, nil
+}
+
+// get decodes the json response of the Quay API, it returns false when the repository is not in Quay
+func (q QuayScanner) get(ctx context.Context, path string, response interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://"+q.host()+path, nil)
+	if err != nil {
+		return false, err
+	}
+	if q.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+q.Token)
+	}
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return false, &lcmerrors.ParseError{Err: err}
+	}
+	return true, nil
+}
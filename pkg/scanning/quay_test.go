@@ -0,0 +1,65 @@
+package scanning
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuayFindingsOfTheManifest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repository/team/app/tag/":
+			fmt.Fprint(w, `{"tags": [{"name": "1.0.0", "manifest_digest": "sha256:abc"}]}`)
+		case "/api/v1/repository/team/app/manifest/sha256:abc/security":
+			fmt.Fprint(w, `{"status": "scanned", "data": {"Layer": {"Features": [
+				{"Name": "openssl", "Vulnerabilities": [{"Name": "CVE-2020-1234", "Severity": "High"}]}]}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer useTestServer(server)()
+
+	scanner := QuayScanner{Enabled: true, URL: strings.TrimPrefix(server.URL, "https://")}
+	findings, err := scanner.GetFindings(context.Background(), "team/app", "1.0.0")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(findings, []Finding{{ID: "CVE-2020-1234", Severity: "High"}}) {
+		t.Errorf("Expected the finding of the manifest but got %v", findings)
+	}
+
+	findings, err = scanner.GetFindings(context.Background(), "library/nginx", "1.0.0")
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Expected no findings and no error for an image that is not in Quay but got %v and [%v]", findings, err)
+	}
+}
+
+func TestScannersUseTheHTTPClientOfTheirOptions(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repository/team/app/tag/":
+			fmt.Fprint(w, `{"tags": [{"name": "1.0.0", "manifest_digest": "sha256:abc"}]}`)
+		default:
+			fmt.Fprint(w, `{"status": "scanned", "data": {"Layer": {"Features": [
+				{"Name": "openssl", "Vulnerabilities": [{"Name": "CVE-2020-1234", "Severity": "High"}]}]}}}`)
+		}
+	}))
+	defer server.Close()
+
+	scanners := ImageScanners{Severity: []string{"High"}, Quay: QuayScanner{Enabled: true, URL: strings.TrimPrefix(server.URL, "https://")}}
+	// the default client doesn't trust the certificate of the test server
+	embedded := scanners.WithOptions(Options{HTTPClient: server.Client()})
+	cves, err := embedded.GetVulnerabilities(context.Background(), "team/app", "1.0.0")
+	if err != nil || !reflect.DeepEqual(cves, []string{"CVE-2020-1234"}) {
+		t.Errorf("Expected the finding through the client of the options but got %v and [%v]", cves, err)
+	}
+	if _, err := scanners.GetVulnerabilities(context.Background(), "team/app", "1.0.0"); err == nil {
+		t.Error("Expected the scanners without options to use the default client")
+	}
+}
@@ -0,0 +1,376 @@
+package scanning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/cache"
+	"github.com/arminc/k8s-platform-lcm/pkg/versioning"
+	log "github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var logger = log.WithField("component", "scanning")
+
+// ImageScanners contains all the information about the vulnerability scanners
+type ImageScanners struct {
+	Severity []string          `koanf:"severity"`
+	Xray     XrayConfig        `koanf:"xray"`
+	Quay     QuayScanner       `koanf:"quay"`
+	Trivy    TrivyScanner      `koanf:"trivy"`
+	Grype    GrypeScanner      `koanf:"grype"`
+	Anchore  AnchoreScanner    `koanf:"anchore"`
+	Snyk     SnykScanner       `koanf:"snyk"`
+	External []ExternalScanner `koanf:"external"`
+	// Thresholds are the severities on which lcm fails or warns when an image has a vulnerability of at least that severity
+	Thresholds SeverityThresholds `koanf:"thresholds"`
+	// Vex are the OpenVEX documents of the vulnerabilities that don't apply to the images
+	Vex VexConfig `koanf:"vex"`
+	// Kev marks the vulnerabilities of the Known Exploited Vulnerabilities catalog as actively exploited
+	Kev KevConfig `koanf:"kev"`
+	// Epss enriches the CVEs with their EPSS score
+	Epss EpssConfig `koanf:"epss"`
+	// Allowlist are the accepted risks, the vulnerabilities are not reported until their waiver expires
+	Allowlist []AllowedVulnerability `koanf:"allowlist"`
+	// Only limits the scanners of the run to the ones of the kinds like trivy or grype, or with the ids like external/corp-scanner,
+	// all the configured scanners run without it
+	Only []string `koanf:"only"`
+	// options are added with WithOptions, the defaults are used without them
+	options *Options
+}
+
+// Scanner fetches the vulnerability findings of an image
+type Scanner interface {
+	// ScannerID identifies the scanner, it is used in errors and cache keys
+	ScannerID() string
+	GetFindings(ctx context.Context, name, version string) ([]Finding, error)
+}
+
+// ReferenceScanner is a scanner that needs the reference of the image with the registry and the tag or digest, like the scanners that pull the image
+type ReferenceScanner interface {
+	Scanner
+	// GetReferenceFindings gets the findings of the image with the reference like docker.io/library/nginx:1.25 or registry.io/app@sha256:...
+	GetReferenceFindings(ctx context.Context, reference string) ([]Finding, error)
+}
+
+// PolicyScanner is a scanner that also evaluates the images against a policy, like the policy bundles of Anchore
+type PolicyScanner interface {
+	Scanner
+	GetPolicyEvaluation(ctx context.Context, reference string) (PolicyEvaluation, error)
+}
+
+// PolicyEvaluation is the result of the policy of a scanner for an image
+type PolicyEvaluation struct {
+	Scanner string `json:"scanner"`
+	// Status is pass or fail
+	Status string `json:"status"`
+	// Reasons are the checks that made the evaluation fail like dockerfile/instruction: User root found as effective user
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Failed returns true when the image doesn't pass the policy
+func (p PolicyEvaluation) Failed() bool {
+	return p.Status != "pass"
+}
+
+// BaseImageScanner is a scanner that also recommends upgrades of the base image of the images, like Snyk
+type BaseImageScanner interface {
+	Scanner
+	GetBaseImageAdvice(ctx context.Context, reference string) (BaseImageAdvice, error)
+}
+
+// BaseImageAdvice is the base image of an image with the upgrades that a scanner recommends, like nginx:1.25.3 for nginx:1.21
+type BaseImageAdvice struct {
+	Scanner   string   `json:"scanner"`
+	BaseImage string   `json:"baseImage"`
+	Upgrades  []string `json:"upgrades,omitempty"`
+}
+
+// PackageScanner is a scanner that also lists the packages in the images, like Trivy
+type PackageScanner interface {
+	Scanner
+	GetPackages(ctx context.Context, reference string) ([]Package, error)
+}
+
+// Package is a package of the operating system or an application in an image
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Purl is the package url like pkg:apk/alpine/musl@1.2.4-r2, it is empty when the scanner doesn't know it
+	Purl string `json:"purl,omitempty"`
+}
+
+// Finding is a single vulnerability found by a scanner
+type Finding struct {
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+}
+
+// Scanners returns the configured scanners that are selected for the run
+func (i ImageScanners) Scanners() []Scanner {
+	var scanners []Scanner
+	for _, scanner := range i.configured() {
+		if len(i.Only) == 0 || i.selects(scanner) {
+			scanners = append(scanners, scanner)
+		}
+	}
+	return scanners
+}
+
+// Validate returns an error when the settings of a scanner are not valid or a scanner of only is not one of the configured scanners
+func (i ImageScanners) Validate() error {
+	if err := i.Anchore.Validate(); err != nil {
+		return err
+	}
+	if err := i.Snyk.Validate(); err != nil {
+		return err
+	}
+	if err := i.Thresholds.Validate(); err != nil {
+		return err
+	}
+	if err := i.Epss.Validate(); err != nil {
+		return err
+	}
+	for _, allowed := range i.Allowlist {
+		if err := allowed.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, only := range i.Only {
+		found := false
+		for _, scanner := range i.configured() {
+			found = found || (ImageScanners{Only: []string{only}}).selects(scanner)
+		}
+		if !found {
+			return fmt.Errorf("Scanner [%s] is not one of the configured scanners", only)
+		}
+	}
+	return nil
+}
+
+// selects returns true when the kind or the id of the scanner is in only
+func (i ImageScanners) selects(scanner Scanner) bool {
+	for _, only := range i.Only {
+		if scanner.ScannerID() == only || strings.HasPrefix(scanner.ScannerID(), only+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (i ImageScanners) configured() []Scanner {
+	var scanners []Scanner
+	if i.Xray.URL != "" {
+		scanners = append(scanners, i.Xray)
+	}
+	if i.Quay.Enabled {
+		scanners = append(scanners, i.Quay)
+	}
+	if i.Trivy.Enabled {
+		scanners = append(scanners, i.Trivy)
+	}
+	if i.Grype.Enabled {
+		scanners = append(scanners, i.Grype)
+	}
+	if i.Anchore.Enabled {
+		scanners = append(scanners, i.Anchore)
+	}
+	if i.Snyk.Enabled {
+		scanners = append(scanners, i.Snyk)
+	}
+	for _, external := range i.External {
+		scanners = append(scanners, external)
+	}
+	return scanners
+}
+
+// GetVulnerabilities gets vulnerabilities for all images using the configured scanners
+// The findings of all scanners are combined, only the findings with an enabled severity are returned
+// When scanners fail the other scanners still run, the findings are returned with an aggregated error of the failed scanners
+func (i ImageScanners) GetVulnerabilities(ctx context.Context, name, version string) ([]string, error) {
+	return i.GetImageVulnerabilities(ctx, name+":"+version, name, version)
+}
+
+// GetImageVulnerabilities gets the vulnerabilities like GetVulnerabilities, the reference of the image is passed to the scanners that pull the image
+func (i ImageScanners) GetImageVulnerabilities(ctx context.Context, reference, name, version string) ([]string, error) {
+	findings, err := i.GetImageFindings(ctx, reference, name, version)
+	cves := []string{}
+	for _, finding := range findings {
+		cves = append(cves, finding.ID)
+	}
+	return cves, err
+}
+
+// GetImageFindings gets the findings with an enabled severity of all scanners sorted by id without the ones of the allowlist, a vulnerability that multiple scanners
+// found has the highest severity of the scanners. Without scanners the only finding is the no data finding without a severity
+func (i ImageScanners) GetImageFindings(ctx context.Context, reference, name, version string) ([]Finding, error) {
+	ctx = i.context(ctx)
+	scanners := i.Scanners()
+	if len(scanners) == 0 {
+		logger.Debug("No scanner enabled")
+		return []Finding{{ID: versioning.Nodata}}, nil
+	}
+
+	unique := []Finding{}
+	seen := map[string]int{}
+	var errs []error
+	now := time.Now()
+	allowed := map[string]bool{}
+	for _, scanner := range scanners {
+		findings, err := i.getFindings(ctx, scanner, reference, name, version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Could not get vulnerabilities from [%s]: %w", scanner.ScannerID(), err))
+			continue
+		}
+		for _, finding := range findings {
+			if !i.isSeverityEnabled(finding.Severity) || finding.Severity == "" {
+				logger.WithField("severity", finding.Severity).Debug("Severity not enabled")
+				continue
+			}
+			if _, checked := allowed[finding.ID]; !checked {
+				allowed[finding.ID] = i.isAllowed(finding.ID, reference, name, now)
+			}
+			if allowed[finding.ID] {
+				continue
+			}
+			if index, exists := seen[finding.ID]; !exists {
+				seen[finding.ID] = len(unique)
+				unique = append(unique, finding)
+			} else if severityRank(finding.Severity) > severityRank(unique[index].Severity) {
+				unique[index].Severity = finding.Severity
+			}
+		}
+	}
+	sort.Slice(unique, func(a, b int) bool {
+		return unique[a].ID < unique[b].ID
+	})
+	return unique, utilerrors.NewAggregate(errs)
+}
+
+// GetPolicyEvaluations evaluates the image against the policies of the selected scanners that have one, like Anchore
+// When scanners fail the evaluations of the other scanners are returned with an aggregated error of the failed scanners
+func (i ImageScanners) GetPolicyEvaluations(ctx context.Context, reference string) ([]PolicyEvaluation, error) {
+	ctx = i.context(ctx)
+	var evaluations []PolicyEvaluation
+	var errs []error
+	for _, scanner := range i.Scanners() {
+		policyScanner, withPolicy := scanner.(PolicyScanner)
+		if !withPolicy {
+			continue
+		}
+		evaluation, err := policyScanner.GetPolicyEvaluation(ctx, reference)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Could not get the policy evaluation from [%s]: %w", scanner.ScannerID(), err))
+			continue
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, utilerrors.NewAggregate(errs)
+}
+
+// GetBaseImageAdvice gets the recommended upgrades of the base image of the image from the selected scanners that have them, like Snyk
+// Only advice with upgrades is returned, when scanners fail the advice of the other scanners is returned with an aggregated error
+func (i ImageScanners) GetBaseImageAdvice(ctx context.Context, reference string) ([]BaseImageAdvice, error) {
+	ctx = i.context(ctx)
+	var advice []BaseImageAdvice
+	var errs []error
+	for _, scanner := range i.Scanners() {
+		baseImageScanner, withBaseImage := scanner.(BaseImageScanner)
+		if !withBaseImage {
+			continue
+		}
+		scannerAdvice, err := baseImageScanner.GetBaseImageAdvice(ctx, reference)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Could not get the base image advice from [%s]: %w", scanner.ScannerID(), err))
+			continue
+		}
+		if len(scannerAdvice.Upgrades) > 0 {
+			advice = append(advice, scannerAdvice)
+		}
+	}
+	return advice, utilerrors.NewAggregate(errs)
+}
+
+// GetPackages gets the packages in the image from the selected scanners that list them, like Trivy
+// The packages are sorted and a package that multiple scanners found is only returned once, when scanners fail
+// the packages of the other scanners are returned with an aggregated error of the failed scanners
+func (i ImageScanners) GetPackages(ctx context.Context, reference string) ([]Package, error) {
+	ctx = i.context(ctx)
+	packages := []Package{}
+	seen := map[Package]bool{}
+	var errs []error
+	for _, scanner := range i.Scanners() {
+		packageScanner, withPackages := scanner.(PackageScanner)
+		if !withPackages {
+			continue
+		}
+		cacheKey := fmt.Sprintf("packages/%s/%s", scanner.ScannerID(), reference)
+		var found []Package
+		if !cache.GetJSON(ctx, scanner.ScannerID(), cacheKey, &found) {
+			var err error
+			if found, err = packageScanner.GetPackages(ctx, reference); err != nil {
+				errs = append(errs, fmt.Errorf("Could not get the packages from [%s]: %w", scanner.ScannerID(), err))
+				continue
+			}
+			cache.SetJSON(ctx, cacheKey, found)
+		}
+		for _, pkg := range found {
+			if !seen[pkg] {
+				seen[pkg] = true
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	sort.SliceStable(packages, func(a, b int) bool {
+		if packages[a].Name != packages[b].Name {
+			return packages[a].Name < packages[b].Name
+		}
+		return packages[a].Version < packages[b].Version
+	})
+	return packages, utilerrors.NewAggregate(errs)
+}
+
+func (i ImageScanners) getFindings(ctx context.Context, scanner Scanner, reference, name, version string) ([]Finding, error) {
+	cacheKey := fmt.Sprintf("vulnerabilities/%s/%s:%s", scanner.ScannerID(), name, version)
+	referenceScanner, withReference := scanner.(ReferenceScanner)
+	if withReference {
+		cacheKey = fmt.Sprintf("vulnerabilities/%s/%s", scanner.ScannerID(), reference)
+	}
+	var findings []Finding
+	if cache.GetJSON(ctx, scanner.ScannerID(), cacheKey, &findings) {
+		return findings, nil
+	}
+
+	logger.WithField("scanner", scanner.ScannerID()).Debugf("Scan image: [%v]", name)
+	var err error
+	if withReference {
+		findings, err = referenceScanner.GetReferenceFindings(ctx, reference)
+	} else {
+		findings, err = scanner.GetFindings(ctx, name, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache.SetJSON(ctx, cacheKey, findings)
+	return findings, nil
+}
+
+// normalizeSeverity returns the severity like the other scanners write it, like High for HIGH, so the same severity config works for all scanners
+func normalizeSeverity(severity string) string {
+	if severity == "" {
+		return severity
+	}
+	return strings.ToUpper(severity[:1]) + strings.ToLower(severity[1:])
+}
+
+func (i ImageScanners) isSeverityEnabled(severity string) bool {
+	for _, s := range i.Severity {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package scanning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+)
+
+// SnykScanner gets the issues and the base image recommendations of the container projects that Snyk monitors in the organization,
+// the project of an image is found by the name and the digest of the image, or the tag when the image is not pinned by digest
+// Images that Snyk doesn't monitor have no findings so the scanner can be combined with other scanners
+type SnykScanner struct {
+	Enabled bool   `koanf:"enabled"`
+	URL     string `koanf:"url"` // Default is https://api.snyk.io, like https://api.eu.snyk.io for other regions
+	Token   string `koanf:"token"`
+	OrgID   string `koanf:"orgId"`
+}
+
+type snykProjects struct {
+	Projects []snykProject `json:"projects"`
+}
+
+type snykProject struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	ImageID        string `json:"imageId"`
+	ImageTag       string `json:"imageTag"`
+	ImageBaseImage string `json:"imageBaseImage"`
+	Remediation    struct {
+		// Upgrade has the upgrades per package, for the base image the package is the base image like nginx@1.21
+		Upgrade map[string]struct {
+			UpgradeTo []string `json:"upgradeTo"`
+		} `json:"upgrade"`
+	} `json:"remediation"`
+}
+
+type snykIssues struct {
+	Issues []struct {
+		IssueData struct {
+			ID          string `json:"id"`
+			Severity    string `json:"severity"`
+			Identifiers struct {
+				CVE []string `json:"CVE"`
+			} `json:"identifiers"`
+		} `json:"issueData"`
+	} `json:"issues"`
+}
+
+func (s SnykScanner) url() string {
+	if s.URL == "" {
+		return "https://api.snyk.io"
+	}
+	return strings.TrimSuffix(s.URL, "/")
+}
+
+// ScannerID identifies the Snyk scanner by its organization
+func (s SnykScanner) ScannerID() string {
+	return "snyk/" + s.OrgID
+}
+
+// Validate returns an error when the organization is missing
+func (s SnykScanner) Validate() error {
+	if s.Enabled && s.OrgID == "" {
+		return fmt.Errorf("Setting [imageScanners.snyk.orgId] is needed for Snyk")
+	}
+	return nil
+}
+
+// GetFindings gets the findings of the image of Docker Hub with the name and version
+func (s SnykScanner) GetFindings(ctx context.Context, name, version string) ([]Finding, error) {
+	return s.GetReferenceFindings(ctx, name+":"+version)
+}
+
+// GetReferenceFindings gets the vulnerabilities of the project of the image, a vulnerability without a CVE has the id of Snyk like SNYK-DEBIAN11-GLIBC-1234
+func (s SnykScanner) GetReferenceFindings(ctx context.Context, reference string) ([]Finding, error) {
+	findings := []Finding{}
+	project, found, err := s.findProject(ctx, reference)
+	if err != nil || !found {
+		return findings, err
+	}
+	var issues snykIssues
+	if err := s.call(ctx, "POST", fmt.Sprintf("/v1/org/%s/project/%s/aggregated-issues", s.OrgID, project.ID), map[string]interface{}{}, &issues); err != nil {
+		return nil, err
+	}
+	for _, issue := range issues.Issues {
+		ids := issue.IssueData.Identifiers.CVE
+		if len(ids) == 0 {
+			ids = []string{issue.IssueData.ID}
+		}
+		for _, id := range ids {
+			findings = append(findings, Finding{ID: id, Severity: normalizeSeverity(issue.IssueData.Severity)})
+		}
+	}
+	return findings, nil
+}
+
+// GetBaseImageAdvice gets the base image of the project of the image and the upgrades of the base image that Snyk recommends
+func (s SnykScanner) GetBaseImageAdvice(ctx context.Context, reference string) (BaseImageAdvice, error) {
+	advice := BaseImageAdvice{Scanner: s.ScannerID()}
+	found, exists, err := s.findProject(ctx, reference)
+	if err != nil || !exists {
+		return advice, err
+	}
+	// the list of projects doesn't have the remediation of the project
+	var project snykProject
+	if err := s.call(ctx, "GET", fmt.Sprintf("/v1/org/%s/project/%s", s.OrgID, found.ID), nil, &project); err != nil {
+		return advice, err
+	}
+	advice.BaseImage = project.ImageBaseImage
+	base := strings.Replace(project.ImageBaseImage, ":", "@", 1)
+	for pkg, upgrade := range project.Remediation.Upgrade {
+		if pkg == base || pkg == project.ImageBaseImage {
+			for _, upgradeTo := range upgrade.UpgradeTo {
+				advice.Upgrades = append(advice.Upgrades, strings.Replace(upgradeTo, "@", ":", 1))
+			}
+		}
+	}
+	sort.Strings(advice.Upgrades)
+	return advice, nil
+}
+
+// findProject returns the container project of the image, it prefers the project of the digest over the project of the tag
+func (s SnykScanner) findProject(ctx context.Context, reference string) (snykProject, bool, error) {
+	image, digest := splitDigest(reference)
+	name, tag := splitTag(image)
+	var projects snykProjects
+	filters := map[string]interface{}{"filters": map[string]string{"name": shortName(name)}}
+	if err := s.call(ctx, "POST", "/v1/org/"+s.OrgID+"/projects", filters, &projects); err != nil {
+		return snykProject{}, false, err
+	}
+	var byTag *snykProject
+	for i, project := range projects.Projects {
+		if digest != "" && project.ImageID == digest {
+			return project, true, nil
+		}
+		if byTag == nil && tag != "" && project.ImageTag == tag {
+			byTag = &projects.Projects[i]
+		}
+	}
+	if byTag == nil {
+		logger.WithField("image", reference).Debug("Image not monitored by Snyk")
+		return snykProject{}, false, nil
+	}
+	return *byTag, true, nil
+}
+
+// shortName returns the name of the image like Snyk names the projects, without docker.io and library of the official images
+func shortName(name string) string {
+	name = strings.TrimPrefix(name, "docker.io/")
+	return strings.TrimPrefix(name, "library/")
+}
+
+// call calls the v1 API of Snyk with the request as json body when there is one and decodes the json response
+func (s SnykScanner) call(ctx context.Context, method, path string, request, response interface{}) error {
+	var body bytes.Buffer
+	if request != nil {
+		if err := json.NewEncoder(&body).Encode(request); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url()+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "token "+s.Token)
+	resp, err := clientFrom(ctx).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lcmerrors.FromStatus(resp.StatusCode, fmt.Errorf("Response code was not 200 but [%v]", resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return &lcmerrors.ParseError{Err: fmt.Errorf("Snyk did not return valid json: %w", err)}
+	}
+	return nil
+}
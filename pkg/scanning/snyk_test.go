@@ -0,0 +1,70 @@
+package scanning
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSnykFindsTheProjectOfTheDigest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/org/corp/projects":
+			var request struct {
+				Filters map[string]string `json:"filters"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Filters["name"] != "nginx" {
+				w.Write([]byte(`{"projects": []}`))
+				return
+			}
+			w.Write([]byte(`{"projects": [
+				{"id": "old", "imageId": "sha256:old", "imageTag": "1.25"},
+				{"id": "current", "imageId": "sha256:abc", "imageTag": "1.25"}
+			]}`))
+		case "POST /v1/org/corp/project/current/aggregated-issues":
+			w.Write([]byte(`{"issues": [
+				{"issueData": {"id": "SNYK-DEBIAN12-GLIBC-1", "severity": "high", "identifiers": {"CVE": ["CVE-2023-4911"]}}},
+				{"issueData": {"id": "SNYK-DEBIAN12-ZLIB-2", "severity": "low", "identifiers": {"CVE": []}}}
+			]}`))
+		case "GET /v1/org/corp/project/current":
+			w.Write([]byte(`{"id": "current", "imageBaseImage": "nginx:1.25.1", "remediation": {"upgrade": {
+				"nginx@1.25.1": {"upgradeTo": ["nginx@1.25.3", "nginx@1.25.3-alpine"]},
+				"openssl@3.0.9": {"upgradeTo": ["openssl@3.0.11"]}
+			}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	scanner := SnykScanner{Enabled: true, URL: server.URL, Token: "secret", OrgID: "corp"}
+	findings, err := scanner.GetReferenceFindings(context.Background(), "docker.io/library/nginx:1.25@sha256:abc")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := []Finding{{ID: "CVE-2023-4911", Severity: "High"}, {ID: "SNYK-DEBIAN12-ZLIB-2", Severity: "Low"}}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("Expected %v but got %v", expected, findings)
+	}
+
+	advice, err := scanner.GetBaseImageAdvice(context.Background(), "docker.io/library/nginx:1.25@sha256:abc")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expectedAdvice := BaseImageAdvice{Scanner: "snyk/corp", BaseImage: "nginx:1.25.1", Upgrades: []string{"nginx:1.25.3", "nginx:1.25.3-alpine"}}
+	if !reflect.DeepEqual(advice, expectedAdvice) {
+		t.Errorf("Expected %v but got %v", expectedAdvice, advice)
+	}
+
+	findings, err = scanner.GetReferenceFindings(context.Background(), "registry.io/team/app:1.0")
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Expected no findings for an image that Snyk doesn't monitor but got %v and [%v]", findings, err)
+	}
+}
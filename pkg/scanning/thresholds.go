@@ -0,0 +1,60 @@
+package scanning
+
+import "fmt"
+
+// severities are the severities of the scanners from the lowest to the highest
+var severities = []string{"Unknown", "Negligible", "Low", "Medium", "High", "Critical"}
+
+// SeverityThresholds are the severities from which the vulnerabilities of an image fail or warn, like failing on Critical and warning on High
+// Only the findings with an enabled severity count, an empty threshold is not used
+type SeverityThresholds struct {
+	Fail string `koanf:"fail"`
+	Warn string `koanf:"warn"`
+}
+
+// Validate returns an error when a threshold is not a known severity
+func (t SeverityThresholds) Validate() error {
+	for name, threshold := range map[string]string{"fail": t.Fail, "warn": t.Warn} {
+		if threshold != "" && severityRank(threshold) == 0 {
+			return fmt.Errorf("Setting [imageScanners.thresholds.%s] not valid, [%s] is not one of %v", name, threshold, severities)
+		}
+	}
+	return nil
+}
+
+// Fails returns true when the severity is at least the fail threshold
+func (t SeverityThresholds) Fails(severity string) bool {
+	return t.Fail != "" && severity != "" && severityRank(severity) >= severityRank(t.Fail)
+}
+
+// Warns returns true when the severity is at least the warn threshold but doesn't fail
+func (t SeverityThresholds) Warns(severity string) bool {
+	return t.Warn != "" && severity != "" && severityRank(severity) >= severityRank(t.Warn) && !t.Fails(severity)
+}
+
+// HighestSeverity returns the highest severity of the findings, it is empty without findings
+func HighestSeverity(findings []Finding) string {
+	highest := ""
+	for _, finding := range findings {
+		if severityRank(finding.Severity) > severityRank(highest) {
+			highest = normalizeSeverity(finding.Severity)
+		}
+	}
+	return highest
+}
+
+// CompareSeverities returns a positive number when severity a is higher than b, a negative number when it is lower and 0 when they are equal
+func CompareSeverities(a, b string) int {
+	return severityRank(a) - severityRank(b)
+}
+
+// severityRank returns the rank of the severity starting at 1 for Unknown, it is 0 for a severity that is not known
+func severityRank(severity string) int {
+	severity = normalizeSeverity(severity)
+	for index, known := range severities {
+		if known == severity {
+			return index + 1
+		}
+	}
+	return 0
+}
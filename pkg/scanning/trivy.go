@@ -0,0 +1,106 @@
+package scanning
+
+import (
+	"context"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/plugins"
+)
+
+// TrivyScanner scans the images with the trivy binary, with a server the binary only sends the layers to the Trivy server
+// that has the vulnerability database, so the database isn't downloaded for every run
+// The registry credentials of the binary are used, like the docker config or TRIVY_USERNAME and TRIVY_PASSWORD
+type TrivyScanner struct {
+	Enabled bool   `koanf:"enabled"`
+	Binary  string `koanf:"binary"` // Default is trivy on the PATH
+	Server  string `koanf:"server"` // URL of the Trivy server like http://trivy.trivy:4954, by default the binary scans by itself
+	Token   string `koanf:"token"`  // Token of the Trivy server
+	// Args are added to the arguments of the scan, like --ignore-unfixed
+	Args []string `koanf:"args"`
+}
+
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID string `json:"VulnerabilityID"`
+			Severity        string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+		// Packages are only in the report with --list-all-pkgs
+		Packages []struct {
+			Name       string `json:"Name"`
+			Version    string `json:"Version"`
+			Identifier struct {
+				PURL string `json:"PURL"`
+			} `json:"Identifier"`
+		} `json:"Packages"`
+	} `json:"Results"`
+}
+
+func (t TrivyScanner) binary() string {
+	if t.Binary == "" {
+		return "trivy"
+	}
+	return t.Binary
+}
+
+// ScannerID identifies the Trivy scanner by its server or binary
+func (t TrivyScanner) ScannerID() string {
+	if t.Server != "" {
+		return "trivy/" + t.Server
+	}
+	return "trivy/" + t.binary()
+}
+
+// GetFindings gets the findings of the image of Docker Hub with the name and version
+func (t TrivyScanner) GetFindings(ctx context.Context, name, version string) ([]Finding, error) {
+	return t.GetReferenceFindings(ctx, name+":"+version)
+}
+
+// GetReferenceFindings scans the image with trivy and returns the vulnerabilities of the operating system and the packages in the image
+func (t TrivyScanner) GetReferenceFindings(ctx context.Context, reference string) ([]Finding, error) {
+	report, err := t.scan(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []Finding{}
+	for _, result := range report.Results {
+		for _, vulnerability := range result.Vulnerabilities {
+			findings = append(findings, Finding{ID: vulnerability.VulnerabilityID, Severity: normalizeSeverity(vulnerability.Severity)})
+		}
+	}
+	return findings, nil
+}
+
+// GetPackages scans the image with trivy and returns the packages of the operating system and the applications in the image
+func (t TrivyScanner) GetPackages(ctx context.Context, reference string) ([]Package, error) {
+	report, err := t.scan(ctx, reference, "--list-all-pkgs")
+	if err != nil {
+		return nil, err
+	}
+
+	packages := []Package{}
+	for _, result := range report.Results {
+		for _, pkg := range result.Packages {
+			packages = append(packages, Package{Name: pkg.Name, Version: pkg.Version, Purl: pkg.Identifier.PURL})
+		}
+	}
+	return packages, nil
+}
+
+// scan runs trivy for the image with the extra arguments and decodes the json report
+func (t TrivyScanner) scan(ctx context.Context, reference string, extra ...string) (trivyReport, error) {
+	args := append([]string{"image", "--quiet", "--format", "json", "--scanners", "vuln"}, extra...)
+	var env []string
+	if t.Server != "" {
+		args = append(args, "--server", t.Server)
+	}
+	if t.Token != "" {
+		// the token is not in the arguments so it doesn't show up in the process list
+		env = append(env, "TRIVY_TOKEN="+t.Token)
+	}
+	plugin := plugins.Plugin{Name: t.ScannerID(), Command: t.binary(), Args: append(append(args, t.Args...), reference), Env: env}
+
+	var report trivyReport
+	err := decodeCommand(ctx, t.ScannerID(), plugin, &report)
+	return report, err
+}
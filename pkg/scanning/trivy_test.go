@@ -0,0 +1,67 @@
+package scanning
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTrivyFindingsOfTheReference(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the fake trivy only knows the image of the last argument and needs the token of the server in the environment
+	binary := filepath.Join(dir, "trivy")
+	script := `#!/bin/sh
+for last; do :; done
+[ "$last" = "registry.io/team/app@sha256:abc" ] && [ "$TRIVY_TOKEN" = "secret" ] || exit 1
+echo '{"Results": [{"Target": "alpine", "Vulnerabilities": [{"VulnerabilityID": "CVE-2023-5678", "Severity": "HIGH"}]}, {"Target": "app"}]}'
+`
+	if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := TrivyScanner{Enabled: true, Binary: binary, Server: "http://trivy:4954", Token: "secret"}
+	findings, err := scanner.GetReferenceFindings(context.Background(), "registry.io/team/app@sha256:abc")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	if !reflect.DeepEqual(findings, []Finding{{ID: "CVE-2023-5678", Severity: "High"}}) {
+		t.Errorf("Expected the finding with a normalized severity but got %v", findings)
+	}
+	if _, err := scanner.GetReferenceFindings(context.Background(), "registry.io/team/other:1.0"); err == nil {
+		t.Errorf("Expected an error when trivy fails")
+	}
+}
+
+func TestTrivyPackagesOfTheReference(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the fake trivy only lists the packages with --list-all-pkgs
+	binary := filepath.Join(dir, "trivy")
+	script := `#!/bin/sh
+case "$*" in *--list-all-pkgs*) ;; *) exit 1 ;; esac
+echo '{"Results": [{"Target": "alpine", "Packages": [{"Name": "musl", "Version": "1.2.4-r2", "Identifier": {"PURL": "pkg:apk/alpine/musl@1.2.4-r2"}}]}, {"Target": "app", "Packages": [{"Name": "golang.org/x/net", "Version": "v0.17.0"}]}]}'
+`
+	if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	scanners := ImageScanners{Trivy: TrivyScanner{Enabled: true, Binary: binary}, Quay: QuayScanner{Enabled: true}}
+	packages, err := scanners.GetPackages(context.Background(), "registry.io/team/app@sha256:abc")
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	expected := []Package{{Name: "golang.org/x/net", Version: "v0.17.0"}, {Name: "musl", Version: "1.2.4-r2", Purl: "pkg:apk/alpine/musl@1.2.4-r2"}}
+	if !reflect.DeepEqual(packages, expected) {
+		t.Errorf("Expected %v of trivy only but got %v", expected, packages)
+	}
+}
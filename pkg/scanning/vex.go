@@ -0,0 +1,167 @@
+package scanning
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// VexPredicateType is the prefix of the predicate type of the attestations with an OpenVEX document like https://openvex.dev/ns/v0.2.0
+const VexPredicateType = "https://openvex.dev/ns"
+
+// VexConfig are the OpenVEX documents with the statements about the vulnerabilities that don't apply to the images
+type VexConfig struct {
+	// Documents are the paths of the OpenVEX documents
+	Documents []string `koanf:"documents"`
+	// Attestations also uses the OpenVEX attestations that cosign attached to the images in their registry
+	Attestations bool `koanf:"attestations"`
+}
+
+// VexStatement is a statement of an OpenVEX document about a vulnerability of the products
+type VexStatement struct {
+	Vulnerability vexID   `json:"vulnerability"`
+	Products      []vexID `json:"products"`
+	Status        string  `json:"status"`
+	Justification string  `json:"justification"`
+}
+
+type vexDocument struct {
+	Statements []VexStatement `json:"statements"`
+}
+
+// vexID is the vulnerability or product of a statement, it is a string in the first versions of OpenVEX and an object with the @id or name since v0.2.0
+type vexID string
+
+// UnmarshalJSON decodes the id of the string or of the object
+func (v *vexID) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		*v = vexID(id)
+		return nil
+	}
+	var object struct {
+		ID   string `json:"@id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*v = vexID(object.Name)
+	if object.Name == "" {
+		*v = vexID(object.ID)
+	}
+	return nil
+}
+
+// ParseVex returns the statements of the OpenVEX document
+func ParseVex(data []byte) ([]VexStatement, error) {
+	var document vexDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("Could not parse the OpenVEX document: %w", err)
+	}
+	return document.Statements, nil
+}
+
+// ParseVexAttestation returns the statements of the OpenVEX document of an attestation of the image with the reference,
+// the statements without products are about the image of the attestation
+func ParseVexAttestation(data []byte, reference string) ([]VexStatement, error) {
+	statements, err := ParseVex(data)
+	for index := range statements {
+		if len(statements[index].Products) == 0 {
+			statements[index].Products = []vexID{vexID(reference)}
+		}
+	}
+	return statements, err
+}
+
+// LoadVexDocuments returns the statements of the OpenVEX documents of the paths
+func LoadVexDocuments(paths []string) ([]VexStatement, error) {
+	var statements []VexStatement
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read the OpenVEX document [%s]: %w", path, err)
+		}
+		document, err := ParseVex(data)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load [%s]: %w", path, err)
+		}
+		statements = append(statements, document...)
+	}
+	return statements, nil
+}
+
+// ApplyVex removes the findings that the statements mark as not_affected or fixed for the image with the reference and the digests it runs,
+// when multiple statements are about the same vulnerability of the image the last statement wins
+func ApplyVex(findings []Finding, statements []VexStatement, reference string, digests []string) []Finding {
+	if len(statements) == 0 {
+		return findings
+	}
+	var applied []Finding
+	for _, finding := range findings {
+		status := ""
+		for _, statement := range statements {
+			if string(statement.Vulnerability) == finding.ID && statement.appliesTo(reference, digests) {
+				status = statement.Status
+			}
+		}
+		if status == "not_affected" || status == "fixed" {
+			logger.WithField("cve", finding.ID).WithField("image", reference).WithField("status", status).Debug("Vulnerability doesn't apply according to VEX")
+			continue
+		}
+		applied = append(applied, finding)
+	}
+	return applied
+}
+
+// appliesTo returns true when one of the products is the image
+func (s VexStatement) appliesTo(reference string, digests []string) bool {
+	for _, product := range s.Products {
+		if productMatches(string(product), reference, digests) {
+			return true
+		}
+	}
+	return false
+}
+
+// productMatches returns true when the product is the image, as a purl like pkg:oci/nginx@sha256%3Aabc?repository_url=docker.io/library/nginx
+// or as a reference like docker.io/library/nginx@sha256:abc, docker.io/library/nginx:1.25 or docker.io/library/nginx for all versions
+func productMatches(product, reference string, digests []string) bool {
+	image, _ := splitDigest(reference)
+	repository, tag := splitTag(image)
+	if strings.HasPrefix(product, "pkg:oci/") {
+		purl, err := url.Parse(product)
+		if err != nil {
+			return false
+		}
+		path, err := url.PathUnescape(purl.Opaque)
+		if err != nil {
+			return false
+		}
+		name, version := splitDigest(strings.TrimPrefix(path, "oci/"))
+		if version != "" && !containsString(digests, version) {
+			return false
+		}
+		if repositoryURL := purl.Query().Get("repository_url"); repositoryURL != "" {
+			return repositoryURL == repository
+		}
+		return repository == name || strings.HasSuffix(repository, "/"+name)
+	}
+	productImage, productDigest := splitDigest(product)
+	if productDigest != "" {
+		return containsString(digests, productDigest)
+	}
+	productRepository, productTag := splitTag(productImage)
+	return productRepository == repository && (productTag == "" || productTag == tag)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
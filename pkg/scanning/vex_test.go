@@ -0,0 +1,40 @@
+package scanning
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyVexToTheImage(t *testing.T) {
+	statements, err := ParseVex([]byte(`{"@context": "https://openvex.dev/ns/v0.2.0", "statements": [
+		{"vulnerability": {"name": "CVE-2023-4911"}, "products": [{"@id": "pkg:oci/nginx@sha256%3Aabc?repository_url=docker.io/library/nginx"}], "status": "not_affected", "justification": "vulnerable_code_not_in_execute_path"},
+		{"vulnerability": {"name": "CVE-2023-1234"}, "products": [{"@id": "docker.io/library/nginx:1.25"}], "status": "fixed"},
+		{"vulnerability": {"name": "CVE-2023-1234"}, "products": [{"@id": "docker.io/library/nginx"}], "status": "affected"},
+		{"vulnerability": {"name": "CVE-2023-5678"}, "products": [{"@id": "docker.io/library/nginx:1.24"}], "status": "not_affected"}
+	]}`))
+	if err != nil {
+		t.Fatalf("Expected no error but got [%v]", err)
+	}
+	old, err := ParseVex([]byte(`{"statements": [{"vulnerability": "CVE-2023-5678", "products": ["docker.io/library/nginx@sha256:abc"], "status": "not_affected"}]}`))
+	if err != nil {
+		t.Fatalf("Expected no error for a document of the first OpenVEX version but got [%v]", err)
+	}
+
+	findings := []Finding{{ID: "CVE-2023-1234", Severity: "High"}, {ID: "CVE-2023-4911", Severity: "High"}, {ID: "CVE-2023-5678", Severity: "Low"}}
+	tests := []struct {
+		statements []VexStatement
+		digests    []string
+		expected   []Finding
+	}{
+		// the later statement that nginx is affected wins from the fix of 1.25
+		{statements, []string{"sha256:abc"}, []Finding{{ID: "CVE-2023-1234", Severity: "High"}, {ID: "CVE-2023-5678", Severity: "Low"}}},
+		{statements[:2], []string{"sha256:other"}, []Finding{{ID: "CVE-2023-4911", Severity: "High"}, {ID: "CVE-2023-5678", Severity: "Low"}}},
+		{old, []string{"sha256:abc"}, findings[:2]},
+		{nil, nil, findings},
+	}
+	for index, test := range tests {
+		if applied := ApplyVex(findings, test.statements, "docker.io/library/nginx:1.25", test.digests); !reflect.DeepEqual(applied, test.expected) {
+			t.Errorf("Expected %v for test %d but got %v", test.expected, index, applied)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package scanning
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/arminc/k8s-platform-lcm/pkg/httpclient"
+	"github.com/arminc/k8s-platform-lcm/pkg/lcmerrors"
+	"github.com/target/go-arty/xray"
+)
+
+// XrayConfig contains all the information about the xray
+type XrayConfig struct {
+	Name     string   `koanf:"name"`
+	URL      string   `koanf:"url"`
+	Username string   `koanf:"username"`
+	Password string   `koanf:"password"`
+	Prefixes []Prefix `koanf:"prefixes"`
+}
+
+// Prefix contains the Xray repository prefix for the images that match the regexps
+type Prefix struct {
+	Prefix string   `koanf:"prefix"`
+	Images []string `koanf:"images"`
+}
+
+// GetVulnerabilities gets vulnerabilities from xray
+func (x XrayConfig) GetVulnerabilities(ctx context.Context, name, version string) ([]xray.SummaryArtifact, error) {
+	url := "https://" + x.URL
+	client, _ := xray.NewClient(url, httpclient.WithContext(ctx, clientFrom(ctx)))
+
+	path := fmt.Sprintf("%s/%s/%s", x.getPrefix(name), name, version)
+	arty := &xray.SummaryArtifactRequest{
+		Paths: &[]string{path},
+	}
+	client.Authentication.SetBasicAuth(x.Username, x.Password)
+	sum, res, err := client.Summary.Artifact(arty)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != 200 {
+		return nil, lcmerrors.FromStatus(res.StatusCode, fmt.Errorf("Response code wrong [%v]", res.StatusCode))
+	}
+	if len(sum.GetErrors()) >= 1 {
+		return nil, fmt.Errorf("Got an error from xray for [%s]m error [%s]", name, *sum.GetErrors()[0].Error)
+	}
+	return sum.GetArtifacts(), nil
+}
+
+// ScannerID identifies the Xray scanner by its URL
+func (x XrayConfig) ScannerID() string {
+	return "xray/" + x.URL
+}
+
+// GetFindings gets the vulnerabilities from xray as findings, every CVE of an issue is a finding with the severity of the issue
+func (x XrayConfig) GetFindings(ctx context.Context, name, version string) ([]Finding, error) {
+	artifacts, err := x.GetVulnerabilities(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	findings := []Finding{}
+	if len(artifacts) == 0 {
+		return findings, nil
+	}
+	for _, issue := range artifacts[0].GetIssues() {
+		logger.WithField("summary", issue.GetSummary()).Debug("Issue")
+		for _, c := range issue.GetCves() {
+			logger.WithField("cve", c.GetCve()).Debug("CVE")
+			findings = append(findings, Finding{ID: c.GetCve(), Severity: issue.GetSeverity()})
+		}
+	}
+	return findings, nil
+}
+
+func (x XrayConfig) getPrefix(name string) string {
+	if len(x.Prefixes) == 1 {
+		return x.Prefixes[0].Prefix
+	}
+	for _, prefix := range x.Prefixes {
+		for _, image := range prefix.Images {
+			match, err := regexp.MatchString(image, name)
+			if err != nil {
+				logger.WithError(err).Warn("Image regexp not valid")
+			}
+			if match {
+				return prefix.Prefix
+			}
+		}
+	}
+
+	return ""
+}
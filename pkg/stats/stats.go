@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	// Requests counts all outbound http requests
+	Requests = "requests"
+	// RequestFailures counts outbound http requests that failed or returned a server error
+	RequestFailures = "requestFailures"
+	// CacheHits counts lookups that were answered from a cache
+	CacheHits = "cacheHits"
+	// CacheMisses counts lookups that were not found in a cache
+	CacheMisses = "cacheMisses"
+	// NotModified counts conditional requests that were answered with the stored response because nothing changed
+	NotModified = "notModified"
+)
+
+var (
+	mu       sync.Mutex
+	counters = map[string]int64{}
+	hosts    = map[string]int64{}
+	degraded = map[string]bool{}
+)
+
+// Inc increments the counter
+func Inc(counter string) {
+	mu.Lock()
+	defer mu.Unlock()
+	counters[counter]++
+}
+
+// IncHost increments the request counter of the host
+func IncHost(host string) {
+	mu.Lock()
+	defer mu.Unlock()
+	hosts[host]++
+}
+
+// Get returns the value of the counter
+func Get(counter string) int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return counters[counter]
+}
+
+// Hosts returns the hosts that were contacted sorted by name
+func Hosts() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var names []string
+	for host := range hosts {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HostRequests returns the number of requests per contacted host
+func HostRequests() map[string]int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	requests := make(map[string]int64, len(hosts))
+	for host, count := range hosts {
+		requests[host] = count
+	}
+	return requests
+}
+
+// AddDegraded marks the host as degraded because calls to it were skipped after failing too often
+func AddDegraded(host string) {
+	mu.Lock()
+	defer mu.Unlock()
+	degraded[host] = true
+}
+
+// Degraded returns the degraded hosts sorted by name
+func Degraded() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var names []string
+	for host := range degraded {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reset resets all the counters, it is used at the start of every scan
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	counters = map[string]int64{}
+	hosts = map[string]int64{}
+	degraded = map[string]bool{}
+}
@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OTLP span kinds and status codes, see opentelemetry-proto trace.proto
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusCodeError  = 2
+)
+
+// otlpExporter sends the spans as OTLP json to the traces endpoint of a collector
+type otlpExporter struct {
+	url         string
+	headers     map[string]string
+	serviceName string
+	version     string
+	client      *http.Client
+}
+
+func newExporter(url string, headers map[string]string, serviceName, version string) *otlpExporter {
+	return &otlpExporter{url: url, headers: headers, serviceName: serviceName, version: version, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            *status    `json:"status,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// request converts the spans of the trace to an export request, the attributes are sorted so the request is stable
+func (e *otlpExporter) request(traceID string, spans []*Span) exportRequest {
+	converted := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		kind := spanKindInternal
+		if span.client {
+			kind = spanKindClient
+		}
+		converted = append(converted, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            span.id,
+			ParentSpanID:      span.parentID,
+			Name:              span.name,
+			Kind:              kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        keyValues(span.attributes),
+			Status:            spanStatus(span.err),
+		})
+	}
+
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: keyValues(map[string]string{
+			"service.name":    e.serviceName,
+			"service.version": e.version,
+		})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: "lcm", Version: e.version}, Spans: converted}},
+	}}}
+}
+
+func (e *otlpExporter) send(request exportRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lcm/"+e.version)
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Response code of [%s] was not 2xx but [%v]", e.url, resp.StatusCode)
+	}
+	return nil
+}
+
+func keyValues(attributes map[string]string) []keyValue {
+	var converted []keyValue
+	for key, value := range attributes {
+		converted = append(converted, keyValue{Key: key, Value: anyValue{StringValue: value}})
+	}
+	sort.Slice(converted, func(i, j int) bool { return converted[i].Key < converted[j].Key })
+	return converted
+}
+
+func spanStatus(err string) *status {
+	if err == "" {
+		return nil
+	}
+	return &status{Code: statusCodeError, Message: err}
+}
@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var logger = log.WithField("component", "tracing")
+
+// Config contains the settings for exporting traces of the scans with OTLP over http, tracing is disabled without an endpoint
+// The endpoint is the base url of the collector like http://tempo:4318, the traces are sent to /v1/traces
+type Config struct {
+	Endpoint    string            `koanf:"endpoint"`
+	ServiceName string            `koanf:"serviceName"`
+	Headers     map[string]string `koanf:"headers"`
+}
+
+var (
+	mu       sync.RWMutex
+	exporter *otlpExporter
+)
+
+// Configure sets up the exporter from the config, without an endpoint no spans are recorded
+func Configure(config Config, version string) error {
+	var configured *otlpExporter
+	if config.Endpoint != "" {
+		if !strings.HasPrefix(config.Endpoint, "http://") && !strings.HasPrefix(config.Endpoint, "https://") {
+			return fmt.Errorf("Tracing endpoint [%s] not valid, it must start with http:// or https://", config.Endpoint)
+		}
+		serviceName := config.ServiceName
+		if serviceName == "" {
+			serviceName = "lcm"
+		}
+		configured = newExporter(strings.TrimSuffix(config.Endpoint, "/")+"/v1/traces", config.Headers, serviceName, version)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	exporter = configured
+	return nil
+}
+
+// Span is a timed operation of a trace, all methods can be called on a nil span which does nothing
+type Span struct {
+	trace      *trace
+	id         string
+	parentID   string
+	name       string
+	client     bool
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	err        string
+}
+
+// trace collects the finished spans of a single root span, they are exported together when the root span ends
+type trace struct {
+	id       string
+	exporter *otlpExporter
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+type spanKey struct{}
+
+// StartTrace starts the root span of a new trace, for example for a scan
+func StartTrace(ctx context.Context, name string) (context.Context, *Span) {
+	mu.RLock()
+	e := exporter
+	mu.RUnlock()
+	if e == nil {
+		return ctx, nil
+	}
+	t := &trace{id: randomID(16), exporter: e}
+	return start(ctx, t, "", name, false)
+}
+
+// Start starts a child span of the span in the context, without a span in the context nothing is recorded
+// so calls outside of a traced scan don't create traces of their own
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return startChild(ctx, name, false)
+}
+
+// StartClient starts a child span for a call to an external system like a registry or scanner
+func StartClient(ctx context.Context, name string) (context.Context, *Span) {
+	return startChild(ctx, name, true)
+}
+
+func startChild(ctx context.Context, name string, client bool) (context.Context, *Span) {
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil {
+		return ctx, nil
+	}
+	return start(ctx, parent.trace, parent.id, name, client)
+}
+
+func start(ctx context.Context, t *trace, parentID, name string, client bool) (context.Context, *Span) {
+	span := &Span{
+		trace:      t,
+		id:         randomID(8),
+		parentID:   parentID,
+		name:       name,
+		client:     client,
+		start:      time.Now(),
+		attributes: map[string]string{},
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttribute adds the attribute to the span
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed with the error, a nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span, when the root span ends the whole trace is exported
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	s.end = time.Now()
+	s.trace.spans = append(s.trace.spans, s)
+	if s.parentID != "" {
+		s.trace.mu.Unlock()
+		return
+	}
+	// spans that end after the root span are not exported
+	request := s.trace.exporter.request(s.trace.id, s.trace.spans)
+	s.trace.spans = nil
+	s.trace.mu.Unlock()
+
+	if err := s.trace.exporter.send(request); err != nil {
+		logger.WithError(err).Warn("Could not export the trace")
+	}
+}
+
+func randomID(bytes int) string {
+	id := make([]byte, bytes)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
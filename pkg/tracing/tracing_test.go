@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceIsExportedWithChildSpans(t *testing.T) {
+	requests := make(chan exportRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("Expected the path /v1/traces but got %s", r.URL.Path)
+		}
+		var request exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Expected an OTLP json request but got %v", err)
+		}
+		requests <- request
+	}))
+	defer server.Close()
+	if err := Configure(Config{Endpoint: server.URL}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure(Config{}, "")
+
+	ctx, root := StartTrace(context.Background(), "scan")
+	phaseCtx, phase := Start(ctx, "Images")
+	_, call := StartClient(phaseCtx, "GET registry")
+	call.SetError(errors.New("failed"))
+	call.End()
+	phase.End()
+	root.End()
+
+	spans := (<-requests).ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans but got %d", len(spans))
+	}
+	byName := map[string]otlpSpan{}
+	for _, span := range spans {
+		byName[span.Name] = span
+		if span.TraceID != spans[0].TraceID {
+			t.Errorf("Expected all spans in one trace but got %s and %s", span.TraceID, spans[0].TraceID)
+		}
+	}
+	if byName["scan"].ParentSpanID != "" {
+		t.Errorf("Expected the scan to be the root span but got parent %s", byName["scan"].ParentSpanID)
+	}
+	if byName["Images"].ParentSpanID != byName["scan"].SpanID {
+		t.Errorf("Expected the phase to be a child of the scan but got parent %s", byName["Images"].ParentSpanID)
+	}
+	if byName["GET registry"].ParentSpanID != byName["Images"].SpanID || byName["GET registry"].Kind != spanKindClient {
+		t.Errorf("Expected the call to be a client span of the phase but got %+v", byName["GET registry"])
+	}
+	if byName["GET registry"].Status == nil || byName["GET registry"].Status.Code != statusCodeError {
+		t.Errorf("Expected the failed call to have an error status but got %+v", byName["GET registry"].Status)
+	}
+}
+
+func TestNoSpansWithoutTrace(t *testing.T) {
+	if _, span := Start(context.Background(), "Images"); span != nil {
+		t.Errorf("Expected no span without a trace but got %+v", span)
+	}
+	if _, span := StartTrace(context.Background(), "scan"); span != nil {
+		t.Errorf("Expected no span without an endpoint but got %+v", span)
+	}
+}
@@ -0,0 +1,161 @@
+package versioning
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// kubernetesCoreRE matches the version without the suffix of the distribution like -eks-5e0fdde or +k3s1
+var kubernetesCoreRE = regexp.MustCompile(`^v?([0-9]+\.[0-9]+\.[0-9]+)`)
+
+// Addon is a cluster addon with the Kubernetes versions that its versions support
+type Addon struct {
+	Name string `koanf:"name"`
+	// Images are regular expressions that match the whole name of the images of the addon without registry, like coredns/coredns
+	Images        []string             `koanf:"images"`
+	Compatibility []AddonCompatibility `koanf:"compatibility"`
+}
+
+// AddonCompatibility are the Kubernetes versions that the versions of the addon support, both as semver constraints
+type AddonCompatibility struct {
+	Versions   string `koanf:"versions"`
+	Kubernetes string `koanf:"kubernetes"`
+}
+
+// DefaultAddons returns the compatibility of the core addons as published by their projects,
+// an addon in the config with the same name replaces the default
+func DefaultAddons() []Addon {
+	return []Addon{
+		{
+			Name:   "coredns",
+			Images: []string{"coredns/coredns", "coredns"},
+			Compatibility: []AddonCompatibility{
+				{Versions: ">=1.11.0", Kubernetes: ">=1.27.0"},
+				{Versions: ">=1.9.0 <1.11.0", Kubernetes: ">=1.22.0 <1.31.0"},
+				{Versions: ">=1.8.1 <1.9.0", Kubernetes: ">=1.20.0 <1.27.0"},
+				{Versions: "<1.8.1", Kubernetes: "<1.21.0"},
+			},
+		},
+		kubeProxy(25, 35),
+		{
+			Name:   "metrics-server",
+			Images: []string{"metrics-server/metrics-server", "metrics-server"},
+			Compatibility: []AddonCompatibility{
+				{Versions: ">=0.6.0", Kubernetes: ">=1.19.0"},
+				{Versions: ">=0.5.0 <0.6.0", Kubernetes: ">=1.8.0"},
+			},
+		},
+		{
+			Name:   "ingress-nginx",
+			Images: []string{"ingress-nginx/controller", "ingress-nginx/controller-chroot"},
+			Compatibility: []AddonCompatibility{
+				{Versions: "~1.12.0", Kubernetes: ">=1.28.0 <1.33.0"},
+				{Versions: "~1.11.0", Kubernetes: ">=1.26.0 <1.31.0"},
+				{Versions: "~1.10.0", Kubernetes: ">=1.26.0 <1.30.0"},
+				{Versions: "~1.9.0", Kubernetes: ">=1.25.0 <1.29.0"},
+				{Versions: "~1.8.0", Kubernetes: ">=1.24.0 <1.28.0"},
+			},
+		},
+		{
+			Name:   "cert-manager",
+			Images: []string{"jetstack/cert-manager-controller", "jetstack/cert-manager-webhook", "jetstack/cert-manager-cainjector"},
+			Compatibility: []AddonCompatibility{
+				{Versions: "~1.16.0", Kubernetes: ">=1.25.0 <1.32.0"},
+				{Versions: "~1.15.0", Kubernetes: ">=1.25.0 <1.31.0"},
+				{Versions: "~1.14.0", Kubernetes: ">=1.24.0 <1.30.0"},
+				{Versions: "~1.13.0", Kubernetes: ">=1.23.0 <1.29.0"},
+				{Versions: "~1.12.0", Kubernetes: ">=1.22.0 <1.28.0"},
+			},
+		},
+	}
+}
+
+// kubeProxy returns the compatibility of kube-proxy, it may not be newer than the API server and at most 3 minor versions older
+func kubeProxy(first, last int) Addon {
+	addon := Addon{Name: "kube-proxy", Images: []string{"kube-proxy", "kube-proxy-amd64", "eks/kube-proxy"}}
+	for minor := last; minor >= first; minor-- {
+		addon.Compatibility = append(addon.Compatibility, AddonCompatibility{
+			Versions:   fmt.Sprintf("~1.%d.0", minor),
+			Kubernetes: fmt.Sprintf(">=1.%d.0 <1.%d.0", minor, minor+4),
+		})
+	}
+	return addon
+}
+
+// MergeAddons returns the default addons with the addons of the config, an addon with the name of a default replaces it
+func MergeAddons(defaults, addons []Addon) []Addon {
+	merged := []Addon{}
+	replaced := map[string]bool{}
+	for _, addon := range addons {
+		replaced[addon.Name] = true
+	}
+	for _, addon := range defaults {
+		if !replaced[addon.Name] {
+			merged = append(merged, addon)
+		}
+	}
+	return append(merged, addons...)
+}
+
+// Validate returns an error when an image regex or a constraint of the addon is not valid
+func (a Addon) Validate() error {
+	for _, image := range a.Images {
+		if _, err := regexp.Compile(image); err != nil {
+			return fmt.Errorf("Image [%s] of addon [%s] not valid: %w", image, a.Name, err)
+		}
+	}
+	for _, compatibility := range a.Compatibility {
+		if _, err := semver.NewConstraint(compatibility.Versions); err != nil {
+			return fmt.Errorf("Versions [%s] of addon [%s] not valid: %w", compatibility.Versions, a.Name, err)
+		}
+		if _, err := semver.NewConstraint(compatibility.Kubernetes); err != nil {
+			return fmt.Errorf("Kubernetes versions [%s] of addon [%s] not valid: %w", compatibility.Kubernetes, a.Name, err)
+		}
+	}
+	return nil
+}
+
+// Matches returns true when the image name without registry is one of the images of the addon
+func (a Addon) Matches(name string) bool {
+	for _, image := range a.Images {
+		if matched, _ := regexp.MatchString("^(?:"+image+")$", name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Compatible returns the Kubernetes versions that the version of the addon supports and if the Kubernetes version is one of them,
+// known is false when the compatibility of the version is not in the table or one of the versions can't be parsed
+func (a Addon) Compatible(version, kubernetes string) (supported string, compatible bool, known bool) {
+	addonVersion, err := semver.NewVersion(KubernetesCore(version))
+	if err != nil {
+		return "", false, false
+	}
+	kubernetesVersion, err := semver.NewVersion(KubernetesCore(kubernetes))
+	if err != nil {
+		return "", false, false
+	}
+	for _, compatibility := range a.Compatibility {
+		versions, err := semver.NewConstraint(compatibility.Versions)
+		if err != nil || !versions.Check(addonVersion) {
+			continue
+		}
+		supportedVersions, err := semver.NewConstraint(compatibility.Kubernetes)
+		if err != nil {
+			return "", false, false
+		}
+		return compatibility.Kubernetes, supportedVersions.Check(kubernetesVersion), true
+	}
+	return "", false, false
+}
+
+// KubernetesCore returns the version without the suffix of the distribution, like 1.29.3 for v1.29.3-eks-5e0fdde
+func KubernetesCore(v string) string {
+	if match := kubernetesCoreRE.FindStringSubmatch(v); match != nil {
+		return match[1]
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+package versioning
+
+import "testing"
+
+func TestAddonCompatibility(t *testing.T) {
+	addons := MergeAddons(DefaultAddons(), []Addon{{Name: "coredns", Images: []string{"coredns/coredns"}, Compatibility: []AddonCompatibility{{Versions: ">=1.0.0", Kubernetes: ">=1.0.0"}}}})
+	var proxy, coredns Addon
+	for _, addon := range addons {
+		if err := addon.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		switch addon.Name {
+		case "kube-proxy":
+			proxy = addon
+		case "coredns":
+			coredns = addon
+		}
+	}
+	if len(coredns.Compatibility) != 1 {
+		t.Errorf("Expected the addon of the config to replace the default but got %v", coredns)
+	}
+	if !proxy.Matches("eks/kube-proxy") || proxy.Matches("kube-proxy-operator") {
+		t.Errorf("Expected kube-proxy to match eks/kube-proxy only")
+	}
+
+	for _, test := range []struct {
+		version    string
+		kubernetes string
+		compatible bool
+		known      bool
+	}{
+		{"v1.29.0-minimal-eksbuild.1", "v1.29.3-eks-5e0fdde", true, true},
+		{"v1.26.4", "v1.29.3", true, true},
+		{"v1.25.4", "v1.29.3", false, true},
+		{"v1.30.1", "v1.29.3", false, true},
+		{"latest", "v1.29.3", false, false},
+	} {
+		_, compatible, known := proxy.Compatible(test.version, test.kubernetes)
+		if compatible != test.compatible || known != test.known {
+			t.Errorf("Expected compatible %t and known %t for %s on %s but got %t and %t", test.compatible, test.known, test.version, test.kubernetes, compatible, known)
+		}
+	}
+}
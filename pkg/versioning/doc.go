@@ -0,0 +1,2 @@
+// Package versioning compares semantic versions and finds the highest version in a list of tags.
+package versioning
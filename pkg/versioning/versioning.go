@@ -0,0 +1,402 @@
+package versioning
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mcuadros/go-version"
+)
+
+var logger = log.WithField("component", "versioning")
+
+const (
+	// Major means a major difference between two versions
+	Major = "MAJOR"
+	// Minor means a minor difference between two versions
+	Minor = "MINOR"
+	// Patch means a patch difference between two versions
+	Patch = "PATCH"
+	// Same means two versions are the same
+	Same = "SAME"
+	// Unknown means the difference between the two versions is unknown
+	Unknown = "UNKNOWN"
+
+	// Notfound means a version could not be found
+	Notfound = "NOTFOUND"
+	// Failure means something went wrong went finding the version
+	Failure = "FAILURE"
+	// Nodata indicates there was not a failure but there wasn't any data
+	Nodata = "NODATA"
+	// CheckFailed means the check of the item failed, the item is still reported so it doesn't silently go missing
+	CheckFailed = "CHECK_FAILED"
+)
+
+// FindHighestVersionInList finds the highest version in an list of versions or returns NOTFOUND
+// Only the tags that look like a version are compared and every tag is normalized at most once, which matters for repositories with thousands of tags
+func FindHighestVersionInList(versions []string, allowAllReleases bool) string {
+	logger.WithField("versions", len(versions)).Debug("FindHighestVersionInList")
+
+	// with prereleases the highest simple version is found first so the prereleases of lower versions can be skipped without normalizing them
+	highestSimple := parseCandidate("0")
+	if allowAllReleases {
+		for _, vers := range versions {
+			numbers, normalized, ok := parseSimpleVersion(vers)
+			if !ok || !strings.Contains(vers, ".") {
+				continue
+			}
+			if candidate := (candidate{tag: vers, normalized: normalized, simple: true, numbers: numbers}); candidate.compare(highestSimple) == 1 {
+				highestSimple = candidate
+			}
+		}
+	}
+
+	latest := parseCandidate("0")
+	found := false
+	for _, vers := range versions {
+		if !strings.Contains(vers, ".") || !isVersionTag(vers, allowAllReleases) || isLowerPrerelease(vers, highestSimple) {
+			continue
+		}
+		if candidate := parseCandidate(vers); candidate.compare(latest) == 1 {
+			latest = candidate
+			found = true
+		}
+	}
+
+	if found {
+		return latest.tag
+	}
+	return Notfound
+}
+
+// prereleaseRE matches the parts of a version suffix that mark a prerelease, like rc.1, beta2 or preview
+var prereleaseRE = regexp.MustCompile(`(?i)^(alpha|beta|rc|pre|preview|dev|snapshot|nightly|canary)[0-9]*$`)
+
+// IsPrerelease returns true when the suffix of the version marks an alpha, beta, rc or preview release like 1.2.3-rc.1,
+// other suffixes like 1.2.3-alpine are variants of a release
+func IsPrerelease(tag string) bool {
+	dash := strings.IndexByte(tag, '-')
+	if dash < 0 {
+		return false
+	}
+	for _, part := range strings.FieldsFunc(tag[dash+1:], func(c rune) bool { return c == '.' || c == '-' || c == '_' }) {
+		if prereleaseRE.MatchString(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// revisionRE matches the last part of a suffix that is a build number or revision like the 1 of 1.2.3-1 or the r4 of 1.2.3-debian-11-r4
+var revisionRE = regexp.MustCompile(`^r?([0-9]+)$`)
+
+// SplitVariant splits the tag in the version and the flavor of the image, like 1.19.2 and alpine for 1.19.2-alpine,
+// the prerelease parts of the suffix like rc.1 in 1.2.3-rc.1-slim stay in the version and a revision at the end is not part of the flavor
+func SplitVariant(tag string) (string, string) {
+	dash := strings.IndexByte(tag, '-')
+	if dash < 0 {
+		return tag, ""
+	}
+	core := []string{tag[:dash]}
+	variant := []string{}
+	parts := strings.Split(tag[dash+1:], "-")
+	for i, part := range parts {
+		if prereleaseRE.MatchString(strings.SplitN(strings.Replace(part, "_", ".", -1), ".", 2)[0]) {
+			core = append(core, part)
+		} else if i < len(parts)-1 || !revisionRE.MatchString(part) {
+			variant = append(variant, part)
+		}
+	}
+	return strings.Join(core, "-"), strings.Join(variant, "-")
+}
+
+// revision returns the revision at the end of the tag or 0
+func revision(tag string) int {
+	match := revisionRE.FindStringSubmatch(tag[strings.LastIndexByte(tag, '-')+1:])
+	if match == nil || !strings.Contains(tag, "-") {
+		return 0
+	}
+	number, _ := strconv.Atoi(match[1])
+	return number
+}
+
+// FindHighestVersionOfVariant finds the highest version of the tags with the same flavor, like 1.20.1-alpine for alpine
+// instead of 1.20.1 or 1.20.1-windowsservercore. The versions of the tags are compared without the flavor,
+// the highest revision wins for the same version and without a variant it is the same as FindHighestVersionInList
+func FindHighestVersionOfVariant(versions []string, variant string, allowAllReleases bool) string {
+	if variant == "" {
+		return FindHighestVersionInList(versions, allowAllReleases)
+	}
+	tags := map[string]string{}
+	cores := []string{}
+	for _, tag := range versions {
+		core, tagVariant := SplitVariant(tag)
+		if tagVariant != variant {
+			continue
+		}
+		if existing, exists := tags[core]; !exists {
+			cores = append(cores, core)
+		} else if revision(tag) <= revision(existing) {
+			continue
+		}
+		tags[core] = tag
+	}
+	if tag, exists := tags[FindHighestVersionInList(cores, allowAllReleases)]; exists {
+		return tag
+	}
+	return Notfound
+}
+
+// FindHighestCalendarVersion finds the highest calendar version like 2021.04.1, 22.04 or 20240115 of the tags with the flavor or returns NOTFOUND,
+// the numbers are compared one by one so 2021.10 is higher than 2021.9 and tags with other characters are skipped
+func FindHighestCalendarVersion(versions []string, variant string) string {
+	latest := Notfound
+	var latestNumbers []int64
+	for _, tag := range versions {
+		core, tagVariant := SplitVariant(tag)
+		if tagVariant != variant {
+			continue
+		}
+		numbers, ok := parseCalendarVersion(core)
+		if ok && (latestNumbers == nil || compareNumbers(numbers, latestNumbers) == 1 ||
+			(compareNumbers(numbers, latestNumbers) == 0 && revision(tag) > revision(latest))) {
+			latest = tag
+			latestNumbers = numbers
+		}
+	}
+	return latest
+}
+
+// parseCalendarVersion parses the numbers of a calendar version, an optional v prefix is allowed
+func parseCalendarVersion(tag string) ([]int64, bool) {
+	parts := strings.Split(strings.TrimPrefix(tag, "v"), ".")
+	numbers := make([]int64, len(parts))
+	for i, part := range parts {
+		if len(part) == 0 || len(part) > 18 {
+			return nil, false
+		}
+		for _, c := range []byte(part) {
+			if !isDigit(c) {
+				return nil, false
+			}
+		}
+		numbers[i], _ = strconv.ParseInt(part, 10, 64)
+	}
+	return numbers, true
+}
+
+// compareNumbers compares the numbers one by one, missing numbers are 0
+func compareNumbers(a, b []int64) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x > y {
+			return 1
+		} else if x < y {
+			return -1
+		}
+	}
+	return 0
+}
+
+// CountNewerVersions returns how many of the versions with the same flavor as the current version are higher, every version is only counted once
+// for all its revisions. It is 0 when the current version is not a version like latest
+func CountNewerVersions(versions []string, current string, allowAllReleases bool) int {
+	currentCore, variant := SplitVariant(current)
+	if !isVersionTag(currentCore, true) {
+		return 0
+	}
+	currentCandidate := parseCandidate(currentCore)
+	return countNewer(versions, variant, func(core string) bool {
+		return strings.Contains(core, ".") && isVersionTag(core, allowAllReleases) && parseCandidate(core).compare(currentCandidate) == 1
+	})
+}
+
+// CountNewerCalendarVersions returns how many of the calendar versions with the same flavor as the current version are higher,
+// it is 0 when the current version is not a calendar version
+func CountNewerCalendarVersions(versions []string, current string) int {
+	currentCore, variant := SplitVariant(current)
+	currentNumbers, ok := parseCalendarVersion(currentCore)
+	if !ok {
+		return 0
+	}
+	return countNewer(versions, variant, func(core string) bool {
+		numbers, ok := parseCalendarVersion(core)
+		return ok && compareNumbers(numbers, currentNumbers) == 1
+	})
+}
+
+// countNewer counts the versions of the flavor that are newer, the revisions of the same version are counted once
+func countNewer(versions []string, variant string, newer func(core string) bool) int {
+	counted := map[string]bool{}
+	for _, tag := range versions {
+		core, tagVariant := SplitVariant(tag)
+		if tagVariant == variant && !counted[core] && newer(core) {
+			counted[core] = true
+		}
+	}
+	return len(counted)
+}
+
+// isVersionTag returns true when the tag matches ^(v?[0-9]*\.?[0-9]*\.?[0-9]*)$, or with allowAllReleases
+// ^(v?[0-9]*\.?[0-9]*\.?[0-9]*)(-[a-z0-9.]+)?$, without the cost of a regular expression
+func isVersionTag(tag string, allowAllReleases bool) bool {
+	i := 0
+	if i < len(tag) && tag[i] == 'v' {
+		i++
+	}
+	for part := 0; part < 3; part++ {
+		if part > 0 && i < len(tag) && tag[i] == '.' {
+			i++
+		}
+		for i < len(tag) && isDigit(tag[i]) {
+			i++
+		}
+	}
+	if i == len(tag) {
+		return true
+	}
+	if !allowAllReleases || tag[i] != '-' || i+1 == len(tag) {
+		return false
+	}
+	for _, c := range []byte(tag[i+1:]) {
+		if !isDigit(c) && (c < 'a' || c > 'z') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// candidate is a tag parsed for comparing, simple versions like 1.2.3 are compared by their numbers
+// and all others by their normalized form
+type candidate struct {
+	tag        string
+	normalized string
+	simple     bool
+	numbers    [4]int64
+}
+
+// parseCandidate parses the tag, the normalized form is the same as the one of go-version
+func parseCandidate(tag string) candidate {
+	if numbers, normalized, ok := parseSimpleVersion(tag); ok {
+		return candidate{tag: tag, normalized: normalized, simple: true, numbers: numbers}
+	}
+	return candidate{tag: tag, normalized: version.Normalize(tag)}
+}
+
+// parseSimpleVersion parses versions like v1.2.3 with one to three numbers where the first has at most 3 digits,
+// which go-version normalizes by dropping the v and adding .0 up to four numbers
+func parseSimpleVersion(tag string) ([4]int64, string, bool) {
+	var numbers [4]int64
+	parts := strings.Split(strings.TrimPrefix(tag, "v"), ".")
+	if len(parts) > 3 || len(parts[0]) == 0 || len(parts[0]) > 3 {
+		return numbers, "", false
+	}
+	for i, part := range parts {
+		// longer numbers could overflow, go-version treats those differently
+		if len(part) == 0 || len(part) > 18 {
+			return numbers, "", false
+		}
+		for _, c := range []byte(part) {
+			if !isDigit(c) {
+				return numbers, "", false
+			}
+		}
+		numbers[i], _ = strconv.ParseInt(part, 10, 64)
+	}
+	normalized := strings.Join(parts, ".") + strings.Repeat(".0", 4-len(parts))
+	return numbers, normalized, true
+}
+
+// isLowerPrerelease returns true when the tag is a prerelease like 1.2.3-rc.1 of a version that is already lower than the latest simple version
+// in one of its numbers, go-version always compares those as lower so they don't need to be normalized
+func isLowerPrerelease(tag string, latest candidate) bool {
+	dash := strings.IndexByte(tag, '-')
+	if !latest.simple || dash < 0 {
+		return false
+	}
+	numbers, _, ok := parseSimpleVersion(tag[:dash])
+	if !ok {
+		return false
+	}
+	for i := 0; i <= strings.Count(tag[:dash], "."); i++ {
+		if numbers[i] != latest.numbers[i] {
+			return numbers[i] < latest.numbers[i]
+		}
+	}
+	return false
+}
+
+// compare returns 1 when c is higher than other, -1 when c is lower than other and 0 when they are the same
+func (c candidate) compare(other candidate) int {
+	if !c.simple || !other.simple {
+		return version.CompareSimple(c.normalized, other.normalized)
+	}
+	for i := range c.numbers {
+		if c.numbers[i] > other.numbers[i] {
+			return 1
+		} else if c.numbers[i] < other.numbers[i] {
+			return -1
+		}
+	}
+	return 0
+}
+
+// DetermineLifeCycleStatus compares two versions to determin the status of the difference
+func DetermineLifeCycleStatus(latestVersion string, currentVersion string) string {
+	logger.WithField("version", currentVersion).WithField("latestVersion", latestVersion).Debug("Determin status for version")
+	latest := strings.Split(version.Normalize(latestVersion), ".")
+	curr := strings.Split(version.Normalize(currentVersion), ".")
+
+	if version.Compare(currentVersion, latestVersion, "=") {
+		return Same
+	}
+	if version.Compare(curr[0], latest[0], "<") {
+		return Major
+	}
+
+	// has minor
+	if len(latest) >= 2 && len(curr) >= 2 {
+		if version.Compare(curr[1], latest[1], "<") {
+			return Minor
+		}
+	}
+
+	// has patch
+	if len(latest) >= 3 && len(curr) >= 3 {
+		if version.Compare(curr[2], latest[2], "<") {
+			return Patch
+		}
+	}
+
+	return Unknown
+}
+
+// UpgradeType returns Major, Minor or Patch for the upgrade from the current to the latest version, it is empty when there is no upgrade
+// or when the latest version is not known
+func UpgradeType(latestVersion string, currentVersion string) string {
+	if latestVersion == Notfound || latestVersion == Failure || latestVersion == CheckFailed || latestVersion == "" {
+		return ""
+	}
+	switch status := DetermineLifeCycleStatus(latestVersion, currentVersion); status {
+	case Major, Minor, Patch:
+		return status
+	}
+	return ""
+}
+
+// CompareVersions compares two versions, returns 1 when a is higher than b, -1 when a is lower than b and 0 when they are the same
+func CompareVersions(a, b string) int {
+	return version.CompareSimple(version.Normalize(a), version.Normalize(b))
+}
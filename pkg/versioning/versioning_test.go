@@ -0,0 +1,177 @@
+package versioning
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-version"
+)
+
+// findHighestVersionWithRegexp is how the highest version was found before, the optimized version must give the same result
+func findHighestVersionWithRegexp(versions []string, allowAllReleases bool) string {
+	regex := regexp.MustCompile("^(v?[0-9]*\\.?[0-9]*\\.?[0-9]*)$")
+	if allowAllReleases {
+		regex = regexp.MustCompile("^(v?[0-9]*\\.?[0-9]*\\.?[0-9]*)(-[a-z0-9.]+)?$")
+	}
+	latestVersion := "0"
+	for _, vers := range versions {
+		if strings.Contains(vers, ".") && regex.MatchString(vers) {
+			if version.CompareSimple(version.Normalize(vers), version.Normalize(latestVersion)) == 1 {
+				latestVersion = vers
+			}
+		}
+	}
+	if latestVersion != "0" {
+		return latestVersion
+	}
+	return Notfound
+}
+
+var edgeCaseTags = []string{
+	"1.2.3", "v1.2.4", "1.10", "01.2.3", "1.", ".5", "v.", "1..2", "1000.0.0", "2020.01.02", "1.2.3.4",
+	"1.2.3-rc.1", "1.2.3-beta", "1.2.3-", "1.2.3-RC1", "1.2.3-alpine", "latest", "lts-alpine", "14-buster",
+	"99999999999999999999.1", "v1.2.3-dev", "1.2.3-p1", "0.0", "0.0.1", "1.2-5", "1.2-p1", "1.2.3-x.dev",
+	"1.3-stable", "v1.1-alpine", "2.0.0-a.3", "1.2.4-rc.2", "0.9-beta", "1.2.3-foodev", "1000.1-rc",
+}
+
+func TestFindHighestVersionInListIsTheSameAsWithRegexp(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		tags := make([]string, 8)
+		for j := range tags {
+			tags[j] = edgeCaseTags[random.Intn(len(edgeCaseTags))]
+		}
+		for _, allowAllReleases := range []bool{false, true} {
+			expected := findHighestVersionWithRegexp(tags, allowAllReleases)
+			if actual := FindHighestVersionInList(tags, allowAllReleases); actual != expected {
+				t.Errorf("Expected [%s] but got [%s] for %v with all releases %v", expected, actual, tags, allowAllReleases)
+			}
+		}
+	}
+}
+
+// nodeTags looks like the tags of library/node, most tags are variants that are not a version
+func nodeTags() []string {
+	var tags []string
+	for major := 0; major < 20; major++ {
+		for minor := 0; minor < 25; minor++ {
+			for patch := 0; patch < 5; patch++ {
+				v := fmt.Sprintf("%d.%d.%d", major, minor, patch)
+				tags = append(tags, v, v+"-alpine", v+"-buster-slim", v+"-rc.1", fmt.Sprintf("%d-alpine", major))
+			}
+		}
+	}
+	return tags
+}
+
+func BenchmarkFindHighestVersionInList(b *testing.B) {
+	tags := nodeTags()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FindHighestVersionInList(tags, false)
+	}
+}
+
+func BenchmarkFindHighestVersionInListAllReleases(b *testing.B) {
+	tags := nodeTags()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FindHighestVersionInList(tags, true)
+	}
+}
+
+func BenchmarkFindHighestVersionWithRegexp(b *testing.B) {
+	tags := nodeTags()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		findHighestVersionWithRegexp(tags, false)
+	}
+}
+
+func BenchmarkFindHighestVersionWithRegexpAllReleases(b *testing.B) {
+	tags := nodeTags()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		findHighestVersionWithRegexp(tags, true)
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := map[string]bool{
+		"1.2.3":            false,
+		"1.2.3-alpine":     false,
+		"1.2.3-rc.1":       true,
+		"1.2.3-beta2":      true,
+		"2.0.0-alpha":      true,
+		"1.0.0-preview.3":  true,
+		"1.21.0-debian-r4": false,
+		"3.1.0-SNAPSHOT":   true,
+	}
+	for tag, expected := range tests {
+		if IsPrerelease(tag) != expected {
+			t.Errorf("Expected prerelease [%v] for %s", expected, tag)
+		}
+	}
+}
+
+func TestFindHighestVersionOfVariant(t *testing.T) {
+	tags := []string{"1.19.2-alpine", "1.20.1", "1.20.1-alpine", "1.20.1-windowsservercore", "1.21.0-rc.1-alpine", "1.20.0-slim-bullseye", "1.21.0-debian-11-r4", "1.21.0-debian-11-r12", "latest"}
+	tests := map[string]string{
+		"alpine":        "1.20.1-alpine",
+		"slim-bullseye": "1.20.0-slim-bullseye",
+		"":              "1.20.1",
+		"nanoserver":    Notfound,
+	}
+	for variant, expected := range tests {
+		if latest := FindHighestVersionOfVariant(tags, variant, false); latest != expected {
+			t.Errorf("Expected %s for variant [%s] but got %s", expected, variant, latest)
+		}
+	}
+	if latest := FindHighestVersionOfVariant(tags, "alpine", true); latest != "1.21.0-rc.1-alpine" {
+		t.Errorf("Expected the prerelease of the variant but got %s", latest)
+	}
+	if core, variant := SplitVariant("1.2.3-rc.1-debian-11-r4"); core != "1.2.3-rc.1" || variant != "debian-11" {
+		t.Errorf("Expected 1.2.3-rc.1 and debian-11 but got %s and %s", core, variant)
+	}
+}
+
+func TestFindHighestCalendarVersion(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		variant  string
+		expected string
+	}{
+		{[]string{"2021.04.1", "2021.10.0", "2021.9.3", "latest"}, "", "2021.10.0"},
+		{[]string{"20231201", "20240115", "20240102"}, "", "20240115"},
+		{[]string{"22.04", "24.04", "23.10", "24.04-minimal"}, "", "24.04"},
+		{[]string{"2023.1-alpine", "2024.2-alpine", "2024.3"}, "alpine", "2024.2-alpine"},
+		{[]string{"stable", "1.2.3-rc.1"}, "", Notfound},
+	}
+	for _, test := range tests {
+		if latest := FindHighestCalendarVersion(test.tags, test.variant); latest != test.expected {
+			t.Errorf("Expected %s for %v but got %s", test.expected, test.tags, latest)
+		}
+	}
+}
+
+func TestCountNewerVersions(t *testing.T) {
+	tags := []string{"1.19.2", "1.19.3", "1.20.0", "1.20.1", "1.20.1-alpine", "1.21.0-rc.1", "latest"}
+	if behind := CountNewerVersions(tags, "1.19.2", false); behind != 3 {
+		t.Errorf("Expected 3 newer versions but got %d", behind)
+	}
+	if behind := CountNewerVersions(tags, "1.19.2", true); behind != 4 {
+		t.Errorf("Expected 4 newer versions with the prerelease but got %d", behind)
+	}
+	if behind := CountNewerVersions(tags, "1.19.2-alpine", false); behind != 1 {
+		t.Errorf("Expected 1 newer version of the flavor but got %d", behind)
+	}
+	if behind := CountNewerVersions(tags, "latest", false); behind != 0 {
+		t.Errorf("Expected no newer versions for latest but got %d", behind)
+	}
+	if behind := CountNewerCalendarVersions([]string{"2021.04.1", "2021.10.0", "2021.9.3"}, "2021.04.1"); behind != 2 {
+		t.Errorf("Expected 2 newer calendar versions but got %d", behind)
+	}
+}